@@ -14,6 +14,7 @@ import (
 	"github.com/yahahaff/rapide/initialize"
 	"github.com/yahahaff/rapide/pkg/config"
 	"github.com/yahahaff/rapide/pkg/console"
+	"github.com/yahahaff/rapide/pkg/etcd"
 	"github.com/yahahaff/rapide/pkg/logger"
 )
 
@@ -40,6 +41,18 @@ func Execute() {
 	// 初始化Validator
 	initialize.SetupValidators()
 
+	// 初始化Traefik发布快照
+	initialize.SetupTraefikSnapshot()
+
+	// 初始化Traefik KV发布
+	initialize.SetupTraefikKV()
+
+	// 初始化Traefik配置的Git同步
+	initialize.SetupTraefikGitOps()
+
+	// 启动定时任务
+	initialize.SetupSchedules()
+
 	// 创建 HTTP 服务器
 	srv := &http.Server{
 		Addr:    ":" + config.GetString("APP_PORT", "8000"),
@@ -69,5 +82,9 @@ func Execute() {
 		logger.ErrorString("gin", "shutdown", "Server forced to shutdown: "+err.Error())
 	}
 
+	if etcd.Etcd != nil {
+		_ = etcd.Etcd.Close()
+	}
+
 	logger.InfoString("gin", "shutdown", "Server exiting")
 }
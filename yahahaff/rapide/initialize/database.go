@@ -67,7 +67,32 @@ func SetupDB() {
 			&traefik.TraefikRouter{},
 
 			&traefik.TraefikMiddleware{},
+			&traefik.TraefikTLSOption{},
+			&traefik.TraefikTLSStore{},
+			&traefik.TraefikServersTransport{},
 			&traefik.TraefikService{},
+			&traefik.TraefikInstance{},
+			&traefik.TraefikRevision{},
+			&traefik.TraefikDriftReport{},
+			&traefik.TraefikDriftItem{},
+			&traefik.TraefikServerState{},
+			&traefik.TraefikServerTransition{},
+			&traefik.TraefikSnapshot{},
+			&traefik.TraefikTemplate{},
+			&traefik.TraefikServerDrain{},
+			&traefik.TraefikRollout{},
+			&traefik.TraefikMaintenance{},
+			&traefik.TraefikCertLink{},
+			&traefik.TraefikAuthPolicy{},
+			&traefik.TraefikTrafficBucket{},
+			&traefik.TraefikAccessLogCursor{},
+			&traefik.TraefikMetricSample{},
+			&traefik.TraefikMetricSource{},
+			&traefik.TraefikAdoption{},
+			&traefik.TraefikGitSync{},
+			&traefik.TraefikApp{},
+			&traefik.TraefikAppMember{},
+			&traefik.TraefikOwner{},
 		)
 
 		if err != nil {
@@ -2,30 +2,21 @@ package initialize
 
 import (
 	"fmt"
+	"strings"
+	"time"
+
 	"github.com/yahahaff/rapide/pkg/config"
 	"github.com/yahahaff/rapide/pkg/etcd"
-	"time"
 )
 
-// EtcdClient Global variable to hold the etcd client
-var EtcdClient *etcd.EtcdClient
-
-// SetupEtcd initializes Etcd
+// SetupEtcd initializes Etcd，连接在进程退出前保持打开
 func SetupEtcd() {
 	etcdUrl := config.GetString("ETCD_URL", "http://localhost:2379")
-	endpoints := []string{etcdUrl}
+	endpoints := strings.Split(etcdUrl, ",")
 	dialTimeout := 5 * time.Second
 
-	var err error
 	// Create etcd client
-	EtcdClient, err = etcd.NewEtcdClient(endpoints, dialTimeout)
-	if err != nil {
+	if err := etcd.ConnectEtcd(endpoints, dialTimeout); err != nil {
 		fmt.Println("Failed to create etcd client:", err)
 	}
-	defer func(EtcdClient *etcd.EtcdClient) {
-		err := EtcdClient.Close()
-		if err != nil {
-			fmt.Println("Failed to close etcd client:", err)
-		}
-	}(EtcdClient)
 }
@@ -0,0 +1,88 @@
+package initialize
+
+import (
+	"time"
+
+	"github.com/yahahaff/rapide/internal/service"
+	"github.com/yahahaff/rapide/pkg/config"
+	"github.com/yahahaff/rapide/pkg/logger"
+	"github.com/yahahaff/rapide/pkg/schedule"
+)
+
+// SetupSchedules 启动定时任务，间隔为空或0的任务不启动
+func SetupSchedules() {
+	// 计划发布的Traefik配置快照生效
+	schedule.Every("traefik-publish", scheduleInterval("TRAEFIK_PUBLISH_CHECK_INTERVAL", "30s"), func() {
+		if _, err := service.Entrance.TraefikService.TraefikPublishService.ActivateDueSnapshots(); err != nil {
+			logger.ErrorString("schedule", "traefik-publish", err.Error())
+		}
+	})
+	// 摘除到期的后端服务器
+	schedule.Every("traefik-drain", scheduleInterval("TRAEFIK_DRAIN_CHECK_INTERVAL", "15s"), func() {
+		if _, err := service.Entrance.TraefikService.TraefikServerService.RemoveDrainedServers(); err != nil {
+			logger.ErrorString("schedule", "traefik-drain", err.Error())
+		}
+	})
+	// 推进灰度发布
+	schedule.Every("traefik-rollout", scheduleInterval("TRAEFIK_ROLLOUT_CHECK_INTERVAL", "15s"), func() {
+		if err := service.Entrance.TraefikService.TraefikRolloutService.ProgressRollouts(); err != nil {
+			logger.ErrorString("schedule", "traefik-rollout", err.Error())
+		}
+	})
+	// 按时间开始和结束路由维护窗口
+	schedule.Every("traefik-maintenance", scheduleInterval("TRAEFIK_MAINTENANCE_CHECK_INTERVAL", "15s"), func() {
+		if err := service.Entrance.TraefikService.TraefikMaintenanceService.ProcessMaintenances(); err != nil {
+			logger.ErrorString("schedule", "traefik-maintenance", err.Error())
+		}
+	})
+	// 为启用TLS的路由申请证书并关联
+	schedule.Every("traefik-autocert", scheduleInterval("TRAEFIK_AUTOCERT_INTERVAL", ""), func() {
+		if _, err := service.Entrance.TraefikService.TraefikCertService.SyncCertificates(); err != nil {
+			logger.ErrorString("schedule", "traefik-autocert", err.Error())
+		}
+	})
+	// 读取Traefik访问日志并统计流量
+	schedule.Every("traefik-accesslog", scheduleInterval("TRAEFIK_ACCESSLOG_INTERVAL", ""), func() {
+		if err := service.Entrance.TraefikService.TraefikTrafficService.IngestAccessLogs(); err != nil {
+			logger.ErrorString("schedule", "traefik-accesslog", err.Error())
+		}
+	})
+	// 抓取Traefik的Prometheus指标
+	schedule.Every("traefik-metrics", scheduleInterval("TRAEFIK_METRICS_INTERVAL", ""), func() {
+		if err := service.Entrance.TraefikService.TraefikMetricService.ScrapeMetrics(); err != nil {
+			logger.ErrorString("schedule", "traefik-metrics", err.Error())
+		}
+	})
+	// 拉取Git仓库中的新提交并发布
+	schedule.Every("traefik-gitops", scheduleInterval("TRAEFIK_GITOPS_PULL_INTERVAL", ""), func() {
+		if _, err := service.Entrance.TraefikService.TraefikGitOpsService.Pull(false, "schedule"); err != nil {
+			logger.ErrorString("schedule", "traefik-gitops", err.Error())
+		}
+	})
+	// Traefik配置对账
+	schedule.Every("traefik-drift", scheduleInterval("TRAEFIK_DRIFT_INTERVAL", ""), func() {
+		if _, err := service.Entrance.TraefikService.TraefikDriftService.RunDriftCheck("schedule", ""); err != nil {
+			logger.ErrorString("schedule", "traefik-drift", err.Error())
+		}
+	})
+	// 后端服务器健康状态采集
+	schedule.Every("traefik-health", scheduleInterval("TRAEFIK_HEALTH_INTERVAL", ""), func() {
+		if err := service.Entrance.TraefikService.TraefikHealthService.PollServerStatus(); err != nil {
+			logger.ErrorString("schedule", "traefik-health", err.Error())
+		}
+	})
+}
+
+// scheduleInterval 读取形如10m、1h的间隔配置，格式错误时记录日志并视为不启动
+func scheduleInterval(key, defaultValue string) time.Duration {
+	value := config.GetString(key, defaultValue)
+	if value == "" {
+		return 0
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil {
+		logger.WarnString("schedule", key, "无效的间隔配置: "+value)
+		return 0
+	}
+	return interval
+}
@@ -0,0 +1,33 @@
+package initialize
+
+import (
+	"github.com/yahahaff/rapide/internal/service"
+	"github.com/yahahaff/rapide/pkg/config"
+	"github.com/yahahaff/rapide/pkg/logger"
+)
+
+// SetupTraefikSnapshot 从未发布过配置时把当前草稿作为初始快照发布，保证升级前的配置继续生效
+func SetupTraefikSnapshot() {
+	if err := service.Entrance.TraefikService.TraefikPublishService.EnsurePublished(); err != nil {
+		logger.ErrorString("traefik", "initial snapshot", err.Error())
+	}
+}
+
+// SetupTraefikKV 启用KV发布时连接所需的后端，并启动配置变更后的自动发布
+func SetupTraefikKV() {
+	etcdEnabled := config.GetBool("TRAEFIK_KV_ETCD_ENABLED", false)
+	redisEnabled := config.GetBool("TRAEFIK_KV_REDIS_ENABLED", false)
+	if !etcdEnabled && !redisEnabled {
+		return
+	}
+
+	if etcdEnabled {
+		SetupEtcd()
+	}
+	service.Entrance.TraefikService.TraefikKVService.StartKVPublisher()
+}
+
+// SetupTraefikGitOps 配置了Git仓库时启动发布后自动提交配置
+func SetupTraefikGitOps() {
+	service.Entrance.TraefikService.TraefikGitOpsService.StartGitSync()
+}
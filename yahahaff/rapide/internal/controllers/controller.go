@@ -1,5 +1,23 @@
 // Package controllers Package api Package v1 处理业务逻辑,  控制器 v1
 package controllers
 
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/yahahaff/rapide/internal/models/sys"
+)
+
+// DeptScopeKey 当前用户部门范围在gin.Context中的键，由DeptScope中间件设置
+const DeptScopeKey = "current_user_dept_scope"
+
 // BaseAPIController 基础控制器
 type BaseAPIController struct{}
+
+// CurrentDeptScope 获取当前用户可以管理的部门范围，未经过DeptScope中间件时为空范围
+func CurrentDeptScope(c *gin.Context) sys.DeptScope {
+	if scope, ok := c.Get(DeptScopeKey); ok {
+		if deptScope, ok := scope.(sys.DeptScope); ok {
+			return deptScope
+		}
+	}
+	return sys.DeptScope{}
+}
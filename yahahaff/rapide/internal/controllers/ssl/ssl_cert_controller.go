@@ -13,6 +13,7 @@ import (
 	requestsSSL "github.com/yahahaff/rapide/internal/requests/ssl"
 	"github.com/yahahaff/rapide/internal/requests/validators"
 	"github.com/yahahaff/rapide/internal/service"
+	traefikService "github.com/yahahaff/rapide/internal/service/traefik"
 	"github.com/yahahaff/rapide/pkg/response"
 )
 
@@ -44,7 +45,7 @@ func (ctrl *SSLCertController) GetSSLCertList(c *gin.Context) {
 	domain := request.Domain
 	applyStatus := request.ApplyStatus
 
-	data, total, err := service.Entrance.SSLService.SSLCertService.GetSSLCertList(page, pageSize, domain, applyStatus)
+	data, total, err := service.Entrance.SSLService.SSLCertService.GetSSLCertList(page, pageSize, domain, applyStatus, request.App, controllers.CurrentDeptScope(c))
 	if err != nil {
 		response.Abort500(c, "获取SSL证书列表失败")
 		return
@@ -65,6 +66,7 @@ func (ctrl *SSLCertController) CreateSSLCert(c *gin.Context) {
 	if ok := validators.Validate(c, &request); !ok {
 		return
 	}
+	scope := controllers.CurrentDeptScope(c)
 
 	// 设置默认值
 	provider := request.Provider
@@ -82,6 +84,32 @@ func (ctrl *SSLCertController) CreateSSLCert(c *gin.Context) {
 		}
 	}
 
+	// 确定所属部门，未指定时使用用户所在的部门
+	deptID, ok := scope.Owner(request.DeptID)
+	if !ok {
+		if deptID == 0 {
+			response.Abort400(c, "请指定所属部门")
+		} else {
+			response.Abort403(c, fmt.Sprintf("部门%d不在可以管理的范围内", deptID))
+		}
+		return
+	}
+	if deptID != 0 {
+		if _, err := service.Entrance.SysService.DeptService.GetDeptByID(deptID); err != nil {
+			response.Abort400(c, fmt.Sprintf("部门不存在: %d", deptID))
+			return
+		}
+	}
+	// 不能为其他部门的路由使用的域名申请证书
+	if err := service.Entrance.TraefikService.TraefikOwnerService.CheckCertDomain(request.Domain, scope); err != nil {
+		if traefikService.IsDeptError(err) {
+			response.Abort403(c, err.Error())
+		} else {
+			response.Abort500(c, "检查域名失败")
+		}
+		return
+	}
+
 	// 转换请求数据为证书模型
 	cert := sslModel.SSLCert{
 		Domain:           request.Domain,
@@ -100,10 +128,11 @@ func (ctrl *SSLCertController) CreateSSLCert(c *gin.Context) {
 		AutoRenew:        request.AutoRenew,
 		RenewStatus:      "idle",
 		Status:           1, // 默认为启用状态
+		DeptID:           deptID,
 	}
 
 	// 调用服务层创建证书
-	err := service.Entrance.SSLService.SSLCertService.CreateSSLCert(cert)
+	_, err := service.Entrance.SSLService.SSLCertService.CreateSSLCert(cert)
 	if err != nil {
 		response.Abort500(c, "创建SSL证书失败")
 		return
@@ -134,6 +163,10 @@ func (ctrl *SSLCertController) DownloadSSLCert(c *gin.Context) {
 		response.Abort404(c, "证书不存在")
 		return
 	}
+	if !controllers.CurrentDeptScope(c).Contains(cert.DeptID) {
+		response.Abort403(c, "证书属于其他部门")
+		return
+	}
 
 	// 3. 验证证书状态
 	if cert.ApplyStatus != "success" {
@@ -229,14 +262,25 @@ func (ctrl *SSLCertController) RevokeSSLCert(c *gin.Context) {
 	// 1. 获取证书ID
 	certID := c.Param("id")
 
-	// 2. 调用服务层吊销证书
-	err := service.Entrance.SSLService.SSLCertService.RevokeSSLCert(certID)
+	// 2. 检查证书属于当前用户可以管理的部门
+	cert, err := service.Entrance.SSLService.SSLCertService.GetSSLCertByID(certID)
+	if err != nil {
+		response.Abort404(c, "证书不存在")
+		return
+	}
+	if !controllers.CurrentDeptScope(c).Contains(cert.DeptID) {
+		response.Abort403(c, "证书属于其他部门")
+		return
+	}
+
+	// 3. 调用服务层吊销证书
+	err = service.Entrance.SSLService.SSLCertService.RevokeSSLCert(certID)
 	if err != nil {
 		response.Abort500(c, "吊销证书失败: "+err.Error())
 		return
 	}
 
-	// 3. 返回成功响应
+	// 4. 返回成功响应
 	response.OK(c, gin.H{"message": "证书吊销成功"})
 }
 
@@ -262,6 +306,10 @@ func (ctrl *SSLCertController) GetSSLCertDetail(c *gin.Context) {
 		response.Abort404(c, "证书不存在")
 		return
 	}
+	if !controllers.CurrentDeptScope(c).Contains(cert.DeptID) {
+		response.Abort403(c, "证书属于其他部门")
+		return
+	}
 
 	// 3. 计算证书剩余天数
 	now := time.Now()
@@ -295,6 +343,7 @@ func (ctrl *SSLCertController) GetSSLCertDetail(c *gin.Context) {
 		"serialNumber":     cert.SerialNumber,
 		"autoRenew":        cert.AutoRenew,
 		"renewStatus":      cert.RenewStatus,
+		"deptId":           cert.DeptID,
 		"created_at":       cert.CreatedAt,
 		"updated_at":       cert.UpdatedAt,
 		"expiresInDays":    expiresInDays,
@@ -0,0 +1,45 @@
+package traefik
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/yahahaff/rapide/internal/controllers"
+	traefikReq "github.com/yahahaff/rapide/internal/requests/traefik"
+	"github.com/yahahaff/rapide/internal/requests/validators"
+	"github.com/yahahaff/rapide/internal/service"
+	"github.com/yahahaff/rapide/pkg/response"
+)
+
+// TraefikAdoptController 接管运行时对象控制器
+type TraefikAdoptController struct {
+	controllers.BaseAPIController
+}
+
+// Adopt 把Docker标签、文件等Provider定义的路由、服务或中间件接管到rapide，写入草稿，发布后生效
+func (ac *TraefikAdoptController) Adopt(c *gin.Context) {
+	request := traefikReq.TraefikAdoptRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+	conflict := request.Conflict
+	if conflict == "" {
+		conflict = "skip"
+	}
+
+	result, err := service.Entrance.TraefikService.TraefikAdoptService.Adopt(request.Kind, request.Protocol, request.Name,
+		conflict, request.DryRun, request.Checklist, c.GetString("current_user_name"))
+	if err != nil {
+		abortConfigError(c, err, "接管运行时对象失败")
+		return
+	}
+	response.OK(c, result)
+}
+
+// GetAdoptions 获取接管记录，可按原Provider筛选
+func (ac *TraefikAdoptController) GetAdoptions(c *gin.Context) {
+	adoptions, err := service.Entrance.TraefikService.TraefikAdoptService.GetAdoptions(c.Query("provider"))
+	if err != nil {
+		response.Abort500(c, "获取接管记录失败")
+		return
+	}
+	response.OK(c, gin.H{"result": adoptions, "total": len(adoptions)})
+}
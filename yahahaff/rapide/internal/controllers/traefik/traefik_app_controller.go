@@ -0,0 +1,108 @@
+package traefik
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/yahahaff/rapide/internal/controllers"
+	traefikModel "github.com/yahahaff/rapide/internal/models/traefik"
+	traefikReq "github.com/yahahaff/rapide/internal/requests/traefik"
+	"github.com/yahahaff/rapide/internal/requests/validators"
+	"github.com/yahahaff/rapide/internal/service"
+	traefikService "github.com/yahahaff/rapide/internal/service/traefik"
+	"github.com/yahahaff/rapide/pkg/response"
+	"github.com/yahahaff/rapide/pkg/types"
+)
+
+// TraefikAppController 应用目录控制器
+type TraefikAppController struct {
+	controllers.BaseAPIController
+}
+
+// GetApps 获取应用列表，可按部门和环境过滤
+func (apc *TraefikAppController) GetApps(c *gin.Context) {
+	request := traefikReq.TraefikAppListRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	apps, err := service.Entrance.TraefikService.TraefikAppService.GetApps(request.DeptID, request.Environment, controllers.CurrentDeptScope(c))
+	if err != nil {
+		response.Abort500(c, "获取应用列表失败")
+		return
+	}
+	response.OK(c, gin.H{"result": apps, "total": len(apps)})
+}
+
+// GetApp 获取应用详情，包含路由、后端健康状况和证书有效期
+func (apc *TraefikAppController) GetApp(c *gin.Context) {
+	detail, err := service.Entrance.TraefikService.TraefikAppService.GetAppDetail(c.Param("name"), controllers.CurrentDeptScope(c))
+	if err != nil {
+		abortConfigError(c, err, "获取应用详情失败")
+		return
+	}
+	response.OK(c, detail)
+}
+
+// CreateApp 创建应用
+func (apc *TraefikAppController) CreateApp(c *gin.Context) {
+	request := traefikReq.TraefikAppCreateRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	objects := appObjects(request.Objects)
+	if objects == nil {
+		objects = make([]traefikService.AppObject, 0)
+	}
+	app, err := service.Entrance.TraefikService.TraefikAppService.SaveApp(request.Name, buildApp(request.TraefikAppRequest), objects, true, controllers.CurrentDeptScope(c), c.GetString("current_user_name"))
+	if err != nil {
+		abortConfigError(c, err, "创建应用失败")
+		return
+	}
+	response.OK(c, app)
+}
+
+// UpdateApp 更新应用
+func (apc *TraefikAppController) UpdateApp(c *gin.Context) {
+	request := traefikReq.TraefikAppRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	app, err := service.Entrance.TraefikService.TraefikAppService.SaveApp(c.Param("name"), buildApp(request), appObjects(request.Objects), false, controllers.CurrentDeptScope(c), c.GetString("current_user_name"))
+	if err != nil {
+		abortConfigError(c, err, "更新应用失败")
+		return
+	}
+	response.OK(c, app)
+}
+
+// DeleteApp 删除应用，应用包含的对象保留
+func (apc *TraefikAppController) DeleteApp(c *gin.Context) {
+	if err := service.Entrance.TraefikService.TraefikAppService.DeleteApp(c.Param("name"), controllers.CurrentDeptScope(c)); err != nil {
+		abortConfigError(c, err, "删除应用失败")
+		return
+	}
+	response.OK(c, gin.H{"name": c.Param("name")})
+}
+
+// buildApp 根据请求构建应用
+func buildApp(request traefikReq.TraefikAppRequest) traefikModel.TraefikApp {
+	return traefikModel.TraefikApp{
+		Description: request.Description,
+		DeptID:      request.DeptID,
+		Environment: request.Environment,
+		Contacts:    types.JSONSlice(request.Contacts),
+	}
+}
+
+// appObjects 转换请求中的对象，未传入时返回nil
+func appObjects(objects []traefikReq.TraefikAppObject) []traefikService.AppObject {
+	if objects == nil {
+		return nil
+	}
+	result := make([]traefikService.AppObject, 0, len(objects))
+	for _, object := range objects {
+		result = append(result, traefikService.AppObject{Kind: object.Kind, Protocol: object.Protocol, Name: object.Name})
+	}
+	return result
+}
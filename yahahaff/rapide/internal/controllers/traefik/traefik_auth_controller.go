@@ -0,0 +1,166 @@
+package traefik
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yahahaff/rapide/internal/controllers"
+	traefikReq "github.com/yahahaff/rapide/internal/requests/traefik"
+	"github.com/yahahaff/rapide/internal/requests/validators"
+	"github.com/yahahaff/rapide/internal/service"
+	traefikService "github.com/yahahaff/rapide/internal/service/traefik"
+	"github.com/yahahaff/rapide/pkg/config"
+	"github.com/yahahaff/rapide/pkg/logger"
+	"github.com/yahahaff/rapide/pkg/response"
+	"gorm.io/gorm"
+)
+
+// TraefikAuthController 路由访问策略和forwardAuth控制器
+type TraefikAuthController struct {
+	controllers.BaseAPIController
+}
+
+// GetPolicies 获取当前用户可以管理的路由的访问策略
+func (ac *TraefikAuthController) GetPolicies(c *gin.Context) {
+	policies, err := service.Entrance.TraefikService.TraefikAuthService.GetPolicies(controllers.CurrentDeptScope(c))
+	if err != nil {
+		response.Abort500(c, "获取访问策略失败")
+		return
+	}
+	response.OK(c, gin.H{"result": policies, "total": len(policies)})
+}
+
+// SavePolicy 设置路由访问策略，首次设置时在草稿中为路由添加forwardAuth中间件
+func (ac *TraefikAuthController) SavePolicy(c *gin.Context) {
+	request := traefikReq.TraefikAuthPolicyRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+	if !checkObjectOwner(c, "router", "http", c.Param("router")) {
+		return
+	}
+
+	policy, err := service.Entrance.TraefikService.TraefikAuthService.SavePolicy(c.Param("router"), request.Roles, request.Users, request.AnyUser, c.GetString("current_user_name"))
+	if err != nil {
+		abortConfigError(c, err, "设置访问策略失败")
+		return
+	}
+	response.OK(c, policy)
+}
+
+// DeletePolicy 删除路由访问策略，同时在草稿中移除forwardAuth中间件
+func (ac *TraefikAuthController) DeletePolicy(c *gin.Context) {
+	router := c.Param("router")
+	if !checkObjectOwner(c, "router", "http", router) {
+		return
+	}
+	if err := service.Entrance.TraefikService.TraefikAuthService.DeletePolicy(router, c.GetString("current_user_name")); err != nil {
+		abortConfigError(c, err, "删除访问策略失败")
+		return
+	}
+	response.OK(c, gin.H{"router": router})
+}
+
+// CreateSession 为当前用户签发forwardAuth专用的短期令牌并写入Cookie，浏览器访问被保护的应用时forwardAuth从Cookie中读取令牌
+// 需要通过TRAEFIK_FORWARD_AUTH_COOKIE_DOMAIN设置为rapide和应用共同的上级域名；该令牌不能访问rapide的接口
+func (ac *TraefikAuthController) CreateSession(c *gin.Context) {
+	token, ttl, err := service.Entrance.TraefikService.TraefikAuthService.IssueSessionToken(c.GetUint64("current_user_id"), c.GetString("current_user_name"))
+	if err != nil {
+		response.Abort500(c, "签发登录令牌失败")
+		return
+	}
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(forwardAuthCookie(), token, int(ttl.Seconds()), "/",
+		config.GetString("TRAEFIK_FORWARD_AUTH_COOKIE_DOMAIN", ""), config.GetBool("TRAEFIK_FORWARD_AUTH_COOKIE_SECURE", true), true)
+	response.OK(c, gin.H{"cookie": forwardAuthCookie(), "expiresIn": int(ttl.Seconds())})
+}
+
+// DeleteSession 清除forwardAuth使用的Cookie
+func (ac *TraefikAuthController) DeleteSession(c *gin.Context) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(forwardAuthCookie(), "", -1, "/",
+		config.GetString("TRAEFIK_FORWARD_AUTH_COOKIE_DOMAIN", ""), config.GetBool("TRAEFIK_FORWARD_AUTH_COOKIE_SECURE", true), true)
+	response.OK(c, gin.H{"cookie": forwardAuthCookie()})
+}
+
+// ForwardAuth Traefik的forwardAuth中间件把请求转发到这里校验
+// 只接受forwardAuth专用令牌，来自Authorization请求头或Cookie，通过时返回200并在响应头中带上用户名、角色和去掉令牌后的Cookie，
+// 未登录的浏览器请求重定向到登录页，其他未登录请求返回401，没有权限时返回403
+func (ac *TraefikAuthController) ForwardAuth(c *gin.Context) {
+	router := c.Param("router")
+	tokens := make([]string, 0, 2)
+	if header := c.GetHeader("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		tokens = append(tokens, strings.TrimPrefix(header, "Bearer "))
+	}
+	if cookie, err := c.Cookie(forwardAuthCookie()); err == nil && cookie != "" {
+		tokens = append(tokens, cookie)
+	}
+
+	// Authorization请求头可能属于被保护的应用自己，不是rapide的令牌时继续尝试Cookie
+	err := traefikService.ErrAuthUnauthenticated
+	var identity traefikService.AuthIdentity
+	for _, token := range tokens {
+		identity, err = service.Entrance.TraefikService.TraefikAuthService.Authorize(router, token)
+		if !errors.Is(err, traefikService.ErrAuthUnauthenticated) {
+			break
+		}
+	}
+
+	c.Header("Cache-Control", "no-store")
+	switch {
+	case err == nil:
+		c.Header("X-Forwarded-User", identity.UserName)
+		c.Header("X-Forwarded-Roles", strings.Join(identity.Roles, ","))
+		// Traefik用authResponseHeaders中的Cookie替换原请求的Cookie，令牌不会转发给应用
+		if cookie := stripCookie(c.Request, forwardAuthCookie()); cookie != "" {
+			c.Header("Cookie", cookie)
+		}
+		c.Status(http.StatusOK)
+	case errors.Is(err, traefikService.ErrAuthUnauthenticated):
+		loginURL := config.GetString("TRAEFIK_FORWARD_AUTH_LOGIN_URL", "")
+		if loginURL != "" && strings.Contains(c.GetHeader("Accept"), "text/html") {
+			c.Redirect(http.StatusFound, loginRedirectURL(c, router, loginURL))
+			return
+		}
+		c.String(http.StatusUnauthorized, err.Error())
+	case errors.Is(err, traefikService.ErrAuthForbidden), errors.Is(err, gorm.ErrRecordNotFound):
+		c.String(http.StatusForbidden, traefikService.ErrAuthForbidden.Error())
+	default:
+		logger.ErrorString("traefik", "forwardauth", err.Error())
+		c.String(http.StatusInternalServerError, "internal error")
+	}
+}
+
+// forwardAuthCookie forwardAuth读取令牌的Cookie名称
+func forwardAuthCookie() string {
+	return config.GetString("TRAEFIK_FORWARD_AUTH_COOKIE", "rapide_token")
+}
+
+// stripCookie 去掉请求Cookie中的指定Cookie，返回其余的Cookie
+func stripCookie(r *http.Request, name string) string {
+	cookies := make([]string, 0)
+	for _, cookie := range r.Cookies() {
+		if cookie.Name != name {
+			cookies = append(cookies, cookie.Name+"="+cookie.Value)
+		}
+	}
+	return strings.Join(cookies, "; ")
+}
+
+// loginRedirectURL 登录页地址，redirect参数为Traefik转发头中的原始地址，登录后回到原页面
+// 原始地址的域名不在路由的Host规则中时不带redirect参数，登录后停留在登录页
+func loginRedirectURL(c *gin.Context, router, loginURL string) string {
+	proto, host, uri := c.GetHeader("X-Forwarded-Proto"), c.GetHeader("X-Forwarded-Host"), c.GetHeader("X-Forwarded-Uri")
+	if !service.Entrance.TraefikService.TraefikAuthService.RedirectAllowed(router, proto, host, uri) {
+		return loginURL
+	}
+	original := proto + "://" + host + uri
+	separator := "?"
+	if strings.Contains(loginURL, "?") {
+		separator = "&"
+	}
+	return loginURL + separator + "redirect=" + url.QueryEscape(original)
+}
@@ -0,0 +1,40 @@
+package traefik
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/yahahaff/rapide/internal/controllers"
+	traefikReq "github.com/yahahaff/rapide/internal/requests/traefik"
+	"github.com/yahahaff/rapide/internal/requests/validators"
+	"github.com/yahahaff/rapide/internal/service"
+	"github.com/yahahaff/rapide/pkg/response"
+)
+
+// TraefikCertController 路由自动证书控制器
+type TraefikCertController struct {
+	controllers.BaseAPIController
+}
+
+// GetCertLinks 获取路由域名与证书的关联及证书状态
+func (cc *TraefikCertController) GetCertLinks(c *gin.Context) {
+	request := traefikReq.TraefikCertLinkListRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	links, err := service.Entrance.TraefikService.TraefikCertService.GetCertLinks(request.Router, request.Status, request.App, controllers.CurrentDeptScope(c))
+	if err != nil {
+		abortConfigError(c, err, "获取证书关联失败")
+		return
+	}
+	response.OK(c, gin.H{"result": links, "total": len(links)})
+}
+
+// SyncCertificates 立即检查启用TLS的路由，为缺少证书的域名申请证书
+func (cc *TraefikCertController) SyncCertificates(c *gin.Context) {
+	result, err := service.Entrance.TraefikService.TraefikCertService.SyncCertificates()
+	if err != nil {
+		response.Abort500(c, "同步证书失败: "+err.Error())
+		return
+	}
+	response.OK(c, result)
+}
@@ -0,0 +1,430 @@
+package traefik
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yahahaff/rapide/internal/controllers"
+	traefikDAO "github.com/yahahaff/rapide/internal/dao/traefik"
+	traefikModel "github.com/yahahaff/rapide/internal/models/traefik"
+	traefikReq "github.com/yahahaff/rapide/internal/requests/traefik"
+	"github.com/yahahaff/rapide/internal/requests/validators"
+	"github.com/yahahaff/rapide/internal/service"
+	traefikService "github.com/yahahaff/rapide/internal/service/traefik"
+	"github.com/yahahaff/rapide/pkg/response"
+	"github.com/yahahaff/rapide/pkg/types"
+	"gorm.io/gorm"
+)
+
+// TraefikConfigController Traefik配置对象管理控制器，所有变更都会记录修订历史
+type TraefikConfigController struct {
+	controllers.BaseAPIController
+}
+
+// ListRouters 获取数据库中的所有路由，可按应用过滤
+func (cc *TraefikConfigController) ListRouters(c *gin.Context) {
+	request := traefikReq.TraefikConfigListRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	routers, err := service.Entrance.TraefikService.TraefikConfigService.ListRouters(request.App, controllers.CurrentDeptScope(c))
+	if err != nil {
+		abortConfigError(c, err, "获取路由列表失败")
+		return
+	}
+	response.OK(c, gin.H{"result": routers, "total": len(routers)})
+}
+
+// CreateRouter 创建路由
+func (cc *TraefikConfigController) CreateRouter(c *gin.Context) {
+	request := traefikReq.TraefikRouterCreateRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	router := buildRouter(request.TraefikRouterRequest)
+	router.Name, router.Protocol = request.Name, request.Protocol
+	deptID, ok := newObjectOwner(c, request.DeptID)
+	if !ok || !checkRouterHosts(c, router) || !checkReferences(c, router) {
+		return
+	}
+	saved, err := service.Entrance.TraefikService.TraefikConfigService.CreateRouter(router, deptID, c.GetString("current_user_name"))
+	if err != nil {
+		abortConfigError(c, err, "创建路由失败")
+		return
+	}
+	response.OK(c, saved)
+}
+
+// UpdateRouter 更新路由
+func (cc *TraefikConfigController) UpdateRouter(c *gin.Context) {
+	request := traefikReq.TraefikRouterRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	router := buildRouter(request)
+	router.Name, router.Protocol = c.Param("name"), c.Param("protocol")
+	if !checkObjectOwner(c, "router", router.Protocol, router.Name) || !checkRouterHosts(c, router) || !checkReferences(c, router) {
+		return
+	}
+	saved, err := service.Entrance.TraefikService.TraefikConfigService.UpdateRouter(c.Param("name"), c.Param("protocol"), router, c.GetString("current_user_name"))
+	if err != nil {
+		abortConfigError(c, err, "更新路由失败")
+		return
+	}
+	response.OK(c, saved)
+}
+
+// DeleteRouter 删除路由
+func (cc *TraefikConfigController) DeleteRouter(c *gin.Context) {
+	cc.deleteObject(c, "router")
+}
+
+// ListServices 获取数据库中的所有服务，可按应用过滤
+func (cc *TraefikConfigController) ListServices(c *gin.Context) {
+	request := traefikReq.TraefikConfigListRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	services, err := service.Entrance.TraefikService.TraefikConfigService.ListServices(request.App, controllers.CurrentDeptScope(c))
+	if err != nil {
+		abortConfigError(c, err, "获取服务列表失败")
+		return
+	}
+	response.OK(c, gin.H{"result": services, "total": len(services)})
+}
+
+// CreateService 创建服务
+func (cc *TraefikConfigController) CreateService(c *gin.Context) {
+	request := traefikReq.TraefikServiceCreateRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	svc := buildService(request.TraefikServiceRequest)
+	svc.Name, svc.Protocol = request.Name, request.Protocol
+	deptID, ok := newObjectOwner(c, request.DeptID)
+	if !ok || !checkReferences(c, svc) {
+		return
+	}
+	saved, err := service.Entrance.TraefikService.TraefikConfigService.CreateService(svc, deptID, c.GetString("current_user_name"))
+	if err != nil {
+		abortConfigError(c, err, "创建服务失败")
+		return
+	}
+	response.OK(c, saved)
+}
+
+// UpdateService 更新服务
+func (cc *TraefikConfigController) UpdateService(c *gin.Context) {
+	request := traefikReq.TraefikServiceRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	svc := buildService(request)
+	svc.Name, svc.Protocol = c.Param("name"), c.Param("protocol")
+	if !checkObjectOwner(c, "service", svc.Protocol, svc.Name) || !checkReferences(c, svc) {
+		return
+	}
+	saved, err := service.Entrance.TraefikService.TraefikConfigService.UpdateService(c.Param("name"), c.Param("protocol"), svc, c.GetString("current_user_name"))
+	if err != nil {
+		abortConfigError(c, err, "更新服务失败")
+		return
+	}
+	response.OK(c, saved)
+}
+
+// DeleteService 删除服务
+func (cc *TraefikConfigController) DeleteService(c *gin.Context) {
+	cc.deleteObject(c, "service")
+}
+
+// ListMiddlewares 获取数据库中的所有中间件，可按应用过滤
+func (cc *TraefikConfigController) ListMiddlewares(c *gin.Context) {
+	request := traefikReq.TraefikConfigListRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	middlewares, err := service.Entrance.TraefikService.TraefikConfigService.ListMiddlewares(request.App, controllers.CurrentDeptScope(c))
+	if err != nil {
+		abortConfigError(c, err, "获取中间件列表失败")
+		return
+	}
+	response.OK(c, gin.H{"result": middlewares, "total": len(middlewares)})
+}
+
+// CreateMiddleware 创建中间件
+func (cc *TraefikConfigController) CreateMiddleware(c *gin.Context) {
+	request := traefikReq.TraefikMiddlewareCreateRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	middleware := buildMiddleware(request.TraefikMiddlewareRequest)
+	middleware.Name, middleware.Protocol = request.Name, request.Protocol
+	deptID, ok := newObjectOwner(c, request.DeptID)
+	if !ok || !checkReferences(c, middleware) {
+		return
+	}
+	saved, err := service.Entrance.TraefikService.TraefikConfigService.CreateMiddleware(middleware, deptID, c.GetString("current_user_name"))
+	if err != nil {
+		abortConfigError(c, err, "创建中间件失败")
+		return
+	}
+	response.OK(c, saved)
+}
+
+// UpdateMiddleware 更新中间件
+func (cc *TraefikConfigController) UpdateMiddleware(c *gin.Context) {
+	request := traefikReq.TraefikMiddlewareRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	middleware := buildMiddleware(request)
+	middleware.Name, middleware.Protocol = c.Param("name"), c.Param("protocol")
+	if !checkObjectOwner(c, "middleware", middleware.Protocol, middleware.Name) || !checkReferences(c, middleware) {
+		return
+	}
+	saved, err := service.Entrance.TraefikService.TraefikConfigService.UpdateMiddleware(c.Param("name"), c.Param("protocol"), middleware, c.GetString("current_user_name"))
+	if err != nil {
+		abortConfigError(c, err, "更新中间件失败")
+		return
+	}
+	response.OK(c, saved)
+}
+
+// DeleteMiddleware 删除中间件
+func (cc *TraefikConfigController) DeleteMiddleware(c *gin.Context) {
+	cc.deleteObject(c, "middleware")
+}
+
+// GetRevisions 分页获取修订历史，可按对象筛选
+func (cc *TraefikConfigController) GetRevisions(c *gin.Context) {
+	request := traefikReq.TraefikRevisionListRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	// 处理分页参数，设置默认值
+	page := request.Page
+	if page <= 0 {
+		page = 1
+	}
+	pageSize := request.PageSize
+	if pageSize == 0 {
+		pageSize = 20
+	}
+
+	filter := traefikDAO.RevisionFilter{
+		Kind:     request.Kind,
+		Name:     request.Name,
+		Protocol: request.Protocol,
+		Operator: request.Operator,
+	}
+	revisions, total, err := service.Entrance.TraefikService.TraefikConfigService.GetRevisions(filter, controllers.CurrentDeptScope(c), page, pageSize)
+	if err != nil {
+		response.Abort500(c, "获取修订历史失败")
+		return
+	}
+
+	response.OK(c, gin.H{
+		"page":     page,
+		"pageSize": pageSize,
+		"result":   revisions,
+		"total":    total,
+	})
+}
+
+// GetRevisionDetail 获取修订详情及结构化差异
+func (cc *TraefikConfigController) GetRevisionDetail(c *gin.Context) {
+	id, ok := parseRevisionID(c)
+	if !ok {
+		return
+	}
+
+	detail, err := service.Entrance.TraefikService.TraefikConfigService.GetRevisionDetail(id, controllers.CurrentDeptScope(c))
+	if err != nil {
+		abortConfigError(c, err, "获取修订详情失败")
+		return
+	}
+	response.OK(c, detail)
+}
+
+// RollbackRevision 撤销一次修订，把对象恢复到修订之前的状态
+func (cc *TraefikConfigController) RollbackRevision(c *gin.Context) {
+	id, ok := parseRevisionID(c)
+	if !ok {
+		return
+	}
+
+	item, err := service.Entrance.TraefikService.TraefikConfigService.RollbackRevision(id, c.GetString("current_user_name"))
+	if err != nil {
+		abortConfigError(c, err, "回滚修订失败")
+		return
+	}
+	response.OK(c, item)
+}
+
+// RollbackToTime 把全部配置回滚到指定时间点
+func (cc *TraefikConfigController) RollbackToTime(c *gin.Context) {
+	request := traefikReq.TraefikRollbackRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	target, err := time.ParseInLocation(time.DateTime, request.Time, time.Local)
+	if err != nil {
+		if target, err = time.Parse(time.RFC3339, request.Time); err != nil {
+			response.Abort400(c, "无效的时间格式")
+			return
+		}
+	}
+
+	result, err := service.Entrance.TraefikService.TraefikConfigService.RollbackToTime(target, c.GetString("current_user_name"), request.DryRun)
+	if err != nil {
+		abortConfigError(c, err, "回滚配置失败")
+		return
+	}
+	response.OK(c, result)
+}
+
+// deleteObject 按路径中的协议和名称删除对象
+func (cc *TraefikConfigController) deleteObject(c *gin.Context, kind string) {
+	name, protocol := c.Param("name"), c.Param("protocol")
+	if !checkObjectOwner(c, kind, protocol, name) {
+		return
+	}
+	if err := service.Entrance.TraefikService.TraefikConfigService.DeleteObject(kind, name, protocol, c.GetString("current_user_name")); err != nil {
+		abortConfigError(c, err, "删除失败")
+		return
+	}
+	response.OK(c, gin.H{"kind": kind, "name": name, "protocol": protocol})
+}
+
+// buildRouter 把请求转换为路由模型
+func buildRouter(request traefikReq.TraefikRouterRequest) traefikModel.TraefikRouter {
+	router := traefikModel.TraefikRouter{
+		EntryPoints: types.JSONSlice(request.EntryPoints),
+		Service:     request.Service,
+		Rule:        request.Rule,
+		RuleSyntax:  request.RuleSyntax,
+		Priority:    request.Priority,
+		Middlewares: types.JSONSlice(request.Middlewares),
+		TLS:         types.JSONMap(request.TLS),
+		Status:      request.Status,
+		Provider:    "http",
+		Tags:        types.JSONSlice(request.Tags),
+		NoAutoCert:  request.NoAutoCert,
+	}
+	if router.RuleSyntax == "" {
+		router.RuleSyntax = "default"
+	}
+	if router.Status == "" {
+		router.Status = "enabled"
+	}
+	return router
+}
+
+// buildService 把请求转换为服务模型
+func buildService(request traefikReq.TraefikServiceRequest) traefikModel.TraefikService {
+	svc := traefikModel.TraefikService{
+		Type:         request.Type,
+		LoadBalancer: types.JSONMap(request.LoadBalancer),
+		Weighted:     types.JSONMap(request.Weighted),
+		Mirror:       types.JSONMap(request.Mirror),
+		TCP:          types.JSONMap(request.TCP),
+		UDP:          types.JSONMap(request.UDP),
+		Status:       request.Status,
+		Provider:     "http",
+		Tags:         types.JSONSlice(request.Tags),
+	}
+	if svc.Status == "" {
+		svc.Status = "enabled"
+	}
+	return svc
+}
+
+// buildMiddleware 把请求转换为中间件模型
+func buildMiddleware(request traefikReq.TraefikMiddlewareRequest) traefikModel.TraefikMiddleware {
+	middleware := traefikModel.TraefikMiddleware{
+		Type:     request.Type,
+		Config:   types.JSONMap(request.Config),
+		Status:   request.Status,
+		Provider: "http",
+		Tags:     types.JSONSlice(request.Tags),
+	}
+	if middleware.Status == "" {
+		middleware.Status = "enabled"
+	}
+	return middleware
+}
+
+// abortConfigError 根据错误类型返回400、404或500
+func abortConfigError(c *gin.Context, err error, message string) {
+	switch {
+	case traefikService.IsValidationError(err):
+		response.Abort400(c, err.Error())
+	case traefikService.IsDeptError(err):
+		response.Abort403(c, err.Error())
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		response.Abort404(c, "对象不存在")
+	default:
+		response.Abort500(c, message)
+	}
+}
+
+// checkObjectOwner 检查对象属于当前用户可以管理的部门，不属于时返回403
+func checkObjectOwner(c *gin.Context, kind, protocol, name string) bool {
+	err := service.Entrance.TraefikService.TraefikOwnerService.CheckObject(kind, protocol, name, controllers.CurrentDeptScope(c))
+	if err != nil {
+		abortConfigError(c, err, "检查所属部门失败")
+		return false
+	}
+	return true
+}
+
+// newObjectOwner 确定新对象的所属部门，未指定时使用用户所在的部门
+func newObjectOwner(c *gin.Context, deptID uint64) (uint64, bool) {
+	owner, err := service.Entrance.TraefikService.TraefikOwnerService.NewObjectOwner(deptID, controllers.CurrentDeptScope(c))
+	if err != nil {
+		abortConfigError(c, err, "检查所属部门失败")
+		return 0, false
+	}
+	return owner, true
+}
+
+// checkRouterHosts 检查路由使用的域名没有被其他部门的路由使用
+func checkRouterHosts(c *gin.Context, router traefikModel.TraefikRouter) bool {
+	if err := service.Entrance.TraefikService.TraefikOwnerService.CheckRouterHosts(router, controllers.CurrentDeptScope(c)); err != nil {
+		abortConfigError(c, err, "检查路由域名失败")
+		return false
+	}
+	return true
+}
+
+// checkReferences 检查对象引用的服务和中间件属于当前用户可以管理的部门
+func checkReferences(c *gin.Context, object interface{}) bool {
+	if err := service.Entrance.TraefikService.TraefikOwnerService.CheckReferences(object, controllers.CurrentDeptScope(c)); err != nil {
+		abortConfigError(c, err, "检查引用的对象失败")
+		return false
+	}
+	return true
+}
+
+// parseRevisionID 从URL路径中解析修订ID
+func parseRevisionID(c *gin.Context) (uint64, bool) {
+	var id uint64
+	if _, err := fmt.Sscan(c.Param("id"), &id); err != nil || id == 0 {
+		response.Abort400(c, "无效的修订ID")
+		return 0, false
+	}
+	return id, true
+}
@@ -25,6 +25,12 @@ func (tc *TraefikController) GetRoutes(c *gin.Context) {
 		response.Abort500(c, err.Error())
 		return
 	}
+	// 只返回当前用户可以管理的对象
+	routes, err = service.Entrance.TraefikService.TraefikOwnerService.FilterRuntime("router", routes, controllers.CurrentDeptScope(c))
+	if err != nil {
+		response.Abort500(c, "检查所属部门失败")
+		return
+	}
 
 	// 获取分页参数，支持两种格式：page[currentPage] 和 page
 	page := c.DefaultQuery("page[currentPage]", c.DefaultQuery("page", "1"))
@@ -53,6 +59,12 @@ func (tc *TraefikController) GetMiddlewares(c *gin.Context) {
 		response.Abort500(c, err.Error())
 		return
 	}
+	// 只返回当前用户可以管理的对象
+	middlewares, err = service.Entrance.TraefikService.TraefikOwnerService.FilterRuntime("middleware", middlewares, controllers.CurrentDeptScope(c))
+	if err != nil {
+		response.Abort500(c, "检查所属部门失败")
+		return
+	}
 
 	// 获取分页参数，支持两种格式：page[currentPage] 和 page
 	page := c.DefaultQuery("page[currentPage]", c.DefaultQuery("page", "1"))
@@ -81,6 +93,12 @@ func (tc *TraefikController) GetServices(c *gin.Context) {
 		response.Abort500(c, err.Error())
 		return
 	}
+	// 只返回当前用户可以管理的对象
+	services, err = service.Entrance.TraefikService.TraefikOwnerService.FilterRuntime("service", services, controllers.CurrentDeptScope(c))
+	if err != nil {
+		response.Abort500(c, "检查所属部门失败")
+		return
+	}
 
 	// 获取分页参数，支持两种格式：page[currentPage] 和 page
 	page := c.DefaultQuery("page[currentPage]", c.DefaultQuery("page", "1"))
@@ -122,6 +140,11 @@ func (tc *TraefikController) GetRouteDetail(c *gin.Context) {
 		response.Abort400(c, "Route name is required")
 		return
 	}
+	routeName, err := service.Entrance.TraefikService.TraefikOwnerService.RuntimeName("router", routeName, controllers.CurrentDeptScope(c))
+	if err != nil {
+		abortConfigError(c, err, "检查所属部门失败")
+		return
+	}
 
 	// 调用服务获取Traefik路由详情
 	routeDetail, err := service.Entrance.TraefikService.TraefikService.GetRouteDetail(routeName)
@@ -145,6 +168,11 @@ func (tc *TraefikController) GetServiceDetail(c *gin.Context) {
 		response.Abort400(c, "Service name is required")
 		return
 	}
+	serviceName, err := service.Entrance.TraefikService.TraefikOwnerService.RuntimeName("service", serviceName, controllers.CurrentDeptScope(c))
+	if err != nil {
+		abortConfigError(c, err, "检查所属部门失败")
+		return
+	}
 
 	// 调用服务获取Traefik服务详情
 	serviceDetail, err := service.Entrance.TraefikService.TraefikService.GetServiceDetail(serviceName)
@@ -168,6 +196,11 @@ func (tc *TraefikController) GetMiddlewareDetail(c *gin.Context) {
 		response.Abort400(c, "Middleware name is required")
 		return
 	}
+	middlewareName, err := service.Entrance.TraefikService.TraefikOwnerService.RuntimeName("middleware", middlewareName, controllers.CurrentDeptScope(c))
+	if err != nil {
+		abortConfigError(c, err, "检查所属部门失败")
+		return
+	}
 
 	// 调用服务获取Traefik中间件详情
 	middlewareDetail, err := service.Entrance.TraefikService.TraefikService.GetMiddlewareDetail(middlewareName)
@@ -0,0 +1,94 @@
+package traefik
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yahahaff/rapide/internal/controllers"
+	traefikReq "github.com/yahahaff/rapide/internal/requests/traefik"
+	"github.com/yahahaff/rapide/internal/requests/validators"
+	"github.com/yahahaff/rapide/internal/service"
+	"github.com/yahahaff/rapide/pkg/response"
+	"gorm.io/gorm"
+)
+
+// TraefikDriftController Traefik配置对账控制器
+type TraefikDriftController struct {
+	controllers.BaseAPIController
+}
+
+// RunDriftCheck 立即执行一次对账
+func (dc *TraefikDriftController) RunDriftCheck(c *gin.Context) {
+	report, err := service.Entrance.TraefikService.TraefikDriftService.RunDriftCheck("manual", c.GetString("current_user_name"))
+	if err != nil {
+		response.Abort500(c, "执行配置对账失败")
+		return
+	}
+	response.OK(c, report)
+}
+
+// GetDriftReports 分页获取对账报告
+func (dc *TraefikDriftController) GetDriftReports(c *gin.Context) {
+	request := traefikReq.TraefikDriftListRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	// 处理分页参数，设置默认值
+	page := request.Page
+	if page <= 0 {
+		page = 1
+	}
+	pageSize := request.PageSize
+	if pageSize == 0 {
+		pageSize = 20
+	}
+
+	reports, total, err := service.Entrance.TraefikService.TraefikDriftService.GetDriftReports(page, pageSize)
+	if err != nil {
+		response.Abort500(c, "获取对账报告失败")
+		return
+	}
+
+	response.OK(c, gin.H{
+		"page":     page,
+		"pageSize": pageSize,
+		"result":   reports,
+		"total":    total,
+	})
+}
+
+// GetLatestDriftReport 获取最近一次对账报告
+func (dc *TraefikDriftController) GetLatestDriftReport(c *gin.Context) {
+	report, err := service.Entrance.TraefikService.TraefikDriftService.GetLatestDriftReport()
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.Abort404(c, "暂无对账报告")
+			return
+		}
+		response.Abort500(c, "获取对账报告失败")
+		return
+	}
+	response.OK(c, report)
+}
+
+// GetDriftReport 获取对账报告详情
+func (dc *TraefikDriftController) GetDriftReport(c *gin.Context) {
+	var id uint64
+	if _, err := fmt.Sscan(c.Param("id"), &id); err != nil || id == 0 {
+		response.Abort400(c, "无效的报告ID")
+		return
+	}
+
+	report, err := service.Entrance.TraefikService.TraefikDriftService.GetDriftReport(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.Abort404(c, "对账报告不存在")
+			return
+		}
+		response.Abort500(c, "获取对账报告失败")
+		return
+	}
+	response.OK(c, report)
+}
@@ -0,0 +1,178 @@
+package traefik
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yahahaff/rapide/internal/controllers"
+	traefikReq "github.com/yahahaff/rapide/internal/requests/traefik"
+	"github.com/yahahaff/rapide/internal/service"
+	traefikService "github.com/yahahaff/rapide/internal/service/traefik"
+	"github.com/yahahaff/rapide/pkg/response"
+)
+
+// maxImportSize 导入文件大小上限
+const maxImportSize = 10 << 20
+
+// TraefikFileController Traefik动态配置文件导入导出控制器
+type TraefikFileController struct {
+	controllers.BaseAPIController
+}
+
+// ImportConfig 导入Traefik文件Provider格式的YAML/TOML配置
+// 支持dryRun预览差异，conflict指定同名对象的处理策略：skip（默认）或overwrite
+func (fc *TraefikFileController) ImportConfig(c *gin.Context) {
+	request := traefikReq.TraefikImportRequest{}
+	if err := c.ShouldBindQuery(&request); err != nil {
+		response.Abort400(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	// 1. 读取配置文档，优先使用multipart上传的文件
+	var data []byte
+	format := request.Format
+	if file, err := c.FormFile("file"); err == nil {
+		if file.Size > maxImportSize {
+			response.Abort400(c, "导入文件过大")
+			return
+		}
+		reader, err := file.Open()
+		if err != nil {
+			response.Abort400(c, "读取导入文件失败")
+			return
+		}
+		defer reader.Close()
+		if data, err = io.ReadAll(reader); err != nil {
+			response.Abort400(c, "读取导入文件失败")
+			return
+		}
+		if format == "" {
+			format = strings.TrimPrefix(strings.ToLower(filepath.Ext(file.Filename)), ".")
+		}
+	} else {
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize))
+		if err != nil {
+			response.Abort400(c, "读取请求体失败")
+			return
+		}
+		data = body
+	}
+	if len(data) == 0 {
+		response.Abort400(c, "导入内容不能为空")
+		return
+	}
+
+	// 2. 设置默认值
+	if format == "" {
+		format = "yaml"
+	}
+	conflict := request.Conflict
+	if conflict == "" {
+		conflict = "skip"
+	}
+
+	// 3. 解析配置文档
+	fileService := &service.Entrance.TraefikService.TraefikFileService
+	set, warnings, err := fileService.ParseDynamicConfig(data, format)
+	if err != nil {
+		response.Abort400(c, err.Error())
+		return
+	}
+
+	// 4. 写入数据库或仅预览差异
+	result, err := fileService.ImportConfigSet(set, conflict, request.DryRun, c.GetString("current_user_name"))
+	if err != nil {
+		response.Abort500(c, "导入Traefik配置失败: "+err.Error())
+		return
+	}
+	result.Warnings = warnings
+
+	response.OK(c, result)
+}
+
+// ExportConfig 导出当前启用的Traefik配置为文件Provider格式
+func (fc *TraefikFileController) ExportConfig(c *gin.Context) {
+	request := traefikReq.TraefikExportRequest{}
+	if err := c.ShouldBindQuery(&request); err != nil {
+		response.Abort400(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	format := request.Format
+	if format == "" {
+		format = "yaml"
+	}
+
+	content, err := service.Entrance.TraefikService.TraefikFileService.ExportConfig(format, request.Version)
+	if err != nil {
+		abortConfigError(c, err, "导出Traefik配置失败")
+		return
+	}
+
+	contentType := "application/x-yaml"
+	if format == "toml" {
+		contentType = "application/toml"
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"dynamic.%s\"", format))
+	c.Data(http.StatusOK, contentType, content)
+}
+
+// ExportKubernetes 把选中的路由及其引用的服务、中间件导出为Kubernetes CRD清单
+func (fc *TraefikFileController) ExportKubernetes(c *gin.Context) {
+	request := traefikReq.TraefikManifestRequest{}
+	if err := c.ShouldBindQuery(&request); err != nil {
+		response.Abort400(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	result, err := service.Entrance.TraefikService.TraefikManifestService.ExportKubernetes(manifestFilter(request), request.Namespace)
+	if err != nil {
+		abortConfigError(c, err, "导出Kubernetes清单失败")
+		return
+	}
+	respondManifest(c, request, result, "traefik-crd.yaml")
+}
+
+// ExportDockerLabels 把选中的路由及其引用的服务、中间件导出为docker-compose标签
+func (fc *TraefikFileController) ExportDockerLabels(c *gin.Context) {
+	request := traefikReq.TraefikManifestRequest{}
+	if err := c.ShouldBindQuery(&request); err != nil {
+		response.Abort400(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	result, err := service.Entrance.TraefikService.TraefikManifestService.ExportDockerLabels(manifestFilter(request))
+	if err != nil {
+		abortConfigError(c, err, "导出Docker标签失败")
+		return
+	}
+	respondManifest(c, request, result, "docker-compose.traefik.yaml")
+}
+
+// manifestFilter 把请求中逗号分隔的参数转换为筛选条件
+func manifestFilter(request traefikReq.TraefikManifestRequest) traefikService.ManifestFilter {
+	split := func(value string) []string {
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items
+	}
+	return traefikService.ManifestFilter{Routers: split(request.Routers), Tags: split(request.Tags)}
+}
+
+// respondManifest 下载时直接返回YAML文件，否则返回内容和警告
+func respondManifest(c *gin.Context, request traefikReq.TraefikManifestRequest, result traefikService.ManifestResult, filename string) {
+	if !request.Download {
+		response.OK(c, result)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	c.Data(http.StatusOK, "application/x-yaml", []byte(result.Content))
+}
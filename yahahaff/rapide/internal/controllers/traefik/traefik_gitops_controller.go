@@ -0,0 +1,55 @@
+package traefik
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/yahahaff/rapide/internal/controllers"
+	traefikReq "github.com/yahahaff/rapide/internal/requests/traefik"
+	"github.com/yahahaff/rapide/internal/requests/validators"
+	"github.com/yahahaff/rapide/internal/service"
+	"github.com/yahahaff/rapide/pkg/response"
+)
+
+// TraefikGitOpsController 配置与Git仓库同步控制器
+type TraefikGitOpsController struct {
+	controllers.BaseAPIController
+}
+
+// Push 立即把当前发布的配置提交到仓库，通常在发布后自动执行，用于失败后重试
+func (gc *TraefikGitOpsController) Push(c *gin.Context) {
+	sync, err := service.Entrance.TraefikService.TraefikGitOpsService.Push()
+	if err != nil {
+		abortConfigError(c, err, "提交配置到Git仓库失败")
+		return
+	}
+	response.OK(c, sync)
+}
+
+// Pull 把仓库HEAD中的配置校验后写入草稿并发布
+func (gc *TraefikGitOpsController) Pull(c *gin.Context) {
+	request := traefikReq.TraefikGitPullRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	result, err := service.Entrance.TraefikService.TraefikGitOpsService.Pull(request.DryRun, c.GetString("current_user_name"))
+	if err != nil {
+		abortConfigError(c, err, "拉取Git仓库中的配置失败")
+		return
+	}
+	response.OK(c, result)
+}
+
+// GetSyncs 分页获取Git同步记录
+func (gc *TraefikGitOpsController) GetSyncs(c *gin.Context) {
+	request := traefikReq.TraefikGitSyncListRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	syncs, total, err := service.Entrance.TraefikService.TraefikGitOpsService.GetGitSyncs(request.Direction, request.Page, request.PageSize)
+	if err != nil {
+		response.Abort500(c, "获取Git同步记录失败")
+		return
+	}
+	response.OK(c, gin.H{"result": syncs, "total": total})
+}
@@ -0,0 +1,55 @@
+package traefik
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yahahaff/rapide/internal/controllers"
+	traefikReq "github.com/yahahaff/rapide/internal/requests/traefik"
+	"github.com/yahahaff/rapide/internal/requests/validators"
+	"github.com/yahahaff/rapide/internal/service"
+	"github.com/yahahaff/rapide/pkg/response"
+)
+
+// TraefikHealthController 后端服务器健康状况控制器
+type TraefikHealthController struct {
+	controllers.BaseAPIController
+}
+
+// GetServiceHealth 获取各服务的后端健康状况，可用率默认统计最近24小时
+func (hc *TraefikHealthController) GetServiceHealth(c *gin.Context) {
+	request := traefikReq.TraefikHealthRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	hours := request.Hours
+	if hours == 0 {
+		hours = 24
+	}
+
+	health, err := service.Entrance.TraefikService.TraefikHealthService.GetServiceHealth(time.Duration(hours)*time.Hour, controllers.CurrentDeptScope(c))
+	if err != nil {
+		response.Abort500(c, "获取后端健康状况失败")
+		return
+	}
+	response.OK(c, gin.H{
+		"hours":  hours,
+		"result": health,
+	})
+}
+
+// GetServiceTransitions 获取后端服务器状态变化记录，按时间倒序
+func (hc *TraefikHealthController) GetServiceTransitions(c *gin.Context) {
+	request := traefikReq.TraefikTransitionListRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	transitions, err := service.Entrance.TraefikService.TraefikHealthService.GetServiceTransitions(request.Service, request.Limit, controllers.CurrentDeptScope(c))
+	if err != nil {
+		abortConfigError(c, err, "获取状态变化记录失败")
+		return
+	}
+	response.OK(c, transitions)
+}
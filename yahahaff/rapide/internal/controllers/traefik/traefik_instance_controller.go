@@ -0,0 +1,175 @@
+package traefik
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yahahaff/rapide/internal/controllers"
+	traefikModel "github.com/yahahaff/rapide/internal/models/traefik"
+	traefikReq "github.com/yahahaff/rapide/internal/requests/traefik"
+	"github.com/yahahaff/rapide/internal/requests/validators"
+	"github.com/yahahaff/rapide/internal/service"
+	traefikService "github.com/yahahaff/rapide/internal/service/traefik"
+	"github.com/yahahaff/rapide/pkg/response"
+	"github.com/yahahaff/rapide/pkg/types"
+)
+
+// TraefikInstanceController Traefik实例控制器
+type TraefikInstanceController struct {
+	controllers.BaseAPIController
+}
+
+// GetInstances 获取Traefik实例列表
+func (ic *TraefikInstanceController) GetInstances(c *gin.Context) {
+	instances, err := service.Entrance.TraefikService.TraefikInstanceService.GetInstances()
+	if err != nil {
+		response.Abort500(c, "获取Traefik实例列表失败")
+		return
+	}
+
+	response.OK(c, gin.H{
+		"result": instances,
+		"total":  len(instances),
+	})
+}
+
+// CreateInstance 创建Traefik实例
+func (ic *TraefikInstanceController) CreateInstance(c *gin.Context) {
+	request := traefikReq.TraefikInstanceCreateRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	status := request.Status
+	if status == "" {
+		status = "enabled"
+	}
+
+	instance := traefikModel.TraefikInstance{
+		Name:           request.Name,
+		AllowIPs:       types.JSONSlice(request.AllowIPs),
+		Tags:           types.JSONSlice(request.Tags),
+		EntryPoints:    types.JSONSlice(request.EntryPoints),
+		Status:         status,
+		Remark:         request.Remark,
+		AccessLogPath:  request.AccessLogPath,
+		MetricsURL:     request.MetricsURL,
+		TraefikVersion: request.TraefikVersion,
+	}
+
+	token, err := service.Entrance.TraefikService.TraefikInstanceService.CreateInstance(&instance)
+	if err != nil {
+		if traefikService.IsValidationError(err) {
+			response.Abort400(c, err.Error())
+			return
+		}
+		response.Abort500(c, "创建Traefik实例失败")
+		return
+	}
+
+	// 令牌只在此处返回一次
+	response.OK(c, gin.H{
+		"instance": instance,
+		"token":    token,
+	})
+}
+
+// UpdateInstance 更新Traefik实例
+func (ic *TraefikInstanceController) UpdateInstance(c *gin.Context) {
+	id, ok := parseInstanceID(c)
+	if !ok {
+		return
+	}
+
+	request := traefikReq.TraefikInstanceUpdateRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	instance, err := service.Entrance.TraefikService.TraefikInstanceService.GetInstanceByID(id)
+	if err != nil {
+		response.Abort404(c, "Traefik实例不存在")
+		return
+	}
+
+	// 只更新请求中包含的字段，传入空数组可清空限制
+	if request.Name != "" {
+		instance.Name = request.Name
+	}
+	if request.AllowIPs != nil {
+		instance.AllowIPs = types.JSONSlice(request.AllowIPs)
+	}
+	if request.Tags != nil {
+		instance.Tags = types.JSONSlice(request.Tags)
+	}
+	if request.EntryPoints != nil {
+		instance.EntryPoints = types.JSONSlice(request.EntryPoints)
+	}
+	if request.Status != "" {
+		instance.Status = request.Status
+	}
+	if request.Remark != "" {
+		instance.Remark = request.Remark
+	}
+	if request.AccessLogPath != nil {
+		instance.AccessLogPath = *request.AccessLogPath
+	}
+	if request.MetricsURL != nil {
+		instance.MetricsURL = *request.MetricsURL
+	}
+	if request.TraefikVersion != nil {
+		instance.TraefikVersion = *request.TraefikVersion
+	}
+
+	if err := service.Entrance.TraefikService.TraefikInstanceService.UpdateInstance(&instance); err != nil {
+		if traefikService.IsValidationError(err) {
+			response.Abort400(c, err.Error())
+			return
+		}
+		response.Abort500(c, "更新Traefik实例失败")
+		return
+	}
+
+	response.OK(c, instance)
+}
+
+// DeleteInstance 删除Traefik实例
+func (ic *TraefikInstanceController) DeleteInstance(c *gin.Context) {
+	id, ok := parseInstanceID(c)
+	if !ok {
+		return
+	}
+
+	if err := service.Entrance.TraefikService.TraefikInstanceService.DeleteInstance(id); err != nil {
+		response.Abort500(c, "删除Traefik实例失败")
+		return
+	}
+
+	response.OK(c, gin.H{"id": id})
+}
+
+// ResetInstanceToken 重置Traefik实例令牌
+func (ic *TraefikInstanceController) ResetInstanceToken(c *gin.Context) {
+	id, ok := parseInstanceID(c)
+	if !ok {
+		return
+	}
+
+	token, err := service.Entrance.TraefikService.TraefikInstanceService.ResetInstanceToken(id)
+	if err != nil {
+		response.Abort500(c, "重置Traefik实例令牌失败")
+		return
+	}
+
+	response.OK(c, gin.H{"id": id, "token": token})
+}
+
+// parseInstanceID 从URL路径中解析实例ID
+func parseInstanceID(c *gin.Context) (uint64, bool) {
+	var id uint64
+	if _, err := fmt.Sscan(c.Param("id"), &id); err != nil || id == 0 {
+		response.Abort400(c, "无效的实例ID")
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,36 @@
+package traefik
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yahahaff/rapide/internal/controllers"
+	"github.com/yahahaff/rapide/internal/service"
+	"github.com/yahahaff/rapide/pkg/config"
+	"github.com/yahahaff/rapide/pkg/response"
+)
+
+// TraefikKVController Traefik KV Provider发布控制器
+type TraefikKVController struct {
+	controllers.BaseAPIController
+}
+
+// PreviewKV 预览将要写入KV存储的键值对
+func (kc *TraefikKVController) PreviewKV(c *gin.Context) {
+	kvs, err := service.Entrance.TraefikService.TraefikKVService.BuildKVPairs(config.GetString("TRAEFIK_KV_ROOT_KEY", "traefik"))
+	if err != nil {
+		response.Abort500(c, "构建KV配置失败")
+		return
+	}
+	response.OK(c, gin.H{"result": kvs, "total": len(kvs)})
+}
+
+// PublishKV 立即将当前配置发布到启用的KV后端
+func (kc *TraefikKVController) PublishKV(c *gin.Context) {
+	results, err := service.Entrance.TraefikService.TraefikKVService.Publish()
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, response.WithMessage("发布KV配置失败: "+err.Error()), response.WithData(results))
+		return
+	}
+	response.OK(c, results)
+}
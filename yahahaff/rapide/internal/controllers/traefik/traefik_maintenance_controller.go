@@ -0,0 +1,187 @@
+package traefik
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yahahaff/rapide/internal/controllers"
+	traefikReq "github.com/yahahaff/rapide/internal/requests/traefik"
+	"github.com/yahahaff/rapide/internal/requests/validators"
+	"github.com/yahahaff/rapide/internal/service"
+	traefikService "github.com/yahahaff/rapide/internal/service/traefik"
+	"github.com/yahahaff/rapide/pkg/response"
+	"gorm.io/gorm"
+)
+
+// TraefikMaintenanceController 路由维护窗口控制器
+type TraefikMaintenanceController struct {
+	controllers.BaseAPIController
+}
+
+// CreateMaintenance 创建维护窗口，未指定开始时间时立即进入维护
+func (mc *TraefikMaintenanceController) CreateMaintenance(c *gin.Context) {
+	request := traefikReq.TraefikMaintenanceRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	if !checkObjectOwner(c, "router", "http", request.Router) {
+		return
+	}
+
+	startAt, err := parseOptionalTime(request.StartAt)
+	if err != nil {
+		response.Abort400(c, "无效的开始时间")
+		return
+	}
+	endAt, err := parseOptionalTime(request.EndAt)
+	if err != nil {
+		response.Abort400(c, "无效的结束时间")
+		return
+	}
+
+	maintenance, err := service.Entrance.TraefikService.TraefikMaintenanceService.CreateMaintenance(traefikService.MaintenanceInput{
+		Router:     request.Router,
+		Backend:    request.Backend,
+		StatusCode: request.StatusCode,
+		Page:       request.Page,
+		Allowlist:  request.Allowlist,
+		StartAt:    startAt,
+		EndAt:      endAt,
+		Reason:     request.Reason,
+	}, c.GetString("current_user_name"))
+	if err != nil {
+		abortConfigError(c, err, "创建维护窗口失败")
+		return
+	}
+	response.OK(c, maintenance)
+}
+
+// GetMaintenances 分页获取维护窗口
+func (mc *TraefikMaintenanceController) GetMaintenances(c *gin.Context) {
+	request := traefikReq.TraefikMaintenanceListRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	// 处理分页参数，设置默认值
+	page := request.Page
+	if page <= 0 {
+		page = 1
+	}
+	pageSize := request.PageSize
+	if pageSize == 0 {
+		pageSize = 20
+	}
+
+	maintenances, total, err := service.Entrance.TraefikService.TraefikMaintenanceService.GetMaintenances(request.Router, request.Status, controllers.CurrentDeptScope(c), page, pageSize)
+	if err != nil {
+		response.Abort500(c, "获取维护窗口失败")
+		return
+	}
+
+	response.OK(c, gin.H{
+		"page":     page,
+		"pageSize": pageSize,
+		"result":   maintenances,
+		"total":    total,
+	})
+}
+
+// GetMaintenance 获取维护窗口详情
+func (mc *TraefikMaintenanceController) GetMaintenance(c *gin.Context) {
+	id, ok := parseMaintenanceID(c)
+	if !ok {
+		return
+	}
+
+	maintenance, err := service.Entrance.TraefikService.TraefikMaintenanceService.GetMaintenance(id)
+	if err != nil {
+		abortConfigError(c, err, "获取维护窗口失败")
+		return
+	}
+	if !checkObjectOwner(c, "router", maintenance.Protocol, maintenance.Router) {
+		return
+	}
+	response.OK(c, maintenance)
+}
+
+// EndMaintenance 结束或取消维护窗口，路由恢复指向原服务
+func (mc *TraefikMaintenanceController) EndMaintenance(c *gin.Context) {
+	id, ok := ownedMaintenanceID(c)
+	if !ok {
+		return
+	}
+
+	maintenance, err := service.Entrance.TraefikService.TraefikMaintenanceService.EndMaintenance(id, c.GetString("current_user_name"))
+	if err != nil {
+		abortConfigError(c, err, "结束维护窗口失败")
+		return
+	}
+	response.OK(c, maintenance)
+}
+
+// MaintenancePage 返回维护页面，由Traefik转发维护中路由的请求，不需要认证
+func (mc *TraefikMaintenanceController) MaintenancePage(c *gin.Context) {
+	var id uint64
+	if _, err := fmt.Sscan(c.Param("id"), &id); err != nil || id == 0 {
+		c.String(404, "not found")
+		return
+	}
+
+	statusCode, page, endAt, err := service.Entrance.TraefikService.TraefikMaintenanceService.MaintenancePage(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.String(404, "not found")
+		return
+	}
+	if err != nil {
+		c.String(500, "internal error")
+		return
+	}
+	if endAt != nil && statusCode == 503 {
+		c.Header("Retry-After", endAt.UTC().Format(http.TimeFormat))
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Data(statusCode, "text/html; charset=utf-8", []byte(page))
+}
+
+// parseMaintenanceID 从URL路径中解析维护窗口ID
+func parseMaintenanceID(c *gin.Context) (uint64, bool) {
+	var id uint64
+	if _, err := fmt.Sscan(c.Param("id"), &id); err != nil || id == 0 {
+		response.Abort400(c, "无效的维护窗口ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// ownedMaintenanceID 解析维护窗口ID，并检查维护的路由属于当前用户可以管理的部门
+func ownedMaintenanceID(c *gin.Context) (uint64, bool) {
+	id, ok := parseMaintenanceID(c)
+	if !ok {
+		return 0, false
+	}
+	maintenance, err := service.Entrance.TraefikService.TraefikMaintenanceService.GetMaintenance(id)
+	if err != nil {
+		abortConfigError(c, err, "获取维护窗口失败")
+		return 0, false
+	}
+	return id, checkObjectOwner(c, "router", maintenance.Protocol, maintenance.Router)
+}
+
+// parseOptionalTime 解析RFC3339或2006-01-02 15:04:05格式的时间，空字符串返回nil
+func parseOptionalTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	target, err := time.ParseInLocation(time.DateTime, value, time.Local)
+	if err != nil {
+		if target, err = time.Parse(time.RFC3339, value); err != nil {
+			return nil, err
+		}
+	}
+	return &target, nil
+}
@@ -0,0 +1,92 @@
+package traefik
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yahahaff/rapide/internal/controllers"
+	traefikReq "github.com/yahahaff/rapide/internal/requests/traefik"
+	"github.com/yahahaff/rapide/internal/requests/validators"
+	"github.com/yahahaff/rapide/internal/service"
+	"github.com/yahahaff/rapide/pkg/response"
+)
+
+// TraefikMetricController Prometheus指标统计控制器
+type TraefikMetricController struct {
+	controllers.BaseAPIController
+}
+
+// GetSources 获取各实例指标的抓取状态
+func (mc *TraefikMetricController) GetSources(c *gin.Context) {
+	sources, err := service.Entrance.TraefikService.TraefikMetricService.GetMetricSources()
+	if err != nil {
+		response.Abort500(c, "获取指标抓取状态失败")
+		return
+	}
+	response.OK(c, gin.H{"result": sources, "total": len(sources)})
+}
+
+// GetOverview 获取时间范围内所有入口点、路由或服务的请求速率和错误率
+func (mc *TraefikMetricController) GetOverview(c *gin.Context) {
+	kind, ok := metricKind(c)
+	if !ok {
+		return
+	}
+	request := traefikReq.TraefikMetricRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+	from, to, ok := parseTimeRange(c, request.From, request.To)
+	if !ok {
+		return
+	}
+
+	stats, err := service.Entrance.TraefikService.TraefikMetricService.GetMetricOverview(kind, request.Instance, from, to, controllers.CurrentDeptScope(c))
+	if err != nil {
+		response.Abort500(c, "获取指标统计失败")
+		return
+	}
+	response.OK(c, gin.H{"result": stats, "total": len(stats), "from": from, "to": to})
+}
+
+// GetSeries 获取单个入口点、路由或服务的请求速率和错误率的时间序列
+func (mc *TraefikMetricController) GetSeries(c *gin.Context) {
+	kind, ok := metricKind(c)
+	if !ok {
+		return
+	}
+	request := traefikReq.TraefikMetricRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+	from, to, ok := parseTimeRange(c, request.From, request.To)
+	if !ok {
+		return
+	}
+	step := time.Minute
+	if request.Step != "" {
+		parsed, err := time.ParseDuration(request.Step)
+		if err != nil || parsed < time.Second {
+			response.Abort400(c, "时间段长度格式错误，最小为1s")
+			return
+		}
+		step = parsed
+	}
+
+	detail, err := service.Entrance.TraefikService.TraefikMetricService.GetMetricSeries(kind, c.Param("name"), request.Instance, from, to, step, controllers.CurrentDeptScope(c))
+	if err != nil {
+		abortConfigError(c, err, "获取指标统计失败")
+		return
+	}
+	response.OK(c, gin.H{"summary": detail.Summary, "series": detail.Series, "from": from, "to": to, "step": step.String()})
+}
+
+// metricKind 校验统计对象类型，只支持entrypoint、router和service
+func metricKind(c *gin.Context) (string, bool) {
+	kind := c.Param("kind")
+	if kind != "entrypoint" && kind != "router" && kind != "service" {
+		response.Abort404(c, "统计对象类型只支持entrypoint、router和service")
+		return "", false
+	}
+	return kind, true
+}
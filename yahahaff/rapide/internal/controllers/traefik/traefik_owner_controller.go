@@ -0,0 +1,45 @@
+package traefik
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/yahahaff/rapide/internal/controllers"
+	traefikReq "github.com/yahahaff/rapide/internal/requests/traefik"
+	"github.com/yahahaff/rapide/internal/requests/validators"
+	"github.com/yahahaff/rapide/internal/service"
+	"github.com/yahahaff/rapide/pkg/response"
+)
+
+// TraefikOwnerController 路由、服务和中间件所属部门控制器
+type TraefikOwnerController struct {
+	controllers.BaseAPIController
+}
+
+// GetOwners 获取当前用户可以管理的对象及其所属部门
+func (owc *TraefikOwnerController) GetOwners(c *gin.Context) {
+	request := traefikReq.TraefikOwnerListRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	owners, err := service.Entrance.TraefikService.TraefikOwnerService.GetOwners(request.Kind, controllers.CurrentDeptScope(c))
+	if err != nil {
+		response.Abort500(c, "获取所属部门失败")
+		return
+	}
+	response.OK(c, gin.H{"result": owners, "total": len(owners)})
+}
+
+// SetOwner 把对象转给其他部门，转出和转入的部门都需要在当前用户可以管理的范围内
+func (owc *TraefikOwnerController) SetOwner(c *gin.Context) {
+	request := traefikReq.TraefikOwnerRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	owner, err := service.Entrance.TraefikService.TraefikOwnerService.SetOwner(c.Param("kind"), c.Param("protocol"), c.Param("name"), request.DeptID, controllers.CurrentDeptScope(c), c.GetString("current_user_name"))
+	if err != nil {
+		abortConfigError(c, err, "修改所属部门失败")
+		return
+	}
+	response.OK(c, owner)
+}
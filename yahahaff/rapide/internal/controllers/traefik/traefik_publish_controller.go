@@ -0,0 +1,126 @@
+package traefik
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yahahaff/rapide/internal/controllers"
+	traefikReq "github.com/yahahaff/rapide/internal/requests/traefik"
+	"github.com/yahahaff/rapide/internal/requests/validators"
+	"github.com/yahahaff/rapide/internal/service"
+	"github.com/yahahaff/rapide/pkg/response"
+)
+
+// TraefikPublishController Traefik草稿发布控制器
+type TraefikPublishController struct {
+	controllers.BaseAPIController
+}
+
+// GetDraftDiff 获取草稿相对于当前发布配置的差异
+func (pc *TraefikPublishController) GetDraftDiff(c *gin.Context) {
+	diff, err := service.Entrance.TraefikService.TraefikPublishService.GetDraftDiff()
+	if err != nil {
+		response.Abort500(c, "获取草稿差异失败")
+		return
+	}
+	response.OK(c, diff)
+}
+
+// Publish 发布草稿，指定activateAt时在该时间生效
+func (pc *TraefikPublishController) Publish(c *gin.Context) {
+	request := traefikReq.TraefikPublishRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	var activateAt *time.Time
+	if request.ActivateAt != "" {
+		target, err := time.ParseInLocation(time.DateTime, request.ActivateAt, time.Local)
+		if err != nil {
+			if target, err = time.Parse(time.RFC3339, request.ActivateAt); err != nil {
+				response.Abort400(c, "无效的时间格式")
+				return
+			}
+		}
+		activateAt = &target
+	}
+
+	snapshot, err := service.Entrance.TraefikService.TraefikPublishService.Publish(request.Remark, c.GetString("current_user_name"), activateAt)
+	if err != nil {
+		abortConfigError(c, err, "发布配置失败")
+		return
+	}
+	response.OK(c, snapshot)
+}
+
+// GetSnapshots 分页获取配置快照
+func (pc *TraefikPublishController) GetSnapshots(c *gin.Context) {
+	request := traefikReq.TraefikSnapshotListRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	// 处理分页参数，设置默认值
+	page := request.Page
+	if page <= 0 {
+		page = 1
+	}
+	pageSize := request.PageSize
+	if pageSize == 0 {
+		pageSize = 20
+	}
+
+	snapshots, total, err := service.Entrance.TraefikService.TraefikPublishService.GetSnapshots(request.Status, page, pageSize)
+	if err != nil {
+		response.Abort500(c, "获取配置快照失败")
+		return
+	}
+
+	response.OK(c, gin.H{
+		"page":     page,
+		"pageSize": pageSize,
+		"result":   snapshots,
+		"total":    total,
+	})
+}
+
+// GetSnapshot 获取配置快照详情
+func (pc *TraefikPublishController) GetSnapshot(c *gin.Context) {
+	id, ok := parseSnapshotID(c)
+	if !ok {
+		return
+	}
+
+	snapshot, err := service.Entrance.TraefikService.TraefikPublishService.GetSnapshot(id)
+	if err != nil {
+		abortConfigError(c, err, "获取配置快照失败")
+		return
+	}
+	response.OK(c, snapshot)
+}
+
+// CancelSnapshot 取消计划中的发布
+func (pc *TraefikPublishController) CancelSnapshot(c *gin.Context) {
+	id, ok := parseSnapshotID(c)
+	if !ok {
+		return
+	}
+
+	snapshot, err := service.Entrance.TraefikService.TraefikPublishService.CancelSnapshot(id)
+	if err != nil {
+		abortConfigError(c, err, "取消发布失败")
+		return
+	}
+	response.OK(c, snapshot)
+}
+
+// parseSnapshotID 从URL路径中解析快照ID
+func parseSnapshotID(c *gin.Context) (uint64, bool) {
+	var id uint64
+	if _, err := fmt.Sscan(c.Param("id"), &id); err != nil || id == 0 {
+		response.Abort400(c, "无效的快照ID")
+		return 0, false
+	}
+	return id, true
+}
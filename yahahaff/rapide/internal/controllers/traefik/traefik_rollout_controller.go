@@ -0,0 +1,162 @@
+package traefik
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yahahaff/rapide/internal/controllers"
+	traefikReq "github.com/yahahaff/rapide/internal/requests/traefik"
+	"github.com/yahahaff/rapide/internal/requests/validators"
+	"github.com/yahahaff/rapide/internal/service"
+	traefikService "github.com/yahahaff/rapide/internal/service/traefik"
+	"github.com/yahahaff/rapide/pkg/response"
+)
+
+// TraefikRolloutController 灰度发布控制器
+type TraefikRolloutController struct {
+	controllers.BaseAPIController
+}
+
+// CreateRollout 创建并开始灰度发布
+func (rc *TraefikRolloutController) CreateRollout(c *gin.Context) {
+	request := traefikReq.TraefikRolloutRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+	if !checkObjectOwner(c, "service", request.Protocol, request.Service) {
+		return
+	}
+
+	rollout, err := service.Entrance.TraefikService.TraefikRolloutService.CreateRollout(traefikService.RolloutInput{
+		Service:   request.Service,
+		Protocol:  request.Protocol,
+		Stable:    request.Stable,
+		Canary:    request.Canary,
+		Steps:     request.Steps,
+		Interval:  request.Interval,
+		OnFailure: request.OnFailure,
+	}, c.GetString("current_user_name"))
+	if err != nil {
+		abortConfigError(c, err, "创建灰度发布失败")
+		return
+	}
+	response.OK(c, rollout)
+}
+
+// GetRollouts 分页获取灰度发布
+func (rc *TraefikRolloutController) GetRollouts(c *gin.Context) {
+	request := traefikReq.TraefikRolloutListRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	// 处理分页参数，设置默认值
+	page := request.Page
+	if page <= 0 {
+		page = 1
+	}
+	pageSize := request.PageSize
+	if pageSize == 0 {
+		pageSize = 20
+	}
+
+	rollouts, total, err := service.Entrance.TraefikService.TraefikRolloutService.GetRollouts(request.Service, request.Status, controllers.CurrentDeptScope(c), page, pageSize)
+	if err != nil {
+		response.Abort500(c, "获取灰度发布失败")
+		return
+	}
+
+	response.OK(c, gin.H{
+		"page":     page,
+		"pageSize": pageSize,
+		"result":   rollouts,
+		"total":    total,
+	})
+}
+
+// GetRollout 获取灰度发布详情
+func (rc *TraefikRolloutController) GetRollout(c *gin.Context) {
+	id, ok := parseRolloutID(c)
+	if !ok {
+		return
+	}
+
+	rollout, err := service.Entrance.TraefikService.TraefikRolloutService.GetRollout(id)
+	if err != nil {
+		abortConfigError(c, err, "获取灰度发布失败")
+		return
+	}
+	if !checkObjectOwner(c, "service", rollout.Protocol, rollout.Service) {
+		return
+	}
+	response.OK(c, rollout)
+}
+
+// PauseRollout 暂停灰度发布
+func (rc *TraefikRolloutController) PauseRollout(c *gin.Context) {
+	id, ok := ownedRolloutID(c)
+	if !ok {
+		return
+	}
+
+	rollout, err := service.Entrance.TraefikService.TraefikRolloutService.PauseRollout(id, c.GetString("current_user_name"))
+	if err != nil {
+		abortConfigError(c, err, "暂停灰度发布失败")
+		return
+	}
+	response.OK(c, rollout)
+}
+
+// ResumeRollout 恢复灰度发布
+func (rc *TraefikRolloutController) ResumeRollout(c *gin.Context) {
+	id, ok := ownedRolloutID(c)
+	if !ok {
+		return
+	}
+
+	rollout, err := service.Entrance.TraefikService.TraefikRolloutService.ResumeRollout(id, c.GetString("current_user_name"))
+	if err != nil {
+		abortConfigError(c, err, "恢复灰度发布失败")
+		return
+	}
+	response.OK(c, rollout)
+}
+
+// AbortRollout 中止灰度发布并恢复原权重
+func (rc *TraefikRolloutController) AbortRollout(c *gin.Context) {
+	id, ok := ownedRolloutID(c)
+	if !ok {
+		return
+	}
+
+	rollout, err := service.Entrance.TraefikService.TraefikRolloutService.AbortRollout(id, c.GetString("current_user_name"))
+	if err != nil {
+		abortConfigError(c, err, "中止灰度发布失败")
+		return
+	}
+	response.OK(c, rollout)
+}
+
+// parseRolloutID 从URL路径中解析灰度发布ID
+func parseRolloutID(c *gin.Context) (uint64, bool) {
+	var id uint64
+	if _, err := fmt.Sscan(c.Param("id"), &id); err != nil || id == 0 {
+		response.Abort400(c, "无效的灰度发布ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// ownedRolloutID 解析灰度发布ID，并检查灰度发布的服务属于当前用户可以管理的部门
+func ownedRolloutID(c *gin.Context) (uint64, bool) {
+	id, ok := parseRolloutID(c)
+	if !ok {
+		return 0, false
+	}
+	rollout, err := service.Entrance.TraefikService.TraefikRolloutService.GetRollout(id)
+	if err != nil {
+		abortConfigError(c, err, "获取灰度发布失败")
+		return 0, false
+	}
+	return id, checkObjectOwner(c, "service", rollout.Protocol, rollout.Service)
+}
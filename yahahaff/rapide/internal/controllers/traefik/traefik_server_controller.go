@@ -0,0 +1,102 @@
+package traefik
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yahahaff/rapide/internal/controllers"
+	traefikReq "github.com/yahahaff/rapide/internal/requests/traefik"
+	"github.com/yahahaff/rapide/internal/requests/validators"
+	"github.com/yahahaff/rapide/internal/service"
+	"github.com/yahahaff/rapide/pkg/response"
+)
+
+// TraefikServerController 负载均衡服务后端管理控制器，修改立即生效
+type TraefikServerController struct {
+	controllers.BaseAPIController
+}
+
+// ListServers 获取服务的后端列表
+func (sc *TraefikServerController) ListServers(c *gin.Context) {
+	if !checkObjectOwner(c, "service", c.Param("protocol"), c.Param("name")) {
+		return
+	}
+	servers, err := service.Entrance.TraefikService.TraefikServerService.ListServers(c.Param("protocol"), c.Param("name"))
+	if err != nil {
+		abortConfigError(c, err, "获取后端列表失败")
+		return
+	}
+	response.OK(c, servers)
+}
+
+// AddServer 添加后端，已存在时更新权重
+func (sc *TraefikServerController) AddServer(c *gin.Context) {
+	if !checkObjectOwner(c, "service", c.Param("protocol"), c.Param("name")) {
+		return
+	}
+	request := traefikReq.TraefikServerRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	servers, err := service.Entrance.TraefikService.TraefikServerService.AddServer(c.Param("protocol"), c.Param("name"), request.Server, request.Weight, c.GetString("current_user_name"))
+	if err != nil {
+		abortConfigError(c, err, "添加后端失败")
+		return
+	}
+	response.OK(c, servers)
+}
+
+// SetServerWeight 调整后端权重
+func (sc *TraefikServerController) SetServerWeight(c *gin.Context) {
+	if !checkObjectOwner(c, "service", c.Param("protocol"), c.Param("name")) {
+		return
+	}
+	request := traefikReq.TraefikServerWeightRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	servers, err := service.Entrance.TraefikService.TraefikServerService.SetServerWeight(c.Param("protocol"), c.Param("name"), request.Server, *request.Weight, c.GetString("current_user_name"))
+	if err != nil {
+		abortConfigError(c, err, "调整后端权重失败")
+		return
+	}
+	response.OK(c, servers)
+}
+
+// RemoveServer 立即删除后端
+func (sc *TraefikServerController) RemoveServer(c *gin.Context) {
+	if !checkObjectOwner(c, "service", c.Param("protocol"), c.Param("name")) {
+		return
+	}
+	request := traefikReq.TraefikServerRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	servers, err := service.Entrance.TraefikService.TraefikServerService.RemoveServer(c.Param("protocol"), c.Param("name"), request.Server, c.GetString("current_user_name"))
+	if err != nil {
+		abortConfigError(c, err, "删除后端失败")
+		return
+	}
+	response.OK(c, servers)
+}
+
+// DrainServer 摘除后端，权重设为0，等待delay秒后删除
+func (sc *TraefikServerController) DrainServer(c *gin.Context) {
+	if !checkObjectOwner(c, "service", c.Param("protocol"), c.Param("name")) {
+		return
+	}
+	request := traefikReq.TraefikServerDrainRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	servers, err := service.Entrance.TraefikService.TraefikServerService.DrainServer(c.Param("protocol"), c.Param("name"), request.Server, time.Duration(request.Delay)*time.Second, c.GetString("current_user_name"))
+	if err != nil {
+		abortConfigError(c, err, "摘除后端失败")
+		return
+	}
+	response.OK(c, servers)
+}
@@ -0,0 +1,58 @@
+package traefik
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/yahahaff/rapide/internal/controllers"
+	traefikReq "github.com/yahahaff/rapide/internal/requests/traefik"
+	"github.com/yahahaff/rapide/internal/requests/validators"
+	"github.com/yahahaff/rapide/internal/service"
+	traefikService "github.com/yahahaff/rapide/internal/service/traefik"
+	"github.com/yahahaff/rapide/pkg/response"
+)
+
+// TraefikSimulateController 路由匹配模拟与配置检查控制器
+type TraefikSimulateController struct {
+	controllers.BaseAPIController
+}
+
+// Simulate 模拟示例请求会命中的路由、经过的中间件和目标服务
+func (sc *TraefikSimulateController) Simulate(c *gin.Context) {
+	request := traefikReq.TraefikSimulateRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	result, err := service.Entrance.TraefikService.TraefikSimulatorService.Simulate(traefikService.SimulateRequest{
+		Protocol:   request.Protocol,
+		Method:     request.Method,
+		Host:       request.Host,
+		Path:       request.Path,
+		Headers:    request.Headers,
+		ClientIP:   request.ClientIP,
+		SNI:        request.SNI,
+		ALPN:       request.ALPN,
+		TLS:        request.TLS,
+		EntryPoint: request.EntryPoint,
+		Source:     request.Source,
+	})
+	if err != nil {
+		abortConfigError(c, err, "模拟路由匹配失败")
+		return
+	}
+	response.OK(c, result)
+}
+
+// Lint 检查整套配置中的规则冲突、覆盖、TLS证书来源以及未使用的服务和中间件
+func (sc *TraefikSimulateController) Lint(c *gin.Context) {
+	request := traefikReq.TraefikLintRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	report, err := service.Entrance.TraefikService.TraefikLintService.Lint(request.Source)
+	if err != nil {
+		abortConfigError(c, err, "检查配置失败")
+		return
+	}
+	response.OK(c, report)
+}
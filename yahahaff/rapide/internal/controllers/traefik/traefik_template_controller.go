@@ -0,0 +1,103 @@
+package traefik
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/yahahaff/rapide/internal/controllers"
+	traefikModel "github.com/yahahaff/rapide/internal/models/traefik"
+	traefikReq "github.com/yahahaff/rapide/internal/requests/traefik"
+	"github.com/yahahaff/rapide/internal/requests/validators"
+	"github.com/yahahaff/rapide/internal/service"
+	"github.com/yahahaff/rapide/pkg/response"
+)
+
+// TraefikTemplateController Traefik应用模板控制器
+type TraefikTemplateController struct {
+	controllers.BaseAPIController
+}
+
+// ListTemplates 获取内置和自定义模板
+func (tc *TraefikTemplateController) ListTemplates(c *gin.Context) {
+	templates, err := service.Entrance.TraefikService.TraefikTemplateService.ListTemplates()
+	if err != nil {
+		response.Abort500(c, "获取模板列表失败")
+		return
+	}
+	response.OK(c, gin.H{"result": templates, "total": len(templates)})
+}
+
+// GetTemplate 获取模板详情
+func (tc *TraefikTemplateController) GetTemplate(c *gin.Context) {
+	template, err := service.Entrance.TraefikService.TraefikTemplateService.GetTemplate(c.Param("name"))
+	if err != nil {
+		abortConfigError(c, err, "获取模板失败")
+		return
+	}
+	response.OK(c, template)
+}
+
+// CreateTemplate 创建自定义模板
+func (tc *TraefikTemplateController) CreateTemplate(c *gin.Context) {
+	request := traefikReq.TraefikTemplateCreateRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	template := buildTemplate(request.TraefikTemplateRequest)
+	template.Name = request.Name
+	saved, err := service.Entrance.TraefikService.TraefikTemplateService.CreateTemplate(template, c.GetString("current_user_name"))
+	if err != nil {
+		abortConfigError(c, err, "创建模板失败")
+		return
+	}
+	response.OK(c, saved)
+}
+
+// UpdateTemplate 更新自定义模板
+func (tc *TraefikTemplateController) UpdateTemplate(c *gin.Context) {
+	request := traefikReq.TraefikTemplateRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	saved, err := service.Entrance.TraefikService.TraefikTemplateService.UpdateTemplate(c.Param("name"), buildTemplate(request), c.GetString("current_user_name"))
+	if err != nil {
+		abortConfigError(c, err, "更新模板失败")
+		return
+	}
+	response.OK(c, saved)
+}
+
+// DeleteTemplate 删除自定义模板
+func (tc *TraefikTemplateController) DeleteTemplate(c *gin.Context) {
+	name := c.Param("name")
+	if err := service.Entrance.TraefikService.TraefikTemplateService.DeleteTemplate(name); err != nil {
+		abortConfigError(c, err, "删除模板失败")
+		return
+	}
+	response.OK(c, gin.H{"name": name})
+}
+
+// ApplyTemplate 按模板创建路由、服务和中间件
+func (tc *TraefikTemplateController) ApplyTemplate(c *gin.Context) {
+	request := traefikReq.TraefikTemplateApplyRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	result, err := service.Entrance.TraefikService.TraefikTemplateService.ApplyTemplate(c.Param("name"), request.Name, request.Params, request.Tags, request.DeptID, controllers.CurrentDeptScope(c), request.DryRun, c.GetString("current_user_name"))
+	if err != nil {
+		abortConfigError(c, err, "应用模板失败")
+		return
+	}
+	response.OK(c, result)
+}
+
+// buildTemplate 把请求转换为模板模型
+func buildTemplate(request traefikReq.TraefikTemplateRequest) traefikModel.TraefikTemplate {
+	return traefikModel.TraefikTemplate{
+		Title:       request.Title,
+		Description: request.Description,
+		Parameters:  traefikModel.TemplateParams(request.Parameters),
+		Body:        request.Body,
+	}
+}
@@ -0,0 +1,180 @@
+package traefik
+
+import (
+	"github.com/gin-gonic/gin"
+	traefikModel "github.com/yahahaff/rapide/internal/models/traefik"
+	traefikReq "github.com/yahahaff/rapide/internal/requests/traefik"
+	"github.com/yahahaff/rapide/internal/requests/validators"
+	"github.com/yahahaff/rapide/internal/service"
+	"github.com/yahahaff/rapide/pkg/response"
+	"github.com/yahahaff/rapide/pkg/types"
+)
+
+// ListTLSOptions 获取数据库中的所有TLS选项
+func (cc *TraefikConfigController) ListTLSOptions(c *gin.Context) {
+	options, err := service.Entrance.TraefikService.TraefikConfigService.ListTLSOptions()
+	if err != nil {
+		response.Abort500(c, "获取TLS选项列表失败")
+		return
+	}
+	response.OK(c, gin.H{"result": options, "total": len(options)})
+}
+
+// CreateTLSOption 创建TLS选项
+func (cc *TraefikConfigController) CreateTLSOption(c *gin.Context) {
+	request := traefikReq.TraefikTLSObjectCreateRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	option := traefikModel.TraefikTLSOption{Name: request.Name}
+	option.Config, option.Status, option.Tags = buildConfigObject(request.TraefikConfigObjectRequest)
+	saved, err := service.Entrance.TraefikService.TraefikConfigService.CreateTLSOption(option, c.GetString("current_user_name"))
+	if err != nil {
+		abortConfigError(c, err, "创建TLS选项失败")
+		return
+	}
+	response.OK(c, saved)
+}
+
+// UpdateTLSOption 更新TLS选项
+func (cc *TraefikConfigController) UpdateTLSOption(c *gin.Context) {
+	request := traefikReq.TraefikConfigObjectRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	var option traefikModel.TraefikTLSOption
+	option.Config, option.Status, option.Tags = buildConfigObject(request)
+	saved, err := service.Entrance.TraefikService.TraefikConfigService.UpdateTLSOption(c.Param("name"), option, c.GetString("current_user_name"))
+	if err != nil {
+		abortConfigError(c, err, "更新TLS选项失败")
+		return
+	}
+	response.OK(c, saved)
+}
+
+// DeleteTLSOption 删除TLS选项
+func (cc *TraefikConfigController) DeleteTLSOption(c *gin.Context) {
+	cc.deleteTLSObject(c, "tlsOption")
+}
+
+// ListTLSStores 获取数据库中的所有TLS证书存储
+func (cc *TraefikConfigController) ListTLSStores(c *gin.Context) {
+	stores, err := service.Entrance.TraefikService.TraefikConfigService.ListTLSStores()
+	if err != nil {
+		response.Abort500(c, "获取TLS证书存储列表失败")
+		return
+	}
+	response.OK(c, gin.H{"result": stores, "total": len(stores)})
+}
+
+// CreateTLSStore 创建TLS证书存储
+func (cc *TraefikConfigController) CreateTLSStore(c *gin.Context) {
+	request := traefikReq.TraefikTLSObjectCreateRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	store := traefikModel.TraefikTLSStore{Name: request.Name}
+	store.Config, store.Status, store.Tags = buildConfigObject(request.TraefikConfigObjectRequest)
+	saved, err := service.Entrance.TraefikService.TraefikConfigService.CreateTLSStore(store, c.GetString("current_user_name"))
+	if err != nil {
+		abortConfigError(c, err, "创建TLS证书存储失败")
+		return
+	}
+	response.OK(c, saved)
+}
+
+// UpdateTLSStore 更新TLS证书存储
+func (cc *TraefikConfigController) UpdateTLSStore(c *gin.Context) {
+	request := traefikReq.TraefikConfigObjectRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	var store traefikModel.TraefikTLSStore
+	store.Config, store.Status, store.Tags = buildConfigObject(request)
+	saved, err := service.Entrance.TraefikService.TraefikConfigService.UpdateTLSStore(c.Param("name"), store, c.GetString("current_user_name"))
+	if err != nil {
+		abortConfigError(c, err, "更新TLS证书存储失败")
+		return
+	}
+	response.OK(c, saved)
+}
+
+// DeleteTLSStore 删除TLS证书存储
+func (cc *TraefikConfigController) DeleteTLSStore(c *gin.Context) {
+	cc.deleteTLSObject(c, "tlsStore")
+}
+
+// ListServersTransports 获取数据库中的所有serversTransport
+func (cc *TraefikConfigController) ListServersTransports(c *gin.Context) {
+	transports, err := service.Entrance.TraefikService.TraefikConfigService.ListServersTransports()
+	if err != nil {
+		response.Abort500(c, "获取serversTransport列表失败")
+		return
+	}
+	response.OK(c, gin.H{"result": transports, "total": len(transports)})
+}
+
+// CreateServersTransport 创建serversTransport
+func (cc *TraefikConfigController) CreateServersTransport(c *gin.Context) {
+	request := traefikReq.TraefikServersTransportCreateRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	transport := traefikModel.TraefikServersTransport{Name: request.Name, Protocol: request.Protocol}
+	if transport.Protocol == "" {
+		transport.Protocol = "http"
+	}
+	transport.Config, transport.Status, transport.Tags = buildConfigObject(request.TraefikConfigObjectRequest)
+	saved, err := service.Entrance.TraefikService.TraefikConfigService.CreateServersTransport(transport, c.GetString("current_user_name"))
+	if err != nil {
+		abortConfigError(c, err, "创建serversTransport失败")
+		return
+	}
+	response.OK(c, saved)
+}
+
+// UpdateServersTransport 更新serversTransport
+func (cc *TraefikConfigController) UpdateServersTransport(c *gin.Context) {
+	request := traefikReq.TraefikConfigObjectRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+
+	var transport traefikModel.TraefikServersTransport
+	transport.Config, transport.Status, transport.Tags = buildConfigObject(request)
+	saved, err := service.Entrance.TraefikService.TraefikConfigService.UpdateServersTransport(c.Param("name"), c.Param("protocol"), transport, c.GetString("current_user_name"))
+	if err != nil {
+		abortConfigError(c, err, "更新serversTransport失败")
+		return
+	}
+	response.OK(c, saved)
+}
+
+// DeleteServersTransport 删除serversTransport
+func (cc *TraefikConfigController) DeleteServersTransport(c *gin.Context) {
+	cc.deleteObject(c, "serversTransport")
+}
+
+// deleteTLSObject 按路径中的名称删除TLS选项或TLS证书存储，它们不区分协议
+func (cc *TraefikConfigController) deleteTLSObject(c *gin.Context, kind string) {
+	name := c.Param("name")
+	if err := service.Entrance.TraefikService.TraefikConfigService.DeleteObject(kind, name, "tls", c.GetString("current_user_name")); err != nil {
+		abortConfigError(c, err, "删除失败")
+		return
+	}
+	response.OK(c, gin.H{"kind": kind, "name": name, "protocol": "tls"})
+}
+
+// buildConfigObject 把请求转换为配置、状态和标签，状态默认为enabled
+func buildConfigObject(request traefikReq.TraefikConfigObjectRequest) (types.JSONMap, string, types.JSONSlice) {
+	status := request.Status
+	if status == "" {
+		status = "enabled"
+	}
+	return types.JSONMap(request.Config), status, types.JSONSlice(request.Tags)
+}
@@ -0,0 +1,106 @@
+package traefik
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yahahaff/rapide/internal/controllers"
+	traefikReq "github.com/yahahaff/rapide/internal/requests/traefik"
+	"github.com/yahahaff/rapide/internal/requests/validators"
+	"github.com/yahahaff/rapide/internal/service"
+	"github.com/yahahaff/rapide/pkg/response"
+)
+
+// TraefikTrafficController 访问日志流量统计控制器
+type TraefikTrafficController struct {
+	controllers.BaseAPIController
+}
+
+// GetSources 获取各实例访问日志的读取进度
+func (tc *TraefikTrafficController) GetSources(c *gin.Context) {
+	cursors, err := service.Entrance.TraefikService.TraefikTrafficService.GetAccessLogCursors()
+	if err != nil {
+		response.Abort500(c, "获取访问日志读取进度失败")
+		return
+	}
+	response.OK(c, gin.H{"result": cursors, "total": len(cursors)})
+}
+
+// GetOverview 获取时间范围内所有路由或服务的访问汇总
+func (tc *TraefikTrafficController) GetOverview(c *gin.Context) {
+	kind, ok := trafficKind(c)
+	if !ok {
+		return
+	}
+	request := traefikReq.TraefikTrafficRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+	from, to, ok := parseTimeRange(c, request.From, request.To)
+	if !ok {
+		return
+	}
+
+	stats, err := service.Entrance.TraefikService.TraefikTrafficService.GetTrafficOverview(kind, request.Instance, from, to, controllers.CurrentDeptScope(c))
+	if err != nil {
+		response.Abort500(c, "获取流量统计失败")
+		return
+	}
+	response.OK(c, gin.H{"result": stats, "total": len(stats), "from": from, "to": to})
+}
+
+// GetTraffic 获取单个路由或服务的访问汇总和每个时间段的数据，名称不带@provider时合并所有Provider中的同名对象
+func (tc *TraefikTrafficController) GetTraffic(c *gin.Context) {
+	kind, ok := trafficKind(c)
+	if !ok {
+		return
+	}
+	request := traefikReq.TraefikTrafficRequest{}
+	if ok := validators.Validate(c, &request); !ok {
+		return
+	}
+	from, to, ok := parseTimeRange(c, request.From, request.To)
+	if !ok {
+		return
+	}
+
+	detail, err := service.Entrance.TraefikService.TraefikTrafficService.GetTraffic(kind, c.Param("name"), request.Instance, from, to, controllers.CurrentDeptScope(c))
+	if err != nil {
+		abortConfigError(c, err, "获取流量统计失败")
+		return
+	}
+	response.OK(c, gin.H{"summary": detail.Summary, "series": detail.Series, "from": from, "to": to})
+}
+
+// trafficKind 校验统计对象类型，只支持router和service
+func trafficKind(c *gin.Context) (string, bool) {
+	kind := c.Param("kind")
+	if kind != "router" && kind != "service" {
+		response.Abort404(c, "统计对象类型只支持router和service")
+		return "", false
+	}
+	return kind, true
+}
+
+// parseTimeRange 解析查询的时间范围，默认最近1小时
+func parseTimeRange(c *gin.Context, fromValue, toValue string) (time.Time, time.Time, bool) {
+	to := time.Now()
+	if parsed, err := parseOptionalTime(toValue); err != nil {
+		response.Abort400(c, "结束时间格式错误")
+		return time.Time{}, time.Time{}, false
+	} else if parsed != nil {
+		to = *parsed
+	}
+	from := to.Add(-time.Hour)
+	if parsed, err := parseOptionalTime(fromValue); err != nil {
+		response.Abort400(c, "开始时间格式错误")
+		return time.Time{}, time.Time{}, false
+	} else if parsed != nil {
+		from = *parsed
+	}
+	if !to.After(from) {
+		response.Abort400(c, "结束时间必须晚于开始时间")
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to, true
+}
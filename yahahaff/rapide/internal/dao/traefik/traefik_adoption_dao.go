@@ -0,0 +1,33 @@
+package traefik
+
+import (
+	"errors"
+
+	"github.com/yahahaff/rapide/internal/models/traefik"
+	"gorm.io/gorm"
+)
+
+// GetAdoptions 获取接管记录，provider为空时不限制
+func (dao *TraefikDAO) GetAdoptions(provider string) ([]traefik.TraefikAdoption, error) {
+	db := dao.conn()
+	if provider != "" {
+		db = db.Where("provider = ?", provider)
+	}
+	var adoptions []traefik.TraefikAdoption
+	result := db.Order("id desc").Find(&adoptions)
+	return adoptions, result.Error
+}
+
+// SaveAdoption 保存接管记录，同一对象再次接管时覆盖原记录
+func (dao *TraefikDAO) SaveAdoption(adoption *traefik.TraefikAdoption) error {
+	var existing traefik.TraefikAdoption
+	err := dao.conn().Where("kind = ? AND name = ? AND protocol = ?", adoption.Kind, adoption.Name, adoption.Protocol).First(&existing).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if err == nil {
+		adoption.ID = existing.ID
+		adoption.CreatedAt = existing.CreatedAt
+	}
+	return dao.conn().Save(adoption).Error
+}
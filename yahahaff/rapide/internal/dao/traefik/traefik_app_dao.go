@@ -0,0 +1,71 @@
+package traefik
+
+import (
+	"github.com/yahahaff/rapide/internal/models/traefik"
+)
+
+// GetApps 获取应用，部门为0或环境为空时不限制
+func (dao *TraefikDAO) GetApps(deptID uint64, environment string) ([]traefik.TraefikApp, error) {
+	db := dao.conn()
+	if deptID != 0 {
+		db = db.Where("dept_id = ?", deptID)
+	}
+	if environment != "" {
+		db = db.Where("environment = ?", environment)
+	}
+	var apps []traefik.TraefikApp
+	result := db.Order("name asc").Find(&apps)
+	return apps, result.Error
+}
+
+// GetApp 根据名称获取应用
+func (dao *TraefikDAO) GetApp(name string) (traefik.TraefikApp, error) {
+	var app traefik.TraefikApp
+	result := dao.conn().Where("name = ?", name).First(&app)
+	return app, result.Error
+}
+
+// SaveApp 创建或更新应用
+func (dao *TraefikDAO) SaveApp(app *traefik.TraefikApp) error {
+	return dao.conn().Save(app).Error
+}
+
+// DeleteApp 删除应用及其包含的对象记录，不影响对象本身
+func (dao *TraefikDAO) DeleteApp(id uint64) error {
+	if err := dao.conn().Where("app_id = ?", id).Delete(&traefik.TraefikAppMember{}).Error; err != nil {
+		return err
+	}
+	return dao.conn().Delete(&traefik.TraefikApp{}, id).Error
+}
+
+// GetAppMembers 获取应用包含的对象，appID为0时获取所有应用的对象
+func (dao *TraefikDAO) GetAppMembers(appID uint64) ([]traefik.TraefikAppMember, error) {
+	db := dao.conn()
+	if appID != 0 {
+		db = db.Where("app_id = ?", appID)
+	}
+	var members []traefik.TraefikAppMember
+	result := db.Order("kind asc, protocol asc, name asc").Find(&members)
+	return members, result.Error
+}
+
+// GetAppMember 获取对象所属应用的记录
+func (dao *TraefikDAO) GetAppMember(kind, protocol, name string) (traefik.TraefikAppMember, error) {
+	var member traefik.TraefikAppMember
+	result := dao.conn().Where("kind = ? AND protocol = ? AND name = ?", kind, protocol, name).First(&member)
+	return member, result.Error
+}
+
+// ReplaceAppMembers 用members替换应用包含的对象
+func (dao *TraefikDAO) ReplaceAppMembers(appID uint64, members []traefik.TraefikAppMember) error {
+	if err := dao.conn().Where("app_id = ?", appID).Delete(&traefik.TraefikAppMember{}).Error; err != nil {
+		return err
+	}
+	if len(members) == 0 {
+		return nil
+	}
+	for i := range members {
+		members[i].AppID = appID
+	}
+	return dao.conn().Create(&members).Error
+}
@@ -0,0 +1,29 @@
+package traefik
+
+import (
+	"github.com/yahahaff/rapide/internal/models/traefik"
+)
+
+// GetAuthPolicies 获取所有路由访问策略
+func (dao *TraefikDAO) GetAuthPolicies() ([]traefik.TraefikAuthPolicy, error) {
+	var policies []traefik.TraefikAuthPolicy
+	result := dao.conn().Order("router asc").Find(&policies)
+	return policies, result.Error
+}
+
+// GetAuthPolicy 获取路由的访问策略
+func (dao *TraefikDAO) GetAuthPolicy(router string) (traefik.TraefikAuthPolicy, error) {
+	var policy traefik.TraefikAuthPolicy
+	result := dao.conn().Where("router = ?", router).First(&policy)
+	return policy, result.Error
+}
+
+// SaveAuthPolicy 创建或更新路由访问策略
+func (dao *TraefikDAO) SaveAuthPolicy(policy *traefik.TraefikAuthPolicy) error {
+	return dao.conn().Save(policy).Error
+}
+
+// DeleteAuthPolicy 删除路由访问策略
+func (dao *TraefikDAO) DeleteAuthPolicy(router string) error {
+	return dao.conn().Where("router = ?", router).Delete(&traefik.TraefikAuthPolicy{}).Error
+}
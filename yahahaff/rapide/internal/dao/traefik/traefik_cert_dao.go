@@ -0,0 +1,31 @@
+package traefik
+
+import (
+	"github.com/yahahaff/rapide/internal/models/traefik"
+)
+
+// GetCertLinks 获取路由与证书的关联，router为空时返回全部
+func (dao *TraefikDAO) GetCertLinks(router, status string) ([]traefik.TraefikCertLink, error) {
+	db := dao.conn()
+	if router != "" {
+		db = db.Where("router = ?", router)
+	}
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+	var links []traefik.TraefikCertLink
+	result := db.Order("protocol asc, router asc, host asc").Find(&links)
+	return links, result.Error
+}
+
+// ReplaceCertLinks 用同步结果整体替换路由与证书的关联
+func (dao *TraefikDAO) ReplaceCertLinks(links []traefik.TraefikCertLink) error {
+	db := dao.conn()
+	if err := db.Where("1 = 1").Delete(&traefik.TraefikCertLink{}).Error; err != nil {
+		return err
+	}
+	if len(links) == 0 {
+		return nil
+	}
+	return db.Create(&links).Error
+}
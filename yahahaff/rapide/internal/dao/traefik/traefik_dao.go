@@ -3,78 +3,136 @@ package traefik
 import (
 	"github.com/yahahaff/rapide/internal/models/traefik"
 	"github.com/yahahaff/rapide/pkg/database"
+	"gorm.io/gorm"
 )
 
 // TraefikDAO Traefik数据访问对象
-type TraefikDAO struct{}
+type TraefikDAO struct {
+	tx *gorm.DB
+}
 
 // NewTraefikDAO 创建TraefikDAO实例
 func NewTraefikDAO() *TraefikDAO {
 	return &TraefikDAO{}
 }
 
+// WithTx 返回在指定事务中执行的TraefikDAO
+func (dao *TraefikDAO) WithTx(tx *gorm.DB) *TraefikDAO {
+	return &TraefikDAO{tx: tx}
+}
+
+// conn 获取当前使用的数据库连接，未绑定事务时使用全局连接
+func (dao *TraefikDAO) conn() *gorm.DB {
+	if dao != nil && dao.tx != nil {
+		return dao.tx
+	}
+	return database.DB
+}
+
 // GetAllRouters 获取所有启用的路由
 func (dao *TraefikDAO) GetAllRouters() ([]traefik.TraefikRouter, error) {
 	var routers []traefik.TraefikRouter
-	result := database.DB.Where("status = ?", "enabled").Find(&routers)
+	result := dao.conn().Where("status = ?", "enabled").Find(&routers)
 	return routers, result.Error
 }
 
 // GetAllServices 获取所有启用的服务
 func (dao *TraefikDAO) GetAllServices() ([]traefik.TraefikService, error) {
 	var services []traefik.TraefikService
-	result := database.DB.Where("status = ?", "enabled").Find(&services)
+	result := dao.conn().Where("status = ?", "enabled").Find(&services)
 	return services, result.Error
 }
 
 // GetAllMiddlewares 获取所有启用的中间件
 func (dao *TraefikDAO) GetAllMiddlewares() ([]traefik.TraefikMiddleware, error) {
 	var middlewares []traefik.TraefikMiddleware
-	result := database.DB.Where("status = ?", "enabled").Find(&middlewares)
+	result := dao.conn().Where("status = ?", "enabled").Find(&middlewares)
 	return middlewares, result.Error
 }
 
 // CreateRouter 创建路由
 func (dao *TraefikDAO) CreateRouter(router *traefik.TraefikRouter) error {
-	return database.DB.Create(router).Error
+	return dao.conn().Create(router).Error
 }
 
 // CreateService 创建服务
 func (dao *TraefikDAO) CreateService(service *traefik.TraefikService) error {
-	return database.DB.Create(service).Error
+	return dao.conn().Create(service).Error
 }
 
 // CreateMiddleware 创建中间件
 func (dao *TraefikDAO) CreateMiddleware(middleware *traefik.TraefikMiddleware) error {
-	return database.DB.Create(middleware).Error
+	return dao.conn().Create(middleware).Error
 }
 
 // UpdateRouter 更新路由
 func (dao *TraefikDAO) UpdateRouter(router *traefik.TraefikRouter) error {
-	return database.DB.Save(router).Error
+	return dao.conn().Save(router).Error
 }
 
 // UpdateService 更新服务
 func (dao *TraefikDAO) UpdateService(service *traefik.TraefikService) error {
-	return database.DB.Save(service).Error
+	return dao.conn().Save(service).Error
 }
 
 // UpdateMiddleware 更新中间件
 func (dao *TraefikDAO) UpdateMiddleware(middleware *traefik.TraefikMiddleware) error {
-	return database.DB.Save(middleware).Error
+	return dao.conn().Save(middleware).Error
+}
+
+// DeleteRouter 根据名称和协议删除路由
+func (dao *TraefikDAO) DeleteRouter(name, protocol string) error {
+	return dao.conn().Where("name = ? AND protocol = ?", name, protocol).Delete(&traefik.TraefikRouter{}).Error
+}
+
+// DeleteService 根据名称和协议删除服务
+func (dao *TraefikDAO) DeleteService(name, protocol string) error {
+	return dao.conn().Where("name = ? AND protocol = ?", name, protocol).Delete(&traefik.TraefikService{}).Error
 }
 
-// DeleteRouter 删除路由
-func (dao *TraefikDAO) DeleteRouter(name string) error {
-	return database.DB.Where("name = ?", name).Delete(&traefik.TraefikRouter{}).Error
+// DeleteMiddleware 根据名称和协议删除中间件
+func (dao *TraefikDAO) DeleteMiddleware(name, protocol string) error {
+	return dao.conn().Where("name = ? AND protocol = ?", name, protocol).Delete(&traefik.TraefikMiddleware{}).Error
 }
 
-// DeleteService 删除服务
-func (dao *TraefikDAO) DeleteService(name string) error {
-	return database.DB.Where("name = ?", name).Delete(&traefik.TraefikService{}).Error
+// GetRouter 根据名称和协议获取路由，包含已禁用的路由
+func (dao *TraefikDAO) GetRouter(name, protocol string) (traefik.TraefikRouter, error) {
+	var router traefik.TraefikRouter
+	result := dao.conn().Where("name = ? AND protocol = ?", name, protocol).First(&router)
+	return router, result.Error
 }
 
-// DeleteMiddleware 删除中间件
-func (dao *TraefikDAO) DeleteMiddleware(name string) error {
-	return database.DB.Where("name = ?", name).Delete(&traefik.TraefikMiddleware{}).Error
+// GetService 根据名称和协议获取服务，包含已禁用的服务
+func (dao *TraefikDAO) GetService(name, protocol string) (traefik.TraefikService, error) {
+	var service traefik.TraefikService
+	result := dao.conn().Where("name = ? AND protocol = ?", name, protocol).First(&service)
+	return service, result.Error
+}
+
+// GetMiddleware 根据名称和协议获取中间件，包含已禁用的中间件
+func (dao *TraefikDAO) GetMiddleware(name, protocol string) (traefik.TraefikMiddleware, error) {
+	var middleware traefik.TraefikMiddleware
+	result := dao.conn().Where("name = ? AND protocol = ?", name, protocol).First(&middleware)
+	return middleware, result.Error
+}
+
+// ListRouters 获取所有路由，包含已禁用的路由
+func (dao *TraefikDAO) ListRouters() ([]traefik.TraefikRouter, error) {
+	var routers []traefik.TraefikRouter
+	result := dao.conn().Order("protocol asc, name asc").Find(&routers)
+	return routers, result.Error
+}
+
+// ListServices 获取所有服务，包含已禁用的服务
+func (dao *TraefikDAO) ListServices() ([]traefik.TraefikService, error) {
+	var services []traefik.TraefikService
+	result := dao.conn().Order("protocol asc, name asc").Find(&services)
+	return services, result.Error
+}
+
+// ListMiddlewares 获取所有中间件，包含已禁用的中间件
+func (dao *TraefikDAO) ListMiddlewares() ([]traefik.TraefikMiddleware, error) {
+	var middlewares []traefik.TraefikMiddleware
+	result := dao.conn().Order("protocol asc, name asc").Find(&middlewares)
+	return middlewares, result.Error
 }
@@ -0,0 +1,42 @@
+package traefik
+
+import (
+	"time"
+
+	"github.com/yahahaff/rapide/internal/models/traefik"
+)
+
+// CreateDrain 创建摘除任务
+func (dao *TraefikDAO) CreateDrain(drain *traefik.TraefikServerDrain) error {
+	return dao.conn().Create(drain).Error
+}
+
+// SaveDrain 保存摘除任务
+func (dao *TraefikDAO) SaveDrain(drain *traefik.TraefikServerDrain) error {
+	return dao.conn().Save(drain).Error
+}
+
+// GetDrains 获取服务的摘除任务，status为空时返回全部，按时间倒序
+func (dao *TraefikDAO) GetDrains(protocol, service, status string, limit int) ([]traefik.TraefikServerDrain, error) {
+	db := dao.conn().Where("protocol = ? AND service = ?", protocol, service)
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+	var drains []traefik.TraefikServerDrain
+	result := db.Order("id desc").Limit(limit).Find(&drains)
+	return drains, result.Error
+}
+
+// GetDueDrains 获取计划删除时间已到的摘除任务
+func (dao *TraefikDAO) GetDueDrains(now time.Time) ([]traefik.TraefikServerDrain, error) {
+	var drains []traefik.TraefikServerDrain
+	result := dao.conn().Where("status = ? AND remove_at <= ?", "draining", now).Order("remove_at asc, id asc").Find(&drains)
+	return drains, result.Error
+}
+
+// FinishDrains 结束服务器上进行中的摘除任务
+func (dao *TraefikDAO) FinishDrains(protocol, service, server, status string) error {
+	return dao.conn().Model(&traefik.TraefikServerDrain{}).
+		Where("protocol = ? AND service = ? AND server = ? AND status = ?", protocol, service, server, "draining").
+		Update("status", status).Error
+}
@@ -0,0 +1,53 @@
+package traefik
+
+import (
+	"github.com/yahahaff/rapide/internal/models/traefik"
+)
+
+// CreateDriftReport 创建对账报告及其差异项
+func (dao *TraefikDAO) CreateDriftReport(report *traefik.TraefikDriftReport) error {
+	return dao.conn().Create(report).Error
+}
+
+// GetDriftReports 分页获取对账报告，不包含差异项，按时间倒序
+func (dao *TraefikDAO) GetDriftReports(page, size int) ([]traefik.TraefikDriftReport, int64, error) {
+	db := dao.conn().Model(&traefik.TraefikDriftReport{})
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var reports []traefik.TraefikDriftReport
+	result := db.Order("id desc").Limit(size).Offset((page - 1) * size).Find(&reports)
+	return reports, total, result.Error
+}
+
+// GetDriftReportByID 根据ID获取对账报告及其差异项
+func (dao *TraefikDAO) GetDriftReportByID(id uint64) (traefik.TraefikDriftReport, error) {
+	var report traefik.TraefikDriftReport
+	result := dao.conn().Preload("Items").Where("id = ?", id).First(&report)
+	return report, result.Error
+}
+
+// GetLatestDriftReport 获取最近一次对账报告及其差异项
+func (dao *TraefikDAO) GetLatestDriftReport() (traefik.TraefikDriftReport, error) {
+	var report traefik.TraefikDriftReport
+	result := dao.conn().Preload("Items").Order("id desc").First(&report)
+	return report, result.Error
+}
+
+// PruneDriftReports 只保留最近keep份对账报告
+func (dao *TraefikDAO) PruneDriftReports(keep int) error {
+	var ids []uint64
+	if err := dao.conn().Model(&traefik.TraefikDriftReport{}).Order("id desc").Offset(keep).Limit(-1).Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := dao.conn().Where("report_id IN ?", ids).Delete(&traefik.TraefikDriftItem{}).Error; err != nil {
+		return err
+	}
+	return dao.conn().Where("id IN ?", ids).Delete(&traefik.TraefikDriftReport{}).Error
+}
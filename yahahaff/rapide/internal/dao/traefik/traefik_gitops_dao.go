@@ -0,0 +1,34 @@
+package traefik
+
+import (
+	"github.com/yahahaff/rapide/internal/models/traefik"
+)
+
+// CreateGitSync 记录一次Git同步
+func (dao *TraefikDAO) CreateGitSync(sync *traefik.TraefikGitSync) error {
+	return dao.conn().Create(sync).Error
+}
+
+// GetGitSyncs 分页获取Git同步记录，direction为空时不限制
+func (dao *TraefikDAO) GetGitSyncs(direction string, page, size int) ([]traefik.TraefikGitSync, int64, error) {
+	db := dao.conn().Model(&traefik.TraefikGitSync{})
+	if direction != "" {
+		db = db.Where("direction = ?", direction)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var syncs []traefik.TraefikGitSync
+	result := db.Order("id desc").Limit(size).Offset((page - 1) * size).Find(&syncs)
+	return syncs, total, result.Error
+}
+
+// GetLastGitSync 获取最近一次成功的同步，其提交是rapide与仓库一致时的HEAD
+func (dao *TraefikDAO) GetLastGitSync() (traefik.TraefikGitSync, error) {
+	var sync traefik.TraefikGitSync
+	result := dao.conn().Where("status = ? AND commit_hash <> ?", "success", "").Order("id desc").First(&sync)
+	return sync, result.Error
+}
@@ -0,0 +1,74 @@
+package traefik
+
+import (
+	"time"
+
+	"github.com/yahahaff/rapide/internal/models/traefik"
+)
+
+// GetServerStates 获取所有后端服务器的当前状态
+func (dao *TraefikDAO) GetServerStates() ([]traefik.TraefikServerState, error) {
+	var states []traefik.TraefikServerState
+	result := dao.conn().Order("service asc, url asc").Find(&states)
+	return states, result.Error
+}
+
+// SaveServerState 创建或更新后端服务器状态
+func (dao *TraefikDAO) SaveServerState(state *traefik.TraefikServerState) error {
+	return dao.conn().Save(state).Error
+}
+
+// DeleteServerState 删除后端服务器状态
+func (dao *TraefikDAO) DeleteServerState(id uint64) error {
+	return dao.conn().Where("id = ?", id).Delete(&traefik.TraefikServerState{}).Error
+}
+
+// CreateServerTransition 记录后端服务器状态变化
+func (dao *TraefikDAO) CreateServerTransition(transition *traefik.TraefikServerTransition) error {
+	return dao.conn().Create(transition).Error
+}
+
+// GetServerTransitions 获取最近的状态变化，按时间倒序，services为nil时不按服务过滤
+func (dao *TraefikDAO) GetServerTransitions(services []string, limit int) ([]traefik.TraefikServerTransition, error) {
+	transitions := make([]traefik.TraefikServerTransition, 0)
+	query := dao.conn()
+	if services != nil {
+		query = query.Where("service IN ?", services)
+	}
+	result := query.Order("at desc, id desc").Limit(limit).Find(&transitions)
+	return transitions, result.Error
+}
+
+// GetServerTransitionsSince 获取服务器在指定时间之后的状态变化，按时间正序
+func (dao *TraefikDAO) GetServerTransitionsSince(service, url string, since time.Time) ([]traefik.TraefikServerTransition, error) {
+	var transitions []traefik.TraefikServerTransition
+	result := dao.conn().Where("service = ? AND url = ? AND at >= ?", service, url, since).Order("at asc, id asc").Find(&transitions)
+	return transitions, result.Error
+}
+
+// GetLastServerTransitionBefore 获取服务器在指定时间之前的最后一次状态变化
+func (dao *TraefikDAO) GetLastServerTransitionBefore(service, url string, before time.Time) (traefik.TraefikServerTransition, error) {
+	var transition traefik.TraefikServerTransition
+	result := dao.conn().Where("service = ? AND url = ? AND at < ?", service, url, before).Order("at desc, id desc").First(&transition)
+	return transition, result.Error
+}
+
+// DeleteServerTransitionsBefore 删除指定时间之前的状态变化
+// 仍在跟踪的服务器保留时间之前的最后一次变化，用于确定之后的初始状态；状态变化按时间顺序写入，ID最大的即为最后一次
+func (dao *TraefikDAO) DeleteServerTransitionsBefore(before time.Time) (int64, error) {
+	var keep []uint64
+	err := dao.conn().Model(&traefik.TraefikServerTransition{}).
+		Joins("JOIN traefik_server_states ON traefik_server_states.service = traefik_server_transitions.service AND traefik_server_states.url = traefik_server_transitions.url").
+		Where("traefik_server_transitions.at < ?", before).
+		Group("traefik_server_transitions.service, traefik_server_transitions.url").
+		Pluck("MAX(traefik_server_transitions.id)", &keep).Error
+	if err != nil {
+		return 0, err
+	}
+	query := dao.conn().Where("at < ?", before)
+	if len(keep) > 0 {
+		query = query.Where("id NOT IN ?", keep)
+	}
+	result := query.Delete(&traefik.TraefikServerTransition{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,41 @@
+package traefik
+
+import (
+	"github.com/yahahaff/rapide/internal/models/traefik"
+)
+
+// GetAllInstances 获取所有Traefik实例
+func (dao *TraefikDAO) GetAllInstances() ([]traefik.TraefikInstance, error) {
+	var instances []traefik.TraefikInstance
+	result := dao.conn().Order("id asc").Find(&instances)
+	return instances, result.Error
+}
+
+// GetInstanceByID 根据ID获取Traefik实例
+func (dao *TraefikDAO) GetInstanceByID(id uint64) (traefik.TraefikInstance, error) {
+	var instance traefik.TraefikInstance
+	result := dao.conn().Where("id = ?", id).First(&instance)
+	return instance, result.Error
+}
+
+// GetInstanceByTokenHash 根据令牌摘要获取启用的Traefik实例
+func (dao *TraefikDAO) GetInstanceByTokenHash(tokenHash string) (traefik.TraefikInstance, error) {
+	var instance traefik.TraefikInstance
+	result := dao.conn().Where("token_hash = ? AND status = ?", tokenHash, "enabled").First(&instance)
+	return instance, result.Error
+}
+
+// CreateInstance 创建Traefik实例
+func (dao *TraefikDAO) CreateInstance(instance *traefik.TraefikInstance) error {
+	return dao.conn().Create(instance).Error
+}
+
+// UpdateInstance 更新Traefik实例
+func (dao *TraefikDAO) UpdateInstance(instance *traefik.TraefikInstance) error {
+	return dao.conn().Save(instance).Error
+}
+
+// DeleteInstance 删除Traefik实例
+func (dao *TraefikDAO) DeleteInstance(id uint64) error {
+	return dao.conn().Where("id = ?", id).Delete(&traefik.TraefikInstance{}).Error
+}
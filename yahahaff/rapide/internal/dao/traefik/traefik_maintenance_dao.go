@@ -0,0 +1,54 @@
+package traefik
+
+import (
+	"github.com/yahahaff/rapide/internal/models/sys"
+	"github.com/yahahaff/rapide/internal/models/traefik"
+)
+
+// CreateMaintenance 创建维护窗口
+func (dao *TraefikDAO) CreateMaintenance(maintenance *traefik.TraefikMaintenance) error {
+	return dao.conn().Create(maintenance).Error
+}
+
+// SaveMaintenance 保存维护窗口
+func (dao *TraefikDAO) SaveMaintenance(maintenance *traefik.TraefikMaintenance) error {
+	return dao.conn().Save(maintenance).Error
+}
+
+// GetMaintenanceByID 根据ID获取维护窗口
+func (dao *TraefikDAO) GetMaintenanceByID(id uint64) (traefik.TraefikMaintenance, error) {
+	var maintenance traefik.TraefikMaintenance
+	result := dao.conn().Where("id = ?", id).First(&maintenance)
+	return maintenance, result.Error
+}
+
+// GetMaintenances 分页获取范围内部门的路由的维护窗口，按开始时间倒序
+func (dao *TraefikDAO) GetMaintenances(router, status string, scope sys.DeptScope, page, size int) ([]traefik.TraefikMaintenance, int64, error) {
+	db := dao.ownedBy(dao.conn().Model(&traefik.TraefikMaintenance{}), "protocol", "router", "router", scope)
+	if router != "" {
+		db = db.Where("router = ?", router)
+	}
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var maintenances []traefik.TraefikMaintenance
+	result := db.Order("start_at desc, id desc").Limit(size).Offset((page - 1) * size).Find(&maintenances)
+	return maintenances, total, result.Error
+}
+
+// GetPendingMaintenances 获取计划中或进行中的维护窗口，router为空时返回全部
+func (dao *TraefikDAO) GetPendingMaintenances(protocol, router string) ([]traefik.TraefikMaintenance, error) {
+	db := dao.conn().Where("status IN ?", []string{"scheduled", "active"})
+	if router != "" {
+		db = db.Where("protocol = ? AND router = ?", protocol, router)
+	}
+	var maintenances []traefik.TraefikMaintenance
+	result := db.Order("start_at asc, id asc").Find(&maintenances)
+	return maintenances, result.Error
+}
@@ -0,0 +1,63 @@
+package traefik
+
+import (
+	"time"
+
+	"github.com/yahahaff/rapide/internal/models/traefik"
+)
+
+// GetMetricSource 获取实例指标的抓取状态
+func (dao *TraefikDAO) GetMetricSource(instanceID uint64) (traefik.TraefikMetricSource, error) {
+	var source traefik.TraefikMetricSource
+	result := dao.conn().Where("instance_id = ?", instanceID).First(&source)
+	return source, result.Error
+}
+
+// GetMetricSources 获取所有实例指标的抓取状态
+func (dao *TraefikDAO) GetMetricSources() ([]traefik.TraefikMetricSource, error) {
+	var sources []traefik.TraefikMetricSource
+	result := dao.conn().Order("instance_id asc").Find(&sources)
+	return sources, result.Error
+}
+
+// SaveMetricSource 保存指标抓取状态
+func (dao *TraefikDAO) SaveMetricSource(source *traefik.TraefikMetricSource) error {
+	return dao.conn().Save(source).Error
+}
+
+// GetLatestMetricSamples 获取实例最近一次抓取的样本
+func (dao *TraefikDAO) GetLatestMetricSamples(instance string) ([]traefik.TraefikMetricSample, error) {
+	var samples []traefik.TraefikMetricSample
+	latest := dao.conn().Model(&traefik.TraefikMetricSample{}).Select("MAX(at)").Where("instance = ?", instance)
+	result := dao.conn().Where("instance = ? AND at = (?)", instance, latest).Find(&samples)
+	return samples, result.Error
+}
+
+// CreateMetricSamples 批量保存样本
+func (dao *TraefikDAO) CreateMetricSamples(samples []traefik.TraefikMetricSample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+	return dao.conn().CreateInBatches(samples, 200).Error
+}
+
+// GetMetricSamples 获取时间范围内的样本，name和instance为空时不限制，按抓取时间排序
+// name不带@provider后缀时匹配所有Provider中的同名对象
+func (dao *TraefikDAO) GetMetricSamples(kind, name, instance string, from, to time.Time) ([]traefik.TraefikMetricSample, error) {
+	db := dao.conn().Where("kind = ? AND at >= ? AND at < ?", kind, from, to)
+	if name != "" {
+		db = db.Where("(name = ? OR name LIKE ?)", name, name+"@%")
+	}
+	if instance != "" {
+		db = db.Where("instance = ?", instance)
+	}
+	var samples []traefik.TraefikMetricSample
+	result := db.Order("at asc, id asc").Find(&samples)
+	return samples, result.Error
+}
+
+// DeleteMetricSamplesBefore 删除早于指定时间的样本
+func (dao *TraefikDAO) DeleteMetricSamplesBefore(before time.Time) (int64, error) {
+	result := dao.conn().Where("at < ?", before).Delete(&traefik.TraefikMetricSample{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,63 @@
+package traefik
+
+import (
+	"errors"
+
+	"github.com/yahahaff/rapide/internal/models/sys"
+	"github.com/yahahaff/rapide/internal/models/traefik"
+	"gorm.io/gorm"
+)
+
+// GetOwners 获取对象的所属部门，kind为空时不限制
+func (dao *TraefikDAO) GetOwners(kind string) ([]traefik.TraefikOwner, error) {
+	db := dao.conn()
+	if kind != "" {
+		db = db.Where("kind = ?", kind)
+	}
+	var owners []traefik.TraefikOwner
+	result := db.Order("kind asc, protocol asc, name asc").Find(&owners)
+	return owners, result.Error
+}
+
+// GetOwner 获取对象的所属部门记录
+func (dao *TraefikDAO) GetOwner(kind, protocol, name string) (traefik.TraefikOwner, error) {
+	var owner traefik.TraefikOwner
+	result := dao.conn().Where("kind = ? AND protocol = ? AND name = ?", kind, protocol, name).First(&owner)
+	return owner, result.Error
+}
+
+// SaveOwner 设置对象的所属部门，已有记录时覆盖
+func (dao *TraefikDAO) SaveOwner(owner *traefik.TraefikOwner) error {
+	existing, err := dao.GetOwner(owner.Kind, owner.Protocol, owner.Name)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if err == nil {
+		owner.ID = existing.ID
+		owner.CreatedAt = existing.CreatedAt
+	}
+	return dao.conn().Save(owner).Error
+}
+
+// DeleteOwner 删除对象的所属部门记录
+func (dao *TraefikDAO) DeleteOwner(kind, protocol, name string) error {
+	return dao.conn().Where("kind = ? AND protocol = ? AND name = ?", kind, protocol, name).Delete(&traefik.TraefikOwner{}).Error
+}
+
+// ownedBy 限制protocolColumn和nameColumn指向的对象属于范围内的部门，超级管理员不限制
+func (dao *TraefikDAO) ownedBy(db *gorm.DB, protocolColumn, nameColumn, kind string, scope sys.DeptScope) *gorm.DB {
+	if scope.All {
+		return db
+	}
+	owned := dao.conn().Model(&traefik.TraefikOwner{}).Select("protocol, name").Where("kind = ? AND dept_id IN ?", kind, scope.DeptIDs)
+	return db.Where("("+protocolColumn+", "+nameColumn+") IN (?)", owned)
+}
+
+// ownedObjects 限制kind、protocol和name列指向的对象属于范围内的部门，超级管理员不限制
+func (dao *TraefikDAO) ownedObjects(db *gorm.DB, scope sys.DeptScope) *gorm.DB {
+	if scope.All {
+		return db
+	}
+	owned := dao.conn().Model(&traefik.TraefikOwner{}).Select("kind, protocol, name").Where("dept_id IN ?", scope.DeptIDs)
+	return db.Where("(kind, protocol, name) IN (?)", owned)
+}
@@ -0,0 +1,61 @@
+package traefik
+
+import (
+	"time"
+
+	"github.com/yahahaff/rapide/internal/models/sys"
+	"github.com/yahahaff/rapide/internal/models/traefik"
+)
+
+// RevisionFilter 修订记录查询条件
+type RevisionFilter struct {
+	Kind     string
+	Name     string
+	Protocol string
+	Operator string
+}
+
+// CreateRevision 创建修订记录
+func (dao *TraefikDAO) CreateRevision(revision *traefik.TraefikRevision) error {
+	return dao.conn().Create(revision).Error
+}
+
+// GetRevisionByID 根据ID获取修订记录
+func (dao *TraefikDAO) GetRevisionByID(id uint64) (traefik.TraefikRevision, error) {
+	var revision traefik.TraefikRevision
+	result := dao.conn().Where("id = ?", id).First(&revision)
+	return revision, result.Error
+}
+
+// GetRevisions 分页获取范围内部门的对象的修订记录，按时间倒序
+func (dao *TraefikDAO) GetRevisions(filter RevisionFilter, scope sys.DeptScope, page, size int) ([]traefik.TraefikRevision, int64, error) {
+	db := dao.ownedObjects(dao.conn().Model(&traefik.TraefikRevision{}), scope)
+	if filter.Kind != "" {
+		db = db.Where("kind = ?", filter.Kind)
+	}
+	if filter.Name != "" {
+		db = db.Where("name = ?", filter.Name)
+	}
+	if filter.Protocol != "" {
+		db = db.Where("protocol = ?", filter.Protocol)
+	}
+	if filter.Operator != "" {
+		db = db.Where("operator = ?", filter.Operator)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var revisions []traefik.TraefikRevision
+	result := db.Order("id desc").Limit(size).Offset((page - 1) * size).Find(&revisions)
+	return revisions, total, result.Error
+}
+
+// GetRevisionsSince 获取指定时间之后的所有修订记录，按ID正序
+func (dao *TraefikDAO) GetRevisionsSince(since time.Time) ([]traefik.TraefikRevision, error) {
+	var revisions []traefik.TraefikRevision
+	result := dao.conn().Where("created_at > ?", since).Order("id asc").Find(&revisions)
+	return revisions, result.Error
+}
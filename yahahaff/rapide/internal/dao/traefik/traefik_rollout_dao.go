@@ -0,0 +1,54 @@
+package traefik
+
+import (
+	"github.com/yahahaff/rapide/internal/models/sys"
+	"github.com/yahahaff/rapide/internal/models/traefik"
+)
+
+// CreateRollout 创建灰度发布
+func (dao *TraefikDAO) CreateRollout(rollout *traefik.TraefikRollout) error {
+	return dao.conn().Create(rollout).Error
+}
+
+// SaveRollout 保存灰度发布
+func (dao *TraefikDAO) SaveRollout(rollout *traefik.TraefikRollout) error {
+	return dao.conn().Save(rollout).Error
+}
+
+// GetRolloutByID 根据ID获取灰度发布
+func (dao *TraefikDAO) GetRolloutByID(id uint64) (traefik.TraefikRollout, error) {
+	var rollout traefik.TraefikRollout
+	result := dao.conn().Where("id = ?", id).First(&rollout)
+	return rollout, result.Error
+}
+
+// GetRollouts 分页获取范围内部门的服务的灰度发布，按时间倒序
+func (dao *TraefikDAO) GetRollouts(service, status string, scope sys.DeptScope, page, size int) ([]traefik.TraefikRollout, int64, error) {
+	db := dao.ownedBy(dao.conn().Model(&traefik.TraefikRollout{}), "protocol", "service", "service", scope)
+	if service != "" {
+		db = db.Where("service = ?", service)
+	}
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rollouts []traefik.TraefikRollout
+	result := db.Order("id desc").Limit(size).Offset((page - 1) * size).Find(&rollouts)
+	return rollouts, total, result.Error
+}
+
+// GetActiveRollouts 获取进行中或暂停的灰度发布，service为空时返回全部
+func (dao *TraefikDAO) GetActiveRollouts(protocol, service string) ([]traefik.TraefikRollout, error) {
+	db := dao.conn().Where("status IN ?", []string{"running", "paused"})
+	if service != "" {
+		db = db.Where("protocol = ? AND service = ?", protocol, service)
+	}
+	var rollouts []traefik.TraefikRollout
+	result := db.Order("id asc").Find(&rollouts)
+	return rollouts, result.Error
+}
@@ -0,0 +1,69 @@
+package traefik
+
+import (
+	"time"
+
+	"github.com/yahahaff/rapide/internal/models/traefik"
+)
+
+// CreateSnapshot 创建配置快照
+func (dao *TraefikDAO) CreateSnapshot(snapshot *traefik.TraefikSnapshot) error {
+	return dao.conn().Create(snapshot).Error
+}
+
+// SaveSnapshot 保存配置快照
+func (dao *TraefikDAO) SaveSnapshot(snapshot *traefik.TraefikSnapshot) error {
+	return dao.conn().Save(snapshot).Error
+}
+
+// GetSnapshotByID 根据ID获取配置快照
+func (dao *TraefikDAO) GetSnapshotByID(id uint64) (traefik.TraefikSnapshot, error) {
+	var snapshot traefik.TraefikSnapshot
+	result := dao.conn().Where("id = ?", id).First(&snapshot)
+	return snapshot, result.Error
+}
+
+// GetSnapshots 分页获取配置快照，不包含配置内容，按时间倒序
+func (dao *TraefikDAO) GetSnapshots(status string, page, size int) ([]traefik.TraefikSnapshot, int64, error) {
+	db := dao.conn().Model(&traefik.TraefikSnapshot{})
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var snapshots []traefik.TraefikSnapshot
+	result := db.Omit("config").Order("id desc").Limit(size).Offset((page - 1) * size).Find(&snapshots)
+	return snapshots, total, result.Error
+}
+
+// GetPublishedSnapshot 获取当前生效的配置快照
+func (dao *TraefikDAO) GetPublishedSnapshot() (traefik.TraefikSnapshot, error) {
+	var snapshot traefik.TraefikSnapshot
+	result := dao.conn().Where("status = ?", "published").Order("published_at desc, id desc").First(&snapshot)
+	return snapshot, result.Error
+}
+
+// GetDueSnapshots 获取计划生效时间已到的快照，按计划时间先后排列
+func (dao *TraefikDAO) GetDueSnapshots(now time.Time) ([]traefik.TraefikSnapshot, error) {
+	var snapshots []traefik.TraefikSnapshot
+	result := dao.conn().Where("status = ? AND activate_at <= ?", "scheduled", now).Order("activate_at asc, id asc").Find(&snapshots)
+	return snapshots, result.Error
+}
+
+// GetScheduledSnapshots 获取所有计划中的快照
+func (dao *TraefikDAO) GetScheduledSnapshots() ([]traefik.TraefikSnapshot, error) {
+	var snapshots []traefik.TraefikSnapshot
+	result := dao.conn().Where("status = ?", "scheduled").Order("id asc").Find(&snapshots)
+	return snapshots, result.Error
+}
+
+// SupersedeSnapshots 把除指定快照外所有已发布的快照标记为已替代
+func (dao *TraefikDAO) SupersedeSnapshots(exceptID uint64) error {
+	return dao.conn().Model(&traefik.TraefikSnapshot{}).
+		Where("status = ? AND id <> ?", "published", exceptID).
+		Update("status", "superseded").Error
+}
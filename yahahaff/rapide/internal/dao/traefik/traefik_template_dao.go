@@ -0,0 +1,34 @@
+package traefik
+
+import (
+	"github.com/yahahaff/rapide/internal/models/traefik"
+)
+
+// GetTemplates 获取所有自定义模板
+func (dao *TraefikDAO) GetTemplates() ([]traefik.TraefikTemplate, error) {
+	var templates []traefik.TraefikTemplate
+	result := dao.conn().Order("name asc").Find(&templates)
+	return templates, result.Error
+}
+
+// GetTemplateByName 根据名称获取自定义模板
+func (dao *TraefikDAO) GetTemplateByName(name string) (traefik.TraefikTemplate, error) {
+	var template traefik.TraefikTemplate
+	result := dao.conn().Where("name = ?", name).First(&template)
+	return template, result.Error
+}
+
+// CreateTemplate 创建自定义模板
+func (dao *TraefikDAO) CreateTemplate(template *traefik.TraefikTemplate) error {
+	return dao.conn().Create(template).Error
+}
+
+// UpdateTemplate 更新自定义模板
+func (dao *TraefikDAO) UpdateTemplate(template *traefik.TraefikTemplate) error {
+	return dao.conn().Save(template).Error
+}
+
+// DeleteTemplate 删除自定义模板
+func (dao *TraefikDAO) DeleteTemplate(name string) error {
+	return dao.conn().Where("name = ?", name).Delete(&traefik.TraefikTemplate{}).Error
+}
@@ -0,0 +1,113 @@
+package traefik
+
+import (
+	"github.com/yahahaff/rapide/internal/models/traefik"
+)
+
+// GetAllTLSOptions 获取所有启用的TLS选项
+func (dao *TraefikDAO) GetAllTLSOptions() ([]traefik.TraefikTLSOption, error) {
+	var options []traefik.TraefikTLSOption
+	result := dao.conn().Where("status = ?", "enabled").Find(&options)
+	return options, result.Error
+}
+
+// GetAllTLSStores 获取所有启用的TLS证书存储
+func (dao *TraefikDAO) GetAllTLSStores() ([]traefik.TraefikTLSStore, error) {
+	var stores []traefik.TraefikTLSStore
+	result := dao.conn().Where("status = ?", "enabled").Find(&stores)
+	return stores, result.Error
+}
+
+// GetAllServersTransports 获取所有启用的serversTransport
+func (dao *TraefikDAO) GetAllServersTransports() ([]traefik.TraefikServersTransport, error) {
+	var transports []traefik.TraefikServersTransport
+	result := dao.conn().Where("status = ?", "enabled").Find(&transports)
+	return transports, result.Error
+}
+
+// ListTLSOptions 获取所有TLS选项，包含已禁用的
+func (dao *TraefikDAO) ListTLSOptions() ([]traefik.TraefikTLSOption, error) {
+	var options []traefik.TraefikTLSOption
+	result := dao.conn().Order("name asc").Find(&options)
+	return options, result.Error
+}
+
+// ListTLSStores 获取所有TLS证书存储，包含已禁用的
+func (dao *TraefikDAO) ListTLSStores() ([]traefik.TraefikTLSStore, error) {
+	var stores []traefik.TraefikTLSStore
+	result := dao.conn().Order("name asc").Find(&stores)
+	return stores, result.Error
+}
+
+// ListServersTransports 获取所有serversTransport，包含已禁用的
+func (dao *TraefikDAO) ListServersTransports() ([]traefik.TraefikServersTransport, error) {
+	var transports []traefik.TraefikServersTransport
+	result := dao.conn().Order("protocol asc, name asc").Find(&transports)
+	return transports, result.Error
+}
+
+// GetTLSOption 根据名称获取TLS选项
+func (dao *TraefikDAO) GetTLSOption(name string) (traefik.TraefikTLSOption, error) {
+	var option traefik.TraefikTLSOption
+	result := dao.conn().Where("name = ?", name).First(&option)
+	return option, result.Error
+}
+
+// GetTLSStore 根据名称获取TLS证书存储
+func (dao *TraefikDAO) GetTLSStore(name string) (traefik.TraefikTLSStore, error) {
+	var store traefik.TraefikTLSStore
+	result := dao.conn().Where("name = ?", name).First(&store)
+	return store, result.Error
+}
+
+// GetServersTransport 根据名称和协议获取serversTransport
+func (dao *TraefikDAO) GetServersTransport(name, protocol string) (traefik.TraefikServersTransport, error) {
+	var transport traefik.TraefikServersTransport
+	result := dao.conn().Where("name = ? AND protocol = ?", name, protocol).First(&transport)
+	return transport, result.Error
+}
+
+// CreateTLSOption 创建TLS选项
+func (dao *TraefikDAO) CreateTLSOption(option *traefik.TraefikTLSOption) error {
+	return dao.conn().Create(option).Error
+}
+
+// CreateTLSStore 创建TLS证书存储
+func (dao *TraefikDAO) CreateTLSStore(store *traefik.TraefikTLSStore) error {
+	return dao.conn().Create(store).Error
+}
+
+// CreateServersTransport 创建serversTransport
+func (dao *TraefikDAO) CreateServersTransport(transport *traefik.TraefikServersTransport) error {
+	return dao.conn().Create(transport).Error
+}
+
+// UpdateTLSOption 更新TLS选项
+func (dao *TraefikDAO) UpdateTLSOption(option *traefik.TraefikTLSOption) error {
+	return dao.conn().Save(option).Error
+}
+
+// UpdateTLSStore 更新TLS证书存储
+func (dao *TraefikDAO) UpdateTLSStore(store *traefik.TraefikTLSStore) error {
+	return dao.conn().Save(store).Error
+}
+
+// UpdateServersTransport 更新serversTransport
+func (dao *TraefikDAO) UpdateServersTransport(transport *traefik.TraefikServersTransport) error {
+	return dao.conn().Save(transport).Error
+}
+
+// DeleteTLSOption 删除TLS选项
+func (dao *TraefikDAO) DeleteTLSOption(name string) error {
+	return dao.conn().Where("name = ?", name).Delete(&traefik.TraefikTLSOption{}).Error
+}
+
+// DeleteTLSStore 删除TLS证书存储
+func (dao *TraefikDAO) DeleteTLSStore(name string) error {
+	return dao.conn().Where("name = ?", name).Delete(&traefik.TraefikTLSStore{}).Error
+}
+
+// DeleteServersTransport 删除serversTransport
+func (dao *TraefikDAO) DeleteServersTransport(name, protocol string) error {
+	return dao.conn().Where("name = ? AND protocol = ?", name, protocol).Delete(&traefik.TraefikServersTransport{}).Error
+}
@@ -0,0 +1,59 @@
+package traefik
+
+import (
+	"time"
+
+	"github.com/yahahaff/rapide/internal/models/traefik"
+)
+
+// GetAccessLogCursor 获取实例访问日志的读取进度
+func (dao *TraefikDAO) GetAccessLogCursor(instanceID uint64) (traefik.TraefikAccessLogCursor, error) {
+	var cursor traefik.TraefikAccessLogCursor
+	result := dao.conn().Where("instance_id = ?", instanceID).First(&cursor)
+	return cursor, result.Error
+}
+
+// GetAccessLogCursors 获取所有实例访问日志的读取进度
+func (dao *TraefikDAO) GetAccessLogCursors() ([]traefik.TraefikAccessLogCursor, error) {
+	var cursors []traefik.TraefikAccessLogCursor
+	result := dao.conn().Order("instance_id asc").Find(&cursors)
+	return cursors, result.Error
+}
+
+// SaveAccessLogCursor 保存访问日志的读取进度
+func (dao *TraefikDAO) SaveAccessLogCursor(cursor *traefik.TraefikAccessLogCursor) error {
+	return dao.conn().Save(cursor).Error
+}
+
+// GetTrafficBucket 获取一个时间段的访问统计
+func (dao *TraefikDAO) GetTrafficBucket(kind, name, instance string, start time.Time) (traefik.TraefikTrafficBucket, error) {
+	var bucket traefik.TraefikTrafficBucket
+	result := dao.conn().Where("kind = ? AND name = ? AND instance = ? AND start = ?", kind, name, instance, start).First(&bucket)
+	return bucket, result.Error
+}
+
+// SaveTrafficBucket 保存访问统计
+func (dao *TraefikDAO) SaveTrafficBucket(bucket *traefik.TraefikTrafficBucket) error {
+	return dao.conn().Save(bucket).Error
+}
+
+// GetTrafficBuckets 获取时间范围内的访问统计，name和instance为空时不限制，按开始时间排序
+// name不带@provider后缀时匹配所有Provider中的同名对象
+func (dao *TraefikDAO) GetTrafficBuckets(kind, name, instance string, from, to time.Time) ([]traefik.TraefikTrafficBucket, error) {
+	db := dao.conn().Where("kind = ? AND start >= ? AND start < ?", kind, from, to)
+	if name != "" {
+		db = db.Where("(name = ? OR name LIKE ?)", name, name+"@%")
+	}
+	if instance != "" {
+		db = db.Where("instance = ?", instance)
+	}
+	var buckets []traefik.TraefikTrafficBucket
+	result := db.Order("start asc, id asc").Find(&buckets)
+	return buckets, result.Error
+}
+
+// DeleteTrafficBucketsBefore 删除早于指定时间的访问统计
+func (dao *TraefikDAO) DeleteTrafficBucketsBefore(before time.Time) (int64, error) {
+	result := dao.conn().Where("start < ?", before).Delete(&traefik.TraefikTrafficBucket{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,34 @@
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/yahahaff/rapide/internal/controllers"
+	"github.com/yahahaff/rapide/internal/service"
+	"github.com/yahahaff/rapide/pkg/logger"
+	"github.com/yahahaff/rapide/pkg/response"
+)
+
+// DeptScope 解析当前用户可以管理的部门范围，需要在AuthJWT之后使用
+func DeptScope() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scope, err := service.Entrance.SysService.DeptService.GetUserDeptScope(c.GetUint64("current_user_id"))
+		if err != nil {
+			logger.ErrorString("middlewares", "DeptScope", err.Error())
+			response.Abort500(c, "获取用户部门失败")
+			return
+		}
+		c.Set(controllers.DeptScopeKey, scope)
+		c.Next()
+	}
+}
+
+// RequireAllDepts 只允许不受部门限制的超级管理员访问，用于影响所有部门的操作
+func RequireAllDepts() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !controllers.CurrentDeptScope(c).All {
+			response.Abort403(c, "只有超级管理员可以执行该操作")
+			return
+		}
+		c.Next()
+	}
+}
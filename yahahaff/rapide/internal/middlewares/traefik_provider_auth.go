@@ -0,0 +1,49 @@
+package middlewares
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yahahaff/rapide/internal/service"
+	"github.com/yahahaff/rapide/pkg/config"
+	"github.com/yahahaff/rapide/pkg/logger"
+)
+
+// TraefikProviderAuth 校验Traefik HTTP Provider的拉取请求
+// 支持 Authorization: Bearer <token>、X-Provider-Token: <token> 请求头，
+// 以及 basic auth（用户名为实例名称，密码为令牌）
+func TraefikProviderAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name, token, ok := c.Request.BasicAuth()
+		if !ok {
+			name = ""
+			token = c.GetHeader("X-Provider-Token")
+			if token == "" {
+				token = strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+			}
+		}
+
+		instanceService := &service.Entrance.TraefikService.TraefikInstanceService
+		instance, ok := instanceService.AuthenticateInstance(name, token)
+		if !ok {
+			c.Header("WWW-Authenticate", `Basic realm="rapide traefik provider"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid provider token"})
+			return
+		}
+
+		// 默认使用TCP连接的对端地址，rapide部署在可信代理之后时才使用转发头中的地址
+		clientIP := c.RemoteIP()
+		if config.GetBool("TRAEFIK_PROVIDER_TRUST_FORWARDED", false) {
+			clientIP = c.ClientIP()
+		}
+		if !instanceService.IsIPAllowed(instance, clientIP) {
+			logger.WarnString("traefik", "provider", "instance "+instance.Name+" rejected from "+clientIP)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "client ip not allowed"})
+			return
+		}
+
+		c.Set("traefik_instance", instance)
+		c.Next()
+	}
+}
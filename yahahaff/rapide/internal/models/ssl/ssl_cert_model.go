@@ -23,6 +23,7 @@ type SSLCert struct {
 	ValidityStart    time.Time `json:"validityStart" gorm:"type:datetime;comment:'有效期开始时间'"`
 	ValidityEnd      time.Time `json:"validityEnd" gorm:"type:datetime;comment:'有效期结束时间'"`
 	Status           int       `json:"status" gorm:"default:1;comment:'状态 0:禁用 1:启用'"`
+	DeptID           uint64    `json:"deptId" gorm:"index;default:0;comment:'所属部门ID'"`
 	// 证书提供商相关字段
 	Provider      string `json:"provider" gorm:"type:varchar(50);not null;default:'letsencrypt';comment:'证书提供商: letsencrypt/google'"`
 	ChallengeType string `json:"challengeType" gorm:"type:varchar(20);not null;default:'http-01';comment:'验证方式: http-01/dns-01'"`
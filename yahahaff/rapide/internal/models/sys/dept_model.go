@@ -53,3 +53,38 @@ func (dept *Dept) Update() error {
 func DeleteDept(id uint64) error {
 	return database.DB.Delete(&Dept{}, "id = ?", id).Error
 }
+
+// DeptScope 用户可以管理的部门范围，包含用户所在的部门及其所有下级部门
+type DeptScope struct {
+	All     bool     `json:"all"`     // 超级管理员，不受部门限制
+	DeptIDs []uint64 `json:"deptIds"` // 可以管理的部门
+	Default uint64   `json:"default"` // 用户只属于一个部门时为该部门，创建对象未指定部门时使用
+}
+
+// Contains 判断部门是否在范围内，未归属部门(0)的对象只有超级管理员可以管理
+func (scope DeptScope) Contains(deptID uint64) bool {
+	if scope.All {
+		return true
+	}
+	if deptID == 0 {
+		return false
+	}
+	for _, id := range scope.DeptIDs {
+		if id == deptID {
+			return true
+		}
+	}
+	return false
+}
+
+// Owner 确定新对象的所属部门，未指定时使用Default；部门不在范围内或无法确定时返回false
+// 超级管理员未指定部门时对象不归属任何部门
+func (scope DeptScope) Owner(deptID uint64) (uint64, bool) {
+	if deptID == 0 {
+		deptID = scope.Default
+	}
+	if scope.All {
+		return deptID, true
+	}
+	return deptID, scope.Contains(deptID)
+}
@@ -0,0 +1,51 @@
+package sys
+
+import "testing"
+
+func TestDeptScopeContains(t *testing.T) {
+	cases := []struct {
+		name   string
+		scope  DeptScope
+		deptID uint64
+		want   bool
+	}{
+		{"超级管理员包含任何部门", DeptScope{All: true}, 99, true},
+		{"超级管理员包含未归属部门", DeptScope{All: true}, 0, true},
+		{"范围内的部门", DeptScope{DeptIDs: []uint64{10, 11}}, 11, true},
+		{"范围外的部门", DeptScope{DeptIDs: []uint64{10, 11}}, 20, false},
+		{"未归属部门只有超级管理员可以管理", DeptScope{DeptIDs: []uint64{10}}, 0, false},
+		{"空范围", DeptScope{}, 10, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.scope.Contains(c.deptID); got != c.want {
+				t.Errorf("%+v.Contains(%d) = %v, want %v", c.scope, c.deptID, got, c.want)
+			}
+		})
+	}
+}
+
+func TestDeptScopeOwner(t *testing.T) {
+	cases := []struct {
+		name   string
+		scope  DeptScope
+		deptID uint64
+		want   uint64
+		ok     bool
+	}{
+		{"超级管理员未指定部门时不归属任何部门", DeptScope{All: true}, 0, 0, true},
+		{"超级管理员指定任意部门", DeptScope{All: true}, 20, 20, true},
+		{"未指定时使用默认部门", DeptScope{DeptIDs: []uint64{10, 11}, Default: 10}, 0, 10, true},
+		{"指定范围内的下级部门", DeptScope{DeptIDs: []uint64{10, 11}, Default: 10}, 11, 11, true},
+		{"指定范围外的部门", DeptScope{DeptIDs: []uint64{10, 11}, Default: 10}, 20, 20, false},
+		{"属于多个部门且未指定", DeptScope{DeptIDs: []uint64{10, 20}}, 0, 0, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, ok := c.scope.Owner(c.deptID)
+			if got != c.want || ok != c.ok {
+				t.Errorf("%+v.Owner(%d) = %d, %v, want %d, %v", c.scope, c.deptID, got, ok, c.want, c.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package traefik
+
+import (
+	"github.com/yahahaff/rapide/internal/models"
+	"github.com/yahahaff/rapide/pkg/types"
+)
+
+// TraefikAdoption 从Traefik运行时接管到rapide的对象，记录它原来所在的Provider和定义
+type TraefikAdoption struct {
+	models.BaseModel
+	models.CommonTimestampsField
+	Kind        string          `json:"kind" gorm:"type:varchar(20);uniqueIndex:idx_adoption_object;not null"` // router, service, middleware
+	Name        string          `json:"name" gorm:"uniqueIndex:idx_adoption_object;not null"`                  // rapide中的名称
+	Protocol    string          `json:"protocol" gorm:"type:varchar(10);uniqueIndex:idx_adoption_object"`      // http, tcp, udp
+	RuntimeName string          `json:"runtimeName"`                                                           // 接管时Traefik中的名称，带@provider后缀
+	Provider    string          `json:"provider" gorm:"type:varchar(50);index"`                                // 原来的Provider，如docker、file
+	Definition  types.JSONMap   `json:"definition" gorm:"type:json"`                                           // 接管时运行时中的定义
+	Checklist   types.JSONSlice `json:"checklist" gorm:"type:json"`                                            // 删除原定义的步骤
+	Operator    string          `json:"operator"`
+}
+
+// TableName 指定表名
+func (TraefikAdoption) TableName() string {
+	return "traefik_adoptions"
+}
@@ -0,0 +1,38 @@
+package traefik
+
+import (
+	"github.com/yahahaff/rapide/internal/models"
+	"github.com/yahahaff/rapide/pkg/types"
+)
+
+// TraefikApp 应用，把一组路由、服务、中间件和证书归到同一个负责部门和联系人下
+type TraefikApp struct {
+	models.BaseModel
+	models.CommonTimestampsField
+	Name        string          `json:"name" gorm:"type:varchar(100);uniqueIndex;not null"`
+	Description string          `json:"description" gorm:"type:varchar(500)"`
+	DeptID      uint64          `json:"deptId" gorm:"index"`                       // 负责部门，sys_dept中的部门
+	Environment string          `json:"environment" gorm:"type:varchar(20);index"` // prod, staging, test, dev
+	Contacts    types.JSONSlice `json:"contacts" gorm:"type:json"`                 // 联系人的用户名
+	Operator    string          `json:"operator"`
+}
+
+// TableName 指定表名
+func (TraefikApp) TableName() string {
+	return "traefik_apps"
+}
+
+// TraefikAppMember 应用包含的对象，每个对象最多属于一个应用
+type TraefikAppMember struct {
+	models.BaseModel
+	models.CommonTimestampsField
+	AppID    uint64 `json:"appId" gorm:"index;not null"`
+	Kind     string `json:"kind" gorm:"type:varchar(20);uniqueIndex:idx_app_member;not null"` // router, service, middleware, cert
+	Protocol string `json:"protocol" gorm:"type:varchar(10);uniqueIndex:idx_app_member"`      // 证书为空
+	Name     string `json:"name" gorm:"uniqueIndex:idx_app_member;not null"`                  // 证书为sys_ssl_cert中的域名
+}
+
+// TableName 指定表名
+func (TraefikAppMember) TableName() string {
+	return "traefik_app_members"
+}
@@ -0,0 +1,22 @@
+package traefik
+
+import (
+	"github.com/yahahaff/rapide/internal/models"
+	"github.com/yahahaff/rapide/pkg/types"
+)
+
+// TraefikAuthPolicy 路由的访问策略，rapide作为forwardAuth服务时按它判断用户能否访问该路由
+type TraefikAuthPolicy struct {
+	models.BaseModel
+	models.CommonTimestampsField
+	Router   string          `json:"router" gorm:"uniqueIndex;not null"` // http路由名称
+	Roles    types.JSONSlice `json:"roles" gorm:"type:json"`             // 允许访问的角色编码
+	Users    types.JSONSlice `json:"users" gorm:"type:json"`             // 允许访问的用户名
+	AnyUser  bool            `json:"anyUser" gorm:"default:false"`       // 允许所有登录用户访问，为false且角色和用户都为空时拒绝所有请求
+	Operator string          `json:"operator" gorm:"type:varchar(100)"`
+}
+
+// TableName 指定表名
+func (TraefikAuthPolicy) TableName() string {
+	return "traefik_auth_policies"
+}
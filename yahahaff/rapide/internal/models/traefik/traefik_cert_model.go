@@ -0,0 +1,27 @@
+package traefik
+
+import (
+	"time"
+
+	"github.com/yahahaff/rapide/internal/models"
+)
+
+// TraefikCertLink 启用TLS的路由中的域名与SSL证书的关联，由证书同步任务维护
+type TraefikCertLink struct {
+	models.BaseModel
+	models.CommonTimestampsField
+	Router      string     `json:"router" gorm:"uniqueIndex:idx_cert_link;not null"`
+	Protocol    string     `json:"protocol" gorm:"type:varchar(10);uniqueIndex:idx_cert_link"`
+	Host        string     `json:"host" gorm:"uniqueIndex:idx_cert_link;not null"` // 从路由规则中提取的域名
+	CertID      uint64     `json:"certId" gorm:"index"`                            // sys_ssl_cert中的证书，0表示没有可用的证书
+	Domain      string     `json:"domain"`                                         // 证书的域名，可能是覆盖该域名的通配符证书
+	Status      string     `json:"status" gorm:"type:varchar(20)"`                 // linked, issuing, failed, expired, missing
+	Message     string     `json:"message"`
+	Fingerprint string     `json:"fingerprint" gorm:"type:varchar(100)"` // 下发时使用的证书指纹，变化时重新下发配置
+	ValidityEnd *time.Time `json:"validityEnd"`
+}
+
+// TableName 指定表名
+func (TraefikCertLink) TableName() string {
+	return "traefik_cert_links"
+}
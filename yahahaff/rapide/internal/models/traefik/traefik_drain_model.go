@@ -0,0 +1,26 @@
+package traefik
+
+import (
+	"time"
+
+	"github.com/yahahaff/rapide/internal/models"
+)
+
+// TraefikServerDrain 后端服务器摘除任务，摘除时先把权重设为0，到期后从服务中删除
+type TraefikServerDrain struct {
+	models.BaseModel
+	models.CommonTimestampsField
+	Protocol string    `json:"protocol" gorm:"type:varchar(10);index:idx_server_drain;not null"`
+	Service  string    `json:"service" gorm:"index:idx_server_drain;not null"`
+	Server   string    `json:"server" gorm:"not null"`                  // 后端地址，http为url，tcp和udp为address
+	Weight   *int      `json:"weight"`                                  // 摘除前的权重，未设置时为空
+	RemoveAt time.Time `json:"removeAt" gorm:"index"`                   // 计划删除时间
+	Status   string    `json:"status" gorm:"type:varchar(20);not null"` // draining, removed, cancelled, abandoned
+	Reason   string    `json:"reason,omitempty"`                        // 任务被放弃的原因
+	Operator string    `json:"operator"`
+}
+
+// TableName 指定表名
+func (TraefikServerDrain) TableName() string {
+	return "traefik_server_drains"
+}
@@ -0,0 +1,39 @@
+package traefik
+
+import (
+	"github.com/yahahaff/rapide/internal/models"
+	"github.com/yahahaff/rapide/pkg/types"
+)
+
+// TraefikDriftReport rapide期望配置与Traefik运行时状态的对账报告
+type TraefikDriftReport struct {
+	models.BaseModel
+	models.CommonTimestampsField
+	Trigger  string             `json:"trigger" gorm:"type:varchar(20);not null"`      // manual, schedule
+	Status   string             `json:"status" gorm:"type:varchar(20);index;not null"` // ok, drift, failed
+	Summary  types.JSONMap      `json:"summary" gorm:"type:json"`                      // 按类型统计的差异数量
+	Error    string             `json:"error" gorm:"type:text"`                        // 获取运行时状态失败的原因
+	Operator string             `json:"operator" gorm:"type:varchar(100)"`
+	Items    []TraefikDriftItem `json:"items,omitempty" gorm:"foreignKey:ReportID"`
+}
+
+// TableName 指定表名
+func (TraefikDriftReport) TableName() string {
+	return "traefik_drift_reports"
+}
+
+// TraefikDriftItem 对账报告中的一项差异
+type TraefikDriftItem struct {
+	models.BaseModel
+	ReportID uint64        `json:"reportId" gorm:"index;not null"`
+	Kind     string        `json:"kind" gorm:"type:varchar(20);not null"` // router, service, middleware
+	Name     string        `json:"name" gorm:"not null"`
+	Protocol string        `json:"protocol" gorm:"type:varchar(10);not null"`
+	Type     string        `json:"type" gorm:"type:varchar(20);not null"` // missing, extra, error, mismatch
+	Details  types.JSONMap `json:"details" gorm:"type:json"`
+}
+
+// TableName 指定表名
+func (TraefikDriftItem) TableName() string {
+	return "traefik_drift_items"
+}
@@ -0,0 +1,26 @@
+package traefik
+
+import (
+	"github.com/yahahaff/rapide/internal/models"
+	"github.com/yahahaff/rapide/pkg/types"
+)
+
+// TraefikGitSync 与Git仓库的一次同步，push为发布后提交配置，pull为把仓库中的提交应用到rapide
+type TraefikGitSync struct {
+	models.BaseModel
+	models.CommonTimestampsField
+	Direction  string        `json:"direction" gorm:"type:varchar(10);index;not null"`  // push, pull
+	Status     string        `json:"status" gorm:"type:varchar(20);index;not null"`     // success, failed
+	Commit     string        `json:"commit" gorm:"column:commit_hash;type:varchar(64)"` // 同步后仓库的HEAD，失败时为空
+	SnapshotID uint64        `json:"snapshotId"`                                        // push提交的快照，pull发布的快照
+	Author     string        `json:"author" gorm:"type:varchar(100)"`                   // 提交的作者
+	Message    string        `json:"message" gorm:"type:varchar(255)"`                  // 提交说明
+	Summary    types.JSONMap `json:"summary" gorm:"type:json"`                          // 变更的文件或对象数量
+	Error      string        `json:"error,omitempty" gorm:"type:text"`
+	Operator   string        `json:"operator" gorm:"type:varchar(100)"`
+}
+
+// TableName 指定表名
+func (TraefikGitSync) TableName() string {
+	return "traefik_git_syncs"
+}
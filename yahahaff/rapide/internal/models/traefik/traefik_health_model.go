@@ -0,0 +1,39 @@
+package traefik
+
+import (
+	"time"
+
+	"github.com/yahahaff/rapide/internal/models"
+)
+
+// TraefikServerState 后端服务器的当前健康状态，来自Traefik服务的serverStatus
+type TraefikServerState struct {
+	models.BaseModel
+	models.CommonTimestampsField
+	Service   string     `json:"service" gorm:"uniqueIndex:idx_server_state;not null"` // 运行时服务名称，带@provider后缀
+	URL       string     `json:"url" gorm:"uniqueIndex:idx_server_state;not null"`
+	Status    string     `json:"status" gorm:"type:varchar(10);not null"` // UP, DOWN
+	Since     time.Time  `json:"since"`                                   // 进入当前状态的时间
+	CheckedAt time.Time  `json:"checkedAt"`                               // 最近一次轮询的时间
+	AlertedAt *time.Time `json:"alertedAt"`                               // 宕机告警发送时间，恢复后清空
+}
+
+// TableName 指定表名
+func (TraefikServerState) TableName() string {
+	return "traefik_server_states"
+}
+
+// TraefikServerTransition 后端服务器的状态变化记录
+type TraefikServerTransition struct {
+	models.BaseModel
+	Service string    `json:"service" gorm:"index:idx_server_transition;not null"`
+	URL     string    `json:"url" gorm:"index:idx_server_transition;not null"`
+	From    string    `json:"from" gorm:"type:varchar(10)"` // 首次发现时为空
+	To      string    `json:"to" gorm:"type:varchar(10);not null"`
+	At      time.Time `json:"at" gorm:"index"`
+}
+
+// TableName 指定表名
+func (TraefikServerTransition) TableName() string {
+	return "traefik_server_transitions"
+}
@@ -0,0 +1,27 @@
+package traefik
+
+import (
+	"github.com/yahahaff/rapide/internal/models"
+	"github.com/yahahaff/rapide/pkg/types"
+)
+
+// TraefikInstance Traefik实例模型，每个实例使用独立令牌拉取HTTP Provider配置
+type TraefikInstance struct {
+	models.BaseModel
+	models.CommonTimestampsField
+	Name           string          `json:"name" gorm:"type:varchar(100);uniqueIndex;not null"`
+	TokenHash      string          `json:"-" gorm:"type:varchar(64);uniqueIndex;not null"` // 令牌的SHA256摘要，明文只在创建和重置时返回一次
+	AllowIPs       types.JSONSlice `json:"allowIps" gorm:"type:json"`                      // IP白名单，支持IP和CIDR，为空时不限制
+	Tags           types.JSONSlice `json:"tags" gorm:"type:json"`                          // 只下发带有这些标签的对象，为空时不限制
+	EntryPoints    types.JSONSlice `json:"entryPoints" gorm:"type:json"`                   // 只下发使用这些入口点的路由，为空时不限制
+	Status         string          `json:"status" gorm:"default:'enabled'"`
+	Remark         string          `json:"remark" gorm:"type:varchar(500)"`
+	AccessLogPath  string          `json:"accessLogPath" gorm:"type:varchar(500)"` // 该实例JSON格式访问日志的路径，rapide需要能读取，为空时不采集
+	TraefikVersion string          `json:"traefikVersion" gorm:"type:varchar(10)"` // 实例的Traefik主版本v2或v3，下发配置时按该版本转换，为空时使用TRAEFIK_VERSION
+	MetricsURL     string          `json:"metricsUrl" gorm:"type:varchar(500)"`    // 该实例Prometheus指标的地址，如http://traefik:8082/metrics，为空时不抓取
+}
+
+// TableName 指定表名
+func (TraefikInstance) TableName() string {
+	return "traefik_instances"
+}
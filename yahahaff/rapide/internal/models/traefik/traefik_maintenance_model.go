@@ -0,0 +1,63 @@
+package traefik
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/yahahaff/rapide/internal/models"
+	"github.com/yahahaff/rapide/pkg/types"
+)
+
+// TraefikMaintenance 路由的维护窗口，生效期间路由指向维护页面，草稿和已发布的配置保持不变
+type TraefikMaintenance struct {
+	models.BaseModel
+	models.CommonTimestampsField
+	Router     string            `json:"router" gorm:"index;not null"` // 路由名称，只支持http路由
+	Protocol   string            `json:"protocol" gorm:"type:varchar(10);default:'http'"`
+	Backend    string            `json:"backend"`                              // 提供维护页面的静态后端，为空时由rapide返回维护页面
+	StatusCode int               `json:"statusCode"`                           // rapide返回维护页面时的状态码，默认503
+	Page       string            `json:"page" gorm:"type:text"`                // rapide返回的维护页面HTML，为空时使用默认页面
+	Allowlist  types.JSONSlice   `json:"allowlist" gorm:"type:json"`           // 可以继续访问原服务的IP或CIDR
+	StartAt    time.Time         `json:"startAt" gorm:"index"`                 // 开始时间
+	EndAt      *time.Time        `json:"endAt"`                                // 结束时间，为空时需要手动结束
+	Status     string            `json:"status" gorm:"type:varchar(20);index"` // scheduled, active, finished, cancelled
+	Reason     string            `json:"reason"`                               // 维护原因
+	Events     MaintenanceEvents `json:"events" gorm:"type:json"`              // 操作记录
+	Operator   string            `json:"operator" gorm:"type:varchar(100)"`
+	FinishedAt *time.Time        `json:"finishedAt"`
+}
+
+// TableName 指定表名
+func (TraefikMaintenance) TableName() string {
+	return "traefik_maintenances"
+}
+
+// MaintenanceEvent 维护窗口的一次状态变化
+type MaintenanceEvent struct {
+	At       time.Time `json:"at"`
+	Action   string    `json:"action"` // schedule, start, update, finish, cancel
+	Operator string    `json:"operator"`
+	Message  string    `json:"message,omitempty"`
+}
+
+// MaintenanceEvents 维护窗口的操作记录，以JSON保存
+type MaintenanceEvents []MaintenanceEvent
+
+// Value 实现 driver.Valuer 接口
+func (e MaintenanceEvents) Value() (driver.Value, error) {
+	if e == nil {
+		return nil, nil
+	}
+	return json.Marshal(e)
+}
+
+// Scan 实现 sql.Scanner 接口
+func (e *MaintenanceEvents) Scan(value interface{}) error {
+	bytes, err := jsonBytes(value)
+	if err != nil || bytes == nil {
+		*e = nil
+		return err
+	}
+	return json.Unmarshal(bytes, e)
+}
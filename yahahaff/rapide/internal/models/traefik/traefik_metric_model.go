@@ -0,0 +1,43 @@
+package traefik
+
+import (
+	"time"
+
+	"github.com/yahahaff/rapide/internal/models"
+)
+
+// TraefikMetricSample 一次抓取得到的入口点、路由或服务的请求计数，来自Traefik的Prometheus指标
+// Requests和Errors是与上一次抓取相比的增量，除以Seconds得到每秒请求数
+type TraefikMetricSample struct {
+	models.BaseModel
+	Kind          string    `json:"kind" gorm:"type:varchar(20);index:idx_metric_sample;not null"` // entrypoint, router, service
+	Name          string    `json:"name" gorm:"index:idx_metric_sample;not null"`                  // 运行时名称，路由和服务带@provider后缀
+	Instance      string    `json:"instance" gorm:"type:varchar(100);index:idx_metric_sample"`     // Traefik实例名称
+	At            time.Time `json:"at" gorm:"index"`                                               // 抓取时间
+	RequestsTotal float64   `json:"requestsTotal"`                                                 // Traefik累计的请求数
+	ErrorsTotal   float64   `json:"errorsTotal"`                                                   // Traefik累计的5xx请求数
+	Requests      float64   `json:"requests"`                                                      // 距上次抓取的请求数，计数器重置时为重置后的累计值
+	Errors        float64   `json:"errors"`                                                        // 距上次抓取的5xx请求数
+	Seconds       float64   `json:"seconds"`                                                       // 距上次抓取的秒数，首次抓取为0
+}
+
+// TableName 指定表名
+func (TraefikMetricSample) TableName() string {
+	return "traefik_metric_samples"
+}
+
+// TraefikMetricSource Traefik实例指标的抓取状态
+type TraefikMetricSource struct {
+	models.BaseModel
+	models.CommonTimestampsField
+	InstanceID uint64     `json:"instanceId" gorm:"uniqueIndex;not null"`
+	URL        string     `json:"url"`    // 抓取的地址
+	Series     int        `json:"series"` // 最近一次抓取得到的入口点、路由和服务数量
+	ScrapedAt  *time.Time `json:"scrapedAt"`
+	Error      string     `json:"error"` // 最近一次抓取的错误，成功时清空
+}
+
+// TableName 指定表名
+func (TraefikMetricSource) TableName() string {
+	return "traefik_metric_sources"
+}
@@ -9,12 +9,13 @@ import (
 type TraefikMiddleware struct {
 	models.BaseModel
 	models.CommonTimestampsField
-	Name     string        `json:"name" gorm:"uniqueIndex:idx_middleware_name_protocol;not null"`
-	Type     string        `json:"type" gorm:"not null"` // stripPrefix, redirectRegex, headers, etc.
-	Config   types.JSONMap `json:"config" gorm:"type:json;not null"`
-	Status   string        `json:"status" gorm:"default:'enabled'"`
-	Provider string        `json:"provider" gorm:"default:'http'"`
-	Protocol string        `json:"protocol" gorm:"type:varchar(10);default:'http';uniqueIndex:idx_middleware_name_protocol"` // http, tcp, tls
+	Name     string          `json:"name" gorm:"uniqueIndex:idx_middleware_name_protocol;not null"`
+	Type     string          `json:"type" gorm:"not null"` // stripPrefix, redirectRegex, headers, etc.
+	Config   types.JSONMap   `json:"config" gorm:"type:json;not null"`
+	Status   string          `json:"status" gorm:"default:'enabled'"`
+	Provider string          `json:"provider" gorm:"default:'http'"`
+	Protocol string          `json:"protocol" gorm:"type:varchar(10);default:'http';uniqueIndex:idx_middleware_name_protocol"` // http, tcp, tls
+	Tags     types.JSONSlice `json:"tags" gorm:"type:json"`                                                                    // 标签，用于按Traefik实例限定下发范围
 }
 
 // TableName 指定表名
@@ -0,0 +1,21 @@
+package traefik
+
+import (
+	"github.com/yahahaff/rapide/internal/models"
+)
+
+// TraefikOwner 路由、服务和中间件的所属部门，没有记录的对象只有超级管理员可以管理
+type TraefikOwner struct {
+	models.BaseModel
+	models.CommonTimestampsField
+	Kind     string `json:"kind" gorm:"type:varchar(20);uniqueIndex:idx_owner_object;not null"` // router, service, middleware
+	Protocol string `json:"protocol" gorm:"type:varchar(10);uniqueIndex:idx_owner_object"`      // http, tcp, udp
+	Name     string `json:"name" gorm:"uniqueIndex:idx_owner_object;not null"`
+	DeptID   uint64 `json:"deptId" gorm:"index;not null"` // sys_dept中的部门
+	Operator string `json:"operator"`
+}
+
+// TableName 指定表名
+func (TraefikOwner) TableName() string {
+	return "traefik_owners"
+}
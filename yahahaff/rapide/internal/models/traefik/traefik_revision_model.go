@@ -0,0 +1,25 @@
+package traefik
+
+import (
+	"github.com/yahahaff/rapide/internal/models"
+	"github.com/yahahaff/rapide/pkg/types"
+)
+
+// TraefikRevision Traefik配置对象的修订记录，每次创建、更新、删除或回滚都会生成一条
+type TraefikRevision struct {
+	models.BaseModel
+	models.CommonTimestampsField
+	Kind     string        `json:"kind" gorm:"type:varchar(20);index:idx_revision_object;not null"` // router, service, middleware
+	Name     string        `json:"name" gorm:"index:idx_revision_object;not null"`
+	Protocol string        `json:"protocol" gorm:"type:varchar(10);index:idx_revision_object;not null"`
+	Action   string        `json:"action" gorm:"type:varchar(20);not null"` // create, update, delete, rollback
+	Before   types.JSONMap `json:"before" gorm:"type:json"`                 // 变更前的对象，创建时为空
+	After    types.JSONMap `json:"after" gorm:"type:json"`                  // 变更后的对象，删除时为空
+	Operator string        `json:"operator" gorm:"type:varchar(100)"`
+	Remark   string        `json:"remark" gorm:"type:varchar(500)"`
+}
+
+// TableName 指定表名
+func (TraefikRevision) TableName() string {
+	return "traefik_revisions"
+}
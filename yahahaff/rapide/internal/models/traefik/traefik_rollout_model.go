@@ -0,0 +1,108 @@
+package traefik
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/yahahaff/rapide/internal/models"
+	"github.com/yahahaff/rapide/pkg/types"
+)
+
+// TraefikRollout 加权服务上的灰度发布，按步骤把流量从稳定版本逐步切换到灰度版本
+type TraefikRollout struct {
+	models.BaseModel
+	models.CommonTimestampsField
+	Service      string        `json:"service" gorm:"index;not null"` // 加权服务名称
+	Protocol     string        `json:"protocol" gorm:"type:varchar(10);default:'http'"`
+	Stable       string        `json:"stable" gorm:"not null"`               // 稳定版本子服务
+	Canary       string        `json:"canary" gorm:"not null"`               // 灰度版本子服务
+	Steps        RolloutSteps  `json:"steps" gorm:"type:json"`               // 每一步灰度版本的流量百分比，如[5,25,50,100]
+	Interval     int           `json:"interval"`                             // 每一步持续的秒数
+	OnFailure    string        `json:"onFailure" gorm:"type:varchar(20)"`    // 灰度后端异常时的处理方式: pause, rollback
+	Step         int           `json:"step"`                                 // 当前步骤的下标
+	Status       string        `json:"status" gorm:"type:varchar(20);index"` // running, paused, completed, rolled_back
+	Original     types.JSONMap `json:"original" gorm:"type:json"`            // 开始前两个子服务的权重，回滚时恢复
+	NextStepAt   *time.Time    `json:"nextStepAt"`                           // 进入下一步的时间
+	Reason       string        `json:"reason"`                               // 最近一次暂停或回滚的原因
+	HealthErrors int           `json:"healthErrors"`                         // 连续无法获取灰度版本健康状态的次数
+	Events       RolloutEvents `json:"events" gorm:"type:json"`              // 过程记录
+	Operator     string        `json:"operator" gorm:"type:varchar(100)"`
+	FinishedAt   *time.Time    `json:"finishedAt"`
+}
+
+// TableName 指定表名
+func (TraefikRollout) TableName() string {
+	return "traefik_rollouts"
+}
+
+// RolloutEvent 灰度发布过程中的一次状态变化
+type RolloutEvent struct {
+	At      time.Time `json:"at"`
+	Action  string    `json:"action"` // start, advance, pause, resume, complete, rollback
+	Weight  int       `json:"weight"` // 此时灰度版本的流量百分比
+	Message string    `json:"message,omitempty"`
+}
+
+// RolloutSteps 灰度步骤，以JSON保存
+type RolloutSteps []int
+
+// Value 实现 driver.Valuer 接口
+func (s RolloutSteps) Value() (driver.Value, error) {
+	if s == nil {
+		return nil, nil
+	}
+	return json.Marshal(s)
+}
+
+// Scan 实现 sql.Scanner 接口
+func (s *RolloutSteps) Scan(value interface{}) error {
+	bytes, err := jsonBytes(value)
+	if err != nil || bytes == nil {
+		*s = nil
+		return err
+	}
+	return json.Unmarshal(bytes, s)
+}
+
+// RolloutEvents 灰度过程记录，以JSON保存
+type RolloutEvents []RolloutEvent
+
+// Value 实现 driver.Valuer 接口
+func (e RolloutEvents) Value() (driver.Value, error) {
+	if e == nil {
+		return nil, nil
+	}
+	return json.Marshal(e)
+}
+
+// Scan 实现 sql.Scanner 接口
+func (e *RolloutEvents) Scan(value interface{}) error {
+	bytes, err := jsonBytes(value)
+	if err != nil || bytes == nil {
+		*e = nil
+		return err
+	}
+	return json.Unmarshal(bytes, e)
+}
+
+// jsonBytes 读取数据库中的JSON列，空值返回nil
+func jsonBytes(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		if len(v) == 0 {
+			return nil, nil
+		}
+		return v, nil
+	case string:
+		if v == "" {
+			return nil, nil
+		}
+		return []byte(v), nil
+	default:
+		return nil, errors.New("invalid type for JSON column")
+	}
+}
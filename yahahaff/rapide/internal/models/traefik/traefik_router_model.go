@@ -9,17 +9,19 @@ import (
 type TraefikRouter struct {
 	models.BaseModel
 	models.CommonTimestampsField
-	Name        string        `json:"name" gorm:"uniqueIndex:idx_router_name_protocol;not null"`
+	Name        string          `json:"name" gorm:"uniqueIndex:idx_router_name_protocol;not null"`
 	EntryPoints types.JSONSlice `json:"entryPoints" gorm:"type:json"`
-	Service     string        `json:"service" gorm:"not null"`
-	Rule        string        `json:"rule" gorm:"not null"`
-	RuleSyntax  string        `json:"ruleSyntax" gorm:"default:'default'"`
-	Priority    int64         `json:"priority" gorm:"default:0"`
+	Service     string          `json:"service" gorm:"not null"`
+	Rule        string          `json:"rule" gorm:"not null"`
+	RuleSyntax  string          `json:"ruleSyntax" gorm:"default:'default'"`
+	Priority    int64           `json:"priority" gorm:"default:0"`
 	Middlewares types.JSONSlice `json:"middlewares" gorm:"type:json"`
-	TLS         types.JSONMap `json:"tls" gorm:"type:json"` // TLS配置
-	Protocol    string        `json:"protocol" gorm:"type:varchar(10);default:'http';uniqueIndex:idx_router_name_protocol"` // http, tcp, udp
-	Status      string        `json:"status" gorm:"default:'enabled'"`
-	Provider    string        `json:"provider" gorm:"default:'http'"`
+	TLS         types.JSONMap   `json:"tls" gorm:"type:json"`                                                                 // TLS配置
+	Protocol    string          `json:"protocol" gorm:"type:varchar(10);default:'http';uniqueIndex:idx_router_name_protocol"` // http, tcp, udp
+	Status      string          `json:"status" gorm:"default:'enabled'"`
+	Provider    string          `json:"provider" gorm:"default:'http'"`
+	Tags        types.JSONSlice `json:"tags" gorm:"type:json"` // 标签，用于按Traefik实例限定下发范围
+	NoAutoCert  bool            `json:"noAutoCert,omitempty"`  // 不为该路由的域名自动申请证书
 }
 
 // TableName 指定表名
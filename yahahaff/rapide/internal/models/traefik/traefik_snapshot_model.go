@@ -0,0 +1,28 @@
+package traefik
+
+import (
+	"time"
+
+	"github.com/yahahaff/rapide/internal/models"
+	"github.com/yahahaff/rapide/pkg/types"
+)
+
+// TraefikSnapshot 发布的配置快照，HTTP Provider、KV发布和配置对账只使用最近一次发布的快照
+type TraefikSnapshot struct {
+	models.BaseModel
+	models.CommonTimestampsField
+	Status      string        `json:"status" gorm:"type:varchar(20);index;not null"` // scheduled, published, superseded, cancelled, failed
+	Config      types.JSONMap `json:"config,omitempty" gorm:"type:json"`             // 发布时草稿中启用的路由、服务和中间件
+	Summary     types.JSONMap `json:"summary" gorm:"type:json"`                      // 相对于上一次发布的变更数量
+	Remark      string        `json:"remark" gorm:"type:varchar(255)"`
+	Operator    string        `json:"operator" gorm:"type:varchar(100)"`
+	ActivateAt  *time.Time    `json:"activateAt" gorm:"index"` // 计划生效时间，为空表示立即发布
+	PublishedAt *time.Time    `json:"publishedAt"`
+	Reason      string        `json:"reason,omitempty" gorm:"type:varchar(1000)"` // 计划发布被替代或生效失败的原因
+	Superseded  []uint64      `json:"superseded,omitempty" gorm:"-"`              // 本次发布替代的计划发布，只在发布时返回
+}
+
+// TableName 指定表名
+func (TraefikSnapshot) TableName() string {
+	return "traefik_snapshots"
+}
@@ -0,0 +1,70 @@
+package traefik
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+
+	"github.com/yahahaff/rapide/internal/models"
+)
+
+// TraefikTemplate 应用模板，一次生成相互引用的路由、服务和中间件
+// Body是Go text/template格式的Traefik文件Provider YAML，渲染时可使用.name和各参数
+type TraefikTemplate struct {
+	models.BaseModel
+	models.CommonTimestampsField
+	Name        string         `json:"name" gorm:"type:varchar(100);uniqueIndex;not null"`
+	Title       string         `json:"title" gorm:"type:varchar(100)"`
+	Description string         `json:"description" gorm:"type:varchar(500)"`
+	Parameters  TemplateParams `json:"parameters" gorm:"type:json"`
+	Body        string         `json:"body" gorm:"type:text;not null"`
+	Operator    string         `json:"operator" gorm:"type:varchar(100)"`
+	Builtin     bool           `json:"builtin" gorm:"-"` // 内置模板不保存在数据库中
+}
+
+// TableName 指定表名
+func (TraefikTemplate) TableName() string {
+	return "traefik_templates"
+}
+
+// TemplateParam 模板参数定义
+type TemplateParam struct {
+	Name        string      `json:"name"`
+	Label       string      `json:"label"`
+	Type        string      `json:"type"` // string, list, bool, int
+	Required    bool        `json:"required"`
+	Default     interface{} `json:"default,omitempty"`
+	Description string      `json:"description,omitempty"`
+}
+
+// TemplateParams 模板参数列表，以JSON保存
+type TemplateParams []TemplateParam
+
+// Value 实现 driver.Valuer 接口
+func (p TemplateParams) Value() (driver.Value, error) {
+	if p == nil {
+		return nil, nil
+	}
+	return json.Marshal(p)
+}
+
+// Scan 实现 sql.Scanner 接口
+func (p *TemplateParams) Scan(value interface{}) error {
+	var bytes []byte
+	switch v := value.(type) {
+	case nil:
+		*p = nil
+		return nil
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return errors.New("invalid type for TemplateParams")
+	}
+	if len(bytes) == 0 {
+		*p = nil
+		return nil
+	}
+	return json.Unmarshal(bytes, p)
+}
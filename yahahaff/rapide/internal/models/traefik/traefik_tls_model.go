@@ -0,0 +1,52 @@
+package traefik
+
+import (
+	"github.com/yahahaff/rapide/internal/models"
+	"github.com/yahahaff/rapide/pkg/types"
+)
+
+// TraefikTLSOption Traefik TLS选项模型，路由通过tls.options按名称引用
+type TraefikTLSOption struct {
+	models.BaseModel
+	models.CommonTimestampsField
+	Name   string          `json:"name" gorm:"uniqueIndex;not null"`
+	Config types.JSONMap   `json:"config" gorm:"type:json;not null"` // minVersion, cipherSuites, clientAuth等
+	Status string          `json:"status" gorm:"default:'enabled'"`
+	Tags   types.JSONSlice `json:"tags" gorm:"type:json"` // 标签，用于按Traefik实例限定下发范围
+}
+
+// TableName 指定表名
+func (TraefikTLSOption) TableName() string {
+	return "traefik_tls_options"
+}
+
+// TraefikTLSStore Traefik TLS证书存储模型，目前Traefik只使用名为default的存储
+type TraefikTLSStore struct {
+	models.BaseModel
+	models.CommonTimestampsField
+	Name   string          `json:"name" gorm:"uniqueIndex;not null"`
+	Config types.JSONMap   `json:"config" gorm:"type:json;not null"` // defaultCertificate, defaultGeneratedCert
+	Status string          `json:"status" gorm:"default:'enabled'"`
+	Tags   types.JSONSlice `json:"tags" gorm:"type:json"`
+}
+
+// TableName 指定表名
+func (TraefikTLSStore) TableName() string {
+	return "traefik_tls_stores"
+}
+
+// TraefikServersTransport Traefik连接后端时使用的传输配置，负载均衡服务通过serversTransport按名称引用
+type TraefikServersTransport struct {
+	models.BaseModel
+	models.CommonTimestampsField
+	Name     string          `json:"name" gorm:"uniqueIndex:idx_transport_name_protocol;not null"`
+	Config   types.JSONMap   `json:"config" gorm:"type:json;not null"` // insecureSkipVerify, rootCAs, certificates等
+	Status   string          `json:"status" gorm:"default:'enabled'"`
+	Protocol string          `json:"protocol" gorm:"type:varchar(10);default:'http';uniqueIndex:idx_transport_name_protocol"` // http, tcp
+	Tags     types.JSONSlice `json:"tags" gorm:"type:json"`
+}
+
+// TableName 指定表名
+func (TraefikServersTransport) TableName() string {
+	return "traefik_servers_transports"
+}
@@ -0,0 +1,91 @@
+package traefik
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/yahahaff/rapide/internal/models"
+)
+
+// TraefikTrafficBucket 一个时间段内路由或服务的访问统计，来自Traefik的JSON访问日志
+type TraefikTrafficBucket struct {
+	models.BaseModel
+	Kind      string           `json:"kind" gorm:"type:varchar(10);uniqueIndex:idx_traffic_bucket;not null"` // router, service
+	Name      string           `json:"name" gorm:"uniqueIndex:idx_traffic_bucket;not null"`                  // 运行时名称，带@provider后缀
+	Instance  string           `json:"instance" gorm:"type:varchar(100);uniqueIndex:idx_traffic_bucket"`     // Traefik实例名称
+	Start     time.Time        `json:"start" gorm:"uniqueIndex:idx_traffic_bucket;index"`                    // 时间段开始时间
+	Requests  int64            `json:"requests"`
+	Status    TrafficCounts    `json:"status" gorm:"type:json"`    // 按状态码类别统计，如2xx
+	Latency   LatencyHistogram `json:"latency" gorm:"type:json"`   // 耗时分布，区间见TrafficLatencyBounds
+	ClientIPs TrafficCounts    `json:"clientIps" gorm:"type:json"` // 请求数最多的客户端IP
+	Paths     TrafficCounts    `json:"paths" gorm:"type:json"`     // 请求数最多的路径，不含查询参数
+}
+
+// TableName 指定表名
+func (TraefikTrafficBucket) TableName() string {
+	return "traefik_traffic_buckets"
+}
+
+// TraefikAccessLogCursor Traefik实例访问日志的读取进度
+type TraefikAccessLogCursor struct {
+	models.BaseModel
+	models.CommonTimestampsField
+	InstanceID uint64     `json:"instanceId" gorm:"uniqueIndex;not null"`
+	Path       string     `json:"path"`    // 读取的日志文件，变化时从头读取
+	Offset     int64      `json:"offset"`  // 已读取的字节数，文件变小(被轮转或截断)时从头读取
+	Lines      int64      `json:"lines"`   // 累计处理的日志行数
+	Invalid    int64      `json:"invalid"` // 累计无法解析的日志行数
+	ReadAt     *time.Time `json:"readAt"`
+	Error      string     `json:"error"` // 最近一次读取的错误，成功时清空
+}
+
+// TableName 指定表名
+func (TraefikAccessLogCursor) TableName() string {
+	return "traefik_access_log_cursors"
+}
+
+// TrafficLatencyBounds 耗时分布各区间的上限(毫秒)，最后一个区间没有上限
+var TrafficLatencyBounds = []float64{1, 2, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
+
+// TrafficCounts 按名称计数，以JSON保存
+type TrafficCounts map[string]int64
+
+// Value 实现 driver.Valuer 接口
+func (c TrafficCounts) Value() (driver.Value, error) {
+	if c == nil {
+		return nil, nil
+	}
+	return json.Marshal(c)
+}
+
+// Scan 实现 sql.Scanner 接口
+func (c *TrafficCounts) Scan(value interface{}) error {
+	bytes, err := jsonBytes(value)
+	if err != nil || bytes == nil {
+		*c = nil
+		return err
+	}
+	return json.Unmarshal(bytes, c)
+}
+
+// LatencyHistogram 各耗时区间的请求数，比TrafficLatencyBounds多一个无上限区间
+type LatencyHistogram []int64
+
+// Value 实现 driver.Valuer 接口
+func (h LatencyHistogram) Value() (driver.Value, error) {
+	if h == nil {
+		return nil, nil
+	}
+	return json.Marshal(h)
+}
+
+// Scan 实现 sql.Scanner 接口
+func (h *LatencyHistogram) Scan(value interface{}) error {
+	bytes, err := jsonBytes(value)
+	if err != nil || bytes == nil {
+		*h = nil
+		return err
+	}
+	return json.Unmarshal(bytes, h)
+}
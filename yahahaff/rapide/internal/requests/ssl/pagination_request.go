@@ -7,4 +7,5 @@ type PaginationRequest struct {
 	Order       string `form:"order" json:"order" binding:"omitempty"`
 	Domain      string `form:"domain" json:"domain" binding:"omitempty"`
 	ApplyStatus string `form:"applyStatus" json:"applyStatus" binding:"omitempty"`
+	App         string `form:"app" json:"app" binding:"omitempty"` // 只返回该应用的证书
 }
\ No newline at end of file
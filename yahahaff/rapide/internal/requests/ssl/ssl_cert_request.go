@@ -16,4 +16,5 @@ type SSLCertCreateRequest struct {
 	Algorithm        string `json:"algorithm" binding:"omitempty"`
 	VerifyMethod     string `json:"verifyMethod" binding:"omitempty"`
 	Type             string `json:"type" binding:"omitempty"`
+	DeptID           uint64 `json:"deptId" binding:"omitempty"` // 所属部门，用户只属于一个部门时可以不传
 }
@@ -0,0 +1,29 @@
+package traefik
+
+// TraefikAppObject 应用包含的对象，证书的名称为SSL证书的域名
+type TraefikAppObject struct {
+	Kind     string `json:"kind" binding:"required,oneof=router service middleware cert"`
+	Protocol string `json:"protocol" binding:"omitempty,oneof=http tcp udp"`
+	Name     string `json:"name" binding:"required"`
+}
+
+// TraefikAppRequest 应用定义，更新时整体替换，objects不传时不修改应用包含的对象
+type TraefikAppRequest struct {
+	Description string             `json:"description" binding:"omitempty,max=500"`
+	DeptID      uint64             `json:"deptId" binding:"omitempty"`
+	Environment string             `json:"environment" binding:"omitempty,oneof=prod staging test dev"`
+	Contacts    []string           `json:"contacts" binding:"omitempty"` // 联系人的用户名
+	Objects     []TraefikAppObject `json:"objects" binding:"omitempty,dive"`
+}
+
+// TraefikAppCreateRequest 创建应用请求
+type TraefikAppCreateRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+	TraefikAppRequest
+}
+
+// TraefikAppListRequest 应用列表查询请求
+type TraefikAppListRequest struct {
+	DeptID      uint64 `form:"deptId" json:"deptId" binding:"omitempty"`
+	Environment string `form:"environment" json:"environment" binding:"omitempty,oneof=prod staging test dev"`
+}
@@ -0,0 +1,248 @@
+package traefik
+
+// TraefikRouterRequest 路由定义，更新时整体替换
+type TraefikRouterRequest struct {
+	EntryPoints []string               `json:"entryPoints" binding:"omitempty"`
+	Service     string                 `json:"service" binding:"required"`
+	Rule        string                 `json:"rule" binding:"omitempty"`
+	RuleSyntax  string                 `json:"ruleSyntax" binding:"omitempty"`
+	Priority    int64                  `json:"priority" binding:"omitempty,min=0"`
+	Middlewares []string               `json:"middlewares" binding:"omitempty"`
+	TLS         map[string]interface{} `json:"tls" binding:"omitempty"`
+	Status      string                 `json:"status" binding:"omitempty,oneof=enabled disabled"`
+	Tags        []string               `json:"tags" binding:"omitempty"`
+	NoAutoCert  bool                   `json:"noAutoCert"` // 不为该路由的域名自动申请证书
+}
+
+// TraefikRouterCreateRequest 创建路由请求
+type TraefikRouterCreateRequest struct {
+	Name     string `json:"name" binding:"required,max=191"`
+	Protocol string `json:"protocol" binding:"omitempty,oneof=http tcp udp"`
+	DeptID   uint64 `json:"deptId" binding:"omitempty"` // 所属部门，用户只属于一个部门时可以不传
+	TraefikRouterRequest
+}
+
+// TraefikServiceRequest 服务定义，更新时整体替换
+type TraefikServiceRequest struct {
+	Type         string                 `json:"type" binding:"required,oneof=loadbalancer weighted mirror"`
+	LoadBalancer map[string]interface{} `json:"loadBalancer" binding:"omitempty"`
+	Weighted     map[string]interface{} `json:"weighted" binding:"omitempty"`
+	Mirror       map[string]interface{} `json:"mirror" binding:"omitempty"`
+	TCP          map[string]interface{} `json:"tcp" binding:"omitempty"`
+	UDP          map[string]interface{} `json:"udp" binding:"omitempty"`
+	Status       string                 `json:"status" binding:"omitempty,oneof=enabled disabled"`
+	Tags         []string               `json:"tags" binding:"omitempty"`
+}
+
+// TraefikServiceCreateRequest 创建服务请求
+type TraefikServiceCreateRequest struct {
+	Name     string `json:"name" binding:"required,max=191"`
+	Protocol string `json:"protocol" binding:"omitempty,oneof=http tcp udp"`
+	DeptID   uint64 `json:"deptId" binding:"omitempty"` // 所属部门，用户只属于一个部门时可以不传
+	TraefikServiceRequest
+}
+
+// TraefikMiddlewareRequest 中间件定义，更新时整体替换
+type TraefikMiddlewareRequest struct {
+	Type   string                 `json:"type" binding:"required"`
+	Config map[string]interface{} `json:"config" binding:"required"`
+	Status string                 `json:"status" binding:"omitempty,oneof=enabled disabled"`
+	Tags   []string               `json:"tags" binding:"omitempty"`
+}
+
+// TraefikMiddlewareCreateRequest 创建中间件请求
+type TraefikMiddlewareCreateRequest struct {
+	Name     string `json:"name" binding:"required,max=191"`
+	Protocol string `json:"protocol" binding:"omitempty,oneof=http tcp"`
+	DeptID   uint64 `json:"deptId" binding:"omitempty"` // 所属部门，用户只属于一个部门时可以不传
+	TraefikMiddlewareRequest
+}
+
+// TraefikConfigObjectRequest TLS选项、TLS证书存储和serversTransport的定义，更新时整体替换
+type TraefikConfigObjectRequest struct {
+	Config map[string]interface{} `json:"config" binding:"required"`
+	Status string                 `json:"status" binding:"omitempty,oneof=enabled disabled"`
+	Tags   []string               `json:"tags" binding:"omitempty"`
+}
+
+// TraefikTLSObjectCreateRequest 创建TLS选项或TLS证书存储请求
+type TraefikTLSObjectCreateRequest struct {
+	Name string `json:"name" binding:"required,max=191"`
+	TraefikConfigObjectRequest
+}
+
+// TraefikServersTransportCreateRequest 创建serversTransport请求
+type TraefikServersTransportCreateRequest struct {
+	Name     string `json:"name" binding:"required,max=191"`
+	Protocol string `json:"protocol" binding:"omitempty,oneof=http tcp"`
+	TraefikConfigObjectRequest
+}
+
+// TraefikRevisionListRequest 修订记录查询请求
+type TraefikRevisionListRequest struct {
+	Page     int    `form:"page" json:"page" binding:"omitempty"`
+	PageSize int    `form:"pageSize" json:"pageSize" binding:"omitempty"`
+	Kind     string `form:"kind" json:"kind" binding:"omitempty,oneof=router service middleware tlsOption tlsStore serversTransport"`
+	Name     string `form:"name" json:"name" binding:"omitempty"`
+	Protocol string `form:"protocol" json:"protocol" binding:"omitempty,oneof=http tcp udp tls"`
+	Operator string `form:"operator" json:"operator" binding:"omitempty"`
+}
+
+// TraefikRollbackRequest 按时间点回滚请求
+type TraefikRollbackRequest struct {
+	Time   string `json:"time" binding:"required"` // RFC3339或2006-01-02 15:04:05格式
+	DryRun bool   `json:"dryRun" binding:"omitempty"`
+}
+
+// TraefikDriftListRequest 对账报告查询请求
+type TraefikDriftListRequest struct {
+	Page     int `form:"page" json:"page" binding:"omitempty"`
+	PageSize int `form:"pageSize" json:"pageSize" binding:"omitempty"`
+}
+
+// TraefikHealthRequest 后端健康状况查询请求
+type TraefikHealthRequest struct {
+	Hours int `form:"hours" json:"hours" binding:"omitempty,min=1,max=720"`
+}
+
+// TraefikTransitionListRequest 后端状态变化查询请求
+type TraefikTransitionListRequest struct {
+	Service string `form:"service" json:"service" binding:"omitempty"`
+	Limit   int    `form:"limit" json:"limit" binding:"omitempty,min=1,max=500"`
+}
+
+// TraefikPublishRequest 发布草稿请求
+type TraefikPublishRequest struct {
+	Remark     string `json:"remark" binding:"omitempty,max=255"`
+	ActivateAt string `json:"activateAt" binding:"omitempty"` // 计划生效时间，RFC3339或2006-01-02 15:04:05格式，为空时立即发布
+}
+
+// TraefikSnapshotListRequest 配置快照查询请求
+type TraefikSnapshotListRequest struct {
+	Page     int    `form:"page" json:"page" binding:"omitempty"`
+	PageSize int    `form:"pageSize" json:"pageSize" binding:"omitempty"`
+	Status   string `form:"status" json:"status" binding:"omitempty,oneof=scheduled published superseded cancelled failed"`
+}
+
+// TraefikGitPullRequest 拉取Git仓库中的配置请求
+type TraefikGitPullRequest struct {
+	DryRun bool `json:"dryRun"` // 为true时只返回差异，不写入草稿
+}
+
+// TraefikGitSyncListRequest Git同步记录查询请求
+type TraefikGitSyncListRequest struct {
+	Page      int    `form:"page" json:"page" binding:"omitempty"`
+	PageSize  int    `form:"pageSize" json:"pageSize" binding:"omitempty"`
+	Direction string `form:"direction" json:"direction" binding:"omitempty,oneof=push pull"`
+}
+
+// TraefikSimulateRequest 路由匹配模拟请求
+type TraefikSimulateRequest struct {
+	Protocol   string            `json:"protocol" binding:"omitempty,oneof=http tcp"`
+	Method     string            `json:"method" binding:"omitempty"`
+	Host       string            `json:"host" binding:"omitempty"`
+	Path       string            `json:"path" binding:"omitempty"` // 可带?查询参数
+	Headers    map[string]string `json:"headers" binding:"omitempty"`
+	ClientIP   string            `json:"clientIp" binding:"omitempty,ip"`
+	SNI        string            `json:"sni" binding:"omitempty"`
+	ALPN       []string          `json:"alpn" binding:"omitempty"`
+	TLS        bool              `json:"tls"`
+	EntryPoint string            `json:"entryPoint" binding:"omitempty"`
+	Source     string            `json:"source" binding:"omitempty,oneof=published draft"` // 默认published
+}
+
+// TraefikLintRequest 配置检查请求
+type TraefikLintRequest struct {
+	Source string `form:"source" json:"source" binding:"omitempty,oneof=draft published"` // 默认draft
+}
+
+// TraefikServerRequest 添加或删除后端请求
+type TraefikServerRequest struct {
+	Server string `json:"server" binding:"required"`        // http服务为URL，tcp和udp服务为host:port
+	Weight *int   `json:"weight" binding:"omitempty,min=0"` // 只用于添加，未设置时使用Traefik的默认权重
+}
+
+// TraefikServerWeightRequest 调整后端权重请求
+type TraefikServerWeightRequest struct {
+	Server string `json:"server" binding:"required"`
+	Weight *int   `json:"weight" binding:"required,min=0"`
+}
+
+// TraefikServerDrainRequest 摘除后端请求
+type TraefikServerDrainRequest struct {
+	Server string `json:"server" binding:"required"`
+	Delay  int    `json:"delay" binding:"omitempty,min=0,max=86400"` // 权重设为0后等待多少秒再删除，为0时使用默认值
+}
+
+// TraefikRolloutRequest 创建灰度发布请求
+type TraefikRolloutRequest struct {
+	Service   string `json:"service" binding:"required"` // 加权服务
+	Protocol  string `json:"protocol" binding:"omitempty,oneof=http tcp"`
+	Stable    string `json:"stable" binding:"required"`                          // 稳定版本子服务
+	Canary    string `json:"canary" binding:"required"`                          // 灰度版本子服务
+	Steps     []int  `json:"steps" binding:"required,min=1,dive,min=1,max=100"`  // 每一步灰度版本的流量百分比
+	Interval  int    `json:"interval" binding:"required,min=1"`                  // 每一步持续的秒数
+	OnFailure string `json:"onFailure" binding:"omitempty,oneof=pause rollback"` // 默认rollback
+}
+
+// TraefikRolloutListRequest 灰度发布查询请求
+type TraefikRolloutListRequest struct {
+	Page     int    `form:"page" json:"page" binding:"omitempty"`
+	PageSize int    `form:"pageSize" json:"pageSize" binding:"omitempty"`
+	Service  string `form:"service" json:"service" binding:"omitempty"`
+	Status   string `form:"status" json:"status" binding:"omitempty,oneof=running paused completed rolled_back"`
+}
+
+// TraefikMaintenanceRequest 创建路由维护窗口请求
+type TraefikMaintenanceRequest struct {
+	Router     string   `json:"router" binding:"required"`                      // http路由名称
+	Backend    string   `json:"backend" binding:"omitempty,url"`                // 提供维护页面的静态后端，为空时由rapide返回维护页面
+	StatusCode int      `json:"statusCode" binding:"omitempty,min=200,max=599"` // 默认503
+	Page       string   `json:"page" binding:"omitempty"`                       // 维护页面HTML
+	Allowlist  []string `json:"allowlist" binding:"omitempty,dive,required"`    // 可以继续访问原服务的IP或CIDR
+	StartAt    string   `json:"startAt" binding:"omitempty"`                    // 开始时间，RFC3339或2006-01-02 15:04:05格式，为空时立即开始
+	EndAt      string   `json:"endAt" binding:"omitempty"`                      // 结束时间，为空时需要手动结束
+	Reason     string   `json:"reason" binding:"omitempty,max=255"`             // 维护原因
+}
+
+// TraefikMaintenanceListRequest 维护窗口查询请求
+type TraefikMaintenanceListRequest struct {
+	Page     int    `form:"page" json:"page" binding:"omitempty"`
+	PageSize int    `form:"pageSize" json:"pageSize" binding:"omitempty"`
+	Router   string `form:"router" json:"router" binding:"omitempty"`
+	Status   string `form:"status" json:"status" binding:"omitempty,oneof=scheduled active finished cancelled"`
+}
+
+// TraefikCertLinkListRequest 路由证书关联查询请求
+type TraefikCertLinkListRequest struct {
+	Router string `form:"router" json:"router" binding:"omitempty"`
+	Status string `form:"status" json:"status" binding:"omitempty,oneof=linked issuing failed expired missing"`
+	App    string `form:"app" json:"app" binding:"omitempty,max=100"` // 只返回该应用的路由
+}
+
+// TraefikAuthPolicyRequest 设置路由访问策略请求，角色和用户都为空时必须显式设置anyUser
+type TraefikAuthPolicyRequest struct {
+	Roles   []string `json:"roles" binding:"omitempty,dive,required"` // 允许访问的角色编码
+	Users   []string `json:"users" binding:"omitempty,dive,required"` // 允许访问的用户名
+	AnyUser bool     `json:"anyUser"`                                 // 允许所有登录用户访问
+}
+
+// TraefikTrafficRequest 访问统计查询请求
+type TraefikTrafficRequest struct {
+	From     string `form:"from" json:"from" binding:"omitempty"` // RFC3339或2006-01-02 15:04:05格式，默认1小时前
+	To       string `form:"to" json:"to" binding:"omitempty"`     // 默认当前时间
+	Instance string `form:"instance" json:"instance" binding:"omitempty"`
+}
+
+// TraefikMetricRequest Prometheus指标统计查询请求
+type TraefikMetricRequest struct {
+	From     string `form:"from" json:"from" binding:"omitempty"` // RFC3339或2006-01-02 15:04:05格式，默认1小时前
+	To       string `form:"to" json:"to" binding:"omitempty"`     // 默认当前时间
+	Instance string `form:"instance" json:"instance" binding:"omitempty"`
+	Step     string `form:"step" json:"step" binding:"omitempty"` // 时间段长度，如1m，默认1m
+}
+
+// TraefikConfigListRequest 配置对象列表查询请求
+type TraefikConfigListRequest struct {
+	App string `form:"app" json:"app" binding:"omitempty,max=100"` // 只返回该应用的对象
+}
@@ -0,0 +1,32 @@
+package traefik
+
+// TraefikImportRequest 导入Traefik动态配置请求，配置文档通过multipart的file字段或请求体上传
+type TraefikImportRequest struct {
+	Format   string `form:"format" binding:"omitempty,oneof=yaml yml toml"`
+	DryRun   bool   `form:"dryRun"`
+	Conflict string `form:"conflict" binding:"omitempty,oneof=skip overwrite"`
+}
+
+// TraefikExportRequest 导出Traefik动态配置请求
+type TraefikExportRequest struct {
+	Format  string `form:"format" binding:"omitempty,oneof=yaml yml toml"`
+	Version string `form:"version" binding:"omitempty,oneof=v2 v3"` // 目标Traefik版本，默认为TRAEFIK_VERSION
+}
+
+// TraefikManifestRequest 导出Kubernetes CRD或Docker标签请求
+type TraefikManifestRequest struct {
+	Routers   string `form:"routers"`                              // 路由名称，逗号分隔
+	Tags      string `form:"tags"`                                 // 路由标签，逗号分隔
+	Namespace string `form:"namespace" binding:"omitempty,max=63"` // 只用于Kubernetes
+	Download  bool   `form:"download"`                             // 为true时以文件形式下载
+}
+
+// TraefikAdoptRequest 接管其他Provider定义的运行时对象请求
+type TraefikAdoptRequest struct {
+	Kind      string `json:"kind" binding:"required,oneof=router service middleware"`
+	Protocol  string `json:"protocol" binding:"omitempty,oneof=http tcp udp"`
+	Name      string `json:"name" binding:"required"` // Traefik中的完整名称，如whoami@docker
+	Conflict  string `json:"conflict" binding:"omitempty,oneof=skip overwrite"`
+	DryRun    bool   `json:"dryRun"`
+	Checklist bool   `json:"checklist"` // 为true时返回删除原定义的步骤
+}
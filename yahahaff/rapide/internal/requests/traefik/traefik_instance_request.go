@@ -0,0 +1,27 @@
+package traefik
+
+// TraefikInstanceCreateRequest 创建Traefik实例请求
+type TraefikInstanceCreateRequest struct {
+	Name           string   `json:"name" binding:"required,max=100"`
+	AllowIPs       []string `json:"allowIps" binding:"omitempty"`
+	Tags           []string `json:"tags" binding:"omitempty"`
+	EntryPoints    []string `json:"entryPoints" binding:"omitempty"`
+	Status         string   `json:"status" binding:"omitempty,oneof=enabled disabled"`
+	Remark         string   `json:"remark" binding:"omitempty,max=500"`
+	AccessLogPath  string   `json:"accessLogPath" binding:"omitempty,max=500"`      // JSON格式访问日志的路径
+	MetricsURL     string   `json:"metricsUrl" binding:"omitempty,url,max=500"`     // Prometheus指标的地址
+	TraefikVersion string   `json:"traefikVersion" binding:"omitempty,oneof=v2 v3"` // 为空时使用TRAEFIK_VERSION
+}
+
+// TraefikInstanceUpdateRequest 更新Traefik实例请求
+type TraefikInstanceUpdateRequest struct {
+	Name           string   `json:"name" binding:"omitempty,max=100"`
+	AllowIPs       []string `json:"allowIps" binding:"omitempty"`
+	Tags           []string `json:"tags" binding:"omitempty"`
+	EntryPoints    []string `json:"entryPoints" binding:"omitempty"`
+	Status         string   `json:"status" binding:"omitempty,oneof=enabled disabled"`
+	Remark         string   `json:"remark" binding:"omitempty,max=500"`
+	AccessLogPath  *string  `json:"accessLogPath" binding:"omitempty,max=500"`      // 传入空字符串停止采集
+	MetricsURL     *string  `json:"metricsUrl" binding:"omitempty,max=500"`         // 传入空字符串停止抓取
+	TraefikVersion *string  `json:"traefikVersion" binding:"omitempty,oneof=v2 v3"` // 传入空字符串使用TRAEFIK_VERSION
+}
@@ -0,0 +1,11 @@
+package traefik
+
+// TraefikOwnerListRequest 对象所属部门查询请求
+type TraefikOwnerListRequest struct {
+	Kind string `form:"kind" json:"kind" binding:"omitempty,oneof=router service middleware"`
+}
+
+// TraefikOwnerRequest 修改对象所属部门请求
+type TraefikOwnerRequest struct {
+	DeptID uint64 `json:"deptId" binding:"required"`
+}
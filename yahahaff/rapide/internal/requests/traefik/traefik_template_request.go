@@ -0,0 +1,28 @@
+package traefik
+
+import (
+	traefikModel "github.com/yahahaff/rapide/internal/models/traefik"
+)
+
+// TraefikTemplateRequest 自定义模板定义，更新时整体替换
+type TraefikTemplateRequest struct {
+	Title       string                       `json:"title" binding:"omitempty,max=100"`
+	Description string                       `json:"description" binding:"omitempty,max=500"`
+	Parameters  []traefikModel.TemplateParam `json:"parameters" binding:"omitempty"`
+	Body        string                       `json:"body" binding:"required"`
+}
+
+// TraefikTemplateCreateRequest 创建自定义模板请求
+type TraefikTemplateCreateRequest struct {
+	Name string `json:"name" binding:"required,max=64"`
+	TraefikTemplateRequest
+}
+
+// TraefikTemplateApplyRequest 应用模板请求
+type TraefikTemplateApplyRequest struct {
+	Name   string                 `json:"name" binding:"required,max=64"` // 应用名称，作为生成对象的名称前缀
+	Params map[string]interface{} `json:"params" binding:"omitempty"`
+	Tags   []string               `json:"tags" binding:"omitempty"` // 写入所有生成对象的标签
+	DryRun bool                   `json:"dryRun" binding:"omitempty"`
+	DeptID uint64                 `json:"deptId" binding:"omitempty"` // 生成对象的所属部门，用户只属于一个部门时可以不传
+}
@@ -19,7 +19,7 @@ func RegisterAPIRoutes(Router *gin.Engine) {
 		sys.InternalRouter(internalGroup)
 	}
 	
-	// 2. Traefik HTTP自动发现路由，不需要认证
+	// 2. Traefik HTTP自动发现路由，使用Traefik实例令牌认证
 	traefik.TraefikHTTPProviderRouter(Router)
 
 	// 2. 验证码路由
@@ -40,6 +40,8 @@ func RegisterAPIRoutes(Router *gin.Engine) {
 	// 4. SSL相关路由 (/api/ssl)
 	sslGroup := Router.Group("/api/ssl")
 	sslGroup.Use(middlewares.AuthJWT()) // JWT认证
+	// 按部门限制可以管理的证书
+	sslGroup.Use(middlewares.DeptScope())
 	{
 		ssl.SSLCertRouter(sslGroup)
 	}
@@ -59,6 +61,8 @@ func RegisterAPIRoutes(Router *gin.Engine) {
 	// 6. Traefik相关路由 (/api/traefik)
 	traefikGroup := Router.Group("/api")
 	traefikGroup.Use(middlewares.AuthJWT()) // JWT认证
+	// 按部门限制可以管理的对象
+	traefikGroup.Use(middlewares.DeptScope())
 	{
 		traefik.TraefikRouter(traefikGroup)
 	}
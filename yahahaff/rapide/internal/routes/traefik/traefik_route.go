@@ -3,6 +3,8 @@ package traefik
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/yahahaff/rapide/internal/controllers/traefik"
+	"github.com/yahahaff/rapide/internal/middlewares"
+	traefikModel "github.com/yahahaff/rapide/internal/models/traefik"
 	"github.com/yahahaff/rapide/internal/service"
 )
 
@@ -25,15 +27,198 @@ func TraefikRouter(Router *gin.RouterGroup) {
 		traefikGroup.GET("/services/:name", tc.GetServiceDetail)
 		// 获取Traefik概览信息
 		traefikGroup.GET("/overview", tc.GetOverview)
+
+		// 影响所有部门的操作只有超级管理员可以执行
+		admin := middlewares.RequireAllDepts()
+
+		ic := new(traefik.TraefikInstanceController)
+		// 获取Traefik实例列表
+		traefikGroup.GET("/instances", ic.GetInstances)
+		// 创建Traefik实例
+		traefikGroup.POST("/instances", admin, ic.CreateInstance)
+		// 更新Traefik实例
+		traefikGroup.PUT("/instances/:id", admin, ic.UpdateInstance)
+		// 删除Traefik实例
+		traefikGroup.DELETE("/instances/:id", admin, ic.DeleteInstance)
+		// 重置Traefik实例令牌
+		traefikGroup.POST("/instances/:id/token", admin, ic.ResetInstanceToken)
+
+		fc := new(traefik.TraefikFileController)
+		// 导入Traefik文件Provider格式的YAML/TOML配置
+		traefikGroup.POST("/config/import", admin, fc.ImportConfig)
+		// 导出Traefik文件Provider格式的YAML/TOML配置
+		traefikGroup.GET("/config/export", admin, fc.ExportConfig)
+		// 导出为Kubernetes CRD清单和docker-compose标签
+		traefikGroup.GET("/config/export/kubernetes", admin, fc.ExportKubernetes)
+		traefikGroup.GET("/config/export/docker", admin, fc.ExportDockerLabels)
+
+		cc := new(traefik.TraefikConfigController)
+		// 数据库中的路由配置
+		traefikGroup.GET("/config/routers", cc.ListRouters)
+		traefikGroup.POST("/config/routers", cc.CreateRouter)
+		traefikGroup.PUT("/config/routers/:protocol/:name", cc.UpdateRouter)
+		traefikGroup.DELETE("/config/routers/:protocol/:name", cc.DeleteRouter)
+		// 数据库中的服务配置
+		traefikGroup.GET("/config/services", cc.ListServices)
+		traefikGroup.POST("/config/services", cc.CreateService)
+		traefikGroup.PUT("/config/services/:protocol/:name", cc.UpdateService)
+		traefikGroup.DELETE("/config/services/:protocol/:name", cc.DeleteService)
+		// 负载均衡服务的后端管理，修改立即下发，供滚动发布调用
+		svc := new(traefik.TraefikServerController)
+		traefikGroup.GET("/config/services/:protocol/:name/servers", svc.ListServers)
+		traefikGroup.POST("/config/services/:protocol/:name/servers", svc.AddServer)
+		traefikGroup.POST("/config/services/:protocol/:name/servers/weight", svc.SetServerWeight)
+		traefikGroup.POST("/config/services/:protocol/:name/servers/remove", svc.RemoveServer)
+		traefikGroup.POST("/config/services/:protocol/:name/servers/drain", svc.DrainServer)
+		// 数据库中的中间件配置
+		traefikGroup.GET("/config/middlewares", cc.ListMiddlewares)
+		traefikGroup.POST("/config/middlewares", cc.CreateMiddleware)
+		traefikGroup.PUT("/config/middlewares/:protocol/:name", cc.UpdateMiddleware)
+		traefikGroup.DELETE("/config/middlewares/:protocol/:name", cc.DeleteMiddleware)
+		// 数据库中的TLS选项、TLS证书存储和serversTransport，路由和服务按名称引用
+		traefikGroup.GET("/config/tls/options", cc.ListTLSOptions)
+		traefikGroup.POST("/config/tls/options", admin, cc.CreateTLSOption)
+		traefikGroup.PUT("/config/tls/options/:name", admin, cc.UpdateTLSOption)
+		traefikGroup.DELETE("/config/tls/options/:name", admin, cc.DeleteTLSOption)
+		traefikGroup.GET("/config/tls/stores", cc.ListTLSStores)
+		traefikGroup.POST("/config/tls/stores", admin, cc.CreateTLSStore)
+		traefikGroup.PUT("/config/tls/stores/:name", admin, cc.UpdateTLSStore)
+		traefikGroup.DELETE("/config/tls/stores/:name", admin, cc.DeleteTLSStore)
+		traefikGroup.GET("/config/serverstransports", cc.ListServersTransports)
+		traefikGroup.POST("/config/serverstransports", admin, cc.CreateServersTransport)
+		traefikGroup.PUT("/config/serverstransports/:protocol/:name", admin, cc.UpdateServersTransport)
+		traefikGroup.DELETE("/config/serverstransports/:protocol/:name", admin, cc.DeleteServersTransport)
+		// 修订历史、差异与回滚
+		traefikGroup.GET("/config/revisions", cc.GetRevisions)
+		traefikGroup.GET("/config/revisions/:id", cc.GetRevisionDetail)
+		traefikGroup.POST("/config/revisions/:id/rollback", admin, cc.RollbackRevision)
+		traefikGroup.POST("/config/rollback", admin, cc.RollbackToTime)
+
+		kc := new(traefik.TraefikKVController)
+		// 预览Traefik KV Provider键值
+		traefikGroup.GET("/kv/preview", admin, kc.PreviewKV)
+		// 立即发布配置到etcd/Redis
+		traefikGroup.POST("/kv/publish", admin, kc.PublishKV)
+
+		dc := new(traefik.TraefikDriftController)
+		// 立即执行一次配置对账
+		traefikGroup.POST("/drift/check", admin, dc.RunDriftCheck)
+		// 获取对账报告列表
+		traefikGroup.GET("/drift/reports", admin, dc.GetDriftReports)
+		// 获取最近一次对账报告
+		traefikGroup.GET("/drift/reports/latest", admin, dc.GetLatestDriftReport)
+		// 获取对账报告详情
+		traefikGroup.GET("/drift/reports/:id", admin, dc.GetDriftReport)
+
+		hc := new(traefik.TraefikHealthController)
+		// 获取后端服务器健康状况与可用率
+		traefikGroup.GET("/health/services", hc.GetServiceHealth)
+		// 获取后端服务器状态变化记录
+		traefikGroup.GET("/health/transitions", hc.GetServiceTransitions)
+
+		pc := new(traefik.TraefikPublishController)
+		// 草稿和快照包含所有部门的对象，发布影响所有部门，只有超级管理员可以查看和发布
+		// 草稿相对于已发布配置的差异
+		traefikGroup.GET("/publish/diff", admin, pc.GetDraftDiff)
+		// 发布草稿，可指定生效时间
+		traefikGroup.POST("/publish", admin, pc.Publish)
+		// 配置快照列表与详情
+		traefikGroup.GET("/publish/snapshots", admin, pc.GetSnapshots)
+		traefikGroup.GET("/publish/snapshots/:id", admin, pc.GetSnapshot)
+		// 取消计划中的发布
+		traefikGroup.POST("/publish/snapshots/:id/cancel", admin, pc.CancelSnapshot)
+
+		tpc := new(traefik.TraefikTemplateController)
+		// 应用模板管理
+		traefikGroup.GET("/templates", tpc.ListTemplates)
+		traefikGroup.GET("/templates/:name", tpc.GetTemplate)
+		traefikGroup.POST("/templates", admin, tpc.CreateTemplate)
+		traefikGroup.PUT("/templates/:name", admin, tpc.UpdateTemplate)
+		traefikGroup.DELETE("/templates/:name", admin, tpc.DeleteTemplate)
+		// 按模板创建路由、服务和中间件
+		traefikGroup.POST("/templates/:name/apply", tpc.ApplyTemplate)
+
+		rc := new(traefik.TraefikRolloutController)
+		// 加权服务的灰度发布
+		traefikGroup.GET("/rollouts", rc.GetRollouts)
+		traefikGroup.POST("/rollouts", rc.CreateRollout)
+		traefikGroup.GET("/rollouts/:id", rc.GetRollout)
+		traefikGroup.POST("/rollouts/:id/pause", rc.PauseRollout)
+		traefikGroup.POST("/rollouts/:id/resume", rc.ResumeRollout)
+		traefikGroup.POST("/rollouts/:id/abort", rc.AbortRollout)
+
+		mc := new(traefik.TraefikMaintenanceController)
+		// 路由维护窗口，维护期间路由指向维护页面
+		traefikGroup.GET("/maintenances", mc.GetMaintenances)
+		traefikGroup.POST("/maintenances", mc.CreateMaintenance)
+		traefikGroup.GET("/maintenances/:id", mc.GetMaintenance)
+		traefikGroup.POST("/maintenances/:id/end", mc.EndMaintenance)
+
+		ac := new(traefik.TraefikCertController)
+		// 启用TLS的路由的自动证书
+		traefikGroup.GET("/certs", ac.GetCertLinks)
+		traefikGroup.POST("/certs/sync", admin, ac.SyncCertificates)
+
+		uc := new(traefik.TraefikAuthController)
+		// 路由访问策略，rapide作为forwardAuth服务保护路由
+		traefikGroup.GET("/authpolicies", uc.GetPolicies)
+		traefikGroup.PUT("/authpolicies/:router", uc.SavePolicy)
+		traefikGroup.DELETE("/authpolicies/:router", uc.DeletePolicy)
+		// 浏览器访问被保护的应用时使用的登录Cookie
+		traefikGroup.POST("/authsession", uc.CreateSession)
+		traefikGroup.DELETE("/authsession", uc.DeleteSession)
+
+		tfc := new(traefik.TraefikTrafficController)
+		// 根据访问日志统计的路由和服务流量
+		traefikGroup.GET("/traffic/sources", tfc.GetSources)
+		traefikGroup.GET("/traffic/:kind", tfc.GetOverview)
+		traefikGroup.GET("/traffic/:kind/:name", tfc.GetTraffic)
+
+		mtc := new(traefik.TraefikMetricController)
+		// 从Traefik的Prometheus指标计算的请求速率和错误率
+		traefikGroup.GET("/metrics/sources", mtc.GetSources)
+		traefikGroup.GET("/metrics/:kind", mtc.GetOverview)
+		traefikGroup.GET("/metrics/:kind/:name", mtc.GetSeries)
+
+		adc := new(traefik.TraefikAdoptController)
+		// 接管其他Provider定义的运行时对象
+		traefikGroup.POST("/adopt", admin, adc.Adopt)
+		traefikGroup.GET("/adoptions", admin, adc.GetAdoptions)
+
+		gc := new(traefik.TraefikGitOpsController)
+		// 发布的配置与Git仓库同步
+		traefikGroup.POST("/gitops/push", admin, gc.Push)
+		traefikGroup.POST("/gitops/pull", admin, gc.Pull)
+		traefikGroup.GET("/gitops/syncs", admin, gc.GetSyncs)
+
+		apc := new(traefik.TraefikAppController)
+		// 应用目录，按应用汇总路由、后端、证书和负责人
+		traefikGroup.GET("/apps", apc.GetApps)
+		traefikGroup.POST("/apps", apc.CreateApp)
+		traefikGroup.GET("/apps/:name", apc.GetApp)
+		traefikGroup.PUT("/apps/:name", apc.UpdateApp)
+		traefikGroup.DELETE("/apps/:name", apc.DeleteApp)
+
+		owc := new(traefik.TraefikOwnerController)
+		// 路由、服务和中间件的所属部门
+		traefikGroup.GET("/owners", owc.GetOwners)
+		traefikGroup.PUT("/owners/:kind/:protocol/:name", owc.SetOwner)
+
+		sc := new(traefik.TraefikSimulateController)
+		// 模拟请求会命中的路由
+		traefikGroup.POST("/simulate", admin, sc.Simulate)
+		// 检查配置中的冲突和未使用的对象，发布前也会执行
+		traefikGroup.GET("/lint", admin, sc.Lint)
 	}
 }
 
-// TraefikHTTPProviderRouter 注册Traefik HTTP自动发现路由，不需要认证
+// TraefikHTTPProviderRouter 注册Traefik HTTP自动发现路由，使用实例令牌认证而不是JWT
 func TraefikHTTPProviderRouter(engine *gin.Engine) {
-	// Traefik HTTP Provider配置路由，公开访问，不需要认证
-	engine.GET("/api/traefik/provider", func(c *gin.Context) {
-		// 从服务层获取配置
-		config, err := service.Entrance.TraefikService.TraefikHTTPProviderService.GetHTTPProviderConfig()
+	// Traefik HTTP Provider配置路由，按实例令牌认证并限定下发范围
+	engine.GET("/api/traefik/provider", middlewares.TraefikProviderAuth(), func(c *gin.Context) {
+		instance := c.MustGet("traefik_instance").(traefikModel.TraefikInstance)
+		// 从服务层获取该实例可见的配置
+		config, err := service.Entrance.TraefikService.TraefikHTTPProviderService.GetInstanceProviderConfig(instance)
 		if err != nil {
 			c.JSON(500, gin.H{"error": err.Error()})
 			return
@@ -41,4 +226,8 @@ func TraefikHTTPProviderRouter(engine *gin.Engine) {
 		// 直接返回配置，不添加任何包装，符合Traefik HTTP Provider期望的格式
 		c.JSON(200, config)
 	})
+	// 维护中路由的请求由Traefik转发到这里，返回维护页面
+	engine.GET("/api/traefik/maintenance/:id", new(traefik.TraefikMaintenanceController).MaintenancePage)
+	// forwardAuth中间件把请求转发到这里，按路由的访问策略校验rapide的登录状态
+	engine.Any("/api/traefik/forwardauth/:router", new(traefik.TraefikAuthController).ForwardAuth)
 }
@@ -16,6 +16,7 @@ import (
 	"github.com/go-acme/lego/v4/providers/dns/cloudflare"
 	"github.com/go-acme/lego/v4/registration"
 	"github.com/yahahaff/rapide/internal/models/ssl"
+	"github.com/yahahaff/rapide/internal/models/sys"
 	"github.com/yahahaff/rapide/pkg/database"
 )
 
@@ -31,8 +32,8 @@ func (ss *SSLCertService) GetSSLCertByID(id string) (ssl.SSLCert, error) {
 	return cert, nil
 }
 
-// GetSSLCertList 获取SSL证书列表
-func (ss *SSLCertService) GetSSLCertList(page int, size int, domain, applyStatus string) (data interface{}, total int64, err error) {
+// GetSSLCertList 获取范围内部门的SSL证书列表，app不为空时只返回归属该应用或被该应用的路由使用的证书
+func (ss *SSLCertService) GetSSLCertList(page int, size int, domain, applyStatus, app string, scope sys.DeptScope) (data interface{}, total int64, err error) {
 	// 参数验证和默认值处理
 	if page < 1 {
 		page = 1
@@ -54,6 +55,22 @@ func (ss *SSLCertService) GetSSLCertList(page int, size int, domain, applyStatus
 	if applyStatus != "" {
 		db = db.Where("apply_status = ?", applyStatus)
 	}
+	if !scope.All {
+		db = db.Where("dept_id IN ?", scope.DeptIDs)
+	}
+	if app != "" {
+		// 应用目录中的证书和应用路由关联的证书，见traefik_app_members和traefik_cert_links
+		certs := database.DB.Table("traefik_app_members").
+			Joins("JOIN traefik_apps ON traefik_apps.id = traefik_app_members.app_id").
+			Where("traefik_apps.name = ? AND traefik_app_members.kind = ?", app, "cert").
+			Select("traefik_app_members.name")
+		linked := database.DB.Table("traefik_cert_links").
+			Joins("JOIN traefik_app_members ON traefik_app_members.kind = ? AND traefik_app_members.name = traefik_cert_links.router AND traefik_app_members.protocol = traefik_cert_links.protocol", "router").
+			Joins("JOIN traefik_apps ON traefik_apps.id = traefik_app_members.app_id").
+			Where("traefik_apps.name = ? AND traefik_cert_links.cert_id <> 0", app).
+			Select("traefik_cert_links.cert_id")
+		db = db.Where("domain IN (?) OR id IN (?)", certs, linked)
+	}
 
 	// 获取总记录数
 	if err := db.Count(&total).Error; err != nil {
@@ -72,6 +89,7 @@ func (ss *SSLCertService) GetSSLCertList(page int, size int, domain, applyStatus
 		ValidityEnd   time.Time `json:"validityEnd"`
 		Provider      string    `json:"provider"`
 		ApplyStatus   string    `json:"applyStatus"`
+		DeptID        uint64    `json:"deptId"`
 	}
 
 	// 执行分页查询，只查询指定字段
@@ -85,8 +103,9 @@ func (ss *SSLCertService) GetSSLCertList(page int, size int, domain, applyStatus
 		ValidityEnd  time.Time `json:"validityEnd"`
 		Provider     string    `json:"provider"`
 		ApplyStatus  string    `json:"applyStatus"`
+		DeptID       uint64    `json:"deptId"`
 	}
-	if err := db.Select("id, domain, common_name, organization, type, algorithm, validity_end, provider, apply_status").Order("id desc").Limit(size).Offset(offset).Find(&certList).Error; err != nil {
+	if err := db.Select("id, domain, common_name, organization, type, algorithm, validity_end, provider, apply_status, dept_id").Order("id desc").Limit(size).Offset(offset).Find(&certList).Error; err != nil {
 		return nil, 0, err
 	}
 
@@ -110,18 +129,19 @@ func (ss *SSLCertService) GetSSLCertList(page int, size int, domain, applyStatus
 			ValidityEnd:   cert.ValidityEnd,
 			Provider:      cert.Provider,
 			ApplyStatus:   cert.ApplyStatus,
+			DeptID:        cert.DeptID,
 		})
 	}
 
 	return responseList, total, nil
 }
 
-// CreateSSLCert 创建SSL证书
-func (ss *SSLCertService) CreateSSLCert(cert ssl.SSLCert) (err error) {
+// CreateSSLCert 创建SSL证书，返回新证书记录的ID，申请在后台进行
+func (ss *SSLCertService) CreateSSLCert(cert ssl.SSLCert) (id uint64, err error) {
 	// 1. 创建初始证书记录，状态为 pending
 	cert.ApplyStatus = "pending"
 	if err := database.DB.Create(&cert).Error; err != nil {
-		return err
+		return 0, err
 	}
 
 	// 2. 异步处理证书申请
@@ -173,7 +193,7 @@ func (ss *SSLCertService) CreateSSLCert(cert ssl.SSLCert) (err error) {
 		}
 	}(cert.ID, cert)
 
-	return nil
+	return cert.ID, nil
 }
 
 // applyLetsEncryptCert 申请 Let's Encrypt 证书
@@ -1,7 +1,12 @@
 package sys
 
 import (
+	"sort"
+	"strings"
+
 	"github.com/yahahaff/rapide/internal/models/sys"
+	"github.com/yahahaff/rapide/pkg/config"
+	"github.com/yahahaff/rapide/pkg/database"
 )
 
 // DeptService 部门服务
@@ -77,3 +82,63 @@ func (ds *DeptService) GetDeptTree() ([]sys.Dept, error) {
 	}
 	return ds.BuildDeptTree(deptList), nil
 }
+
+// GetUserDeptScope 获取用户可以管理的部门范围
+// 拥有DEPT_SCOPE_ADMIN_ROLES中任一启用角色的用户不受部门限制，其他用户可以管理所在部门及按Pid展开的所有下级部门
+func (ds *DeptService) GetUserDeptScope(userID uint64) (sys.DeptScope, error) {
+	scope := sys.DeptScope{DeptIDs: make([]uint64, 0)}
+	user := sys.User{}
+	user.ID = userID
+	roles, err := user.GetUserRoles()
+	if err != nil {
+		return scope, err
+	}
+	for _, role := range roles {
+		if role.Status == 1 && isScopeAdminRole(role.RoleCode) {
+			scope.All = true
+			return scope, nil
+		}
+	}
+
+	var direct []uint64
+	if err := database.DB.Model(&sys.UserDept{}).Where("user_id = ?", userID).Order("dept_id asc").Pluck("dept_id", &direct).Error; err != nil {
+		return scope, err
+	}
+	if len(direct) == 1 {
+		scope.Default = direct[0]
+	}
+	deptList, err := sys.GetDeptList()
+	if err != nil {
+		return scope, err
+	}
+	children := make(map[uint64][]uint64)
+	for _, dept := range deptList {
+		children[dept.Pid] = append(children[dept.Pid], dept.ID)
+	}
+
+	// 按Pid逐层展开下级部门，seen防止错误的循环引用
+	seen := make(map[uint64]bool)
+	queue := append([]uint64(nil), direct...)
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if id == 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		scope.DeptIDs = append(scope.DeptIDs, id)
+		queue = append(queue, children[id]...)
+	}
+	sort.Slice(scope.DeptIDs, func(i, j int) bool { return scope.DeptIDs[i] < scope.DeptIDs[j] })
+	return scope, nil
+}
+
+// isScopeAdminRole 判断角色是否不受部门范围限制
+func isScopeAdminRole(code string) bool {
+	for _, admin := range strings.Split(config.GetString("DEPT_SCOPE_ADMIN_ROLES", "admin"), ",") {
+		if admin = strings.TrimSpace(admin); admin != "" && admin == code {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,342 @@
+package traefik
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	traefikDAO "github.com/yahahaff/rapide/internal/dao/traefik"
+	traefikModel "github.com/yahahaff/rapide/internal/models/traefik"
+	"github.com/yahahaff/rapide/pkg/config"
+	"github.com/yahahaff/rapide/pkg/database"
+	"github.com/yahahaff/rapide/pkg/types"
+	"gorm.io/gorm"
+)
+
+// TraefikAdoptService 把Docker标签、文件等其他Provider定义的运行时对象接管到rapide
+// 从Traefik API读取对象及其引用的服务和中间件，转换为草稿中的对象，发布后由rapide下发
+type TraefikAdoptService struct {
+	traefikDAO *traefikDAO.TraefikDAO
+}
+
+// adoptRemark 接管产生的修订记录备注
+const adoptRemark = "接管运行时对象"
+
+// adoptKeys 运行时对象中属于配置定义的字段，其余为状态等运行时信息
+var adoptKeys = map[string][]string{
+	kindRouter:  {"entryPoints", "middlewares", "service", "rule", "ruleSyntax", "priority", "tls"},
+	kindService: {"loadBalancer", "weighted", "mirroring", "failover"},
+}
+
+// runtimeOnlyKeys 中间件运行时对象中的状态字段，剩下的唯一字段是中间件类型
+var runtimeOnlyKeys = map[string]bool{
+	"status": true, "usedBy": true, "name": true, "provider": true, "type": true, "error": true, "err": true,
+}
+
+// AdoptedObject 接管的对象
+type AdoptedObject struct {
+	Kind        string `json:"kind"`
+	Name        string `json:"name"`
+	Protocol    string `json:"protocol"`
+	RuntimeName string `json:"runtimeName"`
+	Provider    string `json:"provider"`
+}
+
+// AdoptResult 接管结果，Import中是写入草稿的差异
+type AdoptResult struct {
+	Objects   []AdoptedObject `json:"objects"`
+	Import    ImportResult    `json:"import"`
+	Checklist []string        `json:"checklist,omitempty"`
+	Warnings  []string        `json:"warnings"`
+}
+
+// adoptTarget 等待接管的运行时对象
+type adoptTarget struct {
+	kind        string
+	runtimeName string
+}
+
+// Adopt 接管运行时的路由、服务或中间件，引用的同Provider或其他Provider的服务和中间件一并接管
+// 引用改为rapide中的名称，Traefik内部对象(@internal)保持原引用；checklist为true时生成删除原定义的步骤
+// 原定义删除前两份配置同时生效，规则相同的路由由Traefik任选其一
+func (as *TraefikAdoptService) Adopt(kind, protocol, runtimeName, conflict string, dryRun, checklist bool, operator string) (AdoptResult, error) {
+	result := AdoptResult{Objects: make([]AdoptedObject, 0), Warnings: make([]string, 0)}
+	protocol = protocolOf(protocol)
+	if _, _, ok := strings.Cut(runtimeName, "@"); !ok {
+		return result, &validationError{message: "请使用Traefik中带@provider后缀的完整名称，如whoami@docker"}
+	}
+
+	var (
+		set         ConfigSet
+		definitions = make(map[string]map[string]interface{}) // 类型/rapide中的名称 -> 原Provider中的定义
+		names       = make(map[string]string)                 // 类型/rapide中的名称 -> 运行时名称，检查不同Provider的同名对象
+		visited     = make(map[string]bool)
+		queue       = []adoptTarget{{kind: kind, runtimeName: runtimeName}}
+	)
+	for len(queue) > 0 {
+		target := queue[0]
+		queue = queue[1:]
+		if visited[target.kind+"/"+target.runtimeName] {
+			continue
+		}
+		visited[target.kind+"/"+target.runtimeName] = true
+
+		name, provider, _ := strings.Cut(target.runtimeName, "@")
+		if provider == "internal" {
+			return result, &validationError{message: "Traefik内部对象无法接管: " + target.runtimeName}
+		}
+		if provider == rapideProvider() {
+			return result, &validationError{message: "对象已由rapide管理: " + target.runtimeName}
+		}
+		if previous, ok := names[target.kind+"/"+name]; ok {
+			return result, &validationError{message: fmt.Sprintf("%s和%s接管后同名，请先接管其中一个并修改名称", previous, target.runtimeName)}
+		}
+		names[target.kind+"/"+name] = target.runtimeName
+
+		object, err := fetchRuntimeObject(target.kind, protocol, target.runtimeName)
+		if err != nil {
+			return result, err
+		}
+		// 接管记录保存原始定义，改写引用在副本上进行
+		original := runtimeDefinition(target.kind, object)
+		definition, _ := toJSONCompatible(original).(map[string]interface{})
+		deps, warnings := rewriteAdoptRefs(target.kind, protocol, provider, definition)
+		result.Warnings = append(result.Warnings, warnings...)
+		queue = append(queue, deps...)
+
+		switch target.kind {
+		case kindRouter:
+			router, err := parseRouter(name, protocol, definition)
+			if err != nil {
+				return result, &validationError{message: err.Error()}
+			}
+			set.Routers = append(set.Routers, router)
+		case kindService:
+			service, err := parseService(name, protocol, definition)
+			if err != nil {
+				return result, &validationError{message: err.Error()}
+			}
+			if service.Type == "loadbalancer" && (provider == "docker" || provider == "swarm") {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("服务%s的后端地址来自容器，容器重建后地址可能变化，需要在rapide中更新", name))
+			}
+			set.Services = append(set.Services, service)
+		case kindMiddleware:
+			middleware, err := parseMiddleware(name, protocol, definition)
+			if err != nil {
+				return result, &validationError{message: err.Error()}
+			}
+			set.Middlewares = append(set.Middlewares, middleware)
+		}
+		definitions[target.kind+"/"+name] = original
+		result.Objects = append(result.Objects, AdoptedObject{
+			Kind: target.kind, Name: name, Protocol: protocol, RuntimeName: target.runtimeName, Provider: provider,
+		})
+	}
+
+	if checklist {
+		for _, object := range result.Objects {
+			result.Checklist = append(result.Checklist, removalSteps(object)...)
+		}
+	}
+	result.Warnings = append(result.Warnings, "删除原定义前，rapide发布的对象与原对象同时生效")
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		dao := as.traefikDAO.WithTx(tx)
+		imported, err := importConfigSet(dao, set, conflict, dryRun, operator, adoptRemark)
+		if err != nil {
+			return err
+		}
+		result.Import = imported
+		if dryRun {
+			return nil
+		}
+
+		actions := make(map[string]string, len(imported.Items))
+		for _, item := range imported.Items {
+			actions[item.Kind+"/"+item.Name] = item.Action
+		}
+		for _, object := range result.Objects {
+			if actions[object.Kind+"/"+object.Name] == "skip" {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("rapide中已存在%s %s，保留原对象，引用将指向它", object.Kind, object.Name))
+				continue
+			}
+			adoption := traefikModel.TraefikAdoption{
+				Kind:        object.Kind,
+				Name:        object.Name,
+				Protocol:    object.Protocol,
+				RuntimeName: object.RuntimeName,
+				Provider:    object.Provider,
+				Definition:  types.JSONMap(definitions[object.Kind+"/"+object.Name]),
+				Checklist:   types.JSONSlice(removalSteps(object)),
+				Operator:    operator,
+			}
+			if err := dao.SaveAdoption(&adoption); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return result, err
+}
+
+// GetAdoptions 获取接管记录
+func (as *TraefikAdoptService) GetAdoptions(provider string) ([]traefikModel.TraefikAdoption, error) {
+	return as.traefikDAO.GetAdoptions(provider)
+}
+
+// rapideProvider rapide下发的配置在Traefik中的Provider名称
+func rapideProvider() string {
+	return config.GetString("TRAEFIK_DRIFT_PROVIDER", "http")
+}
+
+// fetchRuntimeObject 从Traefik API读取运行时对象，不存在时返回校验错误
+func fetchRuntimeObject(kind, protocol, runtimeName string) (map[string]interface{}, error) {
+	var object map[string]interface{}
+	err := getTraefikJSON(fmt.Sprintf("/api/%s/%ss/%s", protocol, kind, url.PathEscape(runtimeName)), &object)
+	var httpErr *httpError
+	if errors.As(err, &httpErr) && httpErr.statusCode == http.StatusNotFound {
+		return nil, &validationError{message: fmt.Sprintf("Traefik中不存在%s %s", kind, runtimeName)}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取Traefik运行时对象失败: %w", err)
+	}
+	return object, nil
+}
+
+// runtimeDefinition 从运行时对象中取出配置定义，去掉状态、使用者等运行时信息
+func runtimeDefinition(kind string, object map[string]interface{}) map[string]interface{} {
+	definition := make(map[string]interface{})
+	if keys, ok := adoptKeys[kind]; ok {
+		for _, key := range keys {
+			if value, ok := object[key]; ok {
+				definition[key] = value
+			}
+		}
+		return definition
+	}
+	for key, value := range object {
+		if !runtimeOnlyKeys[key] {
+			definition[key] = value
+		}
+	}
+	return definition
+}
+
+// rewriteAdoptRefs 把定义中对服务和中间件的引用改为rapide中的名称，返回需要一并接管的对象
+// 没有@后缀的引用属于对象所在的Provider；TLS选项和serversTransport无法从运行时读取，改为带原Provider后缀的引用
+func rewriteAdoptRefs(kind, protocol, provider string, definition map[string]interface{}) ([]adoptTarget, []string) {
+	var (
+		deps     []adoptTarget
+		warnings []string
+	)
+	local := func(refKind string, ref string) string {
+		name, refProvider, found := strings.Cut(ref, "@")
+		if !found {
+			refProvider = provider
+		}
+		switch refProvider {
+		case "internal":
+			return name + "@internal"
+		case rapideProvider():
+			return name
+		}
+		deps = append(deps, adoptTarget{kind: refKind, runtimeName: name + "@" + refProvider})
+		return name
+	}
+	external := func(field string, value interface{}) interface{} {
+		ref, ok := value.(string)
+		if !ok || ref == "" {
+			return value
+		}
+		if !strings.Contains(ref, "@") {
+			ref += "@" + provider
+		}
+		if !strings.HasSuffix(ref, "@"+rapideProvider()) {
+			warnings = append(warnings, fmt.Sprintf("%s %s仍然引用原Provider中的定义，删除原定义前需要在rapide中创建", field, ref))
+		}
+		return ref
+	}
+
+	switch kind {
+	case kindRouter:
+		if service, ok := definition["service"].(string); ok {
+			definition["service"] = local(kindService, service)
+		}
+		if middlewares, ok := definition["middlewares"].([]interface{}); ok {
+			for i, ref := range middlewares {
+				if name, ok := ref.(string); ok {
+					middlewares[i] = local(kindMiddleware, name)
+				}
+			}
+		}
+		if tls, ok := definition["tls"].(map[string]interface{}); ok {
+			if _, ok := tls["options"]; ok {
+				tls["options"] = external("tls.options", tls["options"])
+			}
+		}
+	case kindService:
+		if lb, ok := definition["loadBalancer"].(map[string]interface{}); ok {
+			if _, ok := lb["serversTransport"]; ok {
+				lb["serversTransport"] = external("serversTransport", lb["serversTransport"])
+			}
+		}
+		if weighted, ok := definition["weighted"].(map[string]interface{}); ok {
+			rewriteServiceList(weighted["services"], func(ref string) string { return local(kindService, ref) })
+		}
+		if mirroring, ok := definition["mirroring"].(map[string]interface{}); ok {
+			if service, ok := mirroring["service"].(string); ok {
+				mirroring["service"] = local(kindService, service)
+			}
+			rewriteServiceList(mirroring["mirrors"], func(ref string) string { return local(kindService, ref) })
+		}
+	case kindMiddleware:
+		if chain, ok := definition["chain"].(map[string]interface{}); ok {
+			if middlewares, ok := chain["middlewares"].([]interface{}); ok {
+				for i, ref := range middlewares {
+					if name, ok := ref.(string); ok {
+						middlewares[i] = local(kindMiddleware, name)
+					}
+				}
+			}
+		}
+	}
+	sort.SliceStable(deps, func(i, j int) bool { return deps[i].kind < deps[j].kind })
+	return deps, warnings
+}
+
+// rewriteServiceList 改写加权服务和镜像服务列表中的服务名称
+func rewriteServiceList(value interface{}, rewrite func(string) string) {
+	items, _ := value.([]interface{})
+	for _, item := range items {
+		if entry, ok := item.(map[string]interface{}); ok {
+			if name, ok := entry["name"].(string); ok {
+				entry["name"] = rewrite(name)
+			}
+		}
+	}
+}
+
+// removalSteps 生成删除原Provider中定义的步骤
+func removalSteps(object AdoptedObject) []string {
+	section := fmt.Sprintf("%s.%ss.%s", object.Protocol, object.Kind, object.Name)
+	switch object.Provider {
+	case "docker", "swarm":
+		steps := []string{fmt.Sprintf("删除容器上以traefik.%s.开头的标签", section)}
+		if object.Kind == kindService {
+			steps = append(steps, "容器不再需要被Traefik发现时，设置标签traefik.enable=false")
+		}
+		return steps
+	case "consulcatalog", "nomad", "ecs":
+		return []string{fmt.Sprintf("删除%s服务中以traefik.%s.开头的标签", object.Provider, section)}
+	case "file":
+		return []string{fmt.Sprintf("从文件Provider的配置中删除%s", section)}
+	case "kubernetescrd":
+		return []string{fmt.Sprintf("删除Kubernetes中生成%s的IngressRoute、Middleware或TraefikService资源", object.RuntimeName)}
+	case "kubernetes", "kubernetesingress", "kubernetesgateway":
+		return []string{fmt.Sprintf("删除或修改Kubernetes中生成%s的Ingress或Gateway资源", object.RuntimeName)}
+	}
+	return []string{fmt.Sprintf("在%s Provider中删除%s的定义", object.Provider, object.RuntimeName)}
+}
@@ -0,0 +1,49 @@
+package traefik
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/yahahaff/rapide/pkg/config"
+	"github.com/yahahaff/rapide/pkg/logger"
+	"github.com/yahahaff/rapide/pkg/mail"
+)
+
+// alertClient 发送Webhook告警使用的HTTP客户端
+var alertClient = &http.Client{Timeout: 10 * time.Second}
+
+// sendAlert 通过邮件和Webhook发送告警，未配置的渠道直接跳过，发送失败只记录日志
+func sendAlert(subject, text string) {
+	if recipients := config.GetString("TRAEFIK_ALERT_MAIL_TO", ""); recipients != "" {
+		mail.NewMailer().Send(mail.Email{
+			From: mail.From{
+				Address: config.GetString("MAIL_ADDRESS", ""),
+				Name:    config.GetString("MAIL_NAME", ""),
+			},
+			To:      strings.Split(recipients, ","),
+			Subject: subject,
+			Text:    []byte(text),
+		})
+	}
+
+	if webhook := config.GetString("TRAEFIK_ALERT_WEBHOOK_URL", ""); webhook != "" {
+		payload, _ := json.Marshal(map[string]interface{}{
+			"title": subject,
+			"text":  text,
+			"time":  time.Now().Format(time.RFC3339),
+		})
+		resp, err := alertClient.Post(webhook, "application/json", bytes.NewReader(payload))
+		if err != nil {
+			logger.ErrorString("traefik", "alert webhook", err.Error())
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			logger.ErrorString("traefik", "alert webhook", fmt.Sprintf("unexpected status %s", resp.Status))
+		}
+	}
+}
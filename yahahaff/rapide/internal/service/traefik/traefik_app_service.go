@@ -0,0 +1,491 @@
+package traefik
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	traefikDAO "github.com/yahahaff/rapide/internal/dao/traefik"
+	sslModel "github.com/yahahaff/rapide/internal/models/ssl"
+	sysModel "github.com/yahahaff/rapide/internal/models/sys"
+	traefikModel "github.com/yahahaff/rapide/internal/models/traefik"
+	"github.com/yahahaff/rapide/pkg/database"
+	"gorm.io/gorm"
+)
+
+// kindCert 应用中的证书，名称为sys_ssl_cert中的域名
+const kindCert = "cert"
+
+// TraefikAppService 应用目录，把路由、服务、中间件和证书按应用归属到部门和联系人
+type TraefikAppService struct {
+	traefikDAO *traefikDAO.TraefikDAO
+}
+
+// AppObject 应用包含的对象引用
+type AppObject struct {
+	Kind     string `json:"kind"`
+	Protocol string `json:"protocol"`
+	Name     string `json:"name"`
+}
+
+// AppSummary 应用列表中的一项
+type AppSummary struct {
+	traefikModel.TraefikApp
+	Objects map[string]int `json:"objects"` // 按类型统计的对象数量
+}
+
+// AppContact 应用联系人
+type AppContact struct {
+	UserName string  `json:"userName"`
+	RealName string  `json:"realName"`
+	Email    *string `json:"email"`
+	Phone    *string `json:"phone"`
+}
+
+// AppRouter 应用中的路由及其证书关联
+type AppRouter struct {
+	Protocol string                         `json:"protocol"`
+	Name     string                         `json:"name"`
+	Missing  bool                           `json:"missing"` // 对象已被删除
+	Router   *traefikModel.TraefikRouter    `json:"router"`
+	Certs    []traefikModel.TraefikCertLink `json:"certs"`
+}
+
+// AppService 应用中的服务及其后端服务器的健康状况
+type AppService struct {
+	Protocol string                       `json:"protocol"`
+	Name     string                       `json:"name"`
+	Missing  bool                         `json:"missing"`
+	Service  *traefikModel.TraefikService `json:"service"`
+	Servers  []ServerHealth               `json:"servers"`
+}
+
+// AppMiddleware 应用中的中间件
+type AppMiddleware struct {
+	Protocol   string                          `json:"protocol"`
+	Name       string                          `json:"name"`
+	Missing    bool                            `json:"missing"`
+	Middleware *traefikModel.TraefikMiddleware `json:"middleware"`
+}
+
+// AppCert 应用使用的证书，包括直接归属应用的证书和应用路由关联的证书
+type AppCert struct {
+	Domain        string     `json:"domain"`
+	Source        string     `json:"source"` // member: 归属应用, router: 由路由关联
+	Missing       bool       `json:"missing"`
+	CertID        uint64     `json:"certId"`
+	ApplyStatus   string     `json:"applyStatus"`
+	ValidityEnd   *time.Time `json:"validityEnd"`
+	ExpiresInDays *int       `json:"expiresInDays"`
+}
+
+// AppDetail 应用详情，汇总路由、后端、证书有效期和健康状况
+type AppDetail struct {
+	App         traefikModel.TraefikApp `json:"app"`
+	Dept        *sysModel.Dept          `json:"dept"`
+	Contacts    []AppContact            `json:"contacts"`
+	Routers     []AppRouter             `json:"routers"`
+	Services    []AppService            `json:"services"`
+	Middlewares []AppMiddleware         `json:"middlewares"`
+	Certs       []AppCert               `json:"certs"`
+	Summary     AppDetailSummary        `json:"summary"`
+}
+
+// AppDetailSummary 应用详情的汇总
+type AppDetailSummary struct {
+	Missing     int        `json:"missing"`     // 已被删除的对象数量
+	ServersUp   int        `json:"serversUp"`   // 状态为UP的后端服务器数量
+	ServersDown int        `json:"serversDown"` // 状态为DOWN的后端服务器数量
+	CertsIssue  int        `json:"certsIssue"`  // 缺失、申请失败或已过期的证书数量
+	NextExpiry  *time.Time `json:"nextExpiry"`  // 最早到期的证书的到期时间
+}
+
+// appHealthWindow 应用详情中后端可用率的统计窗口
+const appHealthWindow = 24 * time.Hour
+
+// GetApps 获取范围内部门的应用列表及各类对象数量
+func (as *TraefikAppService) GetApps(deptID uint64, environment string, scope sysModel.DeptScope) ([]AppSummary, error) {
+	apps, err := as.traefikDAO.GetApps(deptID, environment)
+	if err != nil {
+		return nil, err
+	}
+	members, err := as.traefikDAO.GetAppMembers(0)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[uint64]map[string]int)
+	for _, member := range members {
+		if counts[member.AppID] == nil {
+			counts[member.AppID] = make(map[string]int)
+		}
+		counts[member.AppID][member.Kind]++
+	}
+
+	result := make([]AppSummary, 0, len(apps))
+	for _, app := range apps {
+		if !scope.Contains(app.DeptID) {
+			continue
+		}
+		objects := counts[app.ID]
+		if objects == nil {
+			objects = make(map[string]int)
+		}
+		result = append(result, AppSummary{TraefikApp: app, Objects: objects})
+	}
+	return result, nil
+}
+
+// SaveApp 创建或更新应用，objects为nil时更新不修改应用包含的对象
+// 应用和包含的对象都需要属于范围内的部门，未指定负责部门时使用用户所在的部门
+func (as *TraefikAppService) SaveApp(name string, app traefikModel.TraefikApp, objects []AppObject, create bool, scope sysModel.DeptScope, operator string) (traefikModel.TraefikApp, error) {
+	deptID, err := newObjectOwner(app.DeptID, scope)
+	if err != nil {
+		return app, err
+	}
+	app.DeptID = deptID
+	if err := checkAppOwner(app.DeptID, app.Contacts); err != nil {
+		return app, err
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		dao := as.traefikDAO.WithTx(tx)
+		existing, err := dao.GetApp(name)
+		switch {
+		case err == nil && create:
+			return &validationError{message: "应用已存在: " + name}
+		case errors.Is(err, gorm.ErrRecordNotFound) && !create:
+			return err
+		case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		case err == nil:
+			if err := checkAppDept(existing, scope); err != nil {
+				return err
+			}
+		}
+
+		app.ID, app.CreatedAt = existing.ID, existing.CreatedAt
+		app.Name = name
+		app.Operator = operator
+		if err := dao.SaveApp(&app); err != nil {
+			return err
+		}
+		if objects == nil {
+			return nil
+		}
+
+		members, err := appMembers(dao, app.ID, objects, scope)
+		if err != nil {
+			return err
+		}
+		return dao.ReplaceAppMembers(app.ID, members)
+	})
+	return app, err
+}
+
+// DeleteApp 删除应用，应用包含的对象保留
+func (as *TraefikAppService) DeleteApp(name string, scope sysModel.DeptScope) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		dao := as.traefikDAO.WithTx(tx)
+		app, err := dao.GetApp(name)
+		if err != nil {
+			return err
+		}
+		if err := checkAppDept(app, scope); err != nil {
+			return err
+		}
+		return dao.DeleteApp(app.ID)
+	})
+}
+
+// GetAppDetail 获取应用详情，已被删除的对象标记为missing
+func (as *TraefikAppService) GetAppDetail(name string, scope sysModel.DeptScope) (AppDetail, error) {
+	dao := as.traefikDAO
+	detail := AppDetail{
+		Contacts:    make([]AppContact, 0),
+		Routers:     make([]AppRouter, 0),
+		Services:    make([]AppService, 0),
+		Middlewares: make([]AppMiddleware, 0),
+		Certs:       make([]AppCert, 0),
+	}
+
+	app, err := dao.GetApp(name)
+	if err != nil {
+		return detail, err
+	}
+	if err := checkAppDept(app, scope); err != nil {
+		return detail, err
+	}
+	detail.App = app
+
+	if app.DeptID != 0 {
+		var dept sysModel.Dept
+		err := database.DB.Where("id = ?", app.DeptID).First(&dept).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return detail, err
+		}
+		if err == nil {
+			detail.Dept = &dept
+		}
+	}
+	if len(app.Contacts) > 0 {
+		var users []sysModel.User
+		if err := database.DB.Where("user_name IN ?", []string(app.Contacts)).Order("user_name asc").Find(&users).Error; err != nil {
+			return detail, err
+		}
+		for _, user := range users {
+			detail.Contacts = append(detail.Contacts, AppContact{UserName: user.UserName, RealName: user.RealName, Email: user.Email, Phone: user.Phone})
+		}
+	}
+
+	members, err := dao.GetAppMembers(app.ID)
+	if err != nil {
+		return detail, err
+	}
+	links, err := dao.GetCertLinks("", "")
+	if err != nil {
+		return detail, err
+	}
+	states, err := dao.GetServerStates()
+	if err != nil {
+		return detail, err
+	}
+
+	certDomains := make(map[string]string) // 域名 -> 来源
+	now := time.Now()
+	for _, member := range members {
+		if member.Kind == kindCert {
+			certDomains[member.Name] = "member"
+			continue
+		}
+		object, err := loadObject(dao, member.Kind, member.Name, member.Protocol)
+		if err != nil {
+			return detail, err
+		}
+		if object == nil {
+			detail.Summary.Missing++
+		}
+
+		switch member.Kind {
+		case kindRouter:
+			item := AppRouter{Protocol: member.Protocol, Name: member.Name, Missing: object == nil, Certs: make([]traefikModel.TraefikCertLink, 0)}
+			if router, ok := object.(traefikModel.TraefikRouter); ok {
+				item.Router = &router
+			}
+			for _, link := range links {
+				if link.Router != member.Name || protocolOf(link.Protocol) != member.Protocol {
+					continue
+				}
+				item.Certs = append(item.Certs, link)
+				if link.Domain != "" {
+					if _, ok := certDomains[link.Domain]; !ok {
+						certDomains[link.Domain] = "router"
+					}
+				}
+			}
+			detail.Routers = append(detail.Routers, item)
+		case kindService:
+			item := AppService{Protocol: member.Protocol, Name: member.Name, Missing: object == nil, Servers: make([]ServerHealth, 0)}
+			if service, ok := object.(traefikModel.TraefikService); ok {
+				item.Service = &service
+			}
+			runtimeName := member.Name + "@" + rapideProvider()
+			for _, state := range states {
+				if state.Service != runtimeName {
+					continue
+				}
+				uptime, err := as.health().serverUptime(state.Service, state.URL, now.Add(-appHealthWindow), now)
+				if err != nil {
+					return detail, err
+				}
+				item.Servers = append(item.Servers, ServerHealth{
+					URL:       state.URL,
+					Status:    state.Status,
+					Since:     state.Since,
+					CheckedAt: state.CheckedAt,
+					AlertedAt: state.AlertedAt,
+					Uptime:    uptime,
+				})
+				if state.Status == "UP" {
+					detail.Summary.ServersUp++
+				} else {
+					detail.Summary.ServersDown++
+				}
+			}
+			detail.Services = append(detail.Services, item)
+		case kindMiddleware:
+			item := AppMiddleware{Protocol: member.Protocol, Name: member.Name, Missing: object == nil}
+			if middleware, ok := object.(traefikModel.TraefikMiddleware); ok {
+				item.Middleware = &middleware
+			}
+			detail.Middlewares = append(detail.Middlewares, item)
+		}
+	}
+
+	certs, err := appCerts(certDomains, now)
+	if err != nil {
+		return detail, err
+	}
+	for _, cert := range certs {
+		if cert.Missing || cert.ApplyStatus == "failed" || (cert.ValidityEnd != nil && cert.ValidityEnd.Before(now)) {
+			detail.Summary.CertsIssue++
+		}
+		if cert.ValidityEnd != nil && (detail.Summary.NextExpiry == nil || cert.ValidityEnd.Before(*detail.Summary.NextExpiry)) {
+			detail.Summary.NextExpiry = cert.ValidityEnd
+		}
+	}
+	detail.Certs = certs
+	return detail, nil
+}
+
+// appObjectKeys 获取应用中指定类型的对象，键为protocol/name，应用不存在时返回gorm.ErrRecordNotFound
+func appObjectKeys(dao *traefikDAO.TraefikDAO, name, kind string) (map[string]bool, error) {
+	app, err := dao.GetApp(name)
+	if err != nil {
+		return nil, err
+	}
+	members, err := dao.GetAppMembers(app.ID)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]bool)
+	for _, member := range members {
+		if member.Kind == kind {
+			keys[refKey(member.Protocol, member.Name)] = true
+		}
+	}
+	return keys, nil
+}
+
+// health 复用健康检查服务的可用率统计
+func (as *TraefikAppService) health() *TraefikHealthService {
+	return &TraefikHealthService{traefikDAO: as.traefikDAO}
+}
+
+// appMembers 校验应用要包含的对象存在、属于范围内的部门且不属于其他应用
+func appMembers(dao *traefikDAO.TraefikDAO, appID uint64, objects []AppObject, scope sysModel.DeptScope) ([]traefikModel.TraefikAppMember, error) {
+	members := make([]traefikModel.TraefikAppMember, 0, len(objects))
+	seen := make(map[string]bool)
+	for _, object := range objects {
+		member := traefikModel.TraefikAppMember{Kind: object.Kind, Protocol: protocolOf(object.Protocol), Name: object.Name}
+		switch object.Kind {
+		case kindCert:
+			member.Protocol = ""
+			var cert sslModel.SSLCert
+			err := database.DB.Select("id, dept_id").Where("domain = ?", object.Name).First(&cert).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, &validationError{message: "证书不存在: " + object.Name}
+			}
+			if err != nil {
+				return nil, err
+			}
+			if !scope.Contains(cert.DeptID) {
+				return nil, &deptError{message: fmt.Sprintf("证书%s属于其他部门", object.Name)}
+			}
+		case kindRouter, kindService, kindMiddleware:
+			existing, err := loadObject(dao, object.Kind, object.Name, member.Protocol)
+			if err != nil {
+				return nil, err
+			}
+			if existing == nil {
+				return nil, &validationError{message: fmt.Sprintf("%s不存在: %s", object.Kind, refKey(member.Protocol, object.Name))}
+			}
+			if err := checkOwner(dao, object.Kind, member.Protocol, object.Name, scope); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, &validationError{message: "不支持的对象类型: " + object.Kind}
+		}
+
+		key := member.Kind + ":" + refKey(member.Protocol, member.Name)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		owner, err := dao.GetAppMember(member.Kind, member.Protocol, member.Name)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if err == nil && owner.AppID != appID {
+			return nil, &validationError{message: fmt.Sprintf("%s %s已属于其他应用", member.Kind, member.Name)}
+		}
+		members = append(members, member)
+	}
+	return members, nil
+}
+
+// checkAppDept 检查应用的负责部门在范围内
+func checkAppDept(app traefikModel.TraefikApp, scope sysModel.DeptScope) error {
+	if scope.Contains(app.DeptID) {
+		return nil
+	}
+	return &deptError{message: fmt.Sprintf("应用%s属于其他部门", app.Name)}
+}
+
+// checkAppOwner 检查负责部门和联系人存在
+func checkAppOwner(deptID uint64, contacts []string) error {
+	if err := checkDept(deptID); err != nil {
+		return err
+	}
+	if len(contacts) == 0 {
+		return nil
+	}
+	var existing []string
+	if err := database.DB.Model(&sysModel.User{}).Where("user_name IN ?", contacts).Pluck("user_name", &existing).Error; err != nil {
+		return err
+	}
+	for _, contact := range contacts {
+		found := false
+		for _, candidate := range existing {
+			if candidate == contact {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return &validationError{message: "用户不存在: " + contact}
+		}
+	}
+	return nil
+}
+
+// appCerts 按域名获取证书的状态和有效期，不存在的证书标记为missing
+func appCerts(domains map[string]string, now time.Time) ([]AppCert, error) {
+	result := make([]AppCert, 0, len(domains))
+	if len(domains) == 0 {
+		return result, nil
+	}
+	names := make([]string, 0, len(domains))
+	for domain := range domains {
+		names = append(names, domain)
+	}
+	sort.Strings(names)
+	var certs []sslModel.SSLCert
+	if err := database.DB.Where("domain IN ?", names).Find(&certs).Error; err != nil {
+		return nil, err
+	}
+	byDomain := make(map[string]sslModel.SSLCert, len(certs))
+	for _, cert := range certs {
+		byDomain[cert.Domain] = cert
+	}
+
+	for _, domain := range names {
+		cert, ok := byDomain[domain]
+		if !ok {
+			result = append(result, AppCert{Domain: domain, Source: domains[domain], Missing: true})
+			continue
+		}
+		item := AppCert{Domain: domain, Source: domains[domain], CertID: cert.ID, ApplyStatus: cert.ApplyStatus}
+		if !cert.ValidityEnd.IsZero() {
+			end := cert.ValidityEnd
+			days := int(end.Sub(now).Hours() / 24)
+			if days < 0 {
+				days = 0
+			}
+			item.ValidityEnd, item.ExpiresInDays = &end, &days
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
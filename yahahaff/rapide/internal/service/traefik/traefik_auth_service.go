@@ -0,0 +1,295 @@
+package traefik
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	traefikDAO "github.com/yahahaff/rapide/internal/dao/traefik"
+	sysModel "github.com/yahahaff/rapide/internal/models/sys"
+	traefikModel "github.com/yahahaff/rapide/internal/models/traefik"
+	"github.com/yahahaff/rapide/internal/utils"
+	"github.com/yahahaff/rapide/pkg/config"
+	"github.com/yahahaff/rapide/pkg/database"
+	"github.com/yahahaff/rapide/pkg/jwt"
+	"github.com/yahahaff/rapide/pkg/types"
+	"gorm.io/gorm"
+)
+
+// TraefikAuthService rapide作为Traefik的forwardAuth服务
+// 被保护的路由使用rapide的登录、二步认证和角色，访问策略实时生效，forwardAuth中间件随草稿发布
+type TraefikAuthService struct {
+	traefikDAO *traefikDAO.TraefikDAO
+}
+
+// authMiddlewarePrefix 为路由生成的forwardAuth中间件的名称前缀
+const authMiddlewarePrefix = "rapide-auth-"
+
+// forwardAuthPath rapide提供forwardAuth的路径，后接路由名称
+const forwardAuthPath = "/api/traefik/forwardauth/"
+
+// forwardAuth校验失败的原因
+var (
+	ErrAuthUnauthenticated = errors.New("未登录或登录已过期")
+	ErrAuthForbidden       = errors.New("没有访问该路由的权限")
+)
+
+// AuthIdentity 通过校验的用户，作为请求头传给被保护的应用
+type AuthIdentity struct {
+	UserID   uint64
+	UserName string
+	Roles    []string // 启用的角色编码
+}
+
+// GetPolicies 获取范围内部门的路由的访问策略
+func (as *TraefikAuthService) GetPolicies(scope sysModel.DeptScope) ([]traefikModel.TraefikAuthPolicy, error) {
+	policies, err := as.traefikDAO.GetAuthPolicies()
+	if err != nil {
+		return nil, err
+	}
+	owned, err := ownedKeys(as.traefikDAO, kindRouter, scope)
+	if err != nil || owned == nil {
+		return policies, err
+	}
+	filtered := make([]traefikModel.TraefikAuthPolicy, 0, len(policies))
+	for _, policy := range policies {
+		if owned[refKey("http", policy.Router)] {
+			filtered = append(filtered, policy)
+		}
+	}
+	return filtered, nil
+}
+
+// SavePolicy 设置路由的访问策略，并在草稿中为路由创建forwardAuth中间件，发布后生效
+// 已有策略时只更新角色和用户，立即生效；角色和用户都为空时必须设置anyUser，避免误把路由开放给所有登录用户
+func (as *TraefikAuthService) SavePolicy(router string, roles, users []string, anyUser bool, operator string) (traefikModel.TraefikAuthPolicy, error) {
+	var policy traefikModel.TraefikAuthPolicy
+	authURL := strings.TrimRight(config.GetString("TRAEFIK_FORWARD_AUTH_URL", config.GetString("TRAEFIK_MAINTENANCE_URL", "")), "/")
+	if authURL == "" {
+		return policy, &validationError{message: "未配置TRAEFIK_FORWARD_AUTH_URL，Traefik无法访问rapide进行认证"}
+	}
+	if !anyUser && len(roles) == 0 && len(users) == 0 {
+		return policy, &validationError{message: "请指定允许访问的角色或用户，允许所有登录用户访问时需要设置anyUser"}
+	}
+	if err := checkRoleCodes(roles); err != nil {
+		return policy, err
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		dao := as.traefikDAO.WithTx(tx)
+		existing, err := dao.GetRouter(router, "http")
+		if err != nil {
+			return err
+		}
+		policy, err = dao.GetAuthPolicy(router)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		policy.Router = router
+		policy.Roles = types.JSONSlice(roles)
+		policy.Users = types.JSONSlice(users)
+		policy.AnyUser = anyUser
+		policy.Operator = operator
+		if err := dao.SaveAuthPolicy(&policy); err != nil {
+			return err
+		}
+
+		// forwardAuth中间件指向rapide，认证结果中的用户和角色通过请求头传给应用，覆盖客户端伪造的同名请求头
+		// Cookie同样由认证结果替换，去掉forwardAuth令牌，避免应用拿到令牌
+		// 不信任客户端的X-Forwarded-*请求头，由Traefik按实际请求重新生成，避免伪造原始地址
+		name := authMiddlewarePrefix + router
+		middleware := traefikModel.TraefikMiddleware{
+			Name:     name,
+			Type:     "forwardAuth",
+			Protocol: "http",
+			Provider: "http",
+			Status:   "enabled",
+			Config: types.JSONMap{
+				"address":             authURL + forwardAuthPath + url.PathEscape(router),
+				"trustForwardHeader":  false,
+				"authResponseHeaders": []interface{}{"X-Forwarded-User", "X-Forwarded-Roles", "Cookie"},
+			},
+		}
+		current, err := loadObject(dao, kindMiddleware, name, "http")
+		if err != nil {
+			return err
+		}
+		snapshot := objectSnapshot(middleware)
+		if len(utils.DiffJSON(objectSnapshot(current), snapshot)) > 0 {
+			action := "create"
+			if current != nil {
+				action = "update"
+			}
+			if _, err := applyObject(dao, kindMiddleware, name, "http", snapshot, action, operator, "路由访问策略"); err != nil {
+				return err
+			}
+		}
+
+		// 认证中间件放在最前面，未通过认证的请求不经过其他中间件
+		for _, ref := range existing.Middlewares {
+			if ref == name {
+				return nil
+			}
+		}
+		existing.Middlewares = append(types.JSONSlice{name}, existing.Middlewares...)
+		_, err = applyObject(dao, kindRouter, router, "http", objectSnapshot(existing), "update", operator, "路由访问策略")
+		return err
+	})
+	return policy, err
+}
+
+// DeletePolicy 删除路由的访问策略，同时在草稿中移除路由的forwardAuth中间件
+// 发布前旧的中间件仍然指向rapide，此时因为没有策略所有请求都会被拒绝
+func (as *TraefikAuthService) DeletePolicy(router, operator string) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		dao := as.traefikDAO.WithTx(tx)
+		if _, err := dao.GetAuthPolicy(router); err != nil {
+			return err
+		}
+		if err := dao.DeleteAuthPolicy(router); err != nil {
+			return err
+		}
+
+		name := authMiddlewarePrefix + router
+		existing, err := dao.GetRouter(router, "http")
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err == nil {
+			middlewares := make(types.JSONSlice, 0, len(existing.Middlewares))
+			for _, ref := range existing.Middlewares {
+				if ref != name {
+					middlewares = append(middlewares, ref)
+				}
+			}
+			if len(middlewares) != len(existing.Middlewares) {
+				existing.Middlewares = middlewares
+				if _, err := applyObject(dao, kindRouter, router, "http", objectSnapshot(existing), "update", operator, "删除路由访问策略"); err != nil {
+					return err
+				}
+			}
+		}
+		_, err = applyObject(dao, kindMiddleware, name, "http", nil, "delete", operator, "删除路由访问策略")
+		return err
+	})
+}
+
+// IssueSessionToken 为用户签发forwardAuth专用的短期令牌，有效期由TRAEFIK_FORWARD_AUTH_TOKEN_TTL设置
+// 令牌会随Cookie发送到被保护的应用，rapide的接口不接受该令牌
+func (as *TraefikAuthService) IssueSessionToken(userID uint64, userName string) (string, time.Duration, error) {
+	ttl := time.Duration(config.GetInt("TRAEFIK_FORWARD_AUTH_TOKEN_TTL", 60)) * time.Minute
+	token, err := jwt.NewJWT().IssueAudienceToken(strconv.FormatUint(userID, 10), userName, jwt.AudienceForwardAuth, ttl)
+	return token, ttl, err
+}
+
+// Authorize 校验forwardAuth专用令牌并按路由的访问策略判断用户能否访问
+// 令牌缺失、无效或用户已禁用时返回ErrAuthUnauthenticated，路由没有策略或用户不在策略中时返回ErrAuthForbidden
+// 角色和用户都为空且没有设置anyUser的策略拒绝所有用户
+func (as *TraefikAuthService) Authorize(router, token string) (AuthIdentity, error) {
+	var identity AuthIdentity
+	if token == "" {
+		return identity, ErrAuthUnauthenticated
+	}
+	claims, err := jwt.NewJWT().ParserAudienceToken(token, jwt.AudienceForwardAuth)
+	if err != nil {
+		return identity, ErrAuthUnauthenticated
+	}
+	userID, err := strconv.ParseUint(claims.UserID, 10, 64)
+	if err != nil {
+		return identity, ErrAuthUnauthenticated
+	}
+	var user sysModel.User
+	if err := database.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return identity, ErrAuthUnauthenticated
+		}
+		return identity, err
+	}
+	if user.Status != 1 {
+		return identity, ErrAuthUnauthenticated
+	}
+
+	roles, err := user.GetUserRoles()
+	if err != nil {
+		return identity, err
+	}
+	identity = AuthIdentity{UserID: user.ID, UserName: user.UserName, Roles: make([]string, 0, len(roles))}
+	for _, role := range roles {
+		if role.Status == 1 && role.RoleCode != "" {
+			identity.Roles = append(identity.Roles, role.RoleCode)
+		}
+	}
+	sort.Strings(identity.Roles)
+
+	policy, err := as.traefikDAO.GetAuthPolicy(router)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return identity, ErrAuthForbidden
+	}
+	if err != nil {
+		return identity, err
+	}
+	if policy.AnyUser {
+		return identity, nil
+	}
+	for _, name := range policy.Users {
+		if name == user.UserName {
+			return identity, nil
+		}
+	}
+	if intersects(identity.Roles, []string(policy.Roles)) {
+		return identity, nil
+	}
+	return identity, ErrAuthForbidden
+}
+
+// RedirectAllowed 判断登录后能否跳转回原始地址，只允许http(s)和路由Host规则中的域名，避免开放重定向
+func (as *TraefikAuthService) RedirectAllowed(router, proto, host, uri string) bool {
+	if (proto != "http" && proto != "https") || !strings.HasPrefix(uri, "/") {
+		return false
+	}
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "" {
+		return false
+	}
+	existing, err := as.traefikDAO.GetRouter(router, "http")
+	if err != nil {
+		return false
+	}
+	for _, candidate := range routerCertHosts(existing) {
+		if strings.ToLower(candidate) == host {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRoleCodes 检查策略中的角色编码都存在
+func checkRoleCodes(codes []string) error {
+	if len(codes) == 0 {
+		return nil
+	}
+	var existing []string
+	if err := database.DB.Model(&sysModel.Role{}).Where("role_code IN ?", codes).Pluck("role_code", &existing).Error; err != nil {
+		return err
+	}
+	for _, code := range codes {
+		found := false
+		for _, candidate := range existing {
+			if candidate == code {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return &validationError{message: "角色不存在: " + code}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,336 @@
+package traefik
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	traefikDAO "github.com/yahahaff/rapide/internal/dao/traefik"
+	sslModel "github.com/yahahaff/rapide/internal/models/ssl"
+	sysModel "github.com/yahahaff/rapide/internal/models/sys"
+	traefikModel "github.com/yahahaff/rapide/internal/models/traefik"
+	sslService "github.com/yahahaff/rapide/internal/service/ssl"
+	"github.com/yahahaff/rapide/pkg/config"
+	"github.com/yahahaff/rapide/pkg/database"
+	"github.com/yahahaff/rapide/pkg/traefikrule"
+	"gorm.io/gorm"
+)
+
+// TraefikCertService 为启用TLS的路由自动申请证书
+// 从路由规则的Host和HostSNI中提取域名，没有对应的SSL证书时通过SSL模块申请，并记录路由与证书的关联；
+// 关联的证书随配置下发给Traefik，证书续期后指纹变化会触发重新下发
+type TraefikCertService struct {
+	traefikDAO *traefikDAO.TraefikDAO
+}
+
+// CertSyncResult 一次证书同步的结果
+type CertSyncResult struct {
+	Routers int                            `json:"routers"` // 需要证书的路由数量
+	Issued  []string                       `json:"issued"`  // 本次新申请证书的域名
+	Links   []traefikModel.TraefikCertLink `json:"links"`
+}
+
+// RouterCertificate 下发给Traefik的证书，certFile和keyFile直接使用PEM内容
+type RouterCertificate struct {
+	CertID   uint64
+	Domain   string
+	Routers  []string // 使用该证书的路由，格式为协议/名称
+	CertFile string
+	KeyFile  string
+}
+
+// SyncCertificates 检查所有启用TLS的路由，为缺少证书的域名申请证书并更新关联
+// 申请失败的证书不会自动重试，需要在SSL证书页面处理；未配置TRAEFIK_AUTOCERT_EMAIL时只建立关联不申请
+func (cs *TraefikCertService) SyncCertificates() (CertSyncResult, error) {
+	result := CertSyncResult{Issued: make([]string, 0), Links: make([]traefikModel.TraefikCertLink, 0)}
+	routers, err := cs.traefikDAO.GetAllRouters()
+	if err != nil {
+		return result, err
+	}
+	var certs []sslModel.SSLCert
+	if err := database.DB.Where("status = ? AND apply_status <> ?", 1, "revoked").Find(&certs).Error; err != nil {
+		return result, err
+	}
+
+	// 自动申请的证书归属于使用它的路由的部门
+	owners, err := ownerDepts(cs.traefikDAO, kindRouter)
+	if err != nil {
+		return result, err
+	}
+
+	sort.Slice(routers, func(i, j int) bool {
+		return refKey(routers[i].Protocol, routers[i].Name) < refKey(routers[j].Protocol, routers[j].Name)
+	})
+	email := config.GetString("TRAEFIK_AUTOCERT_EMAIL", "")
+	now := time.Now()
+	for _, router := range routers {
+		if !routerNeedsCert(router) {
+			continue
+		}
+		hosts := routerCertHosts(router)
+		if len(hosts) == 0 {
+			continue
+		}
+		result.Routers++
+
+		for _, host := range hosts {
+			link := traefikModel.TraefikCertLink{Router: router.Name, Protocol: protocolOf(router.Protocol), Host: host}
+			cert := matchCert(certs, host)
+			if cert == nil && email != "" {
+				issued, err := issueCert(host, email, owners[refKey(router.Protocol, router.Name)])
+				if err != nil {
+					link.Status = "failed"
+					link.Message = "申请证书失败: " + err.Error()
+					result.Links = append(result.Links, link)
+					continue
+				}
+				certs = append(certs, issued)
+				cert = &certs[len(certs)-1]
+				result.Issued = append(result.Issued, host)
+			}
+			if cert == nil {
+				link.Status = "missing"
+				link.Message = "没有覆盖该域名的证书，未配置TRAEFIK_AUTOCERT_EMAIL时不会自动申请"
+				result.Links = append(result.Links, link)
+				continue
+			}
+
+			link.CertID = cert.ID
+			link.Domain = cert.Domain
+			switch cert.ApplyStatus {
+			case "success":
+				validityEnd := cert.ValidityEnd
+				link.ValidityEnd = &validityEnd
+				link.Fingerprint = cert.Fingerprint
+				link.Status = "linked"
+				if !validityEnd.IsZero() && !validityEnd.After(now) {
+					link.Status = "expired"
+					link.Message = "证书已过期"
+				}
+			case "failed":
+				link.Status = "failed"
+				link.Message = cert.ErrorMsg
+			default:
+				link.Status = "issuing"
+			}
+			result.Links = append(result.Links, link)
+		}
+	}
+
+	changed := false
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		dao := cs.traefikDAO.WithTx(tx)
+		previous, err := dao.GetCertLinks("", "")
+		if err != nil {
+			return err
+		}
+		changed = certLinkState(previous) != certLinkState(result.Links)
+		return dao.ReplaceCertLinks(result.Links)
+	})
+	if err != nil {
+		return result, err
+	}
+	if changed {
+		notifyConfigChanged()
+	}
+	return result, nil
+}
+
+// GetCertLinks 获取范围内部门的路由与证书的关联，app不为空时只返回该应用的路由
+func (cs *TraefikCertService) GetCertLinks(router, status, app string, scope sysModel.DeptScope) ([]traefikModel.TraefikCertLink, error) {
+	links, err := cs.traefikDAO.GetCertLinks(router, status)
+	if err != nil {
+		return nil, err
+	}
+	var keys map[string]bool
+	if app != "" {
+		if keys, err = appObjectKeys(cs.traefikDAO, app, kindRouter); err != nil {
+			return nil, err
+		}
+	}
+	owned, err := ownedKeys(cs.traefikDAO, kindRouter, scope)
+	if err != nil {
+		return nil, err
+	}
+	if keys == nil && owned == nil {
+		return links, nil
+	}
+	filtered := make([]traefikModel.TraefikCertLink, 0)
+	for _, link := range links {
+		key := refKey(link.Protocol, link.Router)
+		if (keys == nil || keys[key]) && (owned == nil || owned[key]) {
+			filtered = append(filtered, link)
+		}
+	}
+	return filtered, nil
+}
+
+// routerNeedsCert 判断路由是否需要由rapide提供证书：启用了TLS且未关闭自动证书
+// 使用certResolver的路由由Traefik自己申请证书，passthrough的tcp路由由后端处理TLS，udp没有TLS
+func routerNeedsCert(router traefikModel.TraefikRouter) bool {
+	if router.NoAutoCert || router.TLS == nil || protocolOf(router.Protocol) == "udp" {
+		return false
+	}
+	if resolver, _ := router.TLS["certResolver"].(string); resolver != "" {
+		return false
+	}
+	passthrough, _ := router.TLS["passthrough"].(bool)
+	return !passthrough
+}
+
+// routerCertHosts 提取路由规则中Host和HostSNI匹配的域名，跳过取反的条件、通配符、IP以及无法解析的规则
+func routerCertHosts(router traefikModel.TraefikRouter) []string {
+	terms, err := traefikrule.Normalize(router.Rule, protocolOf(router.Protocol), router.RuleSyntax)
+	if err != nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	hosts := make([]string, 0)
+	for _, term := range terms {
+		for _, condition := range term {
+			if condition.Negated || (condition.Name != "Host" && condition.Name != "HostSNI") || len(condition.Args) == 0 {
+				continue
+			}
+			host := strings.TrimSuffix(condition.Args[0], ".")
+			if seen[host] || !certHost(host) {
+				continue
+			}
+			seen[host] = true
+			hosts = append(hosts, host)
+		}
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// certHost 判断是否是可以申请证书的域名，至少包含两级且每一级只由字母、数字和连字符组成
+func certHost(host string) bool {
+	if net.ParseIP(host) != nil || !strings.Contains(host, ".") || len(host) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(host, ".") {
+		if label == "" || len(label) > 63 || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return false
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// matchCert 查找覆盖域名的证书，同名证书优先于通配符证书，已申请成功的证书优先
+func matchCert(certs []sslModel.SSLCert, host string) *sslModel.SSLCert {
+	var candidates []*sslModel.SSLCert
+	_, parent, _ := strings.Cut(host, ".")
+	for i := range certs {
+		if strings.EqualFold(certs[i].Domain, host) {
+			candidates = append(candidates, &certs[i])
+		}
+	}
+	for i := range certs {
+		if strings.EqualFold(certs[i].Domain, "*."+parent) {
+			candidates = append(candidates, &certs[i])
+		}
+	}
+	for _, cert := range candidates {
+		if cert.ApplyStatus == "success" {
+			return cert
+		}
+	}
+	if len(candidates) > 0 {
+		return candidates[0]
+	}
+	return nil
+}
+
+// issueCert 通过SSL模块为域名申请证书，申请在后台进行，返回刚创建的证书记录
+func issueCert(host, email string, deptID uint64) (sslModel.SSLCert, error) {
+	cert := sslModel.SSLCert{
+		Domain:        host,
+		CommonName:    host,
+		Email:         email,
+		Type:          "DV",
+		Algorithm:     config.GetString("TRAEFIK_AUTOCERT_ALGORITHM", "RSA-2048"),
+		Provider:      config.GetString("TRAEFIK_AUTOCERT_PROVIDER", "letsencrypt"),
+		ChallengeType: config.GetString("TRAEFIK_AUTOCERT_CHALLENGE", "http-01"),
+		ApplyStatus:   "pending",
+		AutoRenew:     true,
+		RenewStatus:   "idle",
+		Status:        1,
+		DeptID:        deptID,
+	}
+	id, err := (&sslService.SSLCertService{}).CreateSSLCert(cert)
+	if err != nil {
+		return cert, err
+	}
+	// 同一域名可能已有吊销或禁用的旧证书，按ID读取刚创建的记录
+	err = database.DB.Where("id = ?", id).First(&cert).Error
+	return cert, err
+}
+
+// certLinkState 汇总会影响下发配置的关联状态，用于判断同步后是否需要重新下发
+func certLinkState(links []traefikModel.TraefikCertLink) string {
+	state := make([]string, 0, len(links))
+	for _, link := range links {
+		if link.Status == "linked" {
+			state = append(state, fmt.Sprintf("%s/%s/%s/%d/%s", link.Protocol, link.Router, link.Host, link.CertID, link.Fingerprint))
+		}
+	}
+	sort.Strings(state)
+	return strings.Join(state, "\n")
+}
+
+// loadRouterCertificates 加载配置中路由已关联的证书，证书内容实时读取，续期后的证书直接生效
+func loadRouterCertificates(dao *traefikDAO.TraefikDAO, routers []traefikModel.TraefikRouter) ([]RouterCertificate, error) {
+	links, err := dao.GetCertLinks("", "linked")
+	if err != nil || len(links) == 0 {
+		return nil, err
+	}
+	present := make(map[string]bool)
+	for _, router := range routers {
+		present[refKey(router.Protocol, router.Name)] = true
+	}
+	routersByCert := make(map[uint64][]string)
+	linked := make(map[string]bool)
+	var ids []uint64
+	for _, link := range links {
+		key := refKey(link.Protocol, link.Router)
+		if !present[key] {
+			continue
+		}
+		if _, ok := routersByCert[link.CertID]; !ok {
+			ids = append(ids, link.CertID)
+		}
+		if !linked[fmt.Sprintf("%d %s", link.CertID, key)] {
+			linked[fmt.Sprintf("%d %s", link.CertID, key)] = true
+			routersByCert[link.CertID] = append(routersByCert[link.CertID], key)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var certs []sslModel.SSLCert
+	if err := database.DB.Where("id IN ? AND status = ? AND apply_status = ?", ids, 1, "success").Order("domain asc").Find(&certs).Error; err != nil {
+		return nil, err
+	}
+	certificates := make([]RouterCertificate, 0, len(certs))
+	for _, cert := range certs {
+		if cert.Certificate == "" || cert.PrivateKey == "" {
+			continue
+		}
+		certificates = append(certificates, RouterCertificate{
+			CertID:   cert.ID,
+			Domain:   cert.Domain,
+			Routers:  routersByCert[cert.ID],
+			CertFile: cert.Certificate,
+			KeyFile:  cert.PrivateKey,
+		})
+	}
+	return certificates, nil
+}
@@ -0,0 +1,608 @@
+package traefik
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	traefikDAO "github.com/yahahaff/rapide/internal/dao/traefik"
+	sysModel "github.com/yahahaff/rapide/internal/models/sys"
+	traefikModel "github.com/yahahaff/rapide/internal/models/traefik"
+	"github.com/yahahaff/rapide/internal/utils"
+	"github.com/yahahaff/rapide/pkg/database"
+	"github.com/yahahaff/rapide/pkg/types"
+	"gorm.io/gorm"
+)
+
+// 支持修订记录的配置对象类型
+const (
+	kindRouter     = "router"
+	kindService    = "service"
+	kindMiddleware = "middleware"
+
+	kindTLSOption        = "tlsOption"
+	kindTLSStore         = "tlsStore"
+	kindServersTransport = "serversTransport"
+)
+
+// protocolTLS TLS选项和证书存储不区分协议，修订记录和引用键中使用tls作为协议
+const protocolTLS = "tls"
+
+// TraefikConfigService Traefik配置对象管理服务，修改的是草稿，所有变更都会记录修订历史
+type TraefikConfigService struct {
+	traefikDAO *traefikDAO.TraefikDAO
+}
+
+// RevisionDetail 修订记录详情及结构化差异
+type RevisionDetail struct {
+	Revision traefikModel.TraefikRevision `json:"revision"`
+	Changes  []utils.DiffChange           `json:"changes"`        // 本次修订前后的差异
+	Current  types.JSONMap                `json:"current"`        // 对象当前的状态，已删除时为空
+	Pending  []utils.DiffChange           `json:"pendingChanges"` // 本次修订之后到当前状态的差异
+}
+
+// RollbackResult 回滚结果
+type RollbackResult struct {
+	DryRun  bool           `json:"dryRun"`
+	Target  time.Time      `json:"target"`
+	Items   []ImportItem   `json:"items"`
+	Summary map[string]int `json:"summary"`
+}
+
+// ListRouters 获取范围内所有路由，包含已禁用的路由，app不为空时只返回该应用的路由
+func (cs *TraefikConfigService) ListRouters(app string, scope sysModel.DeptScope) ([]traefikModel.TraefikRouter, error) {
+	routers, err := cs.traefikDAO.ListRouters()
+	if err != nil {
+		return nil, err
+	}
+	owned, err := ownedKeys(cs.traefikDAO, kindRouter, scope)
+	if err != nil {
+		return nil, err
+	}
+	var members map[string]bool
+	if app != "" {
+		if members, err = appObjectKeys(cs.traefikDAO, app, kindRouter); err != nil {
+			return nil, err
+		}
+	}
+	filtered := make([]traefikModel.TraefikRouter, 0, len(routers))
+	for _, item := range routers {
+		key := refKey(item.Protocol, item.Name)
+		if (owned == nil || owned[key]) && (members == nil || members[key]) {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered, nil
+}
+
+// ListServices 获取范围内所有服务，包含已禁用的服务，app不为空时只返回该应用的服务
+func (cs *TraefikConfigService) ListServices(app string, scope sysModel.DeptScope) ([]traefikModel.TraefikService, error) {
+	services, err := cs.traefikDAO.ListServices()
+	if err != nil {
+		return nil, err
+	}
+	owned, err := ownedKeys(cs.traefikDAO, kindService, scope)
+	if err != nil {
+		return nil, err
+	}
+	var members map[string]bool
+	if app != "" {
+		if members, err = appObjectKeys(cs.traefikDAO, app, kindService); err != nil {
+			return nil, err
+		}
+	}
+	filtered := make([]traefikModel.TraefikService, 0, len(services))
+	for _, item := range services {
+		key := refKey(item.Protocol, item.Name)
+		if (owned == nil || owned[key]) && (members == nil || members[key]) {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered, nil
+}
+
+// ListMiddlewares 获取范围内所有中间件，包含已禁用的中间件，app不为空时只返回该应用的中间件
+func (cs *TraefikConfigService) ListMiddlewares(app string, scope sysModel.DeptScope) ([]traefikModel.TraefikMiddleware, error) {
+	middlewares, err := cs.traefikDAO.ListMiddlewares()
+	if err != nil {
+		return nil, err
+	}
+	owned, err := ownedKeys(cs.traefikDAO, kindMiddleware, scope)
+	if err != nil {
+		return nil, err
+	}
+	var members map[string]bool
+	if app != "" {
+		if members, err = appObjectKeys(cs.traefikDAO, app, kindMiddleware); err != nil {
+			return nil, err
+		}
+	}
+	filtered := make([]traefikModel.TraefikMiddleware, 0, len(middlewares))
+	for _, item := range middlewares {
+		key := refKey(item.Protocol, item.Name)
+		if (owned == nil || owned[key]) && (members == nil || members[key]) {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered, nil
+}
+
+// ListTLSOptions 获取所有TLS选项，包含已禁用的
+func (cs *TraefikConfigService) ListTLSOptions() ([]traefikModel.TraefikTLSOption, error) {
+	return cs.traefikDAO.ListTLSOptions()
+}
+
+// ListTLSStores 获取所有TLS证书存储，包含已禁用的
+func (cs *TraefikConfigService) ListTLSStores() ([]traefikModel.TraefikTLSStore, error) {
+	return cs.traefikDAO.ListTLSStores()
+}
+
+// ListServersTransports 获取所有serversTransport，包含已禁用的
+func (cs *TraefikConfigService) ListServersTransports() ([]traefikModel.TraefikServersTransport, error) {
+	return cs.traefikDAO.ListServersTransports()
+}
+
+// CreateRouter 创建路由，deptID为所属部门，0表示不归属任何部门
+func (cs *TraefikConfigService) CreateRouter(router traefikModel.TraefikRouter, deptID uint64, operator string) (interface{}, error) {
+	return cs.create(kindRouter, router.Name, router.Protocol, router, deptID, operator)
+}
+
+// CreateService 创建服务，deptID为所属部门，0表示不归属任何部门
+func (cs *TraefikConfigService) CreateService(service traefikModel.TraefikService, deptID uint64, operator string) (interface{}, error) {
+	return cs.create(kindService, service.Name, service.Protocol, service, deptID, operator)
+}
+
+// CreateMiddleware 创建中间件，deptID为所属部门，0表示不归属任何部门
+func (cs *TraefikConfigService) CreateMiddleware(middleware traefikModel.TraefikMiddleware, deptID uint64, operator string) (interface{}, error) {
+	return cs.create(kindMiddleware, middleware.Name, middleware.Protocol, middleware, deptID, operator)
+}
+
+// CreateTLSOption 创建TLS选项
+func (cs *TraefikConfigService) CreateTLSOption(option traefikModel.TraefikTLSOption, operator string) (interface{}, error) {
+	if err := validateTLSOption(option.Config); err != nil {
+		return nil, err
+	}
+	return cs.create(kindTLSOption, option.Name, protocolTLS, option, 0, operator)
+}
+
+// CreateTLSStore 创建TLS证书存储
+func (cs *TraefikConfigService) CreateTLSStore(store traefikModel.TraefikTLSStore, operator string) (interface{}, error) {
+	if err := validateTLSStore(store.Config); err != nil {
+		return nil, err
+	}
+	return cs.create(kindTLSStore, store.Name, protocolTLS, store, 0, operator)
+}
+
+// CreateServersTransport 创建serversTransport
+func (cs *TraefikConfigService) CreateServersTransport(transport traefikModel.TraefikServersTransport, operator string) (interface{}, error) {
+	if err := validateServersTransport(transport.Protocol, transport.Config); err != nil {
+		return nil, err
+	}
+	return cs.create(kindServersTransport, transport.Name, transport.Protocol, transport, 0, operator)
+}
+
+// UpdateRouter 更新路由，名称和协议以路径参数为准
+func (cs *TraefikConfigService) UpdateRouter(name, protocol string, router traefikModel.TraefikRouter, operator string) (interface{}, error) {
+	return cs.update(kindRouter, name, protocol, router, operator)
+}
+
+// UpdateService 更新服务，名称和协议以路径参数为准
+func (cs *TraefikConfigService) UpdateService(name, protocol string, service traefikModel.TraefikService, operator string) (interface{}, error) {
+	return cs.update(kindService, name, protocol, service, operator)
+}
+
+// UpdateMiddleware 更新中间件，名称和协议以路径参数为准
+func (cs *TraefikConfigService) UpdateMiddleware(name, protocol string, middleware traefikModel.TraefikMiddleware, operator string) (interface{}, error) {
+	return cs.update(kindMiddleware, name, protocol, middleware, operator)
+}
+
+// UpdateTLSOption 更新TLS选项，名称以路径参数为准
+func (cs *TraefikConfigService) UpdateTLSOption(name string, option traefikModel.TraefikTLSOption, operator string) (interface{}, error) {
+	if err := validateTLSOption(option.Config); err != nil {
+		return nil, err
+	}
+	return cs.update(kindTLSOption, name, protocolTLS, option, operator)
+}
+
+// UpdateTLSStore 更新TLS证书存储，名称以路径参数为准
+func (cs *TraefikConfigService) UpdateTLSStore(name string, store traefikModel.TraefikTLSStore, operator string) (interface{}, error) {
+	if err := validateTLSStore(store.Config); err != nil {
+		return nil, err
+	}
+	return cs.update(kindTLSStore, name, protocolTLS, store, operator)
+}
+
+// UpdateServersTransport 更新serversTransport，名称和协议以路径参数为准
+func (cs *TraefikConfigService) UpdateServersTransport(name, protocol string, transport traefikModel.TraefikServersTransport, operator string) (interface{}, error) {
+	if err := validateServersTransport(protocol, transport.Config); err != nil {
+		return nil, err
+	}
+	return cs.update(kindServersTransport, name, protocol, transport, operator)
+}
+
+// DeleteObject 删除配置对象，删除前的内容保存在修订记录中
+func (cs *TraefikConfigService) DeleteObject(kind, name, protocol, operator string) error {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		dao := cs.traefikDAO.WithTx(tx)
+		existing, err := loadObject(dao, kind, name, protocol)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return gorm.ErrRecordNotFound
+		}
+		if _, err := applyObject(dao, kind, name, protocol, nil, "delete", operator, ""); err != nil {
+			return err
+		}
+		if ownedKind(kind) {
+			return dao.DeleteOwner(kind, protocolOf(protocol), name)
+		}
+		return nil
+	})
+	return err
+}
+
+// GetRevisions 分页获取修订记录，不指定对象时返回全局历史
+// 非超级管理员只能看到当前属于范围内部门的路由、服务和中间件的记录
+func (cs *TraefikConfigService) GetRevisions(filter traefikDAO.RevisionFilter, scope sysModel.DeptScope, page, size int) ([]traefikModel.TraefikRevision, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 || size > 100 {
+		size = 20
+	}
+	return cs.traefikDAO.GetRevisions(filter, scope, page, size)
+}
+
+// GetRevisionDetail 获取修订记录及其结构化差异
+func (cs *TraefikConfigService) GetRevisionDetail(id uint64, scope sysModel.DeptScope) (RevisionDetail, error) {
+	revision, err := cs.traefikDAO.GetRevisionByID(id)
+	if err != nil {
+		return RevisionDetail{}, err
+	}
+	if !scope.All {
+		// 已删除的对象没有所属部门，其修订记录只有超级管理员可以查看
+		owner, err := cs.traefikDAO.GetOwner(revision.Kind, revision.Protocol, revision.Name)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return RevisionDetail{}, err
+		}
+		if err != nil || !scope.Contains(owner.DeptID) {
+			return RevisionDetail{}, &deptError{message: fmt.Sprintf("%s %s属于其他部门", revision.Kind, refKey(revision.Protocol, revision.Name))}
+		}
+	}
+
+	current, err := loadObject(cs.traefikDAO, revision.Kind, revision.Name, revision.Protocol)
+	if err != nil {
+		return RevisionDetail{}, err
+	}
+	currentSnapshot := objectSnapshot(current)
+
+	return RevisionDetail{
+		Revision: revision,
+		Changes:  utils.DiffJSON(revision.Before, revision.After),
+		Current:  currentSnapshot,
+		Pending:  utils.DiffJSON(revision.After, currentSnapshot),
+	}, nil
+}
+
+// RollbackRevision 撤销一次修订，把对象恢复到该修订之前的状态
+// 撤销创建会删除对象，撤销删除会重新创建对象
+func (cs *TraefikConfigService) RollbackRevision(id uint64, operator string) (ImportItem, error) {
+	var item ImportItem
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		dao := cs.traefikDAO.WithTx(tx)
+		revision, err := dao.GetRevisionByID(id)
+		if err != nil {
+			return err
+		}
+
+		item, err = rollbackObject(dao, revision.Kind, revision.Name, revision.Protocol, revision.Before, operator, fmt.Sprintf("撤销修订#%d", revision.ID), false)
+		return err
+	})
+	return item, err
+}
+
+// RollbackToTime 把全部配置恢复到指定时间点的状态，dryRun只计算差异不写入
+func (cs *TraefikConfigService) RollbackToTime(target time.Time, operator string, dryRun bool) (RollbackResult, error) {
+	result := RollbackResult{
+		DryRun:  dryRun,
+		Target:  target,
+		Items:   make([]ImportItem, 0),
+		Summary: map[string]int{"create": 0, "update": 0, "delete": 0, "unchanged": 0},
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		dao := cs.traefikDAO.WithTx(tx)
+		revisions, err := dao.GetRevisionsSince(target)
+		if err != nil {
+			return err
+		}
+
+		// 时间点之后每个对象的第一条修订的Before即为该对象在时间点上的状态
+		seen := make(map[string]bool)
+		remark := "回滚到" + target.Format(time.DateTime)
+		for _, revision := range revisions {
+			key := revision.Kind + "/" + refKey(revision.Protocol, revision.Name)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			item, err := rollbackObject(dao, revision.Kind, revision.Name, revision.Protocol, revision.Before, operator, remark, dryRun)
+			if err != nil {
+				return err
+			}
+			result.Items = append(result.Items, item)
+			result.Summary[item.Action]++
+		}
+		return nil
+	})
+	return result, err
+}
+
+// create 创建对象并设置所属部门，同名同协议的对象已存在时返回校验错误
+func (cs *TraefikConfigService) create(kind, name, protocol string, object interface{}, deptID uint64, operator string) (interface{}, error) {
+	var saved interface{}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		dao := cs.traefikDAO.WithTx(tx)
+		existing, err := loadObject(dao, kind, name, protocol)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			return &validationError{message: fmt.Sprintf("%s %s@%s 已存在", kind, name, protocolOf(protocol))}
+		}
+		saved, err = applyObject(dao, kind, name, protocol, objectSnapshot(object), "create", operator, "")
+		if err != nil {
+			return err
+		}
+		return saveObjectOwner(dao, kind, protocol, name, deptID, operator)
+	})
+	return saved, err
+}
+
+// update 更新对象，对象不存在时返回gorm.ErrRecordNotFound
+func (cs *TraefikConfigService) update(kind, name, protocol string, object interface{}, operator string) (interface{}, error) {
+	var saved interface{}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		dao := cs.traefikDAO.WithTx(tx)
+		existing, err := loadObject(dao, kind, name, protocol)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return gorm.ErrRecordNotFound
+		}
+		saved, err = applyObject(dao, kind, name, protocol, objectSnapshot(object), "update", operator, "")
+		return err
+	})
+	return saved, err
+}
+
+// rollbackObject 把对象恢复为目标快照，快照为空表示对象不应存在
+func rollbackObject(dao *traefikDAO.TraefikDAO, kind, name, protocol string, target types.JSONMap, operator, remark string, dryRun bool) (ImportItem, error) {
+	item := ImportItem{Kind: kind, Name: name, Protocol: protocolOf(protocol)}
+
+	current, err := loadObject(dao, kind, name, protocol)
+	if err != nil {
+		return item, err
+	}
+	currentSnapshot := objectSnapshot(current)
+
+	item.Changes = utils.DiffJSON(currentSnapshot, target)
+	switch {
+	case len(item.Changes) == 0:
+		item.Action = "unchanged"
+		return item, nil
+	case current == nil:
+		item.Action = "create"
+	case target == nil:
+		item.Action = "delete"
+	default:
+		item.Action = "update"
+	}
+
+	if dryRun {
+		return item, nil
+	}
+	_, err = applyObject(dao, kind, name, protocol, target, "rollback", operator, remark)
+	return item, err
+}
+
+// applyObject 将对象写为给定快照并记录修订，快照为空时删除对象
+// 所有对配置对象的修改都应通过它完成，以保证修订历史完整
+func applyObject(dao *traefikDAO.TraefikDAO, kind, name, protocol string, snapshot types.JSONMap, action, operator, remark string) (interface{}, error) {
+	existing, err := loadObject(dao, kind, name, protocol)
+	if err != nil {
+		return nil, err
+	}
+
+	var saved interface{}
+	if snapshot == nil {
+		if existing == nil {
+			return nil, nil
+		}
+		if err := deleteObject(dao, kind, name, protocol); err != nil {
+			return nil, err
+		}
+	} else {
+		if saved, err = saveObject(dao, kind, name, protocol, snapshot, existing); err != nil {
+			return nil, err
+		}
+	}
+
+	revision := traefikModel.TraefikRevision{
+		Kind:     kind,
+		Name:     name,
+		Protocol: protocolOf(protocol),
+		Action:   action,
+		Before:   objectSnapshot(existing),
+		After:    objectSnapshot(saved),
+		Operator: operator,
+		Remark:   remark,
+	}
+	if err := dao.CreateRevision(&revision); err != nil {
+		return nil, err
+	}
+	return saved, nil
+}
+
+// loadObject 获取指定对象，不存在时返回nil
+func loadObject(dao *traefikDAO.TraefikDAO, kind, name, protocol string) (interface{}, error) {
+	var object interface{}
+	var err error
+	switch kind {
+	case kindRouter:
+		object, err = dao.GetRouter(name, protocolOf(protocol))
+	case kindService:
+		object, err = dao.GetService(name, protocolOf(protocol))
+	case kindMiddleware:
+		object, err = dao.GetMiddleware(name, protocolOf(protocol))
+	case kindTLSOption:
+		object, err = dao.GetTLSOption(name)
+	case kindTLSStore:
+		object, err = dao.GetTLSStore(name)
+	case kindServersTransport:
+		object, err = dao.GetServersTransport(name, protocolOf(protocol))
+	default:
+		return nil, &validationError{message: "不支持的对象类型: " + kind}
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+// saveObject 按快照创建或覆盖对象，保留已有对象的ID和创建时间
+func saveObject(dao *traefikDAO.TraefikDAO, kind, name, protocol string, snapshot types.JSONMap, existing interface{}) (interface{}, error) {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case kindRouter:
+		var router traefikModel.TraefikRouter
+		if err := json.Unmarshal(data, &router); err != nil {
+			return nil, err
+		}
+		router.Name, router.Protocol = name, protocolOf(protocol)
+		if current, ok := existing.(traefikModel.TraefikRouter); ok {
+			router.BaseModel, router.CreatedAt = current.BaseModel, current.CreatedAt
+			err = dao.UpdateRouter(&router)
+		} else {
+			err = dao.CreateRouter(&router)
+		}
+		return router, err
+	case kindService:
+		var service traefikModel.TraefikService
+		if err := json.Unmarshal(data, &service); err != nil {
+			return nil, err
+		}
+		service.Name, service.Protocol = name, protocolOf(protocol)
+		if current, ok := existing.(traefikModel.TraefikService); ok {
+			service.BaseModel, service.CreatedAt = current.BaseModel, current.CreatedAt
+			err = dao.UpdateService(&service)
+		} else {
+			err = dao.CreateService(&service)
+		}
+		return service, err
+	case kindMiddleware:
+		var middleware traefikModel.TraefikMiddleware
+		if err := json.Unmarshal(data, &middleware); err != nil {
+			return nil, err
+		}
+		middleware.Name, middleware.Protocol = name, protocolOf(protocol)
+		if current, ok := existing.(traefikModel.TraefikMiddleware); ok {
+			middleware.BaseModel, middleware.CreatedAt = current.BaseModel, current.CreatedAt
+			err = dao.UpdateMiddleware(&middleware)
+		} else {
+			err = dao.CreateMiddleware(&middleware)
+		}
+		return middleware, err
+	case kindTLSOption:
+		var option traefikModel.TraefikTLSOption
+		if err := json.Unmarshal(data, &option); err != nil {
+			return nil, err
+		}
+		option.Name = name
+		if current, ok := existing.(traefikModel.TraefikTLSOption); ok {
+			option.BaseModel, option.CreatedAt = current.BaseModel, current.CreatedAt
+			err = dao.UpdateTLSOption(&option)
+		} else {
+			err = dao.CreateTLSOption(&option)
+		}
+		return option, err
+	case kindTLSStore:
+		var store traefikModel.TraefikTLSStore
+		if err := json.Unmarshal(data, &store); err != nil {
+			return nil, err
+		}
+		store.Name = name
+		if current, ok := existing.(traefikModel.TraefikTLSStore); ok {
+			store.BaseModel, store.CreatedAt = current.BaseModel, current.CreatedAt
+			err = dao.UpdateTLSStore(&store)
+		} else {
+			err = dao.CreateTLSStore(&store)
+		}
+		return store, err
+	case kindServersTransport:
+		var transport traefikModel.TraefikServersTransport
+		if err := json.Unmarshal(data, &transport); err != nil {
+			return nil, err
+		}
+		transport.Name, transport.Protocol = name, protocolOf(protocol)
+		if current, ok := existing.(traefikModel.TraefikServersTransport); ok {
+			transport.BaseModel, transport.CreatedAt = current.BaseModel, current.CreatedAt
+			err = dao.UpdateServersTransport(&transport)
+		} else {
+			err = dao.CreateServersTransport(&transport)
+		}
+		return transport, err
+	default:
+		return nil, &validationError{message: "不支持的对象类型: " + kind}
+	}
+}
+
+// deleteObject 删除指定对象
+func deleteObject(dao *traefikDAO.TraefikDAO, kind, name, protocol string) error {
+	switch kind {
+	case kindRouter:
+		return dao.DeleteRouter(name, protocolOf(protocol))
+	case kindService:
+		return dao.DeleteService(name, protocolOf(protocol))
+	case kindMiddleware:
+		return dao.DeleteMiddleware(name, protocolOf(protocol))
+	case kindTLSOption:
+		return dao.DeleteTLSOption(name)
+	case kindTLSStore:
+		return dao.DeleteTLSStore(name)
+	case kindServersTransport:
+		return dao.DeleteServersTransport(name, protocolOf(protocol))
+	default:
+		return &validationError{message: "不支持的对象类型: " + kind}
+	}
+}
+
+// objectSnapshot 把对象转换为修订快照，去掉ID和时间戳，对象为空时返回nil
+func objectSnapshot(object interface{}) types.JSONMap {
+	if object == nil {
+		return nil
+	}
+	data, err := json.Marshal(object)
+	if err != nil {
+		return nil
+	}
+	var snapshot types.JSONMap
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil
+	}
+	delete(snapshot, "id")
+	delete(snapshot, "created_at")
+	delete(snapshot, "updated_at")
+	return snapshot
+}
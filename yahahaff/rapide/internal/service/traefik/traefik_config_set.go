@@ -0,0 +1,275 @@
+package traefik
+
+import (
+	"strings"
+
+	traefikDAO "github.com/yahahaff/rapide/internal/dao/traefik"
+	traefikModel "github.com/yahahaff/rapide/internal/models/traefik"
+)
+
+// ConfigSet 一组Traefik动态配置对象，渲染、限定范围等操作都基于它进行
+type ConfigSet struct {
+	Routers           []traefikModel.TraefikRouter           `json:"routers"`
+	Services          []traefikModel.TraefikService          `json:"services"`
+	Middlewares       []traefikModel.TraefikMiddleware       `json:"middlewares"`
+	TLSOptions        []traefikModel.TraefikTLSOption        `json:"tlsOptions,omitempty"`
+	TLSStores         []traefikModel.TraefikTLSStore         `json:"tlsStores,omitempty"`
+	ServersTransports []traefikModel.TraefikServersTransport `json:"serversTransports,omitempty"`
+	Certificates      []RouterCertificate                    `json:"-"` // 路由关联的证书，只在下发时加载，不保存到快照
+}
+
+// loadEnabledConfigSet 加载草稿中所有启用的配置对象
+func loadEnabledConfigSet(dao *traefikDAO.TraefikDAO) (ConfigSet, error) {
+	// 获取所有启用的路由
+	routers, err := dao.GetAllRouters()
+	if err != nil {
+		return ConfigSet{}, err
+	}
+
+	// 获取所有启用的服务
+	services, err := dao.GetAllServices()
+	if err != nil {
+		return ConfigSet{}, err
+	}
+
+	// 获取所有启用的中间件
+	middlewares, err := dao.GetAllMiddlewares()
+	if err != nil {
+		return ConfigSet{}, err
+	}
+
+	set := ConfigSet{Routers: routers, Services: services, Middlewares: middlewares}
+	if set.TLSOptions, err = dao.GetAllTLSOptions(); err != nil {
+		return ConfigSet{}, err
+	}
+	if set.TLSStores, err = dao.GetAllTLSStores(); err != nil {
+		return ConfigSet{}, err
+	}
+	if set.ServersTransports, err = dao.GetAllServersTransports(); err != nil {
+		return ConfigSet{}, err
+	}
+	return set, nil
+}
+
+// scopeConfigSet 按Traefik实例的标签和入口点限定配置范围
+// 路由按标签和入口点筛选，服务和中间件保留被选中路由引用到的，以及标签匹配的
+func scopeConfigSet(set ConfigSet, instance traefikModel.TraefikInstance) ConfigSet {
+	if len(instance.Tags) == 0 && len(instance.EntryPoints) == 0 {
+		return set
+	}
+
+	var routers []traefikModel.TraefikRouter
+	for _, router := range set.Routers {
+		if len(instance.Tags) > 0 && !intersects(router.Tags, instance.Tags) {
+			continue
+		}
+		if len(instance.EntryPoints) > 0 && !intersects(router.EntryPoints, instance.EntryPoints) {
+			continue
+		}
+		routers = append(routers, router)
+	}
+
+	return withReferences(set, routers, func(tags []string) bool {
+		return len(instance.Tags) > 0 && intersects(tags, instance.Tags)
+	})
+}
+
+// withReferences 以选中的路由为基础，补充它们直接或间接引用的服务、中间件、TLS选项、serversTransport和证书
+// include不为空时，使其返回true的对象即使未被引用也会保留；TLS证书存储对所有路由生效，总是保留
+func withReferences(set ConfigSet, routers []traefikModel.TraefikRouter, include func(tags []string) bool) ConfigSet {
+	scoped := ConfigSet{Routers: routers, TLSStores: set.TLSStores}
+	serviceRefs := make(map[string]bool)
+	middlewareRefs := make(map[string]bool)
+	optionRefs := make(map[string]bool)
+	transportRefs := make(map[string]bool)
+	included := func(tags []string) bool {
+		return include != nil && include(tags)
+	}
+
+	for _, router := range routers {
+		if name, ok := localRefName(router.Service); ok {
+			serviceRefs[refKey(router.Protocol, name)] = true
+		}
+		for _, middleware := range router.Middlewares {
+			if name, ok := localRefName(middleware); ok {
+				middlewareRefs[refKey(router.Protocol, name)] = true
+			}
+		}
+		if name, ok := routerTLSOption(router); ok {
+			optionRefs[name] = true
+		}
+	}
+
+	// 加权和镜像服务会引用其他服务，需要逐层展开直到没有新的引用
+	for changed := true; changed; {
+		changed = false
+		for _, service := range set.Services {
+			key := refKey(service.Protocol, service.Name)
+			if !serviceRefs[key] && !included(service.Tags) {
+				continue
+			}
+			if !serviceRefs[key] {
+				serviceRefs[key] = true
+				changed = true
+			}
+			for _, child := range childServiceNames(service) {
+				if !serviceRefs[refKey(service.Protocol, child)] {
+					serviceRefs[refKey(service.Protocol, child)] = true
+					changed = true
+				}
+			}
+		}
+	}
+
+	// chain中间件会引用其他中间件，同样逐层展开
+	for changed := true; changed; {
+		changed = false
+		for _, middleware := range set.Middlewares {
+			key := refKey(middleware.Protocol, middleware.Name)
+			if !middlewareRefs[key] && !included(middleware.Tags) {
+				continue
+			}
+			if !middlewareRefs[key] {
+				middlewareRefs[key] = true
+				changed = true
+			}
+			for _, child := range chainMiddlewareNames(middleware) {
+				if !middlewareRefs[refKey(middleware.Protocol, child)] {
+					middlewareRefs[refKey(middleware.Protocol, child)] = true
+					changed = true
+				}
+			}
+		}
+	}
+
+	for _, service := range set.Services {
+		if serviceRefs[refKey(service.Protocol, service.Name)] {
+			scoped.Services = append(scoped.Services, service)
+			if name, ok := serviceTransport(service); ok {
+				transportRefs[refKey(service.Protocol, name)] = true
+			}
+		}
+	}
+	for _, middleware := range set.Middlewares {
+		if middlewareRefs[refKey(middleware.Protocol, middleware.Name)] {
+			scoped.Middlewares = append(scoped.Middlewares, middleware)
+		}
+	}
+
+	routerRefs := make(map[string]bool)
+	for _, router := range routers {
+		routerRefs[refKey(router.Protocol, router.Name)] = true
+	}
+	for _, certificate := range set.Certificates {
+		for _, ref := range certificate.Routers {
+			if routerRefs[ref] {
+				scoped.Certificates = append(scoped.Certificates, certificate)
+				break
+			}
+		}
+	}
+
+	for _, option := range set.TLSOptions {
+		if optionRefs[option.Name] || included(option.Tags) {
+			scoped.TLSOptions = append(scoped.TLSOptions, option)
+		}
+	}
+	for _, transport := range set.ServersTransports {
+		if transportRefs[refKey(transport.Protocol, transport.Name)] || included(transport.Tags) {
+			scoped.ServersTransports = append(scoped.ServersTransports, transport)
+		}
+	}
+
+	return scoped
+}
+
+// routerTLSOption 获取路由通过tls.options引用的本Provider中的TLS选项名称
+func routerTLSOption(router traefikModel.TraefikRouter) (string, bool) {
+	ref, _ := router.TLS["options"].(string)
+	return localRefName(ref)
+}
+
+// serviceTransport 获取负载均衡服务引用的本Provider中的serversTransport名称
+func serviceTransport(service traefikModel.TraefikService) (string, bool) {
+	ref, _ := service.LoadBalancer["serversTransport"].(string)
+	return localRefName(ref)
+}
+
+// childServiceNames 获取加权服务和镜像服务引用的子服务名称
+func childServiceNames(service traefikModel.TraefikService) []string {
+	var names []string
+	collect := func(value interface{}) {
+		if name, ok := value.(string); ok {
+			if local, ok := localRefName(name); ok {
+				names = append(names, local)
+			}
+		}
+	}
+
+	if items, ok := service.Weighted["services"].([]interface{}); ok {
+		for _, item := range items {
+			if child, ok := item.(map[string]interface{}); ok {
+				collect(child["name"])
+			}
+		}
+	}
+
+	collect(service.Mirror["service"])
+	if items, ok := service.Mirror["mirrors"].([]interface{}); ok {
+		for _, item := range items {
+			if child, ok := item.(map[string]interface{}); ok {
+				collect(child["name"])
+			}
+		}
+	}
+
+	return names
+}
+
+// chainMiddlewareNames 获取chain中间件引用的中间件名称
+func chainMiddlewareNames(middleware traefikModel.TraefikMiddleware) []string {
+	if middleware.Type != "chain" {
+		return nil
+	}
+
+	var names []string
+	if items, ok := middleware.Config["middlewares"].([]interface{}); ok {
+		for _, item := range items {
+			if name, ok := item.(string); ok {
+				if local, ok := localRefName(name); ok {
+					names = append(names, local)
+				}
+			}
+		}
+	}
+	return names
+}
+
+// localRefName 解析对象引用，去掉@http后缀；引用其他Provider的对象时返回false
+func localRefName(ref string) (string, bool) {
+	if ref == "" {
+		return "", false
+	}
+	name, provider, found := strings.Cut(ref, "@")
+	if found && provider != "http" {
+		return "", false
+	}
+	return name, true
+}
+
+// refKey 生成带协议的对象引用键，同名对象在不同协议下互不影响
+func refKey(protocol, name string) string {
+	return protocolOf(protocol) + "/" + name
+}
+
+// intersects 判断两个字符串切片是否有交集
+func intersects(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,228 @@
+package traefik
+
+import (
+	"sort"
+	"strings"
+
+	traefikDAO "github.com/yahahaff/rapide/internal/dao/traefik"
+	traefikModel "github.com/yahahaff/rapide/internal/models/traefik"
+	"github.com/yahahaff/rapide/internal/utils"
+	"github.com/yahahaff/rapide/pkg/config"
+	"github.com/yahahaff/rapide/pkg/logger"
+	"github.com/yahahaff/rapide/pkg/types"
+)
+
+// TraefikDriftService 对比当前发布的配置与Traefik运行时状态，生成对账报告
+type TraefikDriftService struct {
+	traefikDAO *traefikDAO.TraefikDAO
+}
+
+// driftKinds 运行时对象类型与修订记录中对象类型的对应关系
+var driftKinds = map[string]string{
+	"routers":     kindRouter,
+	"services":    kindService,
+	"middlewares": kindMiddleware,
+}
+
+// RunDriftCheck 执行一次对账并保存报告，获取运行时状态失败时保存状态为failed的报告
+func (ds *TraefikDriftService) RunDriftCheck(trigger, operator string) (traefikModel.TraefikDriftReport, error) {
+	report := traefikModel.TraefikDriftReport{Trigger: trigger, Operator: operator}
+
+	set, err := loadPublishedConfigSet(ds.traefikDAO)
+	if err != nil {
+		return report, err
+	}
+	set, version, err := driftTarget(ds.traefikDAO, set)
+	if err != nil {
+		return report, err
+	}
+
+	runtime := make(map[string][]map[string]interface{})
+	for _, item := range runtimeObjectKinds {
+		list, err := getTraefikList("/api/" + item.Protocol + "/" + item.Kind)
+		if err != nil {
+			report.Error = err.Error()
+			break
+		}
+		runtime[item.Protocol+"/"+item.Kind] = list
+	}
+
+	if report.Error != "" {
+		report.Status = "failed"
+	} else {
+		report.Items = compareDrift(set, version, runtime, config.GetString("TRAEFIK_DRIFT_PROVIDER", "http"))
+		report.Summary = types.JSONMap{"missing": 0, "extra": 0, "error": 0, "mismatch": 0}
+		for _, item := range report.Items {
+			report.Summary[item.Type] = report.Summary[item.Type].(int) + 1
+		}
+		report.Status = "ok"
+		if len(report.Items) > 0 {
+			report.Status = "drift"
+		}
+	}
+
+	if err := ds.traefikDAO.CreateDriftReport(&report); err != nil {
+		return report, err
+	}
+	if err := ds.traefikDAO.PruneDriftReports(config.GetInt("TRAEFIK_DRIFT_KEEP", 100)); err != nil {
+		logger.WarnString("traefik", "drift prune", err.Error())
+	}
+	return report, nil
+}
+
+// GetDriftReports 分页获取对账报告
+func (ds *TraefikDriftService) GetDriftReports(page, size int) ([]traefikModel.TraefikDriftReport, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 || size > 100 {
+		size = 20
+	}
+	return ds.traefikDAO.GetDriftReports(page, size)
+}
+
+// GetDriftReport 获取对账报告详情
+func (ds *TraefikDriftService) GetDriftReport(id uint64) (traefikModel.TraefikDriftReport, error) {
+	return ds.traefikDAO.GetDriftReportByID(id)
+}
+
+// GetLatestDriftReport 获取最近一次对账报告
+func (ds *TraefikDriftService) GetLatestDriftReport() (traefikModel.TraefikDriftReport, error) {
+	return ds.traefikDAO.GetLatestDriftReport()
+}
+
+// driftTarget 确定对账的期望配置范围和Traefik版本
+// 设置TRAEFIK_DRIFT_INSTANCE时使用该实例的可见范围和版本，否则使用全部配置和TRAEFIK_VERSION，与KV一致
+func driftTarget(dao *traefikDAO.TraefikDAO, set ConfigSet) (ConfigSet, string, error) {
+	name := config.GetString("TRAEFIK_DRIFT_INSTANCE", "")
+	if name == "" {
+		return set, defaultTraefikVersion(), nil
+	}
+	instances, err := dao.GetAllInstances()
+	if err != nil {
+		return set, "", err
+	}
+	for _, instance := range instances {
+		if instance.Name == name {
+			return scopeConfigSet(set, instance), instanceVersion(instance), nil
+		}
+	}
+	return set, "", &validationError{message: "TRAEFIK_DRIFT_INSTANCE指定的实例不存在: " + name}
+}
+
+// compareDrift 对比期望配置与运行时对象，运行时对象只考虑指定Provider（名称带@provider后缀）的
+// 期望配置先按下发时的方式转换为目标版本，避免中间件改名、去掉的选项和默认规则语法被报告为漂移
+func compareDrift(set ConfigSet, version string, runtime map[string][]map[string]interface{}, provider string) []traefikModel.TraefikDriftItem {
+	set, _ = convertConfigSet(set, version)
+	desired := make(map[string]map[string]interface{})
+	for _, router := range set.Routers {
+		desired[protocolOf(router.Protocol)+"/routers/"+router.Name] = renderRouter(router)
+	}
+	for _, service := range set.Services {
+		desired[protocolOf(service.Protocol)+"/services/"+service.Name] = renderService(service)
+	}
+	for _, middleware := range set.Middlewares {
+		desired[protocolOf(middleware.Protocol)+"/middlewares/"+middleware.Name] = renderMiddleware(middleware)
+	}
+
+	suffix := "@" + provider
+	items := make([]traefikModel.TraefikDriftItem, 0)
+	seen := make(map[string]bool)
+
+	for _, kind := range runtimeObjectKinds {
+		for _, object := range runtime[kind.Protocol+"/"+kind.Kind] {
+			fullName, _ := object["name"].(string)
+			if !strings.HasSuffix(fullName, suffix) {
+				continue
+			}
+			name := strings.TrimSuffix(fullName, suffix)
+			key := kind.Protocol + "/" + kind.Kind + "/" + name
+			seen[key] = true
+
+			item := traefikModel.TraefikDriftItem{Kind: driftKinds[kind.Kind], Name: name, Protocol: kind.Protocol}
+			want, ok := desired[key]
+			switch {
+			case !ok:
+				item.Type = "extra"
+				item.Details = types.JSONMap{"status": object["status"]}
+			case object["status"] == "disabled":
+				item.Type = "error"
+				item.Details = types.JSONMap{"status": object["status"], "errors": object["error"]}
+			default:
+				changes := runtimeMismatches(want, object, suffix)
+				if len(changes) == 0 {
+					continue
+				}
+				item.Type = "mismatch"
+				item.Details = types.JSONMap{"changes": changes}
+			}
+			items = append(items, item)
+		}
+	}
+
+	for key := range desired {
+		if seen[key] {
+			continue
+		}
+		parts := strings.SplitN(key, "/", 3)
+		items = append(items, traefikModel.TraefikDriftItem{
+			Kind:     driftKinds[parts[1]],
+			Name:     parts[2],
+			Protocol: parts[0],
+			Type:     "missing",
+		})
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Protocol != items[j].Protocol {
+			return items[i].Protocol < items[j].Protocol
+		}
+		if items[i].Kind != items[j].Kind {
+			return items[i].Kind < items[j].Kind
+		}
+		return items[i].Name < items[j].Name
+	})
+	return items
+}
+
+// runtimeMismatches 比较期望配置与运行时对象，忽略运行时补充的默认值和状态字段
+func runtimeMismatches(want map[string]interface{}, object map[string]interface{}, suffix string) []utils.DiffChange {
+	mismatches := make([]utils.DiffChange, 0)
+	for _, change := range utils.DiffJSON(trimRefSuffix(want, suffix), trimRefSuffix(object, suffix)) {
+		if change.Type != "added" {
+			mismatches = append(mismatches, change)
+		}
+	}
+	return mismatches
+}
+
+// trimRefSuffix 复制对象并去掉service和middlewares引用中的@provider后缀
+// 运行时对象的引用总是带有后缀，而数据库中的引用可带可不带
+func trimRefSuffix(object map[string]interface{}, suffix string) map[string]interface{} {
+	trimmed := make(map[string]interface{}, len(object))
+	for key, value := range object {
+		trimmed[key] = value
+	}
+	if service, ok := trimmed["service"].(string); ok {
+		trimmed["service"] = strings.TrimSuffix(service, suffix)
+	}
+	var middlewares []string
+	switch v := trimmed["middlewares"].(type) {
+	case types.JSONSlice:
+		middlewares = v
+	case []interface{}:
+		for _, item := range v {
+			if name, ok := item.(string); ok {
+				middlewares = append(middlewares, name)
+			}
+		}
+	default:
+		return trimmed
+	}
+	names := make([]string, len(middlewares))
+	for i, name := range middlewares {
+		names[i] = strings.TrimSuffix(name, suffix)
+	}
+	trimmed["middlewares"] = names
+	return trimmed
+}
@@ -0,0 +1,41 @@
+package traefik
+
+import (
+	"testing"
+
+	traefikModel "github.com/yahahaff/rapide/internal/models/traefik"
+	"github.com/yahahaff/rapide/pkg/types"
+)
+
+func TestCompareDriftConvertsVersion(t *testing.T) {
+	set := ConfigSet{
+		Routers: []traefikModel.TraefikRouter{
+			{Name: "shop", Protocol: "http", Rule: "Host(`shop.a.com`)", RuleSyntax: "default", Service: "shop", Middlewares: types.JSONSlice{"allow"}},
+		},
+		Middlewares: []traefikModel.TraefikMiddleware{
+			{Name: "allow", Protocol: "http", Type: "ipWhiteList", Config: types.JSONMap{"sourceRange": []interface{}{"10.0.0.0/8"}}},
+		},
+	}
+	// v3的运行时对象使用ipAllowList，不回显默认的规则语法，引用带@http后缀
+	runtime := map[string][]map[string]interface{}{
+		"http/routers": {
+			{"name": "shop@http", "rule": "Host(`shop.a.com`)", "service": "shop@http", "middlewares": []interface{}{"allow@http"}, "status": "enabled"},
+		},
+		"http/middlewares": {
+			{"name": "allow@http", "type": "ipallowlist", "ipAllowList": map[string]interface{}{"sourceRange": []interface{}{"10.0.0.0/8"}}, "status": "enabled"},
+		},
+	}
+
+	if items := compareDrift(set, traefikV3, runtime, "http"); len(items) != 0 {
+		t.Errorf("按v3转换后不应有漂移，实际为%+v", items)
+	}
+
+	// 运行时仍是旧的ipWhiteList时应报告差异
+	runtime["http/middlewares"][0] = map[string]interface{}{
+		"name": "allow@http", "ipWhiteList": map[string]interface{}{"sourceRange": []interface{}{"10.0.0.0/8"}}, "status": "enabled",
+	}
+	items := compareDrift(set, traefikV3, runtime, "http")
+	if len(items) != 1 || items[0].Name != "allow" || items[0].Type != "mismatch" {
+		t.Errorf("compareDrift = %+v, want allow的mismatch", items)
+	}
+}
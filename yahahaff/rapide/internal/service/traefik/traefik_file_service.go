@@ -0,0 +1,538 @@
+package traefik
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/pelletier/go-toml/v2"
+	traefikDAO "github.com/yahahaff/rapide/internal/dao/traefik"
+	traefikModel "github.com/yahahaff/rapide/internal/models/traefik"
+	"github.com/yahahaff/rapide/internal/utils"
+	"github.com/yahahaff/rapide/pkg/database"
+	"github.com/yahahaff/rapide/pkg/types"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+// TraefikFileService Traefik文件Provider格式（YAML/TOML）的导入导出服务
+type TraefikFileService struct {
+	traefikDAO *traefikDAO.TraefikDAO
+}
+
+// ImportItem 导入结果中的单个对象
+type ImportItem struct {
+	Kind     string             `json:"kind"` // router, service, middleware, tlsOption, tlsStore, serversTransport
+	Name     string             `json:"name"`
+	Protocol string             `json:"protocol"`
+	Action   string             `json:"action"` // create, update, skip, unchanged
+	Changes  []utils.DiffChange `json:"changes,omitempty"`
+}
+
+// ImportResult 导入结果
+type ImportResult struct {
+	DryRun   bool           `json:"dryRun"`
+	Conflict string         `json:"conflict"`
+	Items    []ImportItem   `json:"items"`
+	Summary  map[string]int `json:"summary"`
+	Warnings []string       `json:"warnings"`
+}
+
+// importRemark 导入产生的修订记录备注
+const importRemark = "导入配置文件"
+
+// serviceTypeKeys Traefik服务配置键与模型Type的对应关系
+var serviceTypeKeys = map[string]string{
+	"loadBalancer": "loadbalancer",
+	"weighted":     "weighted",
+	"mirroring":    "mirror",
+}
+
+// ParseDynamicConfig 解析Traefik文件Provider格式的动态配置文档
+func (fs *TraefikFileService) ParseDynamicConfig(data []byte, format string) (ConfigSet, []string, error) {
+	return parseDynamicConfig(data, format)
+}
+
+// parseDynamicConfig 解析动态配置文档，返回其中的对象和被忽略内容的警告
+func parseDynamicConfig(data []byte, format string) (ConfigSet, []string, error) {
+	var document map[string]interface{}
+	switch format {
+	case "yaml", "yml":
+		if err := yaml.Unmarshal(data, &document); err != nil {
+			return ConfigSet{}, nil, fmt.Errorf("YAML解析失败: %v", err)
+		}
+	case "toml":
+		if err := toml.Unmarshal(data, &document); err != nil {
+			return ConfigSet{}, nil, fmt.Errorf("TOML解析失败: %v", err)
+		}
+	default:
+		return ConfigSet{}, nil, fmt.Errorf("不支持的格式: %s", format)
+	}
+
+	// 统一转换为JSON兼容的数据结构
+	normalized, ok := toJSONCompatible(document).(map[string]interface{})
+	if !ok {
+		return ConfigSet{}, nil, errors.New("配置文档必须是对象")
+	}
+
+	set := ConfigSet{}
+	var warnings []string
+	for section := range normalized {
+		if section != "http" && section != "tcp" && section != "udp" && section != "tls" {
+			warnings = append(warnings, fmt.Sprintf("忽略不支持的配置段: %s", section))
+		}
+	}
+
+	if section, _ := normalized["tls"].(map[string]interface{}); section != nil {
+		for _, name := range sortedKeys(section["options"]) {
+			config, _ := section["options"].(map[string]interface{})[name].(map[string]interface{})
+			if err := validateTLSOption(config); err != nil {
+				return ConfigSet{}, nil, fmt.Errorf("TLS选项%s: %v", name, err)
+			}
+			set.TLSOptions = append(set.TLSOptions, traefikModel.TraefikTLSOption{Name: name, Config: types.JSONMap(config)})
+		}
+		for _, name := range sortedKeys(section["stores"]) {
+			config, _ := section["stores"].(map[string]interface{})[name].(map[string]interface{})
+			if err := validateTLSStore(config); err != nil {
+				return ConfigSet{}, nil, fmt.Errorf("TLS证书存储%s: %v", name, err)
+			}
+			set.TLSStores = append(set.TLSStores, traefikModel.TraefikTLSStore{Name: name, Config: types.JSONMap(config)})
+		}
+		for key := range section {
+			if key != "options" && key != "stores" {
+				warnings = append(warnings, fmt.Sprintf("忽略不支持的配置项: tls.%s", key))
+			}
+		}
+	}
+
+	for _, protocol := range []string{"http", "tcp", "udp"} {
+		section, _ := normalized[protocol].(map[string]interface{})
+		if section == nil {
+			continue
+		}
+
+		for _, name := range sortedKeys(section["routers"]) {
+			definition, _ := section["routers"].(map[string]interface{})[name].(map[string]interface{})
+			router, err := parseRouter(name, protocol, definition)
+			if err != nil {
+				return ConfigSet{}, nil, err
+			}
+			set.Routers = append(set.Routers, router)
+		}
+
+		for _, name := range sortedKeys(section["services"]) {
+			definition, _ := section["services"].(map[string]interface{})[name].(map[string]interface{})
+			service, err := parseService(name, protocol, definition)
+			if err != nil {
+				return ConfigSet{}, nil, err
+			}
+			set.Services = append(set.Services, service)
+		}
+
+		for _, name := range sortedKeys(section["middlewares"]) {
+			definition, _ := section["middlewares"].(map[string]interface{})[name].(map[string]interface{})
+			middleware, err := parseMiddleware(name, protocol, definition)
+			if err != nil {
+				return ConfigSet{}, nil, err
+			}
+			set.Middlewares = append(set.Middlewares, middleware)
+		}
+
+		for _, name := range sortedKeys(section["serversTransports"]) {
+			config, _ := section["serversTransports"].(map[string]interface{})[name].(map[string]interface{})
+			if err := validateServersTransport(protocol, config); err != nil {
+				return ConfigSet{}, nil, fmt.Errorf("serversTransport %s: %v", name, err)
+			}
+			set.ServersTransports = append(set.ServersTransports, traefikModel.TraefikServersTransport{Name: name, Protocol: protocol, Config: types.JSONMap(config)})
+		}
+
+		for key := range section {
+			if key != "routers" && key != "services" && key != "middlewares" && (key != "serversTransports" || protocol == "udp") {
+				warnings = append(warnings, fmt.Sprintf("忽略不支持的配置项: %s.%s", protocol, key))
+			}
+		}
+	}
+
+	return set, warnings, nil
+}
+
+// ImportConfigSet 将解析后的配置写入数据库，每个写入的对象都会记录修订
+// conflict为skip时保留已存在的同名对象，为overwrite时覆盖；dryRun只计算差异不写入
+func (fs *TraefikFileService) ImportConfigSet(set ConfigSet, conflict string, dryRun bool, operator string) (ImportResult, error) {
+	var result ImportResult
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		result, err = importConfigSet(fs.traefikDAO.WithTx(tx), set, conflict, dryRun, operator, importRemark)
+		return err
+	})
+	return result, err
+}
+
+// importConfigSet 在调用方的事务中导入配置，remark为修订记录的备注
+func importConfigSet(dao *traefikDAO.TraefikDAO, set ConfigSet, conflict string, dryRun bool, operator, remark string) (ImportResult, error) {
+	result := ImportResult{
+		DryRun:   dryRun,
+		Conflict: conflict,
+		Items:    make([]ImportItem, 0),
+		Summary:  map[string]int{"create": 0, "update": 0, "skip": 0, "unchanged": 0},
+	}
+
+	for _, router := range set.Routers {
+		router := router
+		existing, err := dao.GetRouter(router.Name, router.Protocol)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return result, err
+		}
+		found := err == nil
+		item := planImportItem("router", router.Name, router.Protocol, found, conflict, renderRouter(existing), renderRouter(router))
+		result.add(item)
+		if dryRun {
+			continue
+		}
+		switch item.Action {
+		case "create":
+			router.Status = "enabled"
+			if _, err := applyObject(dao, kindRouter, router.Name, router.Protocol, objectSnapshot(router), "create", operator, remark); err != nil {
+				return result, err
+			}
+		case "update":
+			existing.EntryPoints = router.EntryPoints
+			existing.Service = router.Service
+			existing.Rule = router.Rule
+			existing.RuleSyntax = router.RuleSyntax
+			existing.Priority = router.Priority
+			existing.Middlewares = router.Middlewares
+			existing.TLS = router.TLS
+			if _, err := applyObject(dao, kindRouter, router.Name, router.Protocol, objectSnapshot(existing), "update", operator, remark); err != nil {
+				return result, err
+			}
+		}
+	}
+
+	for _, service := range set.Services {
+		service := service
+		existing, err := dao.GetService(service.Name, service.Protocol)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return result, err
+		}
+		found := err == nil
+		item := planImportItem("service", service.Name, service.Protocol, found, conflict, renderService(existing), renderService(service))
+		result.add(item)
+		if dryRun {
+			continue
+		}
+		switch item.Action {
+		case "create":
+			service.Status = "enabled"
+			if _, err := applyObject(dao, kindService, service.Name, service.Protocol, objectSnapshot(service), "create", operator, remark); err != nil {
+				return result, err
+			}
+		case "update":
+			existing.Type = service.Type
+			existing.LoadBalancer = service.LoadBalancer
+			existing.Weighted = service.Weighted
+			existing.Mirror = service.Mirror
+			if _, err := applyObject(dao, kindService, service.Name, service.Protocol, objectSnapshot(existing), "update", operator, remark); err != nil {
+				return result, err
+			}
+		}
+	}
+
+	for _, middleware := range set.Middlewares {
+		middleware := middleware
+		existing, err := dao.GetMiddleware(middleware.Name, middleware.Protocol)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return result, err
+		}
+		found := err == nil
+		item := planImportItem("middleware", middleware.Name, middleware.Protocol, found, conflict, renderMiddleware(existing), renderMiddleware(middleware))
+		result.add(item)
+		if dryRun {
+			continue
+		}
+		switch item.Action {
+		case "create":
+			middleware.Status = "enabled"
+			if _, err := applyObject(dao, kindMiddleware, middleware.Name, middleware.Protocol, objectSnapshot(middleware), "create", operator, remark); err != nil {
+				return result, err
+			}
+		case "update":
+			existing.Type = middleware.Type
+			existing.Config = middleware.Config
+			if _, err := applyObject(dao, kindMiddleware, middleware.Name, middleware.Protocol, objectSnapshot(existing), "update", operator, remark); err != nil {
+				return result, err
+			}
+		}
+	}
+
+	for _, option := range set.TLSOptions {
+		if err := importConfigObject(dao, &result, kindTLSOption, option.Name, protocolTLS, option.Config, conflict, dryRun, operator, remark); err != nil {
+			return result, err
+		}
+	}
+	for _, store := range set.TLSStores {
+		if err := importConfigObject(dao, &result, kindTLSStore, store.Name, protocolTLS, store.Config, conflict, dryRun, operator, remark); err != nil {
+			return result, err
+		}
+	}
+	for _, transport := range set.ServersTransports {
+		if err := importConfigObject(dao, &result, kindServersTransport, transport.Name, transport.Protocol, transport.Config, conflict, dryRun, operator, remark); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// importConfigObject 导入只有Config的配置对象，即TLS选项、TLS证书存储和serversTransport
+func importConfigObject(dao *traefikDAO.TraefikDAO, result *ImportResult, kind, name, protocol string, config types.JSONMap, conflict string, dryRun bool, operator, remark string) error {
+	existing, err := loadObject(dao, kind, name, protocol)
+	if err != nil {
+		return err
+	}
+	snapshot := objectSnapshot(existing)
+	var before map[string]interface{}
+	if snapshot != nil {
+		before, _ = snapshot["config"].(map[string]interface{})
+	}
+
+	item := planImportItem(kind, name, protocol, existing != nil, conflict, before, config)
+	result.add(item)
+	if dryRun || (item.Action != "create" && item.Action != "update") {
+		return nil
+	}
+	if snapshot == nil {
+		snapshot = types.JSONMap{"name": name, "protocol": protocol, "status": "enabled"}
+	}
+	snapshot["config"] = map[string]interface{}(config)
+	_, err = applyObject(dao, kind, name, protocol, snapshot, item.Action, operator, remark)
+	return err
+}
+
+// ExportConfig 将当前启用的配置导出为Traefik文件Provider格式，version为空时使用TRAEFIK_VERSION
+func (fs *TraefikFileService) ExportConfig(format, version string) ([]byte, error) {
+	set, err := loadEnabledConfigSet(fs.traefikDAO)
+	if err != nil {
+		return nil, err
+	}
+	if version == "" {
+		version = defaultTraefikVersion()
+	}
+	config, err := renderConfigSetFor(set, version)
+	if err != nil {
+		return nil, err
+	}
+	return MarshalDynamicConfig(config, format)
+}
+
+// MarshalDynamicConfig 将动态配置序列化为YAML或TOML
+func MarshalDynamicConfig(config map[string]interface{}, format string) ([]byte, error) {
+	document := toJSONCompatible(config)
+	switch format {
+	case "yaml", "yml":
+		return yaml.Marshal(document)
+	case "toml":
+		return toml.Marshal(document)
+	default:
+		return nil, fmt.Errorf("不支持的格式: %s", format)
+	}
+}
+
+// add 记录导入项并更新统计
+func (r *ImportResult) add(item ImportItem) {
+	r.Items = append(r.Items, item)
+	r.Summary[item.Action]++
+}
+
+// planImportItem 根据已有对象和冲突策略决定导入动作
+func planImportItem(kind, name, protocol string, found bool, conflict string, before, after map[string]interface{}) ImportItem {
+	item := ImportItem{Kind: kind, Name: name, Protocol: protocol}
+	if !found {
+		item.Action = "create"
+		item.Changes = utils.DiffJSON(nil, after)
+		return item
+	}
+
+	item.Changes = utils.DiffJSON(before, after)
+	switch {
+	case len(item.Changes) == 0:
+		item.Action = "unchanged"
+	case conflict == "overwrite":
+		item.Action = "update"
+	default:
+		item.Action = "skip"
+	}
+	return item
+}
+
+// parseRouter 将文件中的路由定义转换为路由模型
+func parseRouter(name, protocol string, definition map[string]interface{}) (traefikModel.TraefikRouter, error) {
+	if definition == nil {
+		return traefikModel.TraefikRouter{}, fmt.Errorf("路由%s的定义无效", name)
+	}
+
+	router := traefikModel.TraefikRouter{
+		Name:        name,
+		Protocol:    protocol,
+		Provider:    "http",
+		RuleSyntax:  "default",
+		EntryPoints: toStringSlice(definition["entryPoints"]),
+		Middlewares: toStringSlice(definition["middlewares"]),
+	}
+	router.Service, _ = definition["service"].(string)
+	router.Rule, _ = definition["rule"].(string)
+	if ruleSyntax, ok := definition["ruleSyntax"].(string); ok && ruleSyntax != "" {
+		router.RuleSyntax = ruleSyntax
+	}
+	switch priority := definition["priority"].(type) {
+	case int64:
+		router.Priority = priority
+	case float64:
+		router.Priority = int64(priority)
+	}
+	if tls, ok := definition["tls"].(map[string]interface{}); ok {
+		router.TLS = types.JSONMap(tls)
+	}
+
+	if router.Service == "" {
+		return traefikModel.TraefikRouter{}, fmt.Errorf("路由%s缺少service", name)
+	}
+	if router.Rule == "" && protocol != "udp" {
+		return traefikModel.TraefikRouter{}, fmt.Errorf("路由%s缺少rule", name)
+	}
+	return router, nil
+}
+
+// parseService 将文件中的服务定义转换为服务模型
+func parseService(name, protocol string, definition map[string]interface{}) (traefikModel.TraefikService, error) {
+	service := traefikModel.TraefikService{
+		Name:     name,
+		Protocol: protocol,
+		Provider: "http",
+	}
+	// 导出的TCP和UDP服务带有与协议同名的附加配置，重新导入时还原
+	if extra, ok := definition[protocol].(map[string]interface{}); ok && protocol != "http" {
+		rest := make(map[string]interface{}, len(definition))
+		for key, value := range definition {
+			if key != protocol {
+				rest[key] = value
+			}
+		}
+		definition = rest
+		if protocol == "tcp" {
+			service.TCP = types.JSONMap(extra)
+		} else {
+			service.UDP = types.JSONMap(extra)
+		}
+	}
+	if len(definition) != 1 {
+		return traefikModel.TraefikService{}, fmt.Errorf("服务%s必须且只能包含一种服务类型", name)
+	}
+
+	for key, value := range definition {
+		serviceType, ok := serviceTypeKeys[key]
+		if !ok {
+			return traefikModel.TraefikService{}, fmt.Errorf("服务%s使用了不支持的类型: %s", name, key)
+		}
+		config, _ := value.(map[string]interface{})
+		service.Type = serviceType
+		switch serviceType {
+		case "loadbalancer":
+			service.LoadBalancer = types.JSONMap(config)
+		case "weighted":
+			service.Weighted = types.JSONMap(config)
+		case "mirror":
+			service.Mirror = types.JSONMap(config)
+		}
+	}
+	return service, nil
+}
+
+// parseMiddleware 将文件中的中间件定义转换为中间件模型
+func parseMiddleware(name, protocol string, definition map[string]interface{}) (traefikModel.TraefikMiddleware, error) {
+	if len(definition) != 1 {
+		return traefikModel.TraefikMiddleware{}, fmt.Errorf("中间件%s必须且只能包含一种中间件类型", name)
+	}
+
+	middleware := traefikModel.TraefikMiddleware{
+		Name:     name,
+		Protocol: protocol,
+		Provider: "http",
+	}
+	for key, value := range definition {
+		config, _ := value.(map[string]interface{})
+		if config == nil {
+			config = map[string]interface{}{}
+		}
+		middleware.Type = key
+		middleware.Config = types.JSONMap(config)
+	}
+	return middleware, nil
+}
+
+// toJSONCompatible 将YAML/TOML解析结果或自定义类型转换为JSON兼容结构，整数值保持为整数
+func toJSONCompatible(value interface{}) interface{} {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return value
+	}
+	return restoreIntegers(normalized)
+}
+
+// restoreIntegers 将JSON解析出的整数值float64还原为int64，避免导出为1.0这样的格式
+func restoreIntegers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = restoreIntegers(item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = restoreIntegers(item)
+		}
+		return v
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return int64(v)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// toStringSlice 将列表值转换为字符串切片
+func toStringSlice(value interface{}) types.JSONSlice {
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+	result := make(types.JSONSlice, 0, len(items))
+	for _, item := range items {
+		if str, ok := item.(string); ok {
+			result = append(result, str)
+		}
+	}
+	return result
+}
+
+// sortedKeys 获取对象的键并排序，保证导入顺序稳定
+func sortedKeys(value interface{}) []string {
+	items, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	keys := make([]string, 0, len(items))
+	for key := range items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
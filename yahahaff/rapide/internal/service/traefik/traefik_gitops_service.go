@@ -0,0 +1,573 @@
+package traefik
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	traefikDAO "github.com/yahahaff/rapide/internal/dao/traefik"
+	traefikModel "github.com/yahahaff/rapide/internal/models/traefik"
+	"github.com/yahahaff/rapide/internal/utils"
+	"github.com/yahahaff/rapide/pkg/config"
+	"github.com/yahahaff/rapide/pkg/database"
+	"github.com/yahahaff/rapide/pkg/logger"
+	"github.com/yahahaff/rapide/pkg/types"
+	"gorm.io/gorm"
+)
+
+// TraefikGitOpsService 把发布的配置同步到本地Git仓库，每个对象一个YAML文件
+// push在每次发布后提交当前发布的配置，作者为发布人；pull把仓库HEAD中的配置校验后写入草稿并发布
+// 仓库中的配置与rapide发布的配置一致，Git历史即配置的审计记录，也可用于灾难恢复
+type TraefikGitOpsService struct {
+	traefikDAO *traefikDAO.TraefikDAO
+}
+
+// gitSyncSignal 新快照发布信号，容量为1，连续的发布合并为一次提交
+var gitSyncSignal = make(chan struct{}, 1)
+
+// gitMu 同一时间只有一个push或pull操作仓库
+var gitMu sync.Mutex
+
+// gitDirs 仓库中由rapide管理的目录，push时整体重写
+var gitDirs = []string{"http", "tcp", "udp", "tls"}
+
+// GitPullResult 拉取结果，Import中是写入草稿的差异，包括仓库中已删除的对象
+type GitPullResult struct {
+	Commit   string                       `json:"commit"`
+	Message  string                       `json:"message"`
+	Author   string                       `json:"author"`
+	Files    int                          `json:"files"`
+	Import   ImportResult                 `json:"import"`
+	Snapshot *uint64                      `json:"snapshotId,omitempty"` // 发布的快照，配置没有变化时为空
+	Sync     *traefikModel.TraefikGitSync `json:"sync,omitempty"`       // 实际应用时的同步记录
+}
+
+// gitObject 仓库中的一个对象文件
+type gitObject struct {
+	kind       string
+	protocol   string
+	name       string
+	definition map[string]interface{} // 对象本身的配置，用于比较差异
+	document   map[string]interface{} // 写入文件的动态配置，只包含这一个对象
+}
+
+// gitRepo 配置的仓库路径，为空表示未启用
+func gitRepo() string {
+	return config.GetString("TRAEFIK_GITOPS_REPO", "")
+}
+
+// StartGitSync 启用时启动后台协程，启动时先提交一次当前发布的配置，之后每次发布新快照后提交
+func (gs *TraefikGitOpsService) StartGitSync() {
+	if gitRepo() == "" {
+		return
+	}
+	gitSyncSignal <- struct{}{}
+	go func() {
+		for range gitSyncSignal {
+			if _, err := gs.Push(); err != nil {
+				logger.ErrorString("traefik", "gitops push", err.Error())
+			}
+		}
+	}()
+}
+
+// Push 把当前发布的配置写入仓库并提交，作者和提交说明来自发布的快照，没有变化时不提交
+// 仓库HEAD不是上一次同步的提交时拒绝提交，需要先拉取，避免覆盖直接提交到仓库的修改
+func (gs *TraefikGitOpsService) Push() (traefikModel.TraefikGitSync, error) {
+	repo := gitRepo()
+	if repo == "" {
+		return traefikModel.TraefikGitSync{}, &validationError{message: "未配置TRAEFIK_GITOPS_REPO"}
+	}
+	gitMu.Lock()
+	defer gitMu.Unlock()
+
+	snapshot, set, err := publishedSnapshot(gs.traefikDAO)
+	if err != nil {
+		return traefikModel.TraefikGitSync{}, err
+	}
+	record := traefikModel.TraefikGitSync{
+		Direction:  "push",
+		SnapshotID: snapshot.ID,
+		Author:     snapshot.Operator,
+		Message:    gitCommitMessage(snapshot),
+		Operator:   "system",
+	}
+	summary, commit, err := gs.commitConfigSet(repo, set, snapshot.Operator, record.Message)
+	if commit == "" && err == nil {
+		// 仓库已与发布的配置一致
+		return record, nil
+	}
+	return record, gs.finishSync(&record, commit, summary, err)
+}
+
+// Pull 把仓库HEAD中的配置写入草稿，草稿中启用但仓库中不存在的对象会被删除，然后发布
+// 配置解析失败、文件路径与对象不符或配置检查有error级别的问题时拒绝拉取；HEAD已同步过时不做任何修改
+func (gs *TraefikGitOpsService) Pull(dryRun bool, operator string) (GitPullResult, error) {
+	repo := gitRepo()
+	if repo == "" {
+		return GitPullResult{}, &validationError{message: "未配置TRAEFIK_GITOPS_REPO"}
+	}
+	gitMu.Lock()
+	defer gitMu.Unlock()
+
+	head, err := gitHead(repo)
+	if err != nil {
+		return GitPullResult{}, err
+	}
+	if head == "" {
+		return GitPullResult{}, &validationError{message: "仓库中还没有提交"}
+	}
+	info, err := runGit(repo, nil, "log", "-1", "--format=%an%x00%s", head)
+	if err != nil {
+		return GitPullResult{}, err
+	}
+	author, subject, _ := strings.Cut(strings.TrimSpace(info), "\x00")
+	result := GitPullResult{Commit: head, Author: author, Message: subject}
+
+	record := traefikModel.TraefikGitSync{Direction: "pull", Author: author, Message: subject, Operator: operator}
+	set, files, err := readGitConfigSet(repo, head)
+	result.Files = files
+	if err != nil {
+		if !dryRun {
+			return result, gs.finishSync(&record, "", nil, err)
+		}
+		return result, err
+	}
+
+	last, err := gs.traefikDAO.GetLastGitSync()
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return result, err
+	}
+	synced := err == nil && last.Commit == head
+
+	// 修订记录的操作人为提交作者，便于从修订历史追溯到Git提交
+	remark := fmt.Sprintf("Git提交 %s: %s", shortCommit(head), subject)
+	revisionOperator := author + " (git)"
+	apply := !dryRun && !synced
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		dao := gs.traefikDAO.WithTx(tx)
+		issues, err := lintSet(dao, set)
+		if err != nil {
+			return err
+		}
+		if err := lintErrors(issues); err != nil {
+			return err
+		}
+		if err := checkGitReferences(set); err != nil {
+			return err
+		}
+		if result.Import, err = importConfigSet(dao, set, "overwrite", !apply, revisionOperator, remark); err != nil {
+			return err
+		}
+		return deleteMissingObjects(dao, set, &result.Import, !apply, revisionOperator, remark)
+	})
+	if !apply {
+		return result, err
+	}
+	if err == nil {
+		err = gs.publishPulled(&record, remark, revisionOperator)
+	}
+	if err != nil {
+		return result, gs.finishSync(&record, "", nil, err)
+	}
+
+	// 发布后触发的push等待当前操作结束，发现HEAD已同步，只在渲染结果不同时提交
+	summary := types.JSONMap{}
+	for action, count := range result.Import.Summary {
+		summary[action] = count
+	}
+	if err := gs.finishSync(&record, head, summary, nil); err != nil {
+		return result, err
+	}
+	result.Sync = &record
+	if record.SnapshotID > 0 {
+		result.Snapshot = &record.SnapshotID
+	}
+	return result, nil
+}
+
+// publishPulled 发布拉取后的草稿，草稿与已发布的配置一致时不发布
+func (gs *TraefikGitOpsService) publishPulled(record *traefikModel.TraefikGitSync, remark, operator string) error {
+	publishService := &TraefikPublishService{traefikDAO: gs.traefikDAO}
+	diff, err := publishService.GetDraftDiff()
+	if err != nil || len(diff.Items) == 0 {
+		return err
+	}
+	snapshot, err := publishService.Publish(remark, operator, nil)
+	if err != nil {
+		return err
+	}
+	record.SnapshotID = snapshot.ID
+	return nil
+}
+
+// GetGitSyncs 分页获取同步记录
+func (gs *TraefikGitOpsService) GetGitSyncs(direction string, page, size int) ([]traefikModel.TraefikGitSync, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 || size > 100 {
+		size = 20
+	}
+	return gs.traefikDAO.GetGitSyncs(direction, page, size)
+}
+
+// finishSync 保存同步记录，err不为空时记录为失败并返回err
+func (gs *TraefikGitOpsService) finishSync(record *traefikModel.TraefikGitSync, commit string, summary types.JSONMap, err error) error {
+	record.Commit = commit
+	record.Summary = summary
+	record.Status = "success"
+	if err != nil {
+		record.Status = "failed"
+		record.Commit = ""
+		record.Error = err.Error()
+	}
+	if saveErr := gs.traefikDAO.CreateGitSync(record); saveErr != nil && err == nil {
+		return saveErr
+	}
+	return err
+}
+
+// commitConfigSet 重写仓库中的对象文件并提交，返回变更的文件数量和新的HEAD，没有变化时HEAD为空
+// 由rapide管理的目录整体重写，其中未提交的修改会被覆盖，修改仓库中的配置需要提交后拉取
+func (gs *TraefikGitOpsService) commitConfigSet(repo string, set ConfigSet, author, message string) (types.JSONMap, string, error) {
+	if err := ensureGitRepo(repo); err != nil {
+		return nil, "", err
+	}
+	head, err := gitHead(repo)
+	if err != nil {
+		return nil, "", err
+	}
+	if head != "" {
+		last, err := gs.traefikDAO.GetLastGitSync()
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", err
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", &validationError{message: "仓库中已有提交且从未同步过，请先拉取，确认以仓库还是rapide中的配置为准"}
+		}
+		if last.Commit != head {
+			return nil, "", &validationError{message: fmt.Sprintf("仓库HEAD %s不是上一次同步的提交%s，请先拉取仓库中的提交", shortCommit(head), shortCommit(last.Commit))}
+		}
+	}
+	for _, dir := range gitDirs {
+		if err := os.RemoveAll(filepath.Join(repo, dir)); err != nil {
+			return nil, "", err
+		}
+	}
+	for _, object := range gitObjects(set) {
+		data, err := MarshalDynamicConfig(object.document, "yaml")
+		if err != nil {
+			return nil, "", err
+		}
+		file := filepath.Join(repo, filepath.FromSlash(gitObjectPath(object)))
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			return nil, "", err
+		}
+		if err := os.WriteFile(file, data, 0o644); err != nil {
+			return nil, "", err
+		}
+	}
+
+	// 不存在也未被跟踪的目录不能作为pathspec
+	tracked, err := runGit(repo, nil, append([]string{"ls-files", "--"}, gitDirs...)...)
+	if err != nil {
+		return nil, "", err
+	}
+	pathspec := []string{"add", "-A", "--"}
+	for _, dir := range gitDirs {
+		if _, err := os.Stat(filepath.Join(repo, dir)); err == nil || strings.Contains("\n"+tracked, "\n"+dir+"/") {
+			pathspec = append(pathspec, dir)
+		}
+	}
+	if len(pathspec) > 3 {
+		if _, err := runGit(repo, nil, pathspec...); err != nil {
+			return nil, "", err
+		}
+	}
+	changes, err := runGit(repo, nil, "diff", "--cached", "--name-status")
+	if err != nil {
+		return nil, "", err
+	}
+	if strings.TrimSpace(changes) == "" {
+		return nil, "", nil
+	}
+	counts := make(map[byte]int)
+	for _, line := range strings.Split(strings.TrimSpace(changes), "\n") {
+		counts[line[0]]++
+	}
+	summary := types.JSONMap{"added": counts['A'], "deleted": counts['D'], "modified": counts['M']}
+
+	env := []string{"GIT_COMMITTER_NAME=rapide", "GIT_COMMITTER_EMAIL=" + gitEmail("rapide")}
+	if author == "" {
+		author = "system"
+	}
+	if _, err := runGit(repo, env, "commit", "-q", "--no-verify", "--author", fmt.Sprintf("%s <%s>", author, gitEmail(author)), "-m", message); err != nil {
+		return nil, "", err
+	}
+	head, err = gitHead(repo)
+	return summary, head, err
+}
+
+// deleteMissingObjects 删除草稿中启用但配置中不存在的对象，路由最先删除，避免删除时仍被引用
+func deleteMissingObjects(dao *traefikDAO.TraefikDAO, set ConfigSet, result *ImportResult, dryRun bool, operator, remark string) error {
+	draft, err := loadEnabledConfigSet(dao)
+	if err != nil {
+		return err
+	}
+	keep := make(map[string]bool)
+	for _, object := range gitObjects(set) {
+		keep[gitObjectPath(object)] = true
+	}
+	for _, object := range gitObjects(draft) {
+		if keep[gitObjectPath(object)] {
+			continue
+		}
+		result.add(ImportItem{
+			Kind: object.kind, Name: object.name, Protocol: object.protocol,
+			Action: "delete", Changes: utils.DiffJSON(object.definition, nil),
+		})
+		if dryRun {
+			continue
+		}
+		if _, err := applyObject(dao, object.kind, object.name, object.protocol, nil, "delete", operator, remark); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// gitObjects 把配置拆分为对象文件，路由、中间件、服务、serversTransport、TLS选项、TLS证书存储依次排列
+func gitObjects(set ConfigSet) []gitObject {
+	var objects []gitObject
+	section := func(kind, protocol, name, key string, definition map[string]interface{}) {
+		parent := protocol
+		if protocol == protocolTLS {
+			parent = "tls"
+		}
+		objects = append(objects, gitObject{
+			kind: kind, protocol: protocol, name: name, definition: definition,
+			document: map[string]interface{}{parent: map[string]interface{}{key: map[string]interface{}{name: definition}}},
+		})
+	}
+	for _, router := range set.Routers {
+		section(kindRouter, protocolOf(router.Protocol), router.Name, "routers", renderRouter(router))
+	}
+	for _, middleware := range set.Middlewares {
+		section(kindMiddleware, protocolOf(middleware.Protocol), middleware.Name, "middlewares", renderMiddleware(middleware))
+	}
+	for _, service := range set.Services {
+		section(kindService, protocolOf(service.Protocol), service.Name, "services", renderService(service))
+	}
+	for _, transport := range set.ServersTransports {
+		section(kindServersTransport, protocolOf(transport.Protocol), transport.Name, "serversTransports", transport.Config)
+	}
+	for _, option := range set.TLSOptions {
+		section(kindTLSOption, protocolTLS, option.Name, "options", option.Config)
+	}
+	for _, store := range set.TLSStores {
+		section(kindTLSStore, protocolTLS, store.Name, "stores", store.Config)
+	}
+	return objects
+}
+
+// gitObjectPath 对象在仓库中的文件路径，如http/routers/whoami.yml，名称中的/等字符会被转义
+func gitObjectPath(object gitObject) string {
+	for parent, sections := range object.document {
+		for key := range sections.(map[string]interface{}) {
+			return path.Join(parent, key, url.PathEscape(object.name)+".yml")
+		}
+	}
+	return ""
+}
+
+// readGitConfigSet 读取提交中的所有对象文件并合并为一份配置，每个文件只能包含路径对应的一个对象
+func readGitConfigSet(repo, commit string) (ConfigSet, int, error) {
+	args := append([]string{"ls-tree", "-r", "-z", "--name-only", commit, "--"}, gitDirs...)
+	listing, err := runGit(repo, nil, args...)
+	if err != nil {
+		return ConfigSet{}, 0, err
+	}
+	var files []string
+	for _, file := range strings.Split(listing, "\x00") {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	sort.Strings(files)
+
+	var set ConfigSet
+	for _, file := range files {
+		if ext := path.Ext(file); ext != ".yml" && ext != ".yaml" {
+			return ConfigSet{}, len(files), &validationError{message: file + ": 只支持YAML文件"}
+		}
+		data, err := runGit(repo, nil, "show", commit+":"+file)
+		if err != nil {
+			return ConfigSet{}, len(files), err
+		}
+		parsed, warnings, err := parseDynamicConfig([]byte(data), "yaml")
+		if err != nil {
+			return ConfigSet{}, len(files), &validationError{message: fmt.Sprintf("%s: %v", file, err)}
+		}
+		if len(warnings) > 0 {
+			return ConfigSet{}, len(files), &validationError{message: fmt.Sprintf("%s: %s", file, strings.Join(warnings, "; "))}
+		}
+		objects := gitObjects(parsed)
+		expected := strings.TrimSuffix(file, path.Ext(file)) + ".yml"
+		if len(objects) != 1 || gitObjectPath(objects[0]) != expected {
+			return ConfigSet{}, len(files), &validationError{message: file + ": 文件中应只包含路径对应的一个对象"}
+		}
+		set.Routers = append(set.Routers, parsed.Routers...)
+		set.Services = append(set.Services, parsed.Services...)
+		set.Middlewares = append(set.Middlewares, parsed.Middlewares...)
+		set.TLSOptions = append(set.TLSOptions, parsed.TLSOptions...)
+		set.TLSStores = append(set.TLSStores, parsed.TLSStores...)
+		set.ServersTransports = append(set.ServersTransports, parsed.ServersTransports...)
+	}
+	return set, len(files), nil
+}
+
+// checkGitReferences 检查路由、加权和镜像服务、chain中间件引用的本Provider对象是否都在仓库中
+// 草稿中允许引用尚未创建的对象，仓库中的配置会直接发布，需要是完整的
+func checkGitReferences(set ConfigSet) error {
+	services := make(map[string]bool, len(set.Services))
+	for _, service := range set.Services {
+		services[refKey(service.Protocol, service.Name)] = true
+	}
+	middlewares := make(map[string]bool, len(set.Middlewares))
+	for _, middleware := range set.Middlewares {
+		middlewares[refKey(middleware.Protocol, middleware.Name)] = true
+	}
+
+	var missing []string
+	checkService := func(owner, protocol, ref string) {
+		if name, ok := localRefName(ref); ok && !services[refKey(protocol, name)] {
+			missing = append(missing, fmt.Sprintf("%s引用的服务%s不存在", owner, name))
+		}
+	}
+	checkMiddleware := func(owner, protocol, name string) {
+		if !middlewares[refKey(protocol, name)] {
+			missing = append(missing, fmt.Sprintf("%s引用的中间件%s不存在", owner, name))
+		}
+	}
+	for _, router := range set.Routers {
+		checkService("路由"+router.Name, router.Protocol, router.Service)
+		for _, ref := range router.Middlewares {
+			if name, ok := localRefName(fmt.Sprint(ref)); ok {
+				checkMiddleware("路由"+router.Name, router.Protocol, name)
+			}
+		}
+	}
+	for _, service := range set.Services {
+		for _, child := range childServiceNames(service) {
+			checkService("服务"+service.Name, service.Protocol, child)
+		}
+	}
+	for _, middleware := range set.Middlewares {
+		for _, name := range chainMiddlewareNames(middleware) {
+			checkMiddleware("中间件"+middleware.Name, middleware.Protocol, name)
+		}
+	}
+	if len(missing) > 0 {
+		return &validationError{message: "配置引用不完整: " + strings.Join(missing, "; ")}
+	}
+	return nil
+}
+
+// gitCommitMessage 发布快照对应的提交说明
+func gitCommitMessage(snapshot traefikModel.TraefikSnapshot) string {
+	message := fmt.Sprintf("发布配置快照 #%d", snapshot.ID)
+	if snapshot.Remark != "" {
+		message = snapshot.Remark + "\n\n" + message
+	}
+	return message
+}
+
+// gitEmail 由用户名生成提交使用的邮箱，域名由TRAEFIK_GITOPS_EMAIL_DOMAIN设置
+func gitEmail(name string) string {
+	local := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '_' || r == '-' {
+			return r
+		}
+		return -1
+	}, name)
+	if local == "" {
+		local = "rapide"
+	}
+	return local + "@" + config.GetString("TRAEFIK_GITOPS_EMAIL_DOMAIN", "rapide.local")
+}
+
+// shortCommit 提交的短哈希
+func shortCommit(commit string) string {
+	if len(commit) > 8 {
+		return commit[:8]
+	}
+	return commit
+}
+
+// ensureGitRepo 仓库目录不存在或不是Git仓库时初始化
+func ensureGitRepo(repo string) error {
+	if _, err := os.Stat(filepath.Join(repo, ".git")); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(repo, 0o755); err != nil {
+		return err
+	}
+	_, err := runGit(repo, nil, "init", "-q")
+	return err
+}
+
+// gitHead 仓库HEAD的提交，还没有提交时为空
+func gitHead(repo string) (string, error) {
+	if _, err := os.Stat(filepath.Join(repo, ".git")); err != nil {
+		return "", nil
+	}
+	head, err := runGit(repo, nil, "rev-parse", "--verify", "-q", "HEAD")
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return "", nil
+		}
+		return "", err
+	}
+	return strings.TrimSpace(head), nil
+}
+
+// runGit 在仓库中执行git命令，失败时错误中包含git的输出
+func runGit(repo string, env []string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repo
+	cmd.Env = append(os.Environ(), env...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", &gitError{err: err, message: message}
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
+
+// gitError git命令执行失败
+type gitError struct {
+	err     error
+	message string
+}
+
+func (e *gitError) Error() string {
+	return "git: " + e.message
+}
+
+func (e *gitError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,249 @@
+package traefik
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	traefikDAO "github.com/yahahaff/rapide/internal/dao/traefik"
+	sysModel "github.com/yahahaff/rapide/internal/models/sys"
+	traefikModel "github.com/yahahaff/rapide/internal/models/traefik"
+	"github.com/yahahaff/rapide/pkg/config"
+	"gorm.io/gorm"
+)
+
+// TraefikHealthService 轮询Traefik服务的serverStatus，记录后端服务器的状态变化并在长时间宕机时告警
+type TraefikHealthService struct {
+	traefikDAO *traefikDAO.TraefikDAO
+}
+
+// ServerHealth 单个后端服务器的健康状况
+type ServerHealth struct {
+	URL       string     `json:"url"`
+	Status    string     `json:"status"`
+	Since     time.Time  `json:"since"`
+	CheckedAt time.Time  `json:"checkedAt"`
+	AlertedAt *time.Time `json:"alertedAt"`
+	Uptime    float64    `json:"uptime"` // 统计窗口内的可用率，百分比
+}
+
+// ServiceHealth 服务及其后端服务器的健康状况
+type ServiceHealth struct {
+	Service string         `json:"service"`
+	Servers []ServerHealth `json:"servers"`
+}
+
+// PollServerStatus 拉取一次HTTP和TCP服务的serverStatus并更新状态
+func (hs *TraefikHealthService) PollServerStatus() error {
+	observed := make(map[string]map[string]string)
+	for _, protocol := range []string{"http", "tcp"} {
+		services, err := getTraefikList("/api/" + protocol + "/services")
+		if err != nil {
+			return err
+		}
+		for _, service := range services {
+			name, _ := service["name"].(string)
+			servers, ok := service["serverStatus"].(map[string]interface{})
+			if name == "" || !ok {
+				continue
+			}
+			observed[name] = make(map[string]string, len(servers))
+			for url, status := range servers {
+				observed[name][url] = fmt.Sprint(status)
+			}
+		}
+	}
+
+	states, err := hs.traefikDAO.GetServerStates()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	threshold := time.Duration(config.GetInt("TRAEFIK_HEALTH_DOWN_THRESHOLD", 300)) * time.Second
+	known := make(map[string]bool)
+
+	for i := range states {
+		state := &states[i]
+		status, ok := observed[state.Service][state.URL]
+		if !ok {
+			// 服务器已不在Traefik中，不再跟踪
+			if err := hs.traefikDAO.DeleteServerState(state.ID); err != nil {
+				return err
+			}
+			continue
+		}
+		known[state.Service+"|"+state.URL] = true
+
+		if status != state.Status {
+			if err := hs.recordTransition(state.Service, state.URL, state.Status, status, now); err != nil {
+				return err
+			}
+			if status == "UP" && state.AlertedAt != nil {
+				sendAlert(
+					fmt.Sprintf("[rapide] 后端已恢复: %s", state.URL),
+					fmt.Sprintf("服务 %s 的后端 %s 已恢复，宕机时长 %s", state.Service, state.URL, now.Sub(state.Since).Round(time.Second)),
+				)
+				state.AlertedAt = nil
+			}
+			state.Status = status
+			state.Since = now
+		}
+
+		if state.Status != "UP" && state.AlertedAt == nil && now.Sub(state.Since) >= threshold {
+			sendAlert(
+				fmt.Sprintf("[rapide] 后端宕机: %s", state.URL),
+				fmt.Sprintf("服务 %s 的后端 %s 自 %s 起处于 %s 状态，已持续 %s", state.Service, state.URL, state.Since.Format(time.DateTime), state.Status, now.Sub(state.Since).Round(time.Second)),
+			)
+			alertedAt := now
+			state.AlertedAt = &alertedAt
+		}
+
+		state.CheckedAt = now
+		if err := hs.traefikDAO.SaveServerState(state); err != nil {
+			return err
+		}
+	}
+
+	// 首次发现的服务器
+	for service, servers := range observed {
+		for url, status := range servers {
+			if known[service+"|"+url] {
+				continue
+			}
+			if err := hs.recordTransition(service, url, "", status, now); err != nil {
+				return err
+			}
+			state := traefikModel.TraefikServerState{Service: service, URL: url, Status: status, Since: now, CheckedAt: now}
+			if err := hs.traefikDAO.SaveServerState(&state); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = hs.traefikDAO.DeleteServerTransitionsBefore(healthRetentionStart(now))
+	return err
+}
+
+// healthRetentionStart 保留期的开始时间，更早的状态变化会被删除
+func healthRetentionStart(now time.Time) time.Time {
+	return now.AddDate(0, 0, -config.GetInt("TRAEFIK_HEALTH_RETENTION_DAYS", 30))
+}
+
+// GetServiceHealth 获取范围内部门的服务的后端健康状况，uptime按最近window时长统计
+func (hs *TraefikHealthService) GetServiceHealth(window time.Duration, scope sysModel.DeptScope) ([]ServiceHealth, error) {
+	states, err := hs.traefikDAO.GetServerStates()
+	if err != nil {
+		return nil, err
+	}
+	owned, err := ownedRuntimeNames(hs.traefikDAO, kindService, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	grouped := make(map[string][]ServerHealth)
+	for _, state := range states {
+		if owned != nil && !owned[state.Service] {
+			continue
+		}
+		uptime, err := hs.serverUptime(state.Service, state.URL, now.Add(-window), now)
+		if err != nil {
+			return nil, err
+		}
+		grouped[state.Service] = append(grouped[state.Service], ServerHealth{
+			URL:       state.URL,
+			Status:    state.Status,
+			Since:     state.Since,
+			CheckedAt: state.CheckedAt,
+			AlertedAt: state.AlertedAt,
+			Uptime:    uptime,
+		})
+	}
+
+	result := make([]ServiceHealth, 0, len(grouped))
+	for service, servers := range grouped {
+		result = append(result, ServiceHealth{Service: service, Servers: servers})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Service < result[j].Service })
+	return result, nil
+}
+
+// GetServiceTransitions 获取范围内部门的服务最近的状态变化，service为空时返回所有服务的记录
+func (hs *TraefikHealthService) GetServiceTransitions(service string, limit int, scope sysModel.DeptScope) ([]traefikModel.TraefikServerTransition, error) {
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+	if service != "" {
+		if _, err := runtimeName(hs.traefikDAO, kindService, service, scope); err != nil {
+			return nil, err
+		}
+		return hs.traefikDAO.GetServerTransitions([]string{service}, limit)
+	}
+	owned, err := ownedRuntimeNames(hs.traefikDAO, kindService, scope)
+	if err != nil {
+		return nil, err
+	}
+	if owned == nil {
+		return hs.traefikDAO.GetServerTransitions(nil, limit)
+	}
+	return hs.traefikDAO.GetServerTransitions(sortedNames(owned), limit)
+}
+
+// recordTransition 记录一次状态变化
+func (hs *TraefikHealthService) recordTransition(service, url, from, to string, at time.Time) error {
+	return hs.traefikDAO.CreateServerTransition(&traefikModel.TraefikServerTransition{
+		Service: service,
+		URL:     url,
+		From:    from,
+		To:      to,
+		At:      at,
+	})
+}
+
+// serverUptime 根据状态变化计算服务器在[start, end]内处于UP状态的百分比
+// 窗口开始时的状态取窗口前最后一次变化，首次发现晚于窗口开始时只统计发现之后的时间
+func (hs *TraefikHealthService) serverUptime(service, url string, start, end time.Time) (float64, error) {
+	transitions, err := hs.traefikDAO.GetServerTransitionsSince(service, url, start)
+	if err != nil {
+		return 0, err
+	}
+
+	current := ""
+	cursor := start
+	previous, err := hs.traefikDAO.GetLastServerTransitionBefore(service, url, start)
+	switch {
+	case err == nil:
+		current = previous.To
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return 0, err
+	case len(transitions) > 0:
+		cursor = transitions[0].At
+	}
+
+	var up, observed time.Duration
+	for _, transition := range transitions {
+		if current != "" {
+			observed += transition.At.Sub(cursor)
+			if current == "UP" {
+				up += transition.At.Sub(cursor)
+			}
+		}
+		current, cursor = transition.To, transition.At
+	}
+	if current != "" {
+		observed += end.Sub(cursor)
+		if current == "UP" {
+			up += end.Sub(cursor)
+		}
+	}
+
+	if observed <= 0 {
+		if current == "UP" {
+			return 100, nil
+		}
+		return 0, nil
+	}
+	return float64(up) / float64(observed) * 100, nil
+}
@@ -17,36 +17,94 @@ func NewTraefikHTTPProviderService() *TraefikHTTPProviderService {
 	}
 }
 
-// GetHTTPProviderConfig 获取Traefik HTTP Provider配置
+// GetHTTPProviderConfig 获取当前发布的Traefik HTTP Provider配置，按TRAEFIK_VERSION的版本渲染
 func (svc *TraefikHTTPProviderService) GetHTTPProviderConfig() (map[string]interface{}, error) {
-	// 获取所有启用的路由
-	routers, err := svc.traefikDAO.GetAllRouters()
+	set, err := loadPublishedConfigSet(svc.traefikDAO)
 	if err != nil {
 		return nil, err
 	}
 
-	// 获取所有启用的服务
-	services, err := svc.traefikDAO.GetAllServices()
-	if err != nil {
-		return nil, err
-	}
+	return renderConfigSetFor(set, defaultTraefikVersion())
+}
 
-	// 获取所有启用的中间件
-	middlewares, err := svc.traefikDAO.GetAllMiddlewares()
+// GetInstanceProviderConfig 获取当前发布的配置中指定Traefik实例可见范围内的部分，按实例的Traefik版本渲染
+func (svc *TraefikHTTPProviderService) GetInstanceProviderConfig(instance traefikModel.TraefikInstance) (map[string]interface{}, error) {
+	set, err := loadPublishedConfigSet(svc.traefikDAO)
 	if err != nil {
 		return nil, err
 	}
 
-	// 构建HTTP Provider配置，使用名称作为键，完全符合Traefik HTTP Provider格式
+	return renderConfigSetFor(scopeConfigSet(set, instance), instanceVersion(instance))
+}
+
+// renderConfigSet 构建HTTP Provider配置，按协议分组并使用名称作为键，完全符合Traefik HTTP Provider格式
+func renderConfigSet(set ConfigSet) map[string]interface{} {
 	config := map[string]interface{}{
 		"http": map[string]interface{}{
-			"routers":     buildRoutersConfig(routers),
-			"services":    buildServicesConfig(services),
-			"middlewares": buildMiddlewaresConfig(middlewares),
+			"routers":     buildRoutersConfig(filterRouters(set.Routers, "http")),
+			"services":    buildServicesConfig(filterServices(set.Services, "http")),
+			"middlewares": buildMiddlewaresConfig(filterMiddlewares(set.Middlewares, "http")),
 		},
 	}
 
-	return config, nil
+	// TCP和UDP只在存在对象时输出
+	for _, protocol := range []string{"tcp", "udp"} {
+		section := make(map[string]interface{})
+		if routers := filterRouters(set.Routers, protocol); len(routers) > 0 {
+			section["routers"] = buildRoutersConfig(routers)
+		}
+		if services := filterServices(set.Services, protocol); len(services) > 0 {
+			section["services"] = buildServicesConfig(services)
+		}
+		if middlewares := filterMiddlewares(set.Middlewares, protocol); len(middlewares) > 0 {
+			section["middlewares"] = buildMiddlewaresConfig(middlewares)
+		}
+		if len(section) > 0 {
+			config[protocol] = section
+		}
+	}
+
+	// serversTransports和TLS配置同样只在存在对象时输出，udp没有serversTransports
+	for _, protocol := range []string{"http", "tcp"} {
+		if transports := filterServersTransports(set.ServersTransports, protocol); len(transports) > 0 {
+			section, _ := config[protocol].(map[string]interface{})
+			if section == nil {
+				section = make(map[string]interface{})
+				config[protocol] = section
+			}
+			section["serversTransports"] = buildServersTransportsConfig(transports)
+		}
+	}
+	tls := make(map[string]interface{})
+	if len(set.TLSOptions) > 0 {
+		options := make(map[string]interface{})
+		for _, option := range set.TLSOptions {
+			options[option.Name] = option.Config
+		}
+		tls["options"] = options
+	}
+	if len(set.TLSStores) > 0 {
+		stores := make(map[string]interface{})
+		for _, store := range set.TLSStores {
+			stores[store.Name] = store.Config
+		}
+		tls["stores"] = stores
+	}
+	if len(set.Certificates) > 0 {
+		certificates := make([]interface{}, 0, len(set.Certificates))
+		for _, certificate := range set.Certificates {
+			certificates = append(certificates, map[string]interface{}{
+				"certFile": certificate.CertFile,
+				"keyFile":  certificate.KeyFile,
+			})
+		}
+		tls["certificates"] = certificates
+	}
+	if len(tls) > 0 {
+		config["tls"] = tls
+	}
+
+	return config
 }
 
 // buildRoutersConfig 构建路由配置，以名称为键
@@ -54,34 +112,43 @@ func buildRoutersConfig(routers []traefikModel.TraefikRouter) map[string]interfa
 	routerConfig := make(map[string]interface{})
 
 	for _, router := range routers {
-		routerData := map[string]interface{}{
-			"entryPoints": router.EntryPoints,
-			"service":     router.Service,
-			"rule":        router.Rule,
-		}
+		// 使用名称作为键
+		routerConfig[router.Name] = renderRouter(router)
+	}
 
-		// 添加可选字段
-		if router.RuleSyntax != "" {
-			routerData["ruleSyntax"] = router.RuleSyntax
-		}
+	return routerConfig
+}
 
-		if router.Priority > 0 {
-			routerData["priority"] = router.Priority
-		}
+// renderRouter 构建单个路由的配置
+func renderRouter(router traefikModel.TraefikRouter) map[string]interface{} {
+	routerData := map[string]interface{}{
+		"service": router.Service,
+		"rule":    router.Rule,
+	}
 
-		if len(router.Middlewares) > 0 {
-			routerData["middlewares"] = router.Middlewares
-		}
+	// 添加可选字段，未指定入口点时Traefik默认监听所有入口点
+	if len(router.EntryPoints) > 0 {
+		routerData["entryPoints"] = router.EntryPoints
+	}
 
-		if len(router.TLS) > 0 {
-			routerData["tls"] = router.TLS
-		}
+	if router.RuleSyntax != "" {
+		routerData["ruleSyntax"] = router.RuleSyntax
+	}
 
-		// 使用名称作为键
-		routerConfig[router.Name] = routerData
+	if router.Priority > 0 {
+		routerData["priority"] = router.Priority
 	}
 
-	return routerConfig
+	if len(router.Middlewares) > 0 {
+		routerData["middlewares"] = router.Middlewares
+	}
+
+	// tls为空对象时同样表示启用TLS
+	if router.TLS != nil {
+		routerData["tls"] = router.TLS
+	}
+
+	return routerData
 }
 
 // buildServicesConfig 构建服务配置，以名称为键
@@ -89,38 +156,44 @@ func buildServicesConfig(services []traefikModel.TraefikService) map[string]inte
 	serviceConfig := make(map[string]interface{})
 
 	for _, service := range services {
-		serviceData := make(map[string]interface{})
+		// 使用名称作为键
+		serviceConfig[service.Name] = renderService(service)
+	}
 
-		// 根据服务类型添加不同的配置
-		switch service.Type {
-		case "loadbalancer":
-			if len(service.LoadBalancer) > 0 {
-				serviceData["loadBalancer"] = service.LoadBalancer
-			}
-		case "weighted":
-			if len(service.Weighted) > 0 {
-				serviceData["weighted"] = service.Weighted
-			}
-		case "mirror":
-			if len(service.Mirror) > 0 {
-				serviceData["mirror"] = service.Mirror
-			}
-		}
+	return serviceConfig
+}
 
-		// 为TCP/UDP服务添加特定配置
-		if service.Protocol == "tcp" && len(service.TCP) > 0 {
-			serviceData["tcp"] = service.TCP
-		}
+// renderService 构建单个服务的配置
+func renderService(service traefikModel.TraefikService) map[string]interface{} {
+	serviceData := make(map[string]interface{})
 
-		if service.Protocol == "udp" && len(service.UDP) > 0 {
-			serviceData["udp"] = service.UDP
+	// 根据服务类型添加不同的配置
+	switch service.Type {
+	case "loadbalancer":
+		if len(service.LoadBalancer) > 0 {
+			serviceData["loadBalancer"] = service.LoadBalancer
+		}
+	case "weighted":
+		if len(service.Weighted) > 0 {
+			serviceData["weighted"] = service.Weighted
 		}
+	case "mirror":
+		// Traefik中镜像服务的配置键为mirroring
+		if len(service.Mirror) > 0 {
+			serviceData["mirroring"] = service.Mirror
+		}
+	}
 
-		// 使用名称作为键
-		serviceConfig[service.Name] = serviceData
+	// 为TCP/UDP服务添加特定配置
+	if service.Protocol == "tcp" && len(service.TCP) > 0 {
+		serviceData["tcp"] = service.TCP
 	}
 
-	return serviceConfig
+	if service.Protocol == "udp" && len(service.UDP) > 0 {
+		serviceData["udp"] = service.UDP
+	}
+
+	return serviceData
 }
 
 // buildMiddlewaresConfig 构建中间件配置，以名称为键
@@ -128,13 +201,79 @@ func buildMiddlewaresConfig(middlewares []traefikModel.TraefikMiddleware) map[st
 	middlewareConfig := make(map[string]interface{})
 
 	for _, middleware := range middlewares {
-		middlewareData := map[string]interface{}{
-			middleware.Type: middleware.Config,
-		}
-
 		// 使用名称作为键
-		middlewareConfig[middleware.Name] = middlewareData
+		middlewareConfig[middleware.Name] = renderMiddleware(middleware)
 	}
 
 	return middlewareConfig
 }
+
+// renderMiddleware 构建单个中间件的配置
+func renderMiddleware(middleware traefikModel.TraefikMiddleware) map[string]interface{} {
+	return map[string]interface{}{
+		middleware.Type: middleware.Config,
+	}
+}
+
+// buildServersTransportsConfig 构建serversTransport配置，以名称为键
+func buildServersTransportsConfig(transports []traefikModel.TraefikServersTransport) map[string]interface{} {
+	transportConfig := make(map[string]interface{})
+
+	for _, transport := range transports {
+		transportConfig[transport.Name] = transport.Config
+	}
+
+	return transportConfig
+}
+
+// filterRouters 筛选指定协议的路由，未设置协议的视为http
+func filterRouters(routers []traefikModel.TraefikRouter, protocol string) []traefikModel.TraefikRouter {
+	var result []traefikModel.TraefikRouter
+	for _, router := range routers {
+		if protocolOf(router.Protocol) == protocol {
+			result = append(result, router)
+		}
+	}
+	return result
+}
+
+// filterServices 筛选指定协议的服务，未设置协议的视为http
+func filterServices(services []traefikModel.TraefikService, protocol string) []traefikModel.TraefikService {
+	var result []traefikModel.TraefikService
+	for _, service := range services {
+		if protocolOf(service.Protocol) == protocol {
+			result = append(result, service)
+		}
+	}
+	return result
+}
+
+// filterMiddlewares 筛选指定协议的中间件，未设置协议的视为http
+func filterMiddlewares(middlewares []traefikModel.TraefikMiddleware, protocol string) []traefikModel.TraefikMiddleware {
+	var result []traefikModel.TraefikMiddleware
+	for _, middleware := range middlewares {
+		if protocolOf(middleware.Protocol) == protocol {
+			result = append(result, middleware)
+		}
+	}
+	return result
+}
+
+// filterServersTransports 筛选指定协议的serversTransport，未设置协议的视为http
+func filterServersTransports(transports []traefikModel.TraefikServersTransport, protocol string) []traefikModel.TraefikServersTransport {
+	var result []traefikModel.TraefikServersTransport
+	for _, transport := range transports {
+		if protocolOf(transport.Protocol) == protocol {
+			result = append(result, transport)
+		}
+	}
+	return result
+}
+
+// protocolOf 返回对象协议，空值视为http
+func protocolOf(protocol string) string {
+	if protocol == "" {
+		return "http"
+	}
+	return protocol
+}
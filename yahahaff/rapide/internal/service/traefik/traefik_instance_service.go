@@ -0,0 +1,172 @@
+package traefik
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"strings"
+
+	traefikDAO "github.com/yahahaff/rapide/internal/dao/traefik"
+	traefikModel "github.com/yahahaff/rapide/internal/models/traefik"
+)
+
+// TraefikInstanceService Traefik实例管理服务
+type TraefikInstanceService struct {
+	traefikDAO *traefikDAO.TraefikDAO
+}
+
+// NewTraefikInstanceService 创建TraefikInstanceService实例
+func NewTraefikInstanceService() *TraefikInstanceService {
+	return &TraefikInstanceService{
+		traefikDAO: traefikDAO.NewTraefikDAO(),
+	}
+}
+
+// GetInstances 获取所有Traefik实例
+func (svc *TraefikInstanceService) GetInstances() ([]traefikModel.TraefikInstance, error) {
+	return svc.traefikDAO.GetAllInstances()
+}
+
+// GetInstanceByID 根据ID获取Traefik实例
+func (svc *TraefikInstanceService) GetInstanceByID(id uint64) (traefikModel.TraefikInstance, error) {
+	return svc.traefikDAO.GetInstanceByID(id)
+}
+
+// CreateInstance 创建Traefik实例，返回只展示一次的明文令牌
+func (svc *TraefikInstanceService) CreateInstance(instance *traefikModel.TraefikInstance) (string, error) {
+	if err := ValidateAllowIPs(instance.AllowIPs); err != nil {
+		return "", err
+	}
+
+	token, err := generateProviderToken()
+	if err != nil {
+		return "", err
+	}
+	instance.TokenHash = HashProviderToken(token)
+
+	if err := svc.traefikDAO.CreateInstance(instance); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// UpdateInstance 更新Traefik实例
+func (svc *TraefikInstanceService) UpdateInstance(instance *traefikModel.TraefikInstance) error {
+	if err := ValidateAllowIPs(instance.AllowIPs); err != nil {
+		return err
+	}
+	return svc.traefikDAO.UpdateInstance(instance)
+}
+
+// DeleteInstance 删除Traefik实例
+func (svc *TraefikInstanceService) DeleteInstance(id uint64) error {
+	return svc.traefikDAO.DeleteInstance(id)
+}
+
+// ResetInstanceToken 重置Traefik实例令牌，旧令牌立即失效
+func (svc *TraefikInstanceService) ResetInstanceToken(id uint64) (string, error) {
+	instance, err := svc.traefikDAO.GetInstanceByID(id)
+	if err != nil {
+		return "", err
+	}
+
+	token, err := generateProviderToken()
+	if err != nil {
+		return "", err
+	}
+	instance.TokenHash = HashProviderToken(token)
+
+	if err := svc.traefikDAO.UpdateInstance(&instance); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// AuthenticateInstance 校验Provider令牌，basic auth方式还需要用户名与实例名称一致
+func (svc *TraefikInstanceService) AuthenticateInstance(name, token string) (traefikModel.TraefikInstance, bool) {
+	if token == "" {
+		return traefikModel.TraefikInstance{}, false
+	}
+
+	instance, err := svc.traefikDAO.GetInstanceByTokenHash(HashProviderToken(token))
+	if err != nil {
+		return traefikModel.TraefikInstance{}, false
+	}
+	if name != "" && name != instance.Name {
+		return traefikModel.TraefikInstance{}, false
+	}
+	return instance, true
+}
+
+// IsIPAllowed 判断客户端IP是否在实例白名单内，白名单为空时不限制
+func (svc *TraefikInstanceService) IsIPAllowed(instance traefikModel.TraefikInstance, clientIP string) bool {
+	if len(instance.AllowIPs) == 0 {
+		return true
+	}
+
+	ip := net.ParseIP(clientIP)
+	if ip == nil {
+		return false
+	}
+
+	for _, allow := range instance.AllowIPs {
+		if strings.Contains(allow, "/") {
+			if _, network, err := net.ParseCIDR(allow); err == nil && network.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		if allowIP := net.ParseIP(allow); allowIP != nil && allowIP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateAllowIPs 校验IP白名单格式
+func ValidateAllowIPs(allowIPs []string) error {
+	for _, allow := range allowIPs {
+		if strings.Contains(allow, "/") {
+			if _, _, err := net.ParseCIDR(allow); err != nil {
+				return &validationError{message: "无效的CIDR: " + allow}
+			}
+			continue
+		}
+		if net.ParseIP(allow) == nil {
+			return &validationError{message: "无效的IP地址: " + allow}
+		}
+	}
+	return nil
+}
+
+// HashProviderToken 计算Provider令牌的SHA256摘要
+func HashProviderToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateProviderToken 生成随机Provider令牌
+func generateProviderToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// validationError 参数校验错误，控制器据此返回400
+type validationError struct {
+	message string
+}
+
+// Error 实现error接口
+func (e *validationError) Error() string {
+	return e.message
+}
+
+// IsValidationError 判断是否为参数校验错误
+func IsValidationError(err error) bool {
+	_, ok := err.(*validationError)
+	return ok
+}
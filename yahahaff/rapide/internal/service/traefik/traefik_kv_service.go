@@ -0,0 +1,150 @@
+package traefik
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	traefikDAO "github.com/yahahaff/rapide/internal/dao/traefik"
+	"github.com/yahahaff/rapide/pkg/config"
+	"github.com/yahahaff/rapide/pkg/etcd"
+	"github.com/yahahaff/rapide/pkg/logger"
+	"github.com/yahahaff/rapide/pkg/redis"
+)
+
+// TraefikKVService 将Traefik动态配置按KV Provider的键布局发布到etcd和Redis
+type TraefikKVService struct {
+	traefikDAO *traefikDAO.TraefikDAO
+}
+
+// KVPublishResult 单个KV后端的发布结果
+type KVPublishResult struct {
+	Backend string `json:"backend"` // etcd, redis
+	Keys    int    `json:"keys"`
+	Puts    int    `json:"puts"`
+	Deletes int    `json:"deletes"`
+	Error   string `json:"error,omitempty"`
+}
+
+// kvPublishSignal 新快照发布信号，容量为1，连续的变更会合并为一次发布
+var kvPublishSignal = make(chan struct{}, 1)
+
+// StartKVPublisher 启动后台发布协程，启动时先全量写入一次，之后每次发布新快照后写入
+func (ks *TraefikKVService) StartKVPublisher() {
+	notifyConfigChanged()
+	go func() {
+		for range kvPublishSignal {
+			if _, err := ks.Publish(); err != nil {
+				logger.ErrorString("traefik", "kv publish", err.Error())
+			}
+		}
+	}()
+}
+
+// Publish 将当前发布的配置写入所有启用的KV后端，根键下不再存在的键会被删除
+func (ks *TraefikKVService) Publish() ([]KVPublishResult, error) {
+	rootKey := config.GetString("TRAEFIK_KV_ROOT_KEY", "traefik")
+	kvs, err := ks.BuildKVPairs(rootKey)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]KVPublishResult, 0)
+	var lastErr error
+
+	if config.GetBool("TRAEFIK_KV_ETCD_ENABLED", false) {
+		result := KVPublishResult{Backend: "etcd", Keys: len(kvs)}
+		var err error
+		if etcd.Etcd == nil {
+			err = fmt.Errorf("etcd未连接")
+		} else {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			result.Puts, result.Deletes, err = etcd.Etcd.ReplacePrefix(ctx, rootKey+"/", kvs, config.GetInt("ETCD_MAX_TXN_OPS", 128))
+			cancel()
+		}
+		if err != nil {
+			result.Error = err.Error()
+			lastErr = err
+		}
+		results = append(results, result)
+	}
+
+	if config.GetBool("TRAEFIK_KV_REDIS_ENABLED", false) {
+		result := KVPublishResult{Backend: "redis", Keys: len(kvs)}
+		var err error
+		if redis.Redis == nil {
+			err = fmt.Errorf("Redis未连接")
+		} else {
+			result.Puts, result.Deletes, err = redis.Redis.ReplacePrefix(rootKey+"/", kvs)
+		}
+		if err != nil {
+			result.Error = err.Error()
+			lastErr = err
+		}
+		results = append(results, result)
+	}
+
+	return results, lastErr
+}
+
+// BuildKVPairs 构建当前发布的配置对应的KV键值对
+func (ks *TraefikKVService) BuildKVPairs(rootKey string) (map[string]string, error) {
+	set, err := loadPublishedConfigSet(ks.traefikDAO)
+	if err != nil {
+		return nil, err
+	}
+	config, err := renderConfigSetFor(set, defaultTraefikVersion())
+	if err != nil {
+		return nil, err
+	}
+	return FlattenKV(rootKey, config), nil
+}
+
+// FlattenKV 把动态配置展开为Traefik KV Provider的键值对
+// 对象按路径拼接，列表使用下标作为键，空对象写为"true"以启用该选项（如tls）
+func FlattenKV(rootKey string, config map[string]interface{}) map[string]string {
+	kvs := make(map[string]string)
+	flattenValue(rootKey, toJSONCompatible(config), kvs)
+	return kvs
+}
+
+// flattenValue 递归展开单个值
+func flattenValue(key string, value interface{}, kvs map[string]string) {
+	switch v := value.(type) {
+	case nil:
+		return
+	case map[string]interface{}:
+		if len(v) == 0 {
+			kvs[key] = "true"
+			return
+		}
+		keys := make([]string, 0, len(v))
+		for child := range v {
+			keys = append(keys, child)
+		}
+		sort.Strings(keys)
+		for _, child := range keys {
+			flattenValue(key+"/"+child, v[child], kvs)
+		}
+	case []interface{}:
+		for i, item := range v {
+			flattenValue(key+"/"+strconv.Itoa(i), item, kvs)
+		}
+	case float64:
+		kvs[key] = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		kvs[key] = fmt.Sprint(v)
+	}
+}
+
+// notifyConfigChanged 通知KV发布和Git同步的后台协程已发布新的配置快照，未启动协程时不会阻塞
+func notifyConfigChanged() {
+	for _, signal := range []chan struct{}{kvPublishSignal, gitSyncSignal} {
+		select {
+		case signal <- struct{}{}:
+		default:
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package traefik
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	traefikDAO "github.com/yahahaff/rapide/internal/dao/traefik"
+	traefikModel "github.com/yahahaff/rapide/internal/models/traefik"
+	"github.com/yahahaff/rapide/pkg/traefikrule"
+)
+
+// TraefikLintService 对整套配置做检查，发现单个对象校验无法发现的问题
+type TraefikLintService struct {
+	traefikDAO *traefikDAO.TraefikDAO
+}
+
+// LintIssue 检查发现的问题，error级别的问题会阻止发布
+type LintIssue struct {
+	Level    string `json:"level"` // error, warning
+	Check    string `json:"check"` // 检查项
+	Kind     string `json:"kind"`
+	Name     string `json:"name"`
+	Protocol string `json:"protocol"`
+	Related  string `json:"related,omitempty"` // 相关的路由
+	Message  string `json:"message"`
+}
+
+// LintReport 检查结果
+type LintReport struct {
+	Source  string         `json:"source"`
+	Issues  []LintIssue    `json:"issues"`
+	Summary map[string]int `json:"summary"`
+}
+
+// 检查项
+const (
+	lintInvalidRule        = "invalid_rule"        // 规则无法解析
+	lintDuplicateRule      = "duplicate_rule"      // 同一入口点上规则和优先级都相同，Traefik无法确定使用哪个
+	lintShadowedRule       = "shadowed_rule"       // 被优先级更高的规则完全覆盖，永远不会命中
+	lintTLSWithoutCert     = "tls_without_cert"    // 启用TLS但没有证书来源
+	lintUnusedMiddleware   = "unused_middleware"   // 中间件没有被任何路由使用
+	lintUnusedService      = "unused_service"      // 服务没有被任何路由引用
+	lintMissingTLSOption   = "missing_tls_option"  // 路由引用的TLS选项不存在
+	lintMissingTransport   = "missing_transport"   // 服务引用的serversTransport不存在
+	lintVersionUnsupported = "version_unsupported" // 实例的Traefik版本不支持该配置
+)
+
+// Lint 检查草稿或已发布的配置，source默认为draft
+func (ls *TraefikLintService) Lint(source string) (LintReport, error) {
+	if source == "" {
+		source = "draft"
+	}
+
+	var (
+		set ConfigSet
+		err error
+	)
+	switch source {
+	case "draft":
+		set, err = loadEnabledConfigSet(ls.traefikDAO)
+	case "published":
+		set, err = loadPublishedConfigSet(ls.traefikDAO)
+	default:
+		return LintReport{}, &validationError{message: "source只能是draft或published"}
+	}
+	if err != nil {
+		return LintReport{}, err
+	}
+
+	issues, err := lintSet(ls.traefikDAO, set)
+	if err != nil {
+		return LintReport{}, err
+	}
+	summary := map[string]int{"error": 0, "warning": 0}
+	for _, issue := range issues {
+		summary[issue.Level]++
+	}
+	return LintReport{Source: source, Issues: issues, Summary: summary}, nil
+}
+
+// lintSet 检查配置并按当前的Traefik实例检查版本兼容性
+// 草稿和快照中不包含路由关联的证书，检查前先加载，作为TLS证书来源
+func lintSet(dao *traefikDAO.TraefikDAO, set ConfigSet) ([]LintIssue, error) {
+	if set.Certificates == nil {
+		certificates, err := loadRouterCertificates(dao, set.Routers)
+		if err != nil {
+			return nil, err
+		}
+		set.Certificates = certificates
+	}
+	versionIssues, err := lintTargetVersions(dao, set)
+	if err != nil {
+		return nil, err
+	}
+	return append(lintConfigSet(set), versionIssues...), nil
+}
+
+// lintConfigSet 检查一组配置，结果按路由、中间件、引用、服务的顺序排列
+func lintConfigSet(set ConfigSet) []LintIssue {
+	issues := lintRouters(set.Routers, certifiedRouters(set))
+
+	used := withReferences(set, set.Routers, nil)
+	usedMiddlewares := make(map[string]bool, len(used.Middlewares))
+	for _, middleware := range used.Middlewares {
+		usedMiddlewares[refKey(middleware.Protocol, middleware.Name)] = true
+	}
+	usedServices := make(map[string]bool, len(used.Services))
+	for _, service := range used.Services {
+		usedServices[refKey(service.Protocol, service.Name)] = true
+	}
+
+	for _, middleware := range set.Middlewares {
+		if !usedMiddlewares[refKey(middleware.Protocol, middleware.Name)] {
+			issues = append(issues, LintIssue{
+				Level: "warning", Check: lintUnusedMiddleware, Kind: kindMiddleware,
+				Name: middleware.Name, Protocol: protocolOf(middleware.Protocol),
+				Message: "中间件没有被任何路由使用",
+			})
+		}
+	}
+	options := map[string]bool{"default": true} // Traefik总是提供名为default的TLS选项
+	for _, option := range set.TLSOptions {
+		options[option.Name] = true
+	}
+	for _, router := range set.Routers {
+		if name, ok := routerTLSOption(router); ok && router.TLS != nil && !options[name] {
+			issues = append(issues, routerIssue(router, "error", lintMissingTLSOption, "", "引用的TLS选项不存在: "+name))
+		}
+	}
+	transports := make(map[string]bool, len(set.ServersTransports))
+	for _, transport := range set.ServersTransports {
+		transports[refKey(transport.Protocol, transport.Name)] = true
+	}
+	for _, service := range set.Services {
+		if name, ok := serviceTransport(service); ok && !transports[refKey(service.Protocol, name)] {
+			issues = append(issues, LintIssue{
+				Level: "error", Check: lintMissingTransport, Kind: kindService,
+				Name: service.Name, Protocol: protocolOf(service.Protocol),
+				Message: "引用的serversTransport不存在: " + name,
+			})
+		}
+	}
+
+	for _, service := range set.Services {
+		if !usedServices[refKey(service.Protocol, service.Name)] {
+			issues = append(issues, LintIssue{
+				Level: "warning", Check: lintUnusedService, Kind: kindService,
+				Name: service.Name, Protocol: protocolOf(service.Protocol),
+				Message: "服务没有被任何路由引用",
+			})
+		}
+	}
+	return issues
+}
+
+// certifiedRouters 有证书来源的路由，格式为协议/名称，返回nil表示所有路由都有证书
+// 关联了证书的路由有证书；TLS存储default设置了默认证书时，没有匹配证书的请求都使用该证书
+func certifiedRouters(set ConfigSet) map[string]bool {
+	for _, store := range set.TLSStores {
+		if store.Name == "default" && (store.Config["defaultCertificate"] != nil || store.Config["defaultGeneratedCert"] != nil) {
+			return nil
+		}
+	}
+	certified := make(map[string]bool)
+	for _, certificate := range set.Certificates {
+		for _, router := range certificate.Routers {
+			certified[router] = true
+		}
+	}
+	return certified
+}
+
+// lintRouters 检查规则冲突、覆盖和TLS证书来源，certified为有证书来源的路由，为nil时所有路由都有证书
+// 只比较协议、TLS设置相同并且有共同入口点的路由，未设置入口点的路由监听所有入口点
+func lintRouters(routers []traefikModel.TraefikRouter, certified map[string]bool) []LintIssue {
+	issues := make([]LintIssue, 0)
+	sorted := make([]traefikModel.TraefikRouter, 0, len(routers))
+	terms := make(map[string][]traefikrule.Term)
+
+	for _, router := range routers {
+		protocol := protocolOf(router.Protocol)
+		resolver, _ := router.TLS["certResolver"].(string)
+		if router.TLS != nil && resolver == "" && router.TLS["passthrough"] != true && certified != nil && !certified[refKey(protocol, router.Name)] {
+			issues = append(issues, routerIssue(router, "warning", lintTLSWithoutCert, "", "路由启用了TLS但没有设置certResolver、关联证书或默认证书，将使用Traefik自动生成的证书"))
+		}
+		if protocol == "udp" {
+			continue
+		}
+		normalized, err := traefikrule.Normalize(router.Rule, protocol, router.RuleSyntax)
+		if err != nil {
+			// 过于复杂的规则不参与覆盖分析
+			if !errors.Is(err, traefikrule.ErrTooComplex) {
+				issues = append(issues, routerIssue(router, "error", lintInvalidRule, "", "规则无效: "+err.Error()))
+			}
+			continue
+		}
+		terms[refKey(protocol, router.Name)] = normalized
+		sorted = append(sorted, router)
+	}
+	sortRoutersByPriority(sorted)
+
+	// 每个路由只报告第一个覆盖它的路由
+	for i, low := range sorted {
+		lowTerms := terms[refKey(low.Protocol, low.Name)]
+		for _, high := range sorted[:i] {
+			if protocolOf(high.Protocol) != protocolOf(low.Protocol) || (high.TLS != nil) != (low.TLS != nil) || !shareEntryPoint(high, low) {
+				continue
+			}
+			highTerms := terms[refKey(high.Protocol, high.Name)]
+			if !traefikrule.Covers(highTerms, lowTerms) {
+				continue
+			}
+			if routerPriority(high) == routerPriority(low) && traefikrule.Covers(lowTerms, highTerms) {
+				issues = append(issues, routerIssue(low, "error", lintDuplicateRule, high.Name,
+					fmt.Sprintf("与路由%s的规则和优先级相同，Traefik无法确定使用哪个", high.Name)))
+			} else {
+				issues = append(issues, routerIssue(low, "warning", lintShadowedRule, high.Name,
+					fmt.Sprintf("被优先级为%d的路由%s完全覆盖，永远不会命中", routerPriority(high), high.Name)))
+			}
+			break
+		}
+	}
+	return issues
+}
+
+// routerIssue 生成路由的检查问题
+func routerIssue(router traefikModel.TraefikRouter, level, check, related, message string) LintIssue {
+	return LintIssue{
+		Level: level, Check: check, Kind: kindRouter,
+		Name: router.Name, Protocol: protocolOf(router.Protocol),
+		Related: related, Message: message,
+	}
+}
+
+// shareEntryPoint 判断两个路由是否监听同一个入口点
+func shareEntryPoint(a, b traefikModel.TraefikRouter) bool {
+	return len(a.EntryPoints) == 0 || len(b.EntryPoints) == 0 || intersects(a.EntryPoints, b.EntryPoints)
+}
+
+// lintErrors 汇总error级别的问题，没有时返回nil
+func lintErrors(issues []LintIssue) error {
+	var messages []string
+	for _, issue := range issues {
+		if issue.Level == "error" {
+			messages = append(messages, fmt.Sprintf("%s %s: %s", issue.Kind, issue.Name, issue.Message))
+		}
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+	return &validationError{message: "配置检查未通过: " + strings.Join(messages, "; ")}
+}
@@ -0,0 +1,336 @@
+package traefik
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	traefikDAO "github.com/yahahaff/rapide/internal/dao/traefik"
+	sysModel "github.com/yahahaff/rapide/internal/models/sys"
+	traefikModel "github.com/yahahaff/rapide/internal/models/traefik"
+	"github.com/yahahaff/rapide/pkg/config"
+	"github.com/yahahaff/rapide/pkg/database"
+	"gorm.io/gorm"
+)
+
+// TraefikMaintenanceService 管理路由的维护窗口
+// 维护期间在下发给Traefik的配置中把路由指向维护页面，草稿和发布快照不变，结束后自然恢复原服务
+type TraefikMaintenanceService struct {
+	traefikDAO *traefikDAO.TraefikDAO
+}
+
+// maintenancePrefix 维护期间生成的路由、服务和中间件的名称前缀
+const maintenancePrefix = "rapide-maintenance-"
+
+// maintenancePagePath rapide提供维护页面的路径，后接维护窗口ID
+const maintenancePagePath = "/api/traefik/maintenance/"
+
+// defaultMaintenancePage 未指定维护页面时使用的页面
+const defaultMaintenancePage = `<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>系统维护中</title></head>
+<body style="font-family:sans-serif;text-align:center;padding-top:15%%">
+<h1>系统维护中</h1>
+<p>%s</p>
+</body>
+</html>`
+
+// MaintenanceInput 创建维护窗口的参数
+type MaintenanceInput struct {
+	Router     string
+	Backend    string     // 提供维护页面的静态后端，为空时由rapide返回维护页面
+	StatusCode int        // 默认503
+	Page       string     // 维护页面HTML
+	Allowlist  []string   // 可以继续访问原服务的IP或CIDR
+	StartAt    *time.Time // 为空时立即开始
+	EndAt      *time.Time // 为空时需要手动结束
+	Reason     string
+}
+
+// CreateMaintenance 创建维护窗口，开始时间为空或已过时立即生效
+// 同一路由的维护窗口时间不能重叠
+func (ms *TraefikMaintenanceService) CreateMaintenance(input MaintenanceInput, operator string) (traefikModel.TraefikMaintenance, error) {
+	now := time.Now()
+	if err := validateMaintenanceInput(&input, now); err != nil {
+		return traefikModel.TraefikMaintenance{}, err
+	}
+
+	var maintenance traefikModel.TraefikMaintenance
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		dao := ms.traefikDAO.WithTx(tx)
+		if _, err := dao.GetRouter(input.Router, "http"); err != nil {
+			return err
+		}
+		pending, err := dao.GetPendingMaintenances("http", input.Router)
+		if err != nil {
+			return err
+		}
+		for _, other := range pending {
+			if maintenanceOverlaps(other, *input.StartAt, input.EndAt) {
+				return &validationError{message: fmt.Sprintf("路由%s的维护窗口与已有的维护窗口(ID %d)时间重叠", input.Router, other.ID)}
+			}
+		}
+
+		maintenance = traefikModel.TraefikMaintenance{
+			Router:     input.Router,
+			Protocol:   "http",
+			Backend:    input.Backend,
+			StatusCode: input.StatusCode,
+			Page:       input.Page,
+			Allowlist:  input.Allowlist,
+			StartAt:    *input.StartAt,
+			EndAt:      input.EndAt,
+			Status:     "scheduled",
+			Reason:     input.Reason,
+			Operator:   operator,
+		}
+		maintenance.Events = append(maintenance.Events, traefikModel.MaintenanceEvent{At: now, Action: "schedule", Operator: operator, Message: input.Reason})
+		if !maintenance.StartAt.After(now) {
+			startMaintenance(&maintenance, now, operator)
+		}
+		return dao.CreateMaintenance(&maintenance)
+	})
+	if err == nil && maintenance.Status == "active" {
+		notifyConfigChanged()
+	}
+	return maintenance, err
+}
+
+// EndMaintenance 手动结束进行中的维护窗口或取消计划中的维护窗口，路由恢复指向原服务
+func (ms *TraefikMaintenanceService) EndMaintenance(id uint64, operator string) (traefikModel.TraefikMaintenance, error) {
+	maintenance, err := ms.traefikDAO.GetMaintenanceByID(id)
+	if err != nil {
+		return maintenance, err
+	}
+
+	now := time.Now()
+	switch maintenance.Status {
+	case "active":
+		finishMaintenance(&maintenance, now, "finish", operator, "手动结束")
+	case "scheduled":
+		// 开始时间已到但定时任务尚未处理时，路由已经处于维护状态
+		action := "cancel"
+		if maintenanceEffective(maintenance, now) {
+			action = "finish"
+		}
+		finishMaintenance(&maintenance, now, action, operator, "手动结束")
+	default:
+		return maintenance, &validationError{message: "只能结束计划中或进行中的维护窗口"}
+	}
+	if err := ms.traefikDAO.SaveMaintenance(&maintenance); err != nil {
+		return maintenance, err
+	}
+	notifyConfigChanged()
+	return maintenance, nil
+}
+
+// ProcessMaintenances 按时间开始和结束维护窗口，记录状态变化并通知KV重新发布
+// 下发的配置按时间判断维护窗口是否生效，这里只负责更新状态；单个窗口保存失败时继续处理其他窗口
+func (ms *TraefikMaintenanceService) ProcessMaintenances() error {
+	now := time.Now()
+	pending, err := ms.traefikDAO.GetPendingMaintenances("", "")
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	var errs []error
+	for i := range pending {
+		maintenance := &pending[i]
+		switch {
+		case maintenance.EndAt != nil && !maintenance.EndAt.After(now):
+			finishMaintenance(maintenance, now, "finish", "system", "维护窗口到期")
+		case maintenance.Status == "scheduled" && !maintenance.StartAt.After(now):
+			startMaintenance(maintenance, now, "system")
+		default:
+			continue
+		}
+		if err := ms.traefikDAO.SaveMaintenance(maintenance); err != nil {
+			errs = append(errs, fmt.Errorf("维护窗口%d: %w", maintenance.ID, err))
+			continue
+		}
+		changed = true
+	}
+	// 部分窗口保存失败时，已经变化的窗口仍然需要下发
+	if changed {
+		notifyConfigChanged()
+	}
+	return errors.Join(errs...)
+}
+
+// GetMaintenances 分页获取范围内部门的路由的维护窗口
+func (ms *TraefikMaintenanceService) GetMaintenances(router, status string, scope sysModel.DeptScope, page, size int) ([]traefikModel.TraefikMaintenance, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 || size > 100 {
+		size = 20
+	}
+	return ms.traefikDAO.GetMaintenances(router, status, scope, page, size)
+}
+
+// GetMaintenance 获取维护窗口详情
+func (ms *TraefikMaintenanceService) GetMaintenance(id uint64) (traefikModel.TraefikMaintenance, error) {
+	return ms.traefikDAO.GetMaintenanceByID(id)
+}
+
+// MaintenancePage 获取生效中的维护窗口的状态码和页面，供Traefik转发的请求使用
+func (ms *TraefikMaintenanceService) MaintenancePage(id uint64) (int, string, *time.Time, error) {
+	maintenance, err := ms.traefikDAO.GetMaintenanceByID(id)
+	if err != nil {
+		return 0, "", nil, err
+	}
+	if !maintenanceEffective(maintenance, time.Now()) {
+		return 0, "", nil, gorm.ErrRecordNotFound
+	}
+
+	page := maintenance.Page
+	if page == "" {
+		message := "服务正在维护，请稍后再试"
+		if maintenance.EndAt != nil {
+			message = fmt.Sprintf("服务正在维护，预计%s恢复", maintenance.EndAt.Format("2006-01-02 15:04"))
+		}
+		page = fmt.Sprintf(defaultMaintenancePage, html.EscapeString(message))
+	}
+	return maintenance.StatusCode, page, maintenance.EndAt, nil
+}
+
+// applyMaintenances 把生效中的维护窗口应用到下发的配置
+// 路由改为指向维护服务，设置了白名单时另建优先级更高的路由，让白名单中的客户端继续访问原服务
+func applyMaintenances(dao *traefikDAO.TraefikDAO, set *ConfigSet, now time.Time) error {
+	pending, err := dao.GetPendingMaintenances("", "")
+	if err != nil {
+		return err
+	}
+
+	for _, maintenance := range pending {
+		if !maintenanceEffective(maintenance, now) {
+			continue
+		}
+		for i := range set.Routers {
+			router := set.Routers[i]
+			if refKey(router.Protocol, router.Name) != refKey(maintenance.Protocol, maintenance.Router) {
+				continue
+			}
+
+			name := maintenancePrefix + router.Name
+			if len(maintenance.Allowlist) > 0 {
+				bypass := router
+				bypass.Name = name + "-bypass"
+				bypass.Rule = fmt.Sprintf("(%s) && (%s)", router.Rule, allowlistRule(maintenance.Allowlist))
+				bypass.Priority = routerPriority(router) + 1
+				set.Routers = append(set.Routers, bypass)
+			}
+
+			backend := maintenance.Backend
+			// 维护页面不经过原路由的中间件，避免被认证等中间件拦截
+			set.Routers[i].Middlewares = nil
+			if backend == "" {
+				backend = strings.TrimRight(config.GetString("TRAEFIK_MAINTENANCE_URL", ""), "/")
+				set.Routers[i].Middlewares = []string{name}
+				set.Middlewares = append(set.Middlewares, traefikModel.TraefikMiddleware{
+					Name:     name,
+					Type:     "replacePath",
+					Protocol: "http",
+					Config:   map[string]interface{}{"path": fmt.Sprintf("%s%d", maintenancePagePath, maintenance.ID)},
+					Tags:     router.Tags,
+				})
+			}
+			set.Routers[i].Service = name
+			set.Services = append(set.Services, traefikModel.TraefikService{
+				Name:     name,
+				Type:     "loadbalancer",
+				Protocol: "http",
+				LoadBalancer: map[string]interface{}{
+					"servers":        []interface{}{map[string]interface{}{"url": backend}},
+					"passHostHeader": false,
+				},
+				Tags: router.Tags,
+			})
+			break
+		}
+	}
+	return nil
+}
+
+// allowlistRule 生成匹配白名单客户端的规则，每个地址一个ClientIP，兼容v2和v3语法
+// ClientIP按连接的来源地址匹配，Traefik前面还有代理时需要配置入口点的forwardedHeaders或proxyProtocol
+func allowlistRule(allowlist []string) string {
+	matchers := make([]string, 0, len(allowlist))
+	for _, address := range allowlist {
+		matchers = append(matchers, fmt.Sprintf("ClientIP(`%s`)", address))
+	}
+	return strings.Join(matchers, " || ")
+}
+
+// maintenanceEffective 判断维护窗口在指定时间是否生效
+func maintenanceEffective(maintenance traefikModel.TraefikMaintenance, now time.Time) bool {
+	if maintenance.Status != "scheduled" && maintenance.Status != "active" {
+		return false
+	}
+	return !maintenance.StartAt.After(now) && (maintenance.EndAt == nil || maintenance.EndAt.After(now))
+}
+
+// maintenanceOverlaps 判断维护窗口与指定时间段是否重叠，结束时间为空表示不会自动结束
+func maintenanceOverlaps(maintenance traefikModel.TraefikMaintenance, startAt time.Time, endAt *time.Time) bool {
+	if endAt != nil && !endAt.After(maintenance.StartAt) {
+		return false
+	}
+	return maintenance.EndAt == nil || maintenance.EndAt.After(startAt)
+}
+
+// startMaintenance 开始维护窗口
+func startMaintenance(maintenance *traefikModel.TraefikMaintenance, now time.Time, operator string) {
+	maintenance.Status = "active"
+	maintenance.Events = append(maintenance.Events, traefikModel.MaintenanceEvent{At: now, Action: "start", Operator: operator})
+}
+
+// finishMaintenance 结束或取消维护窗口
+func finishMaintenance(maintenance *traefikModel.TraefikMaintenance, now time.Time, action, operator, message string) {
+	maintenance.Status = "finished"
+	if action == "cancel" {
+		maintenance.Status = "cancelled"
+	}
+	maintenance.FinishedAt = &now
+	maintenance.Events = append(maintenance.Events, traefikModel.MaintenanceEvent{At: now, Action: action, Operator: operator, Message: message})
+}
+
+// validateMaintenanceInput 校验并补全维护窗口参数
+func validateMaintenanceInput(input *MaintenanceInput, now time.Time) error {
+	if input.StartAt == nil {
+		input.StartAt = &now
+	}
+	if input.EndAt != nil && !input.EndAt.After(*input.StartAt) {
+		return &validationError{message: "结束时间必须晚于开始时间"}
+	}
+	if input.EndAt != nil && !input.EndAt.After(now) {
+		return &validationError{message: "结束时间已过"}
+	}
+	if input.StatusCode == 0 {
+		input.StatusCode = 503
+	}
+
+	if input.Backend != "" {
+		u, err := url.Parse(input.Backend)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return &validationError{message: "维护页面后端应为http或https地址"}
+		}
+	} else if config.GetString("TRAEFIK_MAINTENANCE_URL", "") == "" {
+		return &validationError{message: "未配置TRAEFIK_MAINTENANCE_URL，需要指定提供维护页面的后端"}
+	}
+
+	for i, address := range input.Allowlist {
+		address = strings.TrimSpace(address)
+		if net.ParseIP(address) == nil {
+			if _, _, err := net.ParseCIDR(address); err != nil {
+				return &validationError{message: "白名单地址无效: " + address}
+			}
+		}
+		input.Allowlist[i] = address
+	}
+	return nil
+}
@@ -0,0 +1,642 @@
+package traefik
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	traefikDAO "github.com/yahahaff/rapide/internal/dao/traefik"
+	traefikModel "github.com/yahahaff/rapide/internal/models/traefik"
+	"gopkg.in/yaml.v3"
+)
+
+// TraefikManifestService 把数据库中的路由及其引用的服务、中间件转换为其他Provider的写法
+// 目前支持Kubernetes CRD（traefik.io/v1alpha1）和docker-compose标签
+type TraefikManifestService struct {
+	traefikDAO *traefikDAO.TraefikDAO
+}
+
+// ManifestFilter 选择要导出的路由，都为空时导出全部启用的路由
+type ManifestFilter struct {
+	Routers []string // 路由名称
+	Tags    []string // 路由标签，与名称同时指定时取并集
+}
+
+// ManifestResult 导出结果
+type ManifestResult struct {
+	Content  string   `json:"content"`
+	Routers  int      `json:"routers"`
+	Warnings []string `json:"warnings"`
+}
+
+// crdAPIVersion Traefik CRD的API版本
+const crdAPIVersion = "traefik.io/v1alpha1"
+
+// k8sNameInvalid Kubernetes资源名称中不允许的字符
+var k8sNameInvalid = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// ExportKubernetes 导出为Kubernetes CRD清单，多个资源以---分隔
+// 负载均衡服务在CRD中引用同名的Kubernetes Service，端口取自第一个后端地址
+func (ms *TraefikManifestService) ExportKubernetes(filter ManifestFilter, namespace string) (ManifestResult, error) {
+	set, err := ms.selectConfigSet(filter)
+	if err != nil {
+		return ManifestResult{}, err
+	}
+
+	exporter := &crdExporter{set: set, namespace: namespace, warnings: make([]string, 0)}
+	documents := exporter.documents()
+
+	var buf bytes.Buffer
+	for i, document := range documents {
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+		if err := encodeManifestYAML(&buf, document); err != nil {
+			return ManifestResult{}, err
+		}
+	}
+	return ManifestResult{Content: buf.String(), Routers: len(set.Routers), Warnings: exporter.warnings}, nil
+}
+
+// ExportDockerLabels 导出为docker-compose的labels配置，按路由使用的负载均衡服务分组，每组对应一个容器
+func (ms *TraefikManifestService) ExportDockerLabels(filter ManifestFilter) (ManifestResult, error) {
+	set, err := ms.selectConfigSet(filter)
+	if err != nil {
+		return ManifestResult{}, err
+	}
+
+	exporter := &labelExporter{set: set, warnings: make([]string, 0)}
+	containers := exporter.containers()
+	if len(containers) == 0 {
+		return ManifestResult{Content: "", Routers: len(set.Routers), Warnings: exporter.warnings}, nil
+	}
+
+	services := make(map[string]interface{}, len(containers))
+	for name, labels := range containers {
+		services[name] = map[string]interface{}{"labels": labels}
+	}
+	var buf bytes.Buffer
+	if err := encodeManifestYAML(&buf, map[string]interface{}{"services": services}); err != nil {
+		return ManifestResult{}, err
+	}
+	return ManifestResult{Content: buf.String(), Routers: len(set.Routers), Warnings: exporter.warnings}, nil
+}
+
+// encodeManifestYAML 以Kubernetes和docker-compose常用的两空格缩进输出YAML
+func encodeManifestYAML(buf *bytes.Buffer, value interface{}) error {
+	encoder := yaml.NewEncoder(buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(value); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// selectConfigSet 按条件选出路由，并带上它们引用的服务和中间件
+func (ms *TraefikManifestService) selectConfigSet(filter ManifestFilter) (ConfigSet, error) {
+	set, err := loadEnabledConfigSet(ms.traefikDAO)
+	if err != nil {
+		return ConfigSet{}, err
+	}
+
+	var routers []traefikModel.TraefikRouter
+	for _, router := range set.Routers {
+		selected := len(filter.Routers) == 0 && len(filter.Tags) == 0
+		for _, name := range filter.Routers {
+			if router.Name == name {
+				selected = true
+			}
+		}
+		if len(filter.Tags) > 0 && intersects(router.Tags, filter.Tags) {
+			selected = true
+		}
+		if selected {
+			routers = append(routers, router)
+		}
+	}
+	if len(routers) == 0 {
+		return ConfigSet{}, &validationError{message: "没有匹配的路由"}
+	}
+
+	sort.Slice(routers, func(i, j int) bool {
+		if protocolOf(routers[i].Protocol) != protocolOf(routers[j].Protocol) {
+			return protocolOf(routers[i].Protocol) < protocolOf(routers[j].Protocol)
+		}
+		return routers[i].Name < routers[j].Name
+	})
+	return withReferences(set, routers, nil), nil
+}
+
+// crdExporter 生成Kubernetes CRD清单
+type crdExporter struct {
+	set       ConfigSet
+	namespace string
+	warnings  []string
+}
+
+// documents 依次生成中间件、TraefikService和路由资源
+func (e *crdExporter) documents() []map[string]interface{} {
+	documents := make([]map[string]interface{}, 0)
+
+	for _, middleware := range e.set.Middlewares {
+		kind := "Middleware"
+		if protocolOf(middleware.Protocol) == "tcp" {
+			kind = "MiddlewareTCP"
+		}
+		documents = append(documents, e.resource(kind, middleware.Name, map[string]interface{}{
+			middleware.Type: e.middlewareSpec(middleware),
+		}))
+	}
+
+	for _, service := range e.set.Services {
+		switch service.Type {
+		case "weighted":
+			documents = append(documents, e.resource("TraefikService", service.Name, map[string]interface{}{
+				"weighted": e.weightedSpec(service),
+			}))
+		case "mirror":
+			documents = append(documents, e.resource("TraefikService", service.Name, map[string]interface{}{
+				"mirroring": e.mirroringSpec(service),
+			}))
+		default:
+			e.describeBackend(service)
+		}
+	}
+
+	for _, router := range e.set.Routers {
+		kind := map[string]string{"http": "IngressRoute", "tcp": "IngressRouteTCP", "udp": "IngressRouteUDP"}[protocolOf(router.Protocol)]
+		documents = append(documents, e.resource(kind, router.Name, e.routeSpec(router)))
+	}
+	return documents
+}
+
+// resource 构建一个CRD资源
+func (e *crdExporter) resource(kind, name string, spec map[string]interface{}) map[string]interface{} {
+	metadata := map[string]interface{}{"name": e.name(name)}
+	if e.namespace != "" {
+		metadata["namespace"] = e.namespace
+	}
+	return map[string]interface{}{
+		"apiVersion": crdAPIVersion,
+		"kind":       kind,
+		"metadata":   metadata,
+		"spec":       spec,
+	}
+}
+
+// routeSpec 构建IngressRoute、IngressRouteTCP或IngressRouteUDP的spec
+func (e *crdExporter) routeSpec(router traefikModel.TraefikRouter) map[string]interface{} {
+	protocol := protocolOf(router.Protocol)
+	route := map[string]interface{}{
+		"services": []interface{}{e.serviceRef(protocol, router.Service)},
+	}
+	if protocol != "udp" {
+		route["match"] = router.Rule
+	}
+	if protocol == "http" {
+		route["kind"] = "Rule"
+	}
+	if router.Priority > 0 {
+		route["priority"] = router.Priority
+	}
+	if router.RuleSyntax != "" && router.RuleSyntax != "default" {
+		route["syntax"] = router.RuleSyntax
+	}
+	if len(router.Middlewares) > 0 {
+		middlewares := make([]interface{}, 0, len(router.Middlewares))
+		for _, middleware := range router.Middlewares {
+			middlewares = append(middlewares, map[string]interface{}{"name": e.ref(middleware)})
+		}
+		route["middlewares"] = middlewares
+	}
+
+	spec := map[string]interface{}{"routes": []interface{}{route}}
+	if len(router.EntryPoints) > 0 {
+		spec["entryPoints"] = []string(router.EntryPoints)
+	}
+	if router.TLS != nil && protocol != "udp" {
+		spec["tls"] = e.tlsSpec(router)
+	}
+	return spec
+}
+
+// tlsSpec 转换路由的TLS配置，options在CRD中引用TLSOption资源
+func (e *crdExporter) tlsSpec(router traefikModel.TraefikRouter) map[string]interface{} {
+	tls := make(map[string]interface{})
+	for key, value := range router.TLS {
+		switch key {
+		case "certResolver", "domains", "passthrough":
+			tls[key] = value
+		case "options":
+			if name, ok := value.(string); ok && name != "" {
+				tls["options"] = map[string]interface{}{"name": e.ref(name)}
+			}
+		default:
+			e.warn("路由%s的TLS配置%s在CRD中不支持，已忽略", router.Name, key)
+		}
+	}
+	return tls
+}
+
+// serviceRef 构建路由或TraefikService对服务的引用
+// 负载均衡服务引用同名的Kubernetes Service，加权和镜像服务引用TraefikService，其他Provider的服务按TraefikService引用
+func (e *crdExporter) serviceRef(protocol, ref string) map[string]interface{} {
+	service, ok := e.localService(protocol, ref)
+	if !ok {
+		return map[string]interface{}{"name": e.ref(ref), "kind": "TraefikService"}
+	}
+	if service.Type != "loadbalancer" {
+		return map[string]interface{}{"name": e.name(service.Name), "kind": "TraefikService"}
+	}
+
+	result := map[string]interface{}{"name": e.name(service.Name)}
+	servers, _ := service.LoadBalancer["servers"].([]interface{})
+	if len(servers) > 0 {
+		if server, ok := servers[0].(map[string]interface{}); ok {
+			scheme, port := backendPort(server)
+			if port > 0 {
+				result["port"] = port
+			}
+			if scheme == "https" || scheme == "h2c" {
+				result["scheme"] = scheme
+			}
+		}
+	}
+	// 服务级别的负载均衡选项可直接写在引用上
+	for _, key := range []string{"passHostHeader", "responseForwarding", "serversTransport", "sticky", "proxyProtocol", "terminationDelay"} {
+		if value, ok := service.LoadBalancer[key]; ok {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+// weightedSpec 转换加权服务
+func (e *crdExporter) weightedSpec(service traefikModel.TraefikService) map[string]interface{} {
+	spec := make(map[string]interface{})
+	if items, ok := service.Weighted["services"].([]interface{}); ok {
+		refs := make([]interface{}, 0, len(items))
+		for _, item := range items {
+			child, _ := item.(map[string]interface{})
+			name, _ := child["name"].(string)
+			ref := e.serviceRef(protocolOf(service.Protocol), name)
+			if weight, ok := child["weight"]; ok {
+				ref["weight"] = weight
+			}
+			refs = append(refs, ref)
+		}
+		spec["services"] = refs
+	}
+	if sticky, ok := service.Weighted["sticky"]; ok {
+		spec["sticky"] = sticky
+	}
+	return spec
+}
+
+// mirroringSpec 转换镜像服务
+func (e *crdExporter) mirroringSpec(service traefikModel.TraefikService) map[string]interface{} {
+	main, _ := service.Mirror["service"].(string)
+	spec := e.serviceRef(protocolOf(service.Protocol), main)
+	if items, ok := service.Mirror["mirrors"].([]interface{}); ok {
+		mirrors := make([]interface{}, 0, len(items))
+		for _, item := range items {
+			child, _ := item.(map[string]interface{})
+			name, _ := child["name"].(string)
+			ref := e.serviceRef(protocolOf(service.Protocol), name)
+			if percent, ok := child["percent"]; ok {
+				ref["percent"] = percent
+			}
+			mirrors = append(mirrors, ref)
+		}
+		spec["mirrors"] = mirrors
+	}
+	for _, key := range []string{"maxBodySize", "mirrorBody"} {
+		if value, ok := service.Mirror[key]; ok {
+			spec[key] = value
+		}
+	}
+	return spec
+}
+
+// middlewareSpec 转换中间件配置，chain引用的中间件在CRD中写为{name: xxx}
+func (e *crdExporter) middlewareSpec(middleware traefikModel.TraefikMiddleware) interface{} {
+	switch middleware.Type {
+	case "chain":
+		refs := make([]interface{}, 0)
+		if items, ok := middleware.Config["middlewares"].([]interface{}); ok {
+			for _, item := range items {
+				if name, ok := item.(string); ok {
+					refs = append(refs, map[string]interface{}{"name": e.ref(name)})
+				}
+			}
+		}
+		return map[string]interface{}{"middlewares": refs}
+	case "basicAuth", "digestAuth":
+		if _, ok := middleware.Config["users"]; ok {
+			e.warn("中间件%s的users在CRD中需要改为引用Secret（secret字段）", middleware.Name)
+		}
+	case "errors":
+		e.warn("中间件%s的service在CRD中需要改为Kubernetes Service引用", middleware.Name)
+	}
+	return map[string]interface{}(middleware.Config)
+}
+
+// describeBackend 提示负载均衡服务在Kubernetes中需要对应的Service
+func (e *crdExporter) describeBackend(service traefikModel.TraefikService) {
+	servers, _ := service.LoadBalancer["servers"].([]interface{})
+	addresses := make([]string, 0, len(servers))
+	ports := make(map[int]bool)
+	for _, item := range servers {
+		server, _ := item.(map[string]interface{})
+		for _, key := range []string{"url", "address"} {
+			if address, ok := server[key].(string); ok {
+				addresses = append(addresses, address)
+			}
+		}
+		if _, port := backendPort(server); port > 0 {
+			ports[port] = true
+		}
+	}
+	e.warn("服务%s需要在Kubernetes中提供名为%s的Service，原后端地址: %s", service.Name, e.name(service.Name), strings.Join(addresses, ", "))
+	if len(ports) > 1 {
+		e.warn("服务%s的后端使用了不同端口，CRD中只使用第一个后端的端口", service.Name)
+	}
+}
+
+// localService 查找被引用的本Provider服务
+func (e *crdExporter) localService(protocol, ref string) (traefikModel.TraefikService, bool) {
+	name, ok := localRefName(ref)
+	if !ok {
+		return traefikModel.TraefikService{}, false
+	}
+	for _, service := range e.set.Services {
+		if service.Name == name && protocolOf(service.Protocol) == protocol {
+			return service, true
+		}
+	}
+	return traefikModel.TraefikService{}, false
+}
+
+// ref 转换对象引用，本Provider的引用转换为资源名称，其他Provider的引用保持name@provider形式
+func (e *crdExporter) ref(ref string) string {
+	if name, ok := localRefName(ref); ok {
+		return e.name(name)
+	}
+	return ref
+}
+
+// name 把对象名称转换为合法的Kubernetes资源名称
+func (e *crdExporter) name(name string) string {
+	converted := strings.Trim(k8sNameInvalid.ReplaceAllString(strings.ToLower(name), "-"), "-.")
+	if converted != name {
+		e.warnOnce(fmt.Sprintf("名称%s不符合Kubernetes命名规则，已转换为%s", name, converted))
+	}
+	return converted
+}
+
+// warn 记录一条警告
+func (e *crdExporter) warn(format string, args ...interface{}) {
+	e.warnOnce(fmt.Sprintf(format, args...))
+}
+
+// warnOnce 记录警告，相同内容只记录一次
+func (e *crdExporter) warnOnce(message string) {
+	for _, warning := range e.warnings {
+		if warning == message {
+			return
+		}
+	}
+	e.warnings = append(e.warnings, message)
+}
+
+// labelExporter 生成docker-compose标签
+type labelExporter struct {
+	set      ConfigSet
+	warnings []string
+}
+
+// containers 按负载均衡服务分组生成标签，每组包含使用该服务的路由和它们引用的中间件
+func (e *labelExporter) containers() map[string][]string {
+	routersByService := make(map[string][]traefikModel.TraefikRouter)
+	services := make(map[string]traefikModel.TraefikService)
+	for _, router := range e.set.Routers {
+		protocol := protocolOf(router.Protocol)
+		service, ok := e.localService(protocol, router.Service)
+		if !ok || service.Type != "loadbalancer" {
+			e.warnings = append(e.warnings, fmt.Sprintf("路由%s使用的服务%s不是本地的负载均衡服务，Docker标签无法表示，已跳过", router.Name, router.Service))
+			continue
+		}
+		key := refKey(protocol, service.Name)
+		routersByService[key] = append(routersByService[key], router)
+		services[key] = service
+	}
+
+	containers := make(map[string][]string, len(services))
+	for key, service := range services {
+		protocol := protocolOf(service.Protocol)
+		labels := []string{"traefik.enable=true"}
+
+		middlewareRefs := make(map[string]bool)
+		for _, router := range routersByService[key] {
+			prefix := fmt.Sprintf("traefik.%s.routers.%s", protocol, router.Name)
+			labels = append(labels, routerLabels(prefix, router)...)
+			for _, middleware := range router.Middlewares {
+				if name, ok := localRefName(middleware); ok {
+					middlewareRefs[name] = true
+				}
+			}
+		}
+		for _, name := range e.middlewareClosure(protocol, middlewareRefs) {
+			middleware, _ := e.localMiddleware(protocol, name)
+			prefix := fmt.Sprintf("traefik.%s.middlewares.%s.%s", protocol, middleware.Name, middleware.Type)
+			labels = append(labels, flattenLabels(prefix, map[string]interface{}(middleware.Config))...)
+		}
+		labels = append(labels, e.serviceLabels(service)...)
+
+		container := service.Name
+		if protocol != "http" {
+			container = protocol + "-" + service.Name
+		}
+		containers[container] = labels
+	}
+	return containers
+}
+
+// routerLabels 生成路由标签
+func routerLabels(prefix string, router traefikModel.TraefikRouter) []string {
+	labels := make([]string, 0)
+	if router.Rule != "" {
+		labels = append(labels, prefix+".rule="+router.Rule)
+	}
+	if router.RuleSyntax != "" && router.RuleSyntax != "default" {
+		labels = append(labels, prefix+".ruleSyntax="+router.RuleSyntax)
+	}
+	if router.Priority > 0 {
+		labels = append(labels, prefix+".priority="+strconv.FormatInt(router.Priority, 10))
+	}
+	if len(router.EntryPoints) > 0 {
+		labels = append(labels, prefix+".entrypoints="+strings.Join(router.EntryPoints, ","))
+	}
+	if len(router.Middlewares) > 0 {
+		names := make([]string, len(router.Middlewares))
+		for i, middleware := range router.Middlewares {
+			names[i] = strings.TrimSuffix(middleware, "@http")
+		}
+		labels = append(labels, prefix+".middlewares="+strings.Join(names, ","))
+	}
+	if router.TLS != nil {
+		labels = append(labels, prefix+".tls=true")
+		if len(router.TLS) > 0 {
+			labels = append(labels, flattenLabels(prefix+".tls", map[string]interface{}(router.TLS))...)
+		}
+	}
+	if name, ok := localRefName(router.Service); ok {
+		labels = append(labels, prefix+".service="+name)
+	}
+	return labels
+}
+
+// serviceLabels 生成负载均衡服务标签，Docker中后端地址由容器决定，只保留端口和协议
+func (e *labelExporter) serviceLabels(service traefikModel.TraefikService) []string {
+	protocol := protocolOf(service.Protocol)
+	prefix := fmt.Sprintf("traefik.%s.services.%s.loadbalancer", protocol, service.Name)
+	labels := make([]string, 0)
+
+	servers, _ := service.LoadBalancer["servers"].([]interface{})
+	if len(servers) > 1 {
+		e.warnings = append(e.warnings, fmt.Sprintf("服务%s有%d个后端，Docker中由容器副本提供，只保留第一个后端的端口", service.Name, len(servers)))
+	}
+	if len(servers) > 0 {
+		server, _ := servers[0].(map[string]interface{})
+		scheme, port := backendPort(server)
+		if port > 0 {
+			labels = append(labels, fmt.Sprintf("%s.server.port=%d", prefix, port))
+		}
+		if protocol == "http" && scheme != "" && scheme != "http" {
+			labels = append(labels, prefix+".server.scheme="+scheme)
+		}
+	}
+
+	for _, key := range sortedKeys(map[string]interface{}(service.LoadBalancer)) {
+		if key != "servers" {
+			labels = append(labels, flattenLabels(prefix+"."+key, service.LoadBalancer[key])...)
+		}
+	}
+	return labels
+}
+
+// middlewareClosure 展开chain引用的中间件，返回排序后的名称
+func (e *labelExporter) middlewareClosure(protocol string, refs map[string]bool) []string {
+	for changed := true; changed; {
+		changed = false
+		for name := range refs {
+			middleware, ok := e.localMiddleware(protocol, name)
+			if !ok {
+				continue
+			}
+			for _, child := range chainMiddlewareNames(middleware) {
+				if !refs[child] {
+					refs[child] = true
+					changed = true
+				}
+			}
+		}
+	}
+
+	names := make([]string, 0, len(refs))
+	for name := range refs {
+		if _, ok := e.localMiddleware(protocol, name); ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// localService 查找被引用的本Provider服务
+func (e *labelExporter) localService(protocol, ref string) (traefikModel.TraefikService, bool) {
+	name, ok := localRefName(ref)
+	if !ok {
+		return traefikModel.TraefikService{}, false
+	}
+	for _, service := range e.set.Services {
+		if service.Name == name && protocolOf(service.Protocol) == protocol {
+			return service, true
+		}
+	}
+	return traefikModel.TraefikService{}, false
+}
+
+// localMiddleware 查找被引用的本Provider中间件
+func (e *labelExporter) localMiddleware(protocol, name string) (traefikModel.TraefikMiddleware, bool) {
+	for _, middleware := range e.set.Middlewares {
+		if middleware.Name == name && protocolOf(middleware.Protocol) == protocol {
+			return middleware, true
+		}
+	}
+	return traefikModel.TraefikMiddleware{}, false
+}
+
+// flattenLabels 把配置展开为Docker标签
+// 对象按路径用.连接，标量列表用逗号连接，对象列表使用[i]下标，空对象写为true
+func flattenLabels(prefix string, value interface{}) []string {
+	labels := make([]string, 0)
+	switch v := toJSONCompatible(value).(type) {
+	case nil:
+	case map[string]interface{}:
+		if len(v) == 0 {
+			return []string{prefix + "=true"}
+		}
+		for _, key := range sortedKeys(v) {
+			labels = append(labels, flattenLabels(prefix+"."+key, v[key])...)
+		}
+	case []interface{}:
+		scalars := make([]string, 0, len(v))
+		for i, item := range v {
+			switch item.(type) {
+			case map[string]interface{}, []interface{}:
+				labels = append(labels, flattenLabels(fmt.Sprintf("%s[%d]", prefix, i), item)...)
+			default:
+				scalars = append(scalars, fmt.Sprint(item))
+			}
+		}
+		if len(scalars) > 0 {
+			labels = append(labels, prefix+"="+strings.Join(scalars, ","))
+		}
+	default:
+		labels = append(labels, prefix+"="+fmt.Sprint(v))
+	}
+	return labels
+}
+
+// backendPort 从后端的url或address中解析协议和端口，未写端口时按协议推断
+func backendPort(server map[string]interface{}) (string, int) {
+	if rawURL, ok := server["url"].(string); ok {
+		parsed, err := url.Parse(rawURL)
+		if err != nil {
+			return "", 0
+		}
+		if port, err := strconv.Atoi(parsed.Port()); err == nil {
+			return parsed.Scheme, port
+		}
+		switch parsed.Scheme {
+		case "https":
+			return parsed.Scheme, 443
+		case "http", "h2c":
+			return parsed.Scheme, 80
+		}
+		return parsed.Scheme, 0
+	}
+	if address, ok := server["address"].(string); ok {
+		if _, port, err := net.SplitHostPort(address); err == nil {
+			number, _ := strconv.Atoi(port)
+			return "", number
+		}
+	}
+	return "", 0
+}
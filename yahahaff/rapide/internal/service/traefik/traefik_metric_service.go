@@ -0,0 +1,291 @@
+package traefik
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	traefikDAO "github.com/yahahaff/rapide/internal/dao/traefik"
+	sysModel "github.com/yahahaff/rapide/internal/models/sys"
+	traefikModel "github.com/yahahaff/rapide/internal/models/traefik"
+	"github.com/yahahaff/rapide/pkg/config"
+	"github.com/yahahaff/rapide/pkg/database"
+	"github.com/yahahaff/rapide/pkg/logger"
+	"github.com/yahahaff/rapide/pkg/promtext"
+	"gorm.io/gorm"
+)
+
+// TraefikMetricService 抓取各Traefik实例的Prometheus指标，保存入口点、路由和服务的请求数和5xx请求数，计算请求速率和错误率
+type TraefikMetricService struct {
+	traefikDAO *traefikDAO.TraefikDAO
+}
+
+// metricCounters 各类对象的请求计数器及其中表示对象名称的标签
+var metricCounters = map[string]struct{ Kind, Label string }{
+	"traefik_entrypoint_requests_total": {"entrypoint", "entrypoint"},
+	"traefik_router_requests_total":     {"router", "router"},
+	"traefik_service_requests_total":    {"service", "service"},
+}
+
+// MetricStats 一段时间内的请求统计，速率单位为每秒请求数，多个实例的速率相加
+type MetricStats struct {
+	Name        string     `json:"name,omitempty"`
+	At          *time.Time `json:"at,omitempty"`
+	Requests    float64    `json:"requests"`
+	Errors      float64    `json:"errors"`
+	RequestRate float64    `json:"requestRate"`
+	ErrorRate   float64    `json:"errorRate"`  // 每秒5xx请求数
+	ErrorRatio  float64    `json:"errorRatio"` // 5xx请求的比例
+}
+
+// MetricOverview 路由等对象在时间范围内的平均速率，以及最近一次抓取的速率
+type MetricOverview struct {
+	MetricStats
+	CurrentRequestRate float64 `json:"currentRequestRate"`
+	CurrentErrorRate   float64 `json:"currentErrorRate"`
+}
+
+// MetricDetail 单个对象的请求统计，包括整个时间范围的汇总和按step划分的数据
+type MetricDetail struct {
+	Summary MetricStats   `json:"summary"`
+	Series  []MetricStats `json:"series"`
+}
+
+// ScrapeMetrics 抓取所有配置了指标地址的实例，然后清理超过保留期的样本
+func (ms *TraefikMetricService) ScrapeMetrics() error {
+	instances, err := ms.traefikDAO.GetAllInstances()
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for _, instance := range instances {
+		if instance.MetricsURL == "" || instance.Status == "disabled" {
+			continue
+		}
+		if err := ms.scrapeInstance(instance); err != nil {
+			logger.ErrorString("traefik", "metrics", instance.Name+": "+err.Error())
+			lastErr = err
+		}
+	}
+
+	if _, err := ms.traefikDAO.DeleteMetricSamplesBefore(metricRetentionStart(time.Now()).UTC()); err != nil {
+		return err
+	}
+	return lastErr
+}
+
+// GetMetricSources 获取各实例指标的抓取状态
+func (ms *TraefikMetricService) GetMetricSources() ([]traefikModel.TraefikMetricSource, error) {
+	return ms.traefikDAO.GetMetricSources()
+}
+
+// GetMetricOverview 获取时间范围内每个入口点、路由或服务的请求统计，按请求数倒序
+// 非超级管理员只返回范围内部门的路由和服务，入口点由所有部门共用不返回
+func (ms *TraefikMetricService) GetMetricOverview(kind, instance string, from, to time.Time, scope sysModel.DeptScope) ([]MetricOverview, error) {
+	samples, err := ms.traefikDAO.GetMetricSamples(kind, "", instance, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, err
+	}
+	owned, err := ownedRuntimeNames(ms.traefikDAO, kind, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	grouped := make(map[string][]traefikModel.TraefikMetricSample)
+	for _, sample := range samples {
+		if owned != nil && !owned[sample.Name] {
+			continue
+		}
+		grouped[sample.Name] = append(grouped[sample.Name], sample)
+	}
+	result := make([]MetricOverview, 0, len(grouped))
+	for name, items := range grouped {
+		overview := MetricOverview{MetricStats: summarizeMetrics(items)}
+		overview.Name = name
+		// 样本按时间排序，每个实例最后一个有效样本即最近一次抓取的速率
+		latest := make(map[string]traefikModel.TraefikMetricSample)
+		for _, sample := range items {
+			if sample.Seconds > 0 {
+				latest[sample.Instance] = sample
+			}
+		}
+		for _, sample := range latest {
+			overview.CurrentRequestRate += sample.Requests / sample.Seconds
+			overview.CurrentErrorRate += sample.Errors / sample.Seconds
+		}
+		result = append(result, overview)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Requests != result[j].Requests {
+			return result[i].Requests > result[j].Requests
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+// GetMetricSeries 获取单个对象在时间范围内的请求统计，按step划分时间段，多个实例的数据合并计算
+func (ms *TraefikMetricService) GetMetricSeries(kind, name, instance string, from, to time.Time, step time.Duration, scope sysModel.DeptScope) (MetricDetail, error) {
+	name, err := runtimeName(ms.traefikDAO, kind, name, scope)
+	if err != nil {
+		return MetricDetail{}, err
+	}
+	samples, err := ms.traefikDAO.GetMetricSamples(kind, name, instance, from.UTC(), to.UTC())
+	if err != nil {
+		return MetricDetail{}, err
+	}
+
+	detail := MetricDetail{Summary: summarizeMetrics(samples), Series: make([]MetricStats, 0)}
+	detail.Summary.Name = name
+	for i := 0; i < len(samples); {
+		start := samples[i].At.Truncate(step)
+		j := i
+		for j < len(samples) && samples[j].At.Truncate(step).Equal(start) {
+			j++
+		}
+		stats := summarizeMetrics(samples[i:j])
+		stats.At = &start
+		detail.Series = append(detail.Series, stats)
+		i = j
+	}
+	return detail, nil
+}
+
+// scrapeInstance 抓取实例的指标，与上一次抓取的计数比较得到增量，样本和抓取状态在同一事务中保存
+func (ms *TraefikMetricService) scrapeInstance(instance traefikModel.TraefikInstance) error {
+	source, err := ms.traefikDAO.GetMetricSource(instance.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		source = traefikModel.TraefikMetricSource{InstanceID: instance.ID}
+	} else if err != nil {
+		return err
+	}
+	source.URL = instance.MetricsURL
+	now := time.Now().UTC()
+	source.ScrapedAt = &now
+
+	counters, err := fetchMetricCounters(instance.MetricsURL)
+	if err != nil {
+		source.Error = err.Error()
+		_ = ms.traefikDAO.SaveMetricSource(&source)
+		return err
+	}
+	source.Series = len(counters)
+	source.Error = ""
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		dao := ms.traefikDAO.WithTx(tx)
+		previous, err := dao.GetLatestMetricSamples(instance.Name)
+		if err != nil {
+			return err
+		}
+		previousByKey := make(map[string]traefikModel.TraefikMetricSample, len(previous))
+		for _, sample := range previous {
+			previousByKey[sample.Kind+"/"+sample.Name] = sample
+		}
+
+		samples := make([]traefikModel.TraefikMetricSample, 0, len(counters))
+		for key, counter := range counters {
+			sample := traefikModel.TraefikMetricSample{
+				Kind:          counter.Kind,
+				Name:          counter.Name,
+				Instance:      instance.Name,
+				At:            now,
+				RequestsTotal: counter.RequestsTotal,
+				ErrorsTotal:   counter.ErrorsTotal,
+			}
+			if last, ok := previousByKey[key]; ok && now.After(last.At) {
+				sample.Seconds = now.Sub(last.At).Seconds()
+				sample.Requests = counterIncrease(last.RequestsTotal, counter.RequestsTotal)
+				sample.Errors = counterIncrease(last.ErrorsTotal, counter.ErrorsTotal)
+			}
+			samples = append(samples, sample)
+		}
+		sort.Slice(samples, func(i, j int) bool {
+			return samples[i].Kind+"/"+samples[i].Name < samples[j].Kind+"/"+samples[j].Name
+		})
+		if err := dao.CreateMetricSamples(samples); err != nil {
+			return err
+		}
+		return dao.SaveMetricSource(&source)
+	})
+}
+
+// fetchMetricCounters 请求指标地址并按对象汇总请求计数，不同状态码、方法和协议的计数相加
+func fetchMetricCounters(url string) (map[string]*traefikModel.TraefikMetricSample, error) {
+	resp, err := traefikAPIClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("抓取指标失败，状态码: %d", resp.StatusCode)
+	}
+	samples, err := promtext.Parse(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("解析指标失败: %w", err)
+	}
+
+	counters := make(map[string]*traefikModel.TraefikMetricSample)
+	for _, sample := range samples {
+		target, ok := metricCounters[sample.Name]
+		name := sample.Labels[target.Label]
+		if !ok || name == "" || math.IsNaN(sample.Value) {
+			continue
+		}
+		key := target.Kind + "/" + name
+		counter, ok := counters[key]
+		if !ok {
+			counter = &traefikModel.TraefikMetricSample{Kind: target.Kind, Name: name}
+			counters[key] = counter
+		}
+		counter.RequestsTotal += sample.Value
+		if strings.HasPrefix(sample.Labels["code"], "5") {
+			counter.ErrorsTotal += sample.Value
+		}
+	}
+	return counters, nil
+}
+
+// counterIncrease 计数器的增量，计数变小说明Traefik重启过，重置后的计数即为增量
+func counterIncrease(previous, current float64) float64 {
+	if current < previous {
+		return current
+	}
+	return current - previous
+}
+
+// summarizeMetrics 汇总样本，每个实例的速率为增量之和除以时间之和，多个实例的速率相加
+func summarizeMetrics(samples []traefikModel.TraefikMetricSample) MetricStats {
+	var stats MetricStats
+	seconds := make(map[string]float64)
+	requests := make(map[string]float64)
+	errs := make(map[string]float64)
+	for _, sample := range samples {
+		if sample.Seconds <= 0 {
+			continue
+		}
+		stats.Requests += sample.Requests
+		stats.Errors += sample.Errors
+		seconds[sample.Instance] += sample.Seconds
+		requests[sample.Instance] += sample.Requests
+		errs[sample.Instance] += sample.Errors
+	}
+	for instance, total := range seconds {
+		stats.RequestRate += requests[instance] / total
+		stats.ErrorRate += errs[instance] / total
+	}
+	if stats.Requests > 0 {
+		stats.ErrorRatio = stats.Errors / stats.Requests
+	}
+	return stats
+}
+
+// metricRetentionStart 保留期的开始时间，更早的样本会被删除
+func metricRetentionStart(now time.Time) time.Time {
+	return now.Add(-time.Duration(config.GetInt("TRAEFIK_METRICS_RETENTION_HOURS", 24)) * time.Hour)
+}
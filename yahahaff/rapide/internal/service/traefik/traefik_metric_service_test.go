@@ -0,0 +1,91 @@
+package traefik
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// metricsFixture 本地替身指标服务返回的Traefik指标
+const metricsFixture = `# HELP traefik_entrypoint_requests_total How many HTTP requests processed on an entrypoint.
+# TYPE traefik_entrypoint_requests_total counter
+traefik_entrypoint_requests_total{code="200",entrypoint="web",method="GET",protocol="http"} 100
+traefik_entrypoint_requests_total{code="502",entrypoint="web",method="GET",protocol="http"} 4
+traefik_router_requests_total{code="200",method="GET",protocol="http",router="shop@http",service="shop@http"} 60
+traefik_router_requests_total{code="200",method="POST",protocol="http",router="shop@http",service="shop@http"} 30
+traefik_router_requests_total{code="500",method="POST",protocol="http",router="shop@http",service="shop@http"} 5
+traefik_router_requests_total{code="404",method="GET",protocol="http",router="shop@http",service="shop@http"} 2
+traefik_router_requests_total{code="200",method="GET",protocol="http",router="",service="shop@http"} 7
+traefik_service_requests_total{code="200",method="GET",protocol="http",service="shop@http"} NaN
+traefik_service_requests_total{code="200",method="GET",protocol="http",service="api@internal"} 9
+traefik_router_request_duration_seconds_count{code="200",method="GET",protocol="http",router="shop@http",service="shop@http"} 97
+process_open_fds 12
+`
+
+func TestFetchMetricCounters(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		w.Write([]byte(metricsFixture))
+	}))
+	defer server.Close()
+
+	counters, err := fetchMetricCounters(server.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("fetchMetricCounters error: %v", err)
+	}
+	want := map[string][2]float64{
+		"entrypoint/web":       {104, 4},
+		"router/shop@http":     {97, 5},
+		"service/api@internal": {9, 0},
+	}
+	if len(counters) != len(want) {
+		t.Errorf("fetchMetricCounters returned %d counters, want %d: %v", len(counters), len(want), counters)
+	}
+	for key, totals := range want {
+		counter, ok := counters[key]
+		if !ok {
+			t.Errorf("缺少计数器%s", key)
+			continue
+		}
+		if counter.RequestsTotal != totals[0] || counter.ErrorsTotal != totals[1] {
+			t.Errorf("%s = %v/%v, want %v/%v", key, counter.RequestsTotal, counter.ErrorsTotal, totals[0], totals[1])
+		}
+	}
+}
+
+func TestFetchMetricCountersErrors(t *testing.T) {
+	cases := []struct {
+		name     string
+		status   int
+		body     string
+		contains string
+	}{
+		{"状态码错误", http.StatusServiceUnavailable, "", "状态码: 503"},
+		{"格式错误", http.StatusOK, "traefik_router_requests_total{router=\"a\" 1\n", "解析指标失败"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(c.status)
+				w.Write([]byte(c.body))
+			}))
+			defer server.Close()
+
+			_, err := fetchMetricCounters(server.URL)
+			if err == nil || !strings.Contains(err.Error(), c.contains) {
+				t.Errorf("fetchMetricCounters error = %v, want it to contain %q", err, c.contains)
+			}
+		})
+	}
+}
+
+func TestCounterIncrease(t *testing.T) {
+	if got := counterIncrease(100, 130); got != 30 {
+		t.Errorf("counterIncrease(100, 130) = %v, want 30", got)
+	}
+	// Traefik重启后计数从0开始
+	if got := counterIncrease(100, 12); got != 12 {
+		t.Errorf("counterIncrease(100, 12) = %v, want 12", got)
+	}
+}
@@ -0,0 +1,408 @@
+package traefik
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	traefikDAO "github.com/yahahaff/rapide/internal/dao/traefik"
+	sysModel "github.com/yahahaff/rapide/internal/models/sys"
+	traefikModel "github.com/yahahaff/rapide/internal/models/traefik"
+	"github.com/yahahaff/rapide/pkg/database"
+	"github.com/yahahaff/rapide/pkg/traefikrule"
+	"gorm.io/gorm"
+)
+
+// TraefikOwnerService 路由、服务和中间件的所属部门
+// 用户只能查看和修改所在部门及下级部门的对象，也不能使用其他部门的路由已经使用的域名；
+// TLS选项、证书存储和serversTransport由所有部门共用，只有超级管理员可以修改
+type TraefikOwnerService struct {
+	traefikDAO *traefikDAO.TraefikDAO
+}
+
+// deptError 对象不属于当前用户可以管理的部门
+type deptError struct {
+	message string
+}
+
+// Error 实现error接口
+func (e *deptError) Error() string {
+	return e.message
+}
+
+// IsDeptError 判断是否是部门权限错误
+func IsDeptError(err error) bool {
+	_, ok := err.(*deptError)
+	return ok
+}
+
+// ownedKind 判断对象类型是否按部门管理
+func ownedKind(kind string) bool {
+	return kind == kindRouter || kind == kindService || kind == kindMiddleware
+}
+
+// GetOwners 获取范围内对象的所属部门，kind为空时不限制
+func (ow *TraefikOwnerService) GetOwners(kind string, scope sysModel.DeptScope) ([]traefikModel.TraefikOwner, error) {
+	owners, err := ow.traefikDAO.GetOwners(kind)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]traefikModel.TraefikOwner, 0, len(owners))
+	for _, owner := range owners {
+		if scope.Contains(owner.DeptID) {
+			result = append(result, owner)
+		}
+	}
+	return result, nil
+}
+
+// SetOwner 把对象转给其他部门，对象当前和转入的部门都需要在范围内
+func (ow *TraefikOwnerService) SetOwner(kind, protocol, name string, deptID uint64, scope sysModel.DeptScope, operator string) (traefikModel.TraefikOwner, error) {
+	owner := traefikModel.TraefikOwner{Kind: kind, Protocol: protocolOf(protocol), Name: name, DeptID: deptID, Operator: operator}
+	if !ownedKind(kind) {
+		return owner, &validationError{message: "不支持的对象类型: " + kind}
+	}
+	if !scope.Contains(deptID) {
+		return owner, &deptError{message: fmt.Sprintf("部门%d不在可以管理的范围内", deptID)}
+	}
+	if err := checkDept(deptID); err != nil {
+		return owner, err
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		dao := ow.traefikDAO.WithTx(tx)
+		existing, err := loadObject(dao, kind, name, owner.Protocol)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return gorm.ErrRecordNotFound
+		}
+		if err := checkOwner(dao, kind, owner.Protocol, name, scope); err != nil {
+			return err
+		}
+		return dao.SaveOwner(&owner)
+	})
+	return owner, err
+}
+
+// CheckObject 检查对象属于范围内的部门，对象不存在时不检查，由后续操作返回不存在
+func (ow *TraefikOwnerService) CheckObject(kind, protocol, name string, scope sysModel.DeptScope) error {
+	return checkOwner(ow.traefikDAO, kind, protocol, name, scope)
+}
+
+// NewObjectOwner 确定新对象的所属部门
+func (ow *TraefikOwnerService) NewObjectOwner(deptID uint64, scope sysModel.DeptScope) (uint64, error) {
+	return newObjectOwner(deptID, scope)
+}
+
+// CheckRouterHosts 检查路由规则中的域名没有被其他部门的路由使用
+func (ow *TraefikOwnerService) CheckRouterHosts(router traefikModel.TraefikRouter, scope sysModel.DeptScope) error {
+	return checkRouterHosts(ow.traefikDAO, router, scope)
+}
+
+// checkRouterHosts 检查路由限定了域名且域名没有被范围外的路由使用，超级管理员不检查
+func checkRouterHosts(dao *traefikDAO.TraefikDAO, router traefikModel.TraefikRouter, scope sysModel.DeptScope) error {
+	if scope.All {
+		return nil
+	}
+	if err := checkHostMatchers(router); err != nil {
+		return err
+	}
+	hosts := routerCertHosts(router)
+	if len(hosts) == 0 {
+		return nil
+	}
+	wanted := make(map[string]bool, len(hosts))
+	for _, host := range hosts {
+		wanted[host] = true
+	}
+
+	owners, err := ownerDepts(dao, kindRouter)
+	if err != nil {
+		return err
+	}
+	routers, err := dao.ListRouters()
+	if err != nil {
+		return err
+	}
+	for _, other := range routers {
+		if other.Name == router.Name && protocolOf(other.Protocol) == protocolOf(router.Protocol) {
+			continue
+		}
+		if scope.Contains(owners[refKey(other.Protocol, other.Name)]) {
+			continue
+		}
+		for _, host := range routerCertHosts(other) {
+			if wanted[host] {
+				return &deptError{message: fmt.Sprintf("域名%s已被其他部门的路由%s使用", host, refKey(other.Protocol, other.Name))}
+			}
+		}
+	}
+	return nil
+}
+
+// checkHostMatchers 检查路由规则的每个或条件都包含Host或HostSNI，且没有使用HostRegexp或HostSNIRegexp
+// 不限域名或用正则匹配域名的路由可能截获其他部门的请求，UDP路由按入口点匹配所有流量，都只有超级管理员可以创建
+func checkHostMatchers(router traefikModel.TraefikRouter) error {
+	protocol := protocolOf(router.Protocol)
+	if protocol == "udp" {
+		return &deptError{message: "UDP路由会匹配入口点的所有流量，只有超级管理员可以创建"}
+	}
+	terms, err := traefikrule.Normalize(router.Rule, protocol, router.RuleSyntax)
+	if errors.Is(err, traefikrule.ErrTooComplex) {
+		return &deptError{message: "规则过于复杂，无法确认只匹配本部门的域名"}
+	}
+	if err != nil {
+		return &validationError{message: "规则无效: " + err.Error()}
+	}
+	for _, term := range terms {
+		limited := false
+		for _, condition := range term {
+			switch condition.Name {
+			case "HostRegexp", "HostSNIRegexp":
+				return &deptError{message: condition.Name + "可能匹配其他部门的域名，只有超级管理员可以使用"}
+			case "Host", "HostSNI":
+				if !condition.Negated {
+					limited = true
+				}
+			}
+		}
+		if !limited {
+			return &deptError{message: "规则的每个或条件都需要包含Host或HostSNI，不限域名的路由只有超级管理员可以创建"}
+		}
+	}
+	return nil
+}
+
+// CheckReferences 检查路由、服务或中间件引用的本Provider中的服务和中间件属于范围内的部门
+func (ow *TraefikOwnerService) CheckReferences(object interface{}, scope sysModel.DeptScope) error {
+	return checkReferences(ow.traefikDAO, object, scope)
+}
+
+// checkReferences 检查路由的服务和中间件、加权和镜像服务的子服务、chain中间件的成员都属于范围内的部门
+// 引用其他部门的对象会把对方的后端或认证配置挂到自己的路由上，对方修改时也会影响自己；尚不存在的对象不检查
+func checkReferences(dao *traefikDAO.TraefikDAO, object interface{}, scope sysModel.DeptScope) error {
+	if scope.All {
+		return nil
+	}
+	var protocol string
+	var services, middlewares []string
+	switch object := object.(type) {
+	case traefikModel.TraefikRouter:
+		protocol = object.Protocol
+		if name, ok := localRefName(object.Service); ok {
+			services = append(services, name)
+		}
+		for _, ref := range object.Middlewares {
+			if name, ok := localRefName(ref); ok {
+				middlewares = append(middlewares, name)
+			}
+		}
+	case traefikModel.TraefikService:
+		protocol, services = object.Protocol, childServiceNames(object)
+	case traefikModel.TraefikMiddleware:
+		protocol, middlewares = object.Protocol, chainMiddlewareNames(object)
+	}
+
+	for _, name := range services {
+		if err := checkOwner(dao, kindService, protocol, name, scope); err != nil {
+			return err
+		}
+	}
+	for _, name := range middlewares {
+		if err := checkOwner(dao, kindMiddleware, protocol, name, scope); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckCertDomain 检查证书域名没有被范围外的路由使用，通配符证书检查覆盖的所有域名
+func (ow *TraefikOwnerService) CheckCertDomain(domain string, scope sysModel.DeptScope) error {
+	if scope.All {
+		return nil
+	}
+	domain = strings.ToLower(domain)
+	owners, err := ownerDepts(ow.traefikDAO, kindRouter)
+	if err != nil {
+		return err
+	}
+	routers, err := ow.traefikDAO.ListRouters()
+	if err != nil {
+		return err
+	}
+	for _, router := range routers {
+		if scope.Contains(owners[refKey(router.Protocol, router.Name)]) {
+			continue
+		}
+		for _, host := range routerCertHosts(router) {
+			_, parent, _ := strings.Cut(host, ".")
+			if host == domain || domain == "*."+parent {
+				return &deptError{message: fmt.Sprintf("域名%s已被其他部门的路由%s使用", host, refKey(router.Protocol, router.Name))}
+			}
+		}
+	}
+	return nil
+}
+
+// FilterRuntime 过滤Traefik API返回的运行时对象，只保留范围内部门的对象
+func (ow *TraefikOwnerService) FilterRuntime(kind string, objects []map[string]interface{}, scope sysModel.DeptScope) ([]map[string]interface{}, error) {
+	names, err := ownedRuntimeNames(ow.traefikDAO, kind, scope)
+	if err != nil || names == nil {
+		return objects, err
+	}
+	filtered := make([]map[string]interface{}, 0)
+	for _, object := range objects {
+		if name, _ := object["name"].(string); names[name] {
+			filtered = append(filtered, object)
+		}
+	}
+	return filtered, nil
+}
+
+// RuntimeName 检查运行时对象属于范围内的部门，返回查询使用的名称
+// 非超级管理员查询不带@provider的名称时只匹配rapide下发的对象，不合并其他Provider中的同名对象
+func (ow *TraefikOwnerService) RuntimeName(kind, name string, scope sysModel.DeptScope) (string, error) {
+	return runtimeName(ow.traefikDAO, kind, name, scope)
+}
+
+// runtimeName 见RuntimeName
+func runtimeName(dao *traefikDAO.TraefikDAO, kind, name string, scope sysModel.DeptScope) (string, error) {
+	if !scope.All && !ownedKind(kind) {
+		return name, &deptError{message: fmt.Sprintf("%s由所有部门共用，只有超级管理员可以查看", kind)}
+	}
+	names, err := ownedRuntimeNames(dao, kind, scope)
+	if err != nil || names == nil {
+		return name, err
+	}
+	if !strings.Contains(name, "@") {
+		name += "@" + rapideProvider()
+	}
+	if !names[name] {
+		return name, &deptError{message: fmt.Sprintf("%s %s属于其他部门", kind, name)}
+	}
+	return name, nil
+}
+
+// ownedRuntimeNames 获取范围内的http对象在Traefik中的名称name@provider，超级管理员返回nil表示不限制
+func ownedRuntimeNames(dao *traefikDAO.TraefikDAO, kind string, scope sysModel.DeptScope) (map[string]bool, error) {
+	owned, err := ownedKeys(dao, kind, scope)
+	if err != nil || owned == nil {
+		return nil, err
+	}
+	names := make(map[string]bool, len(owned))
+	for key := range owned {
+		if protocol, name, _ := strings.Cut(key, "/"); protocol == "http" {
+			names[name+"@"+rapideProvider()] = true
+		}
+	}
+	return names, nil
+}
+
+// sortedNames 把名称集合转换为排序后的切片
+func sortedNames(names map[string]bool) []string {
+	result := make([]string, 0, len(names))
+	for name := range names {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// checkOwner 检查对象属于范围内的部门，没有所属部门的对象只有超级管理员可以管理
+func checkOwner(dao *traefikDAO.TraefikDAO, kind, protocol, name string, scope sysModel.DeptScope) error {
+	if scope.All || !ownedKind(kind) {
+		return nil
+	}
+	owner, err := dao.GetOwner(kind, protocolOf(protocol), name)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if err == nil && scope.Contains(owner.DeptID) {
+		return nil
+	}
+	if err != nil {
+		existing, err := loadObject(dao, kind, name, protocol)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return nil
+		}
+	}
+	return &deptError{message: fmt.Sprintf("%s %s属于其他部门", kind, refKey(protocol, name))}
+}
+
+// newObjectOwner 确定新对象的所属部门，用户属于多个部门时需要指定部门
+func newObjectOwner(deptID uint64, scope sysModel.DeptScope) (uint64, error) {
+	owner, ok := scope.Owner(deptID)
+	if !ok {
+		if owner == 0 {
+			return 0, &validationError{message: "请指定所属部门"}
+		}
+		return 0, &deptError{message: fmt.Sprintf("部门%d不在可以管理的范围内", owner)}
+	}
+	if err := checkDept(owner); err != nil {
+		return 0, err
+	}
+	return owner, nil
+}
+
+// saveObjectOwner 设置新建对象的所属部门，deptID为0时删除可能残留的记录
+func saveObjectOwner(dao *traefikDAO.TraefikDAO, kind, protocol, name string, deptID uint64, operator string) error {
+	if !ownedKind(kind) {
+		return nil
+	}
+	if deptID == 0 {
+		return dao.DeleteOwner(kind, protocolOf(protocol), name)
+	}
+	return dao.SaveOwner(&traefikModel.TraefikOwner{Kind: kind, Protocol: protocolOf(protocol), Name: name, DeptID: deptID, Operator: operator})
+}
+
+// ownerDepts 获取指定类型对象的所属部门，键为protocol/name
+func ownerDepts(dao *traefikDAO.TraefikDAO, kind string) (map[string]uint64, error) {
+	owners, err := dao.GetOwners(kind)
+	if err != nil {
+		return nil, err
+	}
+	depts := make(map[string]uint64, len(owners))
+	for _, owner := range owners {
+		depts[refKey(owner.Protocol, owner.Name)] = owner.DeptID
+	}
+	return depts, nil
+}
+
+// ownedKeys 获取范围内指定类型的对象，键为protocol/name，超级管理员返回nil表示不限制
+func ownedKeys(dao *traefikDAO.TraefikDAO, kind string, scope sysModel.DeptScope) (map[string]bool, error) {
+	if scope.All {
+		return nil, nil
+	}
+	depts, err := ownerDepts(dao, kind)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]bool)
+	for key, deptID := range depts {
+		if scope.Contains(deptID) {
+			keys[key] = true
+		}
+	}
+	return keys, nil
+}
+
+// checkDept 检查部门存在，0表示不归属任何部门
+func checkDept(deptID uint64) error {
+	if deptID == 0 {
+		return nil
+	}
+	var count int64
+	if err := database.DB.Model(&sysModel.Dept{}).Where("id = ?", deptID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return &validationError{message: fmt.Sprintf("部门不存在: %d", deptID)}
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package traefik
+
+import (
+	"fmt"
+	"testing"
+
+	traefikDAO "github.com/yahahaff/rapide/internal/dao/traefik"
+	sysModel "github.com/yahahaff/rapide/internal/models/sys"
+	traefikModel "github.com/yahahaff/rapide/internal/models/traefik"
+	"github.com/yahahaff/rapide/pkg/database"
+	"github.com/yahahaff/rapide/pkg/types"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// 部门10的用户，可以管理部门10及下级部门11
+var deptScope = sysModel.DeptScope{DeptIDs: []uint64{10, 11}, Default: 10}
+
+// setupOwnerDB 使用内存数据库，部门10有shop路由、服务和auth中间件，部门20有pay路由、服务和limit中间件，legacy服务没有所属部门
+func setupOwnerDB(t *testing.T) *traefikDAO.TraefikDAO {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("打开内存数据库失败: %v", err)
+	}
+	if err := db.AutoMigrate(&traefikModel.TraefikRouter{}, &traefikModel.TraefikService{}, &traefikModel.TraefikMiddleware{}, &traefikModel.TraefikOwner{}); err != nil {
+		t.Fatalf("创建表失败: %v", err)
+	}
+	previous := database.DB
+	database.DB = db
+	t.Cleanup(func() {
+		database.DB = previous
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	objects := []interface{}{
+		&traefikModel.TraefikRouter{Name: "shop", Rule: "Host(`shop.a.com`)", Service: "shop", Protocol: "http"},
+		&traefikModel.TraefikRouter{Name: "pay", Rule: "Host(`pay.a.com`) && PathPrefix(`/api`)", Service: "pay", Protocol: "http"},
+		&traefikModel.TraefikService{Name: "shop", Type: "loadbalancer", Protocol: "http"},
+		&traefikModel.TraefikService{Name: "pay", Type: "loadbalancer", Protocol: "http"},
+		&traefikModel.TraefikService{Name: "legacy", Type: "loadbalancer", Protocol: "http"},
+		&traefikModel.TraefikMiddleware{Name: "auth", Type: "basicAuth", Protocol: "http", Config: types.JSONMap{}},
+		&traefikModel.TraefikMiddleware{Name: "limit", Type: "rateLimit", Protocol: "http", Config: types.JSONMap{}},
+		&traefikModel.TraefikOwner{Kind: kindRouter, Protocol: "http", Name: "shop", DeptID: 10},
+		&traefikModel.TraefikOwner{Kind: kindRouter, Protocol: "http", Name: "pay", DeptID: 20},
+		&traefikModel.TraefikOwner{Kind: kindService, Protocol: "http", Name: "shop", DeptID: 11},
+		&traefikModel.TraefikOwner{Kind: kindService, Protocol: "http", Name: "pay", DeptID: 20},
+		&traefikModel.TraefikOwner{Kind: kindMiddleware, Protocol: "http", Name: "auth", DeptID: 10},
+		&traefikModel.TraefikOwner{Kind: kindMiddleware, Protocol: "http", Name: "limit", DeptID: 20},
+	}
+	for _, object := range objects {
+		if err := db.Create(object).Error; err != nil {
+			t.Fatalf("写入测试数据失败: %v", err)
+		}
+	}
+	return traefikDAO.NewTraefikDAO()
+}
+
+// errorKind 返回错误的类型，便于在用例中比较
+func errorKind(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case IsDeptError(err):
+		return "dept"
+	case IsValidationError(err):
+		return "validation"
+	default:
+		return err.Error()
+	}
+}
+
+func TestCheckOwner(t *testing.T) {
+	dao := setupOwnerDB(t)
+	cases := []struct {
+		name  string
+		kind  string
+		obj   string
+		scope sysModel.DeptScope
+		want  string
+	}{
+		{"本部门的对象", kindRouter, "shop", deptScope, ""},
+		{"下级部门的对象", kindService, "shop", deptScope, ""},
+		{"其他部门的对象", kindService, "pay", deptScope, "dept"},
+		{"没有所属部门的已有对象", kindService, "legacy", deptScope, "dept"},
+		{"不存在的对象", kindService, "new", deptScope, ""},
+		{"超级管理员管理其他部门的对象", kindMiddleware, "limit", sysModel.DeptScope{All: true}, ""},
+		{"共用对象不按部门检查", kindTLSOption, "default", deptScope, ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := checkOwner(dao, c.kind, "http", c.obj, c.scope)
+			if got := errorKind(err); got != c.want {
+				t.Errorf("checkOwner(%s %s) = %v, want %q", c.kind, c.obj, err, c.want)
+			}
+		})
+	}
+}
+
+func TestCheckRouterHosts(t *testing.T) {
+	dao := setupOwnerDB(t)
+	cases := []struct {
+		name   string
+		router traefikModel.TraefikRouter
+		scope  sysModel.DeptScope
+		want   string
+	}{
+		{"新域名", traefikModel.TraefikRouter{Name: "web", Rule: "Host(`web.a.com`)"}, deptScope, ""},
+		{"更新自己的路由", traefikModel.TraefikRouter{Name: "shop", Rule: "Host(`shop.a.com`) && PathPrefix(`/v2`)"}, deptScope, ""},
+		{"其他部门已使用的域名", traefikModel.TraefikRouter{Name: "web", Rule: "Host(`PAY.a.com`)"}, deptScope, "dept"},
+		{"每个或条件都有域名", traefikModel.TraefikRouter{Name: "web", Rule: "(Host(`web.a.com`) || Host(`www.a.com`)) && PathPrefix(`/`)"}, deptScope, ""},
+		{"没有域名", traefikModel.TraefikRouter{Name: "web", Rule: "PathPrefix(`/`)", Priority: 10000}, deptScope, "dept"},
+		{"部分或条件没有域名", traefikModel.TraefikRouter{Name: "web", Rule: "Host(`web.a.com`) || PathPrefix(`/pay`)"}, deptScope, "dept"},
+		{"取反的域名不算限定", traefikModel.TraefikRouter{Name: "web", Rule: "!Host(`web.a.com`) && Path(`/`)"}, deptScope, "dept"},
+		{"hostRegexp", traefikModel.TraefikRouter{Name: "web", Rule: "Host(`web.a.com`) && HostRegexp(`.+`)"}, deptScope, "dept"},
+		{"tcp hostSNIRegexp", traefikModel.TraefikRouter{Name: "db", Protocol: "tcp", Rule: "HostSNIRegexp(`^.+\\.a\\.com$`)"}, deptScope, "dept"},
+		{"tcp 通配", traefikModel.TraefikRouter{Name: "db", Protocol: "tcp", Rule: "HostSNI(`*`)"}, deptScope, "dept"},
+		{"tcp 指定域名", traefikModel.TraefikRouter{Name: "db", Protocol: "tcp", Rule: "HostSNI(`db.a.com`)"}, deptScope, ""},
+		{"udp", traefikModel.TraefikRouter{Name: "dns", Protocol: "udp"}, deptScope, "dept"},
+		{"规则无效", traefikModel.TraefikRouter{Name: "web", Rule: "Host(`web.a.com`"}, deptScope, "validation"},
+		{"超级管理员不限域名", traefikModel.TraefikRouter{Name: "web", Rule: "PathPrefix(`/`)"}, sysModel.DeptScope{All: true}, ""},
+		{"超级管理员使用其他部门的域名", traefikModel.TraefikRouter{Name: "web", Rule: "Host(`pay.a.com`)"}, sysModel.DeptScope{All: true}, ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := checkRouterHosts(dao, c.router, c.scope)
+			if got := errorKind(err); got != c.want {
+				t.Errorf("checkRouterHosts(%q) = %v, want %q", c.router.Rule, err, c.want)
+			}
+		})
+	}
+}
+
+func TestCheckReferences(t *testing.T) {
+	dao := setupOwnerDB(t)
+	weighted := func(names ...string) types.JSONMap {
+		items := make([]interface{}, 0, len(names))
+		for _, name := range names {
+			items = append(items, map[string]interface{}{"name": name, "weight": float64(1)})
+		}
+		return types.JSONMap{"services": items}
+	}
+	cases := []struct {
+		name   string
+		object interface{}
+		scope  sysModel.DeptScope
+		want   string
+	}{
+		{"路由引用本部门的服务和中间件", traefikModel.TraefikRouter{Service: "shop", Middlewares: types.JSONSlice{"auth@http"}}, deptScope, ""},
+		{"路由引用其他部门的服务", traefikModel.TraefikRouter{Service: "pay@http"}, deptScope, "dept"},
+		{"路由引用其他部门的中间件", traefikModel.TraefikRouter{Service: "shop", Middlewares: types.JSONSlice{"auth", "limit"}}, deptScope, "dept"},
+		{"路由引用没有所属部门的服务", traefikModel.TraefikRouter{Service: "legacy"}, deptScope, "dept"},
+		{"其他Provider的对象不检查", traefikModel.TraefikRouter{Service: "pay@docker", Middlewares: types.JSONSlice{"limit@file"}}, deptScope, ""},
+		{"尚不存在的对象不检查", traefikModel.TraefikRouter{Service: "new"}, deptScope, ""},
+		{"加权服务引用本部门的服务", traefikModel.TraefikService{Type: "weighted", Weighted: weighted("shop", "new")}, deptScope, ""},
+		{"加权服务引用其他部门的服务", traefikModel.TraefikService{Type: "weighted", Weighted: weighted("shop", "pay")}, deptScope, "dept"},
+		{"镜像服务的主服务属于其他部门", traefikModel.TraefikService{Type: "mirror", Mirror: types.JSONMap{"service": "pay"}}, deptScope, "dept"},
+		{"镜像服务的镜像属于其他部门", traefikModel.TraefikService{Type: "mirror", Mirror: types.JSONMap{"service": "shop", "mirrors": []interface{}{map[string]interface{}{"name": "legacy", "percent": float64(10)}}}}, deptScope, "dept"},
+		{"chain引用本部门的中间件", traefikModel.TraefikMiddleware{Type: "chain", Config: types.JSONMap{"middlewares": []interface{}{"auth"}}}, deptScope, ""},
+		{"chain引用其他部门的中间件", traefikModel.TraefikMiddleware{Type: "chain", Config: types.JSONMap{"middlewares": []interface{}{"auth", "limit@http"}}}, deptScope, "dept"},
+		{"超级管理员不检查", traefikModel.TraefikRouter{Service: "pay", Middlewares: types.JSONSlice{"limit"}}, sysModel.DeptScope{All: true}, ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := checkReferences(dao, c.object, c.scope)
+			if got := errorKind(err); got != c.want {
+				t.Errorf("checkReferences(%+v) = %v, want %q", c.object, err, c.want)
+			}
+		})
+	}
+}
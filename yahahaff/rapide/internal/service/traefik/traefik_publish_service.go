@@ -0,0 +1,451 @@
+package traefik
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	traefikDAO "github.com/yahahaff/rapide/internal/dao/traefik"
+	traefikModel "github.com/yahahaff/rapide/internal/models/traefik"
+	"github.com/yahahaff/rapide/internal/utils"
+	"github.com/yahahaff/rapide/pkg/database"
+	"github.com/yahahaff/rapide/pkg/types"
+	"gorm.io/gorm"
+)
+
+// TraefikPublishService 管理草稿配置的发布
+// 路由、服务和中间件表中的内容是草稿，只有发布后才会通过HTTP Provider和KV下发到Traefik
+type TraefikPublishService struct {
+	traefikDAO *traefikDAO.TraefikDAO
+}
+
+// PublishDiff 草稿相对于当前发布快照的差异
+type PublishDiff struct {
+	PublishedID uint64         `json:"publishedId"`
+	Items       []ImportItem   `json:"items"`
+	Summary     map[string]int `json:"summary"`
+}
+
+// EnsurePublished 确保存在已发布的快照，应在启动时调用，避免启动后的草稿修改被当作初始配置发布
+func (ps *TraefikPublishService) EnsurePublished() error {
+	_, _, err := ensurePublishedSnapshot(ps.traefikDAO)
+	return err
+}
+
+// GetDraftDiff 比较草稿与当前发布的快照
+func (ps *TraefikPublishService) GetDraftDiff() (PublishDiff, error) {
+	published, set, err := publishedSnapshot(ps.traefikDAO)
+	if err != nil {
+		return PublishDiff{}, err
+	}
+	draft, err := loadEnabledConfigSet(ps.traefikDAO)
+	if err != nil {
+		return PublishDiff{}, err
+	}
+
+	items := diffConfigSets(set, draft)
+	return PublishDiff{PublishedID: published.ID, Items: items, Summary: diffSummary(items)}, nil
+}
+
+// Publish 把当前草稿保存为快照，activateAt为空或已过时立即生效，否则在该时间生效
+// 计划发布的内容在调用时确定，之后对草稿的修改需要再次发布；配置检查有error级别的问题时拒绝发布
+// 生效时间不早于本次发布的计划发布包含的是旧的草稿，生效后会撤销本次发布，因此标记为已替代并在返回的快照中列出
+func (ps *TraefikPublishService) Publish(remark, operator string, activateAt *time.Time) (traefikModel.TraefikSnapshot, error) {
+	var snapshot traefikModel.TraefikSnapshot
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		dao := ps.traefikDAO.WithTx(tx)
+		_, published, err := ensurePublishedSnapshot(dao)
+		if err != nil {
+			return err
+		}
+		draft, err := loadEnabledConfigSet(dao)
+		if err != nil {
+			return err
+		}
+
+		items := diffConfigSets(published, draft)
+		if len(items) == 0 {
+			return &validationError{message: "草稿与已发布的配置一致，无需发布"}
+		}
+		issues, err := lintSet(dao, draft)
+		if err != nil {
+			return err
+		}
+		if err := lintErrors(issues); err != nil {
+			return err
+		}
+		summary := types.JSONMap{}
+		for action, count := range diffSummary(items) {
+			summary[action] = count
+		}
+
+		snapshot = traefikModel.TraefikSnapshot{
+			Status:   "scheduled",
+			Config:   snapshotConfig(draft),
+			Summary:  summary,
+			Remark:   remark,
+			Operator: operator,
+		}
+		now := time.Now()
+		if activateAt != nil && activateAt.After(now) {
+			snapshot.ActivateAt = activateAt
+		}
+		if err := dao.CreateSnapshot(&snapshot); err != nil {
+			return err
+		}
+		if snapshot.Superseded, err = supersedeScheduledSnapshots(dao, snapshot); err != nil {
+			return err
+		}
+		if snapshot.ActivateAt != nil {
+			return nil
+		}
+		return activateSnapshot(dao, &snapshot, now)
+	})
+	if err == nil && snapshot.Status == "published" {
+		notifyConfigChanged()
+	}
+	return snapshot, err
+}
+
+// CancelSnapshot 取消尚未生效的计划发布
+func (ps *TraefikPublishService) CancelSnapshot(id uint64) (traefikModel.TraefikSnapshot, error) {
+	snapshot, err := ps.traefikDAO.GetSnapshotByID(id)
+	if err != nil {
+		return snapshot, err
+	}
+	if snapshot.Status != "scheduled" {
+		return snapshot, &validationError{message: "只能取消计划中的发布"}
+	}
+	snapshot.Status = "cancelled"
+	return snapshot, ps.traefikDAO.SaveSnapshot(&snapshot)
+}
+
+// ActivateDueSnapshots 使计划时间已到的快照生效，返回生效的快照数量
+// 多个快照同时到期时按计划时间依次生效，较新的发布已经替代了计划时间更晚的旧快照，最终生效的是计划时间最晚的；
+// 生效前按当前的Traefik实例重新检查配置，有error级别的问题时标记为失败并告警
+func (ps *TraefikPublishService) ActivateDueSnapshots() (int, error) {
+	now := time.Now()
+	activated := 0
+	var failed []traefikModel.TraefikSnapshot
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		dao := ps.traefikDAO.WithTx(tx)
+		snapshots, err := dao.GetDueSnapshots(now)
+		if err != nil {
+			return err
+		}
+		for i := range snapshots {
+			set, err := snapshotConfigSet(snapshots[i])
+			if err != nil {
+				return err
+			}
+			issues, err := lintSet(dao, set)
+			if err != nil {
+				return err
+			}
+			if err := lintErrors(issues); err != nil {
+				snapshots[i].Status = "failed"
+				snapshots[i].Reason = err.Error()
+				if err := dao.SaveSnapshot(&snapshots[i]); err != nil {
+					return err
+				}
+				failed = append(failed, snapshots[i])
+				continue
+			}
+			if err := activateSnapshot(dao, &snapshots[i], now); err != nil {
+				return err
+			}
+			activated++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	for _, snapshot := range failed {
+		sendAlert(fmt.Sprintf("[rapide] 计划发布 #%d 未生效", snapshot.ID),
+			fmt.Sprintf("计划发布 #%d（%s，操作人%s）到期时配置检查未通过，已标记为失败：%s", snapshot.ID, snapshot.Remark, snapshot.Operator, snapshot.Reason))
+	}
+	if activated > 0 {
+		notifyConfigChanged()
+	}
+	return activated, nil
+}
+
+// GetSnapshots 分页获取配置快照
+func (ps *TraefikPublishService) GetSnapshots(status string, page, size int) ([]traefikModel.TraefikSnapshot, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 || size > 100 {
+		size = 20
+	}
+	return ps.traefikDAO.GetSnapshots(status, page, size)
+}
+
+// GetSnapshot 获取配置快照详情
+func (ps *TraefikPublishService) GetSnapshot(id uint64) (traefikModel.TraefikSnapshot, error) {
+	return ps.traefikDAO.GetSnapshotByID(id)
+}
+
+// activateSnapshot 使快照生效并把之前发布的快照标记为已替代
+func activateSnapshot(dao *traefikDAO.TraefikDAO, snapshot *traefikModel.TraefikSnapshot, now time.Time) error {
+	snapshot.Status = "published"
+	snapshot.PublishedAt = &now
+	if err := dao.SaveSnapshot(snapshot); err != nil {
+		return err
+	}
+	return dao.SupersedeSnapshots(snapshot.ID)
+}
+
+// supersedeScheduledSnapshots 把生效时间不早于新快照的其他计划发布标记为已替代，返回被替代的快照ID
+// 立即发布时替代所有计划发布，包括已到期但还未被定时任务处理的
+func supersedeScheduledSnapshots(dao *traefikDAO.TraefikDAO, snapshot traefikModel.TraefikSnapshot) ([]uint64, error) {
+	scheduled, err := dao.GetScheduledSnapshots()
+	if err != nil {
+		return nil, err
+	}
+	superseded := make([]uint64, 0)
+	for i := range scheduled {
+		if scheduled[i].ID == snapshot.ID {
+			continue
+		}
+		if snapshot.ActivateAt != nil && scheduled[i].ActivateAt != nil && scheduled[i].ActivateAt.Before(*snapshot.ActivateAt) {
+			continue
+		}
+		scheduled[i].Status = "superseded"
+		scheduled[i].Reason = fmt.Sprintf("计划发布的草稿早于快照#%d，生效会撤销该发布，需要重新发布", snapshot.ID)
+		if err := dao.SaveSnapshot(&scheduled[i]); err != nil {
+			return nil, err
+		}
+		superseded = append(superseded, scheduled[i].ID)
+	}
+	return superseded, nil
+}
+
+// loadPublishedConfigSet 加载当前发布的配置，下发给Traefik的配置都应来自这里
+// 生效中的维护窗口和路由关联的证书只体现在这里返回的配置中，不写入发布快照
+func loadPublishedConfigSet(dao *traefikDAO.TraefikDAO) (ConfigSet, error) {
+	_, set, err := publishedSnapshot(dao)
+	if err != nil {
+		return set, err
+	}
+	if set.Certificates, err = loadRouterCertificates(dao, set.Routers); err != nil {
+		return set, err
+	}
+	return set, applyMaintenances(dao, &set, time.Now())
+}
+
+// publishedSnapshot 获取当前发布的快照及其配置
+// 从未发布过时返回草稿但不创建快照，初始快照只在EnsurePublished和Publish中创建
+func publishedSnapshot(dao *traefikDAO.TraefikDAO) (traefikModel.TraefikSnapshot, ConfigSet, error) {
+	snapshot, err := dao.GetPublishedSnapshot()
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		draft, err := loadEnabledConfigSet(dao)
+		return snapshot, draft, err
+	}
+	if err != nil {
+		return snapshot, ConfigSet{}, err
+	}
+	set, err := snapshotConfigSet(snapshot)
+	return snapshot, set, err
+}
+
+// ensurePublishedSnapshot 获取当前发布的快照及其配置
+// 从未发布过时把草稿作为初始快照发布，使升级前已在使用的配置继续生效
+func ensurePublishedSnapshot(dao *traefikDAO.TraefikDAO) (traefikModel.TraefikSnapshot, ConfigSet, error) {
+	snapshot, err := dao.GetPublishedSnapshot()
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		draft, err := loadEnabledConfigSet(dao)
+		if err != nil {
+			return snapshot, ConfigSet{}, err
+		}
+		now := time.Now()
+		snapshot = traefikModel.TraefikSnapshot{
+			Status:      "published",
+			Config:      snapshotConfig(draft),
+			Summary:     types.JSONMap{},
+			Remark:      "初始发布",
+			Operator:    "system",
+			PublishedAt: &now,
+		}
+		return snapshot, draft, dao.CreateSnapshot(&snapshot)
+	}
+	if err != nil {
+		return snapshot, ConfigSet{}, err
+	}
+	set, err := snapshotConfigSet(snapshot)
+	return snapshot, set, err
+}
+
+// snapshotConfigSet 解析快照中的配置
+func snapshotConfigSet(snapshot traefikModel.TraefikSnapshot) (ConfigSet, error) {
+	var set ConfigSet
+	data, err := json.Marshal(snapshot.Config)
+	if err != nil {
+		return set, err
+	}
+	return set, json.Unmarshal(data, &set)
+}
+
+// serviceUpdate 对服务的修改，返回是否有变化
+type serviceUpdate func(service *traefikModel.TraefikService) bool
+
+// applyLiveServiceUpdate 把对服务的修改同时应用到草稿、当前发布的配置和计划中的快照，发布的配置有变化时立即生效
+// 用于增减后端、调整灰度权重这类运维操作，只应用这一项修改，草稿中对该服务的其他修改不会因此发布
+// 返回是否生成了新的发布快照，服务尚未发布时只修改草稿
+func applyLiveServiceUpdate(dao *traefikDAO.TraefikDAO, protocol, name string, update serviceUpdate, remark, operator string) (bool, error) {
+	draft, err := dao.GetService(name, protocolOf(protocol))
+	if err != nil {
+		return false, err
+	}
+	if update(&draft) {
+		if _, err := applyObject(dao, kindService, name, protocol, objectSnapshot(draft), "update", operator, remark); err != nil {
+			return false, err
+		}
+	}
+
+	// 计划中的快照同步修改，避免生效时恢复为旧的内容
+	scheduled, err := dao.GetScheduledSnapshots()
+	if err != nil {
+		return false, err
+	}
+	for i := range scheduled {
+		pending, err := snapshotConfigSet(scheduled[i])
+		if err != nil {
+			return false, err
+		}
+		if updateSetService(&pending, protocol, name, update) {
+			scheduled[i].Config = snapshotConfig(pending)
+			if err := dao.SaveSnapshot(&scheduled[i]); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	_, set, err := publishedSnapshot(dao)
+	if err != nil {
+		return false, err
+	}
+	if !updateSetService(&set, protocol, name, update) {
+		return false, nil
+	}
+	snapshot := traefikModel.TraefikSnapshot{
+		Status:   "scheduled",
+		Config:   snapshotConfig(set),
+		Summary:  types.JSONMap{"create": 0, "update": 1, "delete": 0},
+		Remark:   remark,
+		Operator: operator,
+	}
+	if err := dao.CreateSnapshot(&snapshot); err != nil {
+		return false, err
+	}
+	return true, activateSnapshot(dao, &snapshot, time.Now())
+}
+
+// updateSetService 修改配置集合中的指定服务，服务不存在或没有变化时返回false
+func updateSetService(set *ConfigSet, protocol, name string, update serviceUpdate) bool {
+	for i := range set.Services {
+		if refKey(set.Services[i].Protocol, set.Services[i].Name) == refKey(protocol, name) {
+			return update(&set.Services[i])
+		}
+	}
+	return false
+}
+
+// snapshotConfig 把配置集合转换为快照内容
+func snapshotConfig(set ConfigSet) types.JSONMap {
+	config := objectSnapshot(set)
+	if config == nil {
+		return types.JSONMap{}
+	}
+	return config
+}
+
+// diffConfigSets 按对象比较两组配置，返回从from变为to需要的变更
+func diffConfigSets(from, to ConfigSet) []ImportItem {
+	before, after := indexConfigSet(from), indexConfigSet(to)
+
+	keys := make([]string, 0, len(before)+len(after))
+	for key := range before {
+		keys = append(keys, key)
+	}
+	for key := range after {
+		if _, ok := before[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	items := make([]ImportItem, 0)
+	for _, key := range keys {
+		old, oldOk := before[key]
+		current, newOk := after[key]
+		item := ImportItem{Kind: current.kind, Name: current.name, Protocol: current.protocol}
+		switch {
+		case !oldOk:
+			item.Action = "create"
+		case !newOk:
+			item.Kind, item.Name, item.Protocol = old.kind, old.name, old.protocol
+			item.Action = "delete"
+		default:
+			item.Action = "update"
+		}
+		item.Changes = utils.DiffJSON(old.snapshot, current.snapshot)
+		if len(item.Changes) == 0 {
+			continue
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// indexedObject 配置集合中的单个对象
+type indexedObject struct {
+	kind     string
+	name     string
+	protocol string
+	snapshot types.JSONMap
+}
+
+// indexConfigSet 按对象类型、协议和名称索引配置集合
+func indexConfigSet(set ConfigSet) map[string]indexedObject {
+	index := make(map[string]indexedObject)
+	add := func(kind, name, protocol string, object interface{}) {
+		index[kind+"/"+refKey(protocol, name)] = indexedObject{
+			kind:     kind,
+			name:     name,
+			protocol: protocolOf(protocol),
+			snapshot: objectSnapshot(object),
+		}
+	}
+	for _, router := range set.Routers {
+		add(kindRouter, router.Name, router.Protocol, router)
+	}
+	for _, service := range set.Services {
+		add(kindService, service.Name, service.Protocol, service)
+	}
+	for _, middleware := range set.Middlewares {
+		add(kindMiddleware, middleware.Name, middleware.Protocol, middleware)
+	}
+	for _, option := range set.TLSOptions {
+		add(kindTLSOption, option.Name, protocolTLS, option)
+	}
+	for _, store := range set.TLSStores {
+		add(kindTLSStore, store.Name, protocolTLS, store)
+	}
+	for _, transport := range set.ServersTransports {
+		add(kindServersTransport, transport.Name, transport.Protocol, transport)
+	}
+	return index
+}
+
+// diffSummary 按动作统计变更数量
+func diffSummary(items []ImportItem) map[string]int {
+	summary := map[string]int{"create": 0, "update": 0, "delete": 0}
+	for _, item := range items {
+		summary[item.Action]++
+	}
+	return summary
+}
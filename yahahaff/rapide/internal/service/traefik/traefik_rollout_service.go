@@ -0,0 +1,392 @@
+package traefik
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	traefikDAO "github.com/yahahaff/rapide/internal/dao/traefik"
+	sysModel "github.com/yahahaff/rapide/internal/models/sys"
+	traefikModel "github.com/yahahaff/rapide/internal/models/traefik"
+	"github.com/yahahaff/rapide/pkg/config"
+	"github.com/yahahaff/rapide/pkg/database"
+	"github.com/yahahaff/rapide/pkg/types"
+	"gorm.io/gorm"
+)
+
+// TraefikRolloutService 在加权服务上执行灰度发布
+// 按步骤调整稳定版本和灰度版本两个子服务的权重，期间根据Traefik API中灰度版本后端的健康状态暂停或回滚
+type TraefikRolloutService struct {
+	traefikDAO *traefikDAO.TraefikDAO
+}
+
+// rolloutStatusText 告警中使用的状态说明
+var rolloutStatusText = map[string]string{"paused": "暂停", "rolled_back": "回滚"}
+
+// RolloutInput 创建灰度发布的参数
+type RolloutInput struct {
+	Service   string
+	Protocol  string
+	Stable    string
+	Canary    string
+	Steps     []int
+	Interval  int    // 每一步持续的秒数
+	OnFailure string // pause或rollback，默认rollback
+}
+
+// CreateRollout 创建并开始灰度发布，立即切换到第一步的权重
+// 同一服务同时只能有一个进行中或暂停的灰度发布
+func (rs *TraefikRolloutService) CreateRollout(input RolloutInput, operator string) (traefikModel.TraefikRollout, error) {
+	if err := validateRolloutInput(&input); err != nil {
+		return traefikModel.TraefikRollout{}, err
+	}
+
+	var rollout traefikModel.TraefikRollout
+	var published bool
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		dao := rs.traefikDAO.WithTx(tx)
+		active, err := dao.GetActiveRollouts(protocolOf(input.Protocol), input.Service)
+		if err != nil {
+			return err
+		}
+		if len(active) > 0 {
+			return &validationError{message: fmt.Sprintf("服务%s已有进行中的灰度发布(ID %d)", input.Service, active[0].ID)}
+		}
+
+		service, err := dao.GetService(input.Service, protocolOf(input.Protocol))
+		if err != nil {
+			return err
+		}
+		original, err := rolloutWeights(service, input.Stable, input.Canary)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		next := now.Add(time.Duration(input.Interval) * time.Second)
+		rollout = traefikModel.TraefikRollout{
+			Service:    input.Service,
+			Protocol:   protocolOf(input.Protocol),
+			Stable:     input.Stable,
+			Canary:     input.Canary,
+			Steps:      input.Steps,
+			Interval:   input.Interval,
+			OnFailure:  input.OnFailure,
+			Status:     "running",
+			Original:   original,
+			NextStepAt: &next,
+			Operator:   operator,
+		}
+		rollout.Events = append(rollout.Events, traefikModel.RolloutEvent{At: now, Action: "start", Weight: input.Steps[0]})
+		if err := dao.CreateRollout(&rollout); err != nil {
+			return err
+		}
+		published, err = applyRolloutWeights(dao, rollout, input.Steps[0], operator)
+		return err
+	})
+	if err == nil && published {
+		notifyConfigChanged()
+	}
+	return rollout, err
+}
+
+// PauseRollout 暂停灰度发布，保持当前权重
+func (rs *TraefikRolloutService) PauseRollout(id uint64, operator string) (traefikModel.TraefikRollout, error) {
+	return rs.transition(id, func(dao *traefikDAO.TraefikDAO, rollout *traefikModel.TraefikRollout) (bool, error) {
+		if rollout.Status != "running" {
+			return false, &validationError{message: "只能暂停进行中的灰度发布"}
+		}
+		pauseRollout(rollout, operator+"手动暂停", time.Now())
+		return false, nil
+	})
+}
+
+// ResumeRollout 恢复暂停的灰度发布，当前步骤重新计时
+func (rs *TraefikRolloutService) ResumeRollout(id uint64, operator string) (traefikModel.TraefikRollout, error) {
+	return rs.transition(id, func(dao *traefikDAO.TraefikDAO, rollout *traefikModel.TraefikRollout) (bool, error) {
+		if rollout.Status != "paused" {
+			return false, &validationError{message: "只能恢复暂停的灰度发布"}
+		}
+		now := time.Now()
+		next := now.Add(time.Duration(rollout.Interval) * time.Second)
+		rollout.Status = "running"
+		rollout.NextStepAt = &next
+		rollout.Events = append(rollout.Events, traefikModel.RolloutEvent{At: now, Action: "resume", Weight: rollout.Steps[rollout.Step], Message: operator + "恢复"})
+		return false, nil
+	})
+}
+
+// AbortRollout 中止灰度发布并把两个子服务恢复为开始前的权重
+func (rs *TraefikRolloutService) AbortRollout(id uint64, operator string) (traefikModel.TraefikRollout, error) {
+	return rs.transition(id, func(dao *traefikDAO.TraefikDAO, rollout *traefikModel.TraefikRollout) (bool, error) {
+		if rollout.Status != "running" && rollout.Status != "paused" {
+			return false, &validationError{message: "灰度发布已结束"}
+		}
+		return rollbackRollout(dao, rollout, operator+"手动中止", operator, time.Now())
+	})
+}
+
+// ProgressRollouts 推进所有进行中的灰度发布，由定时任务调用
+// 灰度版本有后端不健康时按onFailure暂停或回滚；健康且当前步骤到期时进入下一步，最后一步到期后完成
+// 连续TRAEFIK_ROLLOUT_HEALTH_ERROR_LIMIT次无法获取健康状态时按不健康处理；单个灰度发布处理失败时告警并继续处理其他的
+func (rs *TraefikRolloutService) ProgressRollouts() error {
+	rollouts, err := rs.traefikDAO.GetActiveRollouts("", "")
+	if err != nil {
+		return err
+	}
+
+	limit := config.GetInt("TRAEFIK_ROLLOUT_HEALTH_ERROR_LIMIT", 5)
+	var errs []error
+	for _, rollout := range rollouts {
+		if rollout.Status != "running" {
+			continue
+		}
+		healthy, message, healthErr := canaryHealth(rollout)
+		if healthErr != nil {
+			message = fmt.Sprintf("连续%d次无法获取灰度版本的健康状态: %s", limit, healthErr.Error())
+		} else if healthy && rollout.HealthErrors == 0 && rollout.NextStepAt != nil && time.Now().Before(*rollout.NextStepAt) {
+			continue
+		}
+
+		updated, err := rs.transition(rollout.ID, func(dao *traefikDAO.TraefikDAO, current *traefikModel.TraefikRollout) (bool, error) {
+			if current.Status != "running" {
+				return false, nil
+			}
+			now := time.Now()
+			if healthErr != nil {
+				// 无法获取健康状态时不推进，等待下次检查
+				current.HealthErrors++
+				if current.HealthErrors < limit {
+					return false, nil
+				}
+			} else {
+				current.HealthErrors = 0
+			}
+			if !healthy {
+				current.HealthErrors = 0
+				if current.OnFailure == "pause" {
+					pauseRollout(current, message, now)
+					return false, nil
+				}
+				return rollbackRollout(dao, current, message, "system", now)
+			}
+			if current.NextStepAt != nil && now.Before(*current.NextStepAt) {
+				return false, nil
+			}
+
+			if current.Step >= len(current.Steps)-1 {
+				current.Status = "completed"
+				current.NextStepAt = nil
+				current.FinishedAt = &now
+				current.Events = append(current.Events, traefikModel.RolloutEvent{At: now, Action: "complete", Weight: current.Steps[current.Step]})
+				return false, nil
+			}
+			current.Step++
+			next := now.Add(time.Duration(current.Interval) * time.Second)
+			current.NextStepAt = &next
+			current.Events = append(current.Events, traefikModel.RolloutEvent{At: now, Action: "advance", Weight: current.Steps[current.Step]})
+			return applyRolloutWeights(dao, *current, current.Steps[current.Step], "system")
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("灰度发布%d: %w", rollout.ID, err))
+			sendAlert(
+				fmt.Sprintf("[rapide] 灰度发布处理失败: %s", rollout.Service),
+				fmt.Sprintf("服务 %s 的灰度发布(ID %d)处理失败，将在下次检查时重试: %s", rollout.Service, rollout.ID, err.Error()),
+			)
+			continue
+		}
+		if !healthy && updated.Status != "running" {
+			sendAlert(
+				fmt.Sprintf("[rapide] 灰度发布已%s: %s", rolloutStatusText[updated.Status], updated.Service),
+				fmt.Sprintf("服务 %s 的灰度发布(ID %d)在灰度流量%d%%时%s，原因: %s", updated.Service, updated.ID, updated.Steps[updated.Step], rolloutStatusText[updated.Status], message),
+			)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// GetRollouts 分页获取范围内部门的服务的灰度发布
+func (rs *TraefikRolloutService) GetRollouts(service, status string, scope sysModel.DeptScope, page, size int) ([]traefikModel.TraefikRollout, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 || size > 100 {
+		size = 20
+	}
+	return rs.traefikDAO.GetRollouts(service, status, scope, page, size)
+}
+
+// GetRollout 获取灰度发布详情
+func (rs *TraefikRolloutService) GetRollout(id uint64) (traefikModel.TraefikRollout, error) {
+	return rs.traefikDAO.GetRolloutByID(id)
+}
+
+// transition 在事务中修改灰度发布的状态，change返回配置是否已下发
+func (rs *TraefikRolloutService) transition(id uint64, change func(dao *traefikDAO.TraefikDAO, rollout *traefikModel.TraefikRollout) (bool, error)) (traefikModel.TraefikRollout, error) {
+	var rollout traefikModel.TraefikRollout
+	var published bool
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		dao := rs.traefikDAO.WithTx(tx)
+		var err error
+		if rollout, err = dao.GetRolloutByID(id); err != nil {
+			return err
+		}
+		if published, err = change(dao, &rollout); err != nil {
+			return err
+		}
+		return dao.SaveRollout(&rollout)
+	})
+	if err == nil && published {
+		notifyConfigChanged()
+	}
+	return rollout, err
+}
+
+// pauseRollout 暂停灰度发布
+func pauseRollout(rollout *traefikModel.TraefikRollout, reason string, now time.Time) {
+	rollout.Status = "paused"
+	rollout.NextStepAt = nil
+	rollout.Reason = reason
+	rollout.Events = append(rollout.Events, traefikModel.RolloutEvent{At: now, Action: "pause", Weight: rollout.Steps[rollout.Step], Message: reason})
+}
+
+// rollbackRollout 恢复两个子服务开始前的权重并结束灰度发布
+func rollbackRollout(dao *traefikDAO.TraefikDAO, rollout *traefikModel.TraefikRollout, reason, operator string, now time.Time) (bool, error) {
+	rollout.Status = "rolled_back"
+	rollout.NextStepAt = nil
+	rollout.FinishedAt = &now
+	rollout.Reason = reason
+	rollout.Events = append(rollout.Events, traefikModel.RolloutEvent{At: now, Action: "rollback", Weight: 0, Message: reason})
+
+	remark := fmt.Sprintf("灰度发布%d回滚: %s", rollout.ID, reason)
+	return applyLiveServiceUpdate(dao, rollout.Protocol, rollout.Service, func(service *traefikModel.TraefikService) bool {
+		return setChildWeights(service, rollout.Original)
+	}, remark, operator)
+}
+
+// applyRolloutWeights 把灰度版本的流量设为percent%，稳定版本为其余部分
+func applyRolloutWeights(dao *traefikDAO.TraefikDAO, rollout traefikModel.TraefikRollout, percent int, operator string) (bool, error) {
+	weights := types.JSONMap{rollout.Stable: 100 - percent, rollout.Canary: percent}
+	remark := fmt.Sprintf("灰度发布%d: %s流量%d%%", rollout.ID, rollout.Canary, percent)
+	return applyLiveServiceUpdate(dao, rollout.Protocol, rollout.Service, func(service *traefikModel.TraefikService) bool {
+		return setChildWeights(service, weights)
+	}, remark, operator)
+}
+
+// setChildWeights 设置加权服务中子服务的权重，值为空时删除权重使用默认值，返回是否有变化
+func setChildWeights(service *traefikModel.TraefikService, weights types.JSONMap) bool {
+	items, _ := service.Weighted["services"].([]interface{})
+	children := make([]interface{}, 0, len(items))
+	changed := false
+	for _, item := range items {
+		child, ok := item.(map[string]interface{})
+		if !ok {
+			children = append(children, item)
+			continue
+		}
+		copied := make(map[string]interface{}, len(child))
+		for key, value := range child {
+			copied[key] = value
+		}
+		name, _ := copied["name"].(string)
+		if weight, ok := weights[name]; ok && fmt.Sprint(weight) != fmt.Sprint(copied["weight"]) {
+			if weight == nil {
+				delete(copied, "weight")
+			} else {
+				copied["weight"] = weight
+			}
+			changed = true
+		}
+		children = append(children, copied)
+	}
+	if !changed {
+		return false
+	}
+
+	weighted := types.JSONMap{}
+	for key, value := range service.Weighted {
+		weighted[key] = value
+	}
+	weighted["services"] = children
+	service.Weighted = weighted
+	return true
+}
+
+// rolloutWeights 检查服务是否为包含两个子服务的加权服务，返回两个子服务当前的权重
+func rolloutWeights(service traefikModel.TraefikService, stable, canary string) (types.JSONMap, error) {
+	if service.Type != "weighted" {
+		return nil, &validationError{message: fmt.Sprintf("服务%s的类型为%s，只有加权服务可以灰度发布", service.Name, service.Type)}
+	}
+	weights := types.JSONMap{}
+	items, _ := service.Weighted["services"].([]interface{})
+	for _, item := range items {
+		child, _ := item.(map[string]interface{})
+		if name, _ := child["name"].(string); name == stable || name == canary {
+			weights[name] = child["weight"]
+		}
+	}
+	for _, name := range []string{stable, canary} {
+		if _, ok := weights[name]; !ok {
+			return nil, &validationError{message: fmt.Sprintf("加权服务%s中没有子服务%s", service.Name, name)}
+		}
+	}
+	return weights, nil
+}
+
+// validateRolloutInput 检查灰度步骤，流量百分比应在1到100之间并逐步增加
+func validateRolloutInput(input *RolloutInput) error {
+	if input.Stable == input.Canary {
+		return &validationError{message: "稳定版本和灰度版本不能是同一个服务"}
+	}
+	if len(input.Steps) == 0 {
+		return &validationError{message: "至少需要一个灰度步骤"}
+	}
+	for i, step := range input.Steps {
+		if step < 1 || step > 100 {
+			return &validationError{message: fmt.Sprintf("灰度步骤的流量百分比应在1到100之间: %d", step)}
+		}
+		if i > 0 && step <= input.Steps[i-1] {
+			return &validationError{message: "灰度步骤的流量百分比应逐步增加"}
+		}
+	}
+	if input.Interval <= 0 {
+		return &validationError{message: "每一步的持续时间应大于0"}
+	}
+	if input.OnFailure == "" {
+		input.OnFailure = "rollback"
+	}
+	if input.OnFailure != "pause" && input.OnFailure != "rollback" {
+		return &validationError{message: "onFailure只能是pause或rollback"}
+	}
+	return nil
+}
+
+// canaryHealth 从Traefik API获取灰度版本的后端状态，有后端不是UP时视为不健康
+// 灰度版本未配置健康检查时Traefik没有serverStatus，视为健康
+func canaryHealth(rollout traefikModel.TraefikRollout) (bool, string, error) {
+	services, err := getTraefikList("/api/" + rollout.Protocol + "/services")
+	if err != nil {
+		return false, "", err
+	}
+	for _, service := range services {
+		name, _ := service["name"].(string)
+		if name != rollout.Canary && !strings.HasPrefix(name, rollout.Canary+"@") {
+			continue
+		}
+		servers, _ := service["serverStatus"].(map[string]interface{})
+		var down []string
+		for url, status := range servers {
+			if fmt.Sprint(status) != "UP" {
+				down = append(down, url)
+			}
+		}
+		if len(down) > 0 {
+			sort.Strings(down)
+			return false, fmt.Sprintf("灰度版本%s的后端不健康: %s", rollout.Canary, strings.Join(down, ", ")), nil
+		}
+		return true, "", nil
+	}
+	return false, "", fmt.Errorf("Traefik中没有服务%s", rollout.Canary)
+}
@@ -0,0 +1,430 @@
+package traefik
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	traefikDAO "github.com/yahahaff/rapide/internal/dao/traefik"
+	traefikModel "github.com/yahahaff/rapide/internal/models/traefik"
+	"github.com/yahahaff/rapide/pkg/config"
+	"github.com/yahahaff/rapide/pkg/database"
+	"github.com/yahahaff/rapide/pkg/types"
+	"gorm.io/gorm"
+)
+
+// TraefikServerService 管理负载均衡服务的后端服务器，供滚动发布等场景调用
+// 修改同时写入草稿和当前发布的配置，立即下发到Traefik，不需要再发布草稿
+type TraefikServerService struct {
+	traefikDAO *traefikDAO.TraefikDAO
+}
+
+// BackendServer 负载均衡服务中的一个后端
+type BackendServer struct {
+	Server string                           `json:"server"`
+	Weight *int                             `json:"weight"`
+	Drain  *traefikModel.TraefikServerDrain `json:"drain,omitempty"` // 进行中的摘除任务
+}
+
+// BackendServers 服务的后端列表
+type BackendServers struct {
+	Service   string          `json:"service"`
+	Protocol  string          `json:"protocol"`
+	Servers   []BackendServer `json:"servers"`
+	Published bool            `json:"published"` // 本次修改是否已下发，没有变化或服务尚未发布时为false
+}
+
+// serverUpdate 对后端列表的修改，会分别作用于草稿、当前发布和计划中的配置
+type serverUpdate func(servers []interface{}) []interface{}
+
+// ListServers 获取服务的后端列表及进行中的摘除任务
+func (ss *TraefikServerService) ListServers(protocol, name string) (BackendServers, error) {
+	service, err := loadBalancerService(ss.traefikDAO, protocol, name)
+	if err != nil {
+		return BackendServers{}, err
+	}
+	return backendServers(ss.traefikDAO, service)
+}
+
+// AddServer 添加后端，已存在时只更新权重，便于重复调用
+// 正在摘除的后端重新添加时取消摘除，未指定权重则恢复摘除前的权重
+func (ss *TraefikServerService) AddServer(protocol, name, server string, weight *int, operator string) (BackendServers, error) {
+	server, err := normalizeServer(protocol, server)
+	if err != nil {
+		return BackendServers{}, err
+	}
+	if err := checkWeight(protocol, weight); err != nil {
+		return BackendServers{}, err
+	}
+
+	return ss.modify(protocol, name, "添加后端"+server, operator, func(dao *traefikDAO.TraefikDAO, servers []interface{}) (serverUpdate, error) {
+		drains, err := dao.GetDrains(protocolOf(protocol), name, "draining", 100)
+		if err != nil {
+			return nil, err
+		}
+		for _, drain := range drains {
+			if drain.Server == server && weight == nil {
+				weight = drain.Weight
+				if weight == nil {
+					weight = intPtr(1)
+				}
+			}
+		}
+		if err := dao.FinishDrains(protocolOf(protocol), name, server, "cancelled"); err != nil {
+			return nil, err
+		}
+
+		key := serverKey(protocol)
+		return func(servers []interface{}) []interface{} {
+			if i := findServer(servers, key, server); i >= 0 {
+				if weight != nil {
+					servers[i].(map[string]interface{})["weight"] = *weight
+				}
+				return servers
+			}
+			added := map[string]interface{}{key: server}
+			if weight != nil {
+				added["weight"] = *weight
+			}
+			return append(servers, added)
+		}, nil
+	})
+}
+
+// SetServerWeight 调整后端权重，权重为0时该后端不再接收新请求
+func (ss *TraefikServerService) SetServerWeight(protocol, name, server string, weight int, operator string) (BackendServers, error) {
+	server, err := normalizeServer(protocol, server)
+	if err != nil {
+		return BackendServers{}, err
+	}
+	if err := checkWeight(protocol, &weight); err != nil {
+		return BackendServers{}, err
+	}
+
+	return ss.modify(protocol, name, fmt.Sprintf("调整后端%s的权重为%d", server, weight), operator, func(dao *traefikDAO.TraefikDAO, servers []interface{}) (serverUpdate, error) {
+		key := serverKey(protocol)
+		if findServer(servers, key, server) < 0 {
+			return nil, &validationError{message: "后端不存在: " + server}
+		}
+		// 手动调整权重后不再按计划删除
+		if weight > 0 {
+			if err := dao.FinishDrains(protocolOf(protocol), name, server, "cancelled"); err != nil {
+				return nil, err
+			}
+		}
+		return func(servers []interface{}) []interface{} {
+			if i := findServer(servers, key, server); i >= 0 {
+				servers[i].(map[string]interface{})["weight"] = weight
+			}
+			return servers
+		}, nil
+	})
+}
+
+// RemoveServer 立即删除后端，后端不存在时不做修改
+func (ss *TraefikServerService) RemoveServer(protocol, name, server, operator string) (BackendServers, error) {
+	server, err := normalizeServer(protocol, server)
+	if err != nil {
+		return BackendServers{}, err
+	}
+	return ss.modify(protocol, name, "删除后端"+server, operator, func(dao *traefikDAO.TraefikDAO, servers []interface{}) (serverUpdate, error) {
+		if err := dao.FinishDrains(protocolOf(protocol), name, server, "removed"); err != nil {
+			return nil, err
+		}
+		return removeServer(serverKey(protocol), server), nil
+	})
+}
+
+// DrainServer 摘除后端：先把权重设为0，delay后再从服务中删除，delay为0时使用TRAEFIK_DRAIN_DELAY
+// 只有http服务支持权重，重复摘除同一后端时返回进行中的任务
+func (ss *TraefikServerService) DrainServer(protocol, name, server string, delay time.Duration, operator string) (BackendServers, error) {
+	server, err := normalizeServer(protocol, server)
+	if err != nil {
+		return BackendServers{}, err
+	}
+	if protocolOf(protocol) != "http" {
+		return BackendServers{}, &validationError{message: "只有http服务支持摘除，tcp和udp服务请直接删除后端"}
+	}
+	if delay <= 0 {
+		delay = time.Duration(config.GetInt("TRAEFIK_DRAIN_DELAY", 60)) * time.Second
+	}
+
+	return ss.modify(protocol, name, "摘除后端"+server, operator, func(dao *traefikDAO.TraefikDAO, servers []interface{}) (serverUpdate, error) {
+		key := serverKey(protocol)
+		i := findServer(servers, key, server)
+		if i < 0 {
+			return nil, &validationError{message: "后端不存在: " + server}
+		}
+		drains, err := dao.GetDrains(protocolOf(protocol), name, "draining", 100)
+		if err != nil {
+			return nil, err
+		}
+		for _, drain := range drains {
+			if drain.Server == server {
+				return nil, nil
+			}
+		}
+
+		drain := traefikModel.TraefikServerDrain{
+			Protocol: protocolOf(protocol),
+			Service:  name,
+			Server:   server,
+			Weight:   serverWeight(servers[i]),
+			RemoveAt: time.Now().Add(delay),
+			Status:   "draining",
+			Operator: operator,
+		}
+		if err := dao.CreateDrain(&drain); err != nil {
+			return nil, err
+		}
+		return func(servers []interface{}) []interface{} {
+			if i := findServer(servers, key, server); i >= 0 {
+				servers[i].(map[string]interface{})["weight"] = 0
+			}
+			return servers
+		}, nil
+	})
+}
+
+// DrainResult 一次处理到期摘除任务的结果
+type DrainResult struct {
+	Removed   int `json:"removed"`   // 删除的后端数量
+	Abandoned int `json:"abandoned"` // 服务已被删除或不再是负载均衡服务而放弃的任务数量
+}
+
+// RemoveDrainedServers 删除摘除时间已到的后端
+// 单个任务失败时继续处理其他任务，返回所有失败任务的错误
+func (ss *TraefikServerService) RemoveDrainedServers() (DrainResult, error) {
+	var result DrainResult
+	drains, err := ss.traefikDAO.GetDueDrains(time.Now())
+	if err != nil {
+		return result, err
+	}
+
+	var errs []error
+	for _, drain := range drains {
+		_, err := ss.modify(drain.Protocol, drain.Service, "摘除到期，删除后端"+drain.Server, drain.Operator, func(dao *traefikDAO.TraefikDAO, servers []interface{}) (serverUpdate, error) {
+			if err := dao.FinishDrains(drain.Protocol, drain.Service, drain.Server, "removed"); err != nil {
+				return nil, err
+			}
+			return removeServer(serverKey(drain.Protocol), drain.Server), nil
+		})
+		if err == nil {
+			result.Removed++
+			continue
+		}
+		// 服务已被删除或不再是负载均衡服务时放弃任务，后端没有被删除
+		if errors.Is(err, gorm.ErrRecordNotFound) || IsValidationError(err) {
+			drain.Status = "abandoned"
+			drain.Reason = "服务不存在或不是负载均衡服务"
+			if IsValidationError(err) {
+				drain.Reason = err.Error()
+			}
+			if err = ss.traefikDAO.SaveDrain(&drain); err == nil {
+				result.Abandoned++
+				continue
+			}
+		}
+		errs = append(errs, fmt.Errorf("摘除%s %s: %w", drain.Service, drain.Server, err))
+	}
+	return result, errors.Join(errs...)
+}
+
+// modify 在一个事务中修改服务的后端
+// prepare根据草稿中的后端列表检查参数、记录摘除任务，返回要执行的修改，返回nil表示不需要修改
+func (ss *TraefikServerService) modify(protocol, name, remark, operator string, prepare func(dao *traefikDAO.TraefikDAO, servers []interface{}) (serverUpdate, error)) (BackendServers, error) {
+	var (
+		result    BackendServers
+		published bool
+	)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		dao := ss.traefikDAO.WithTx(tx)
+		service, err := loadBalancerService(dao, protocol, name)
+		if err != nil {
+			return err
+		}
+
+		update, err := prepare(dao, serverList(service.LoadBalancer))
+		if err != nil {
+			return err
+		}
+		if update != nil {
+			published, err = applyLiveServiceUpdate(dao, protocol, name, func(service *traefikModel.TraefikService) bool {
+				return applyServerUpdate(service, update)
+			}, remark, operator)
+			if err != nil {
+				return err
+			}
+		}
+
+		if service, err = loadBalancerService(dao, protocol, name); err != nil {
+			return err
+		}
+		result, err = backendServers(dao, service)
+		return err
+	})
+	if err != nil {
+		return BackendServers{}, err
+	}
+	if published {
+		notifyConfigChanged()
+	}
+	result.Published = published
+	return result, nil
+}
+
+// applyServerUpdate 修改服务的后端列表，返回是否有变化，不是负载均衡服务时不修改
+func applyServerUpdate(service *traefikModel.TraefikService, update serverUpdate) bool {
+	if service.Type != "loadbalancer" {
+		return false
+	}
+	// 比较JSON而不是直接比较，权重从数据库读出时为float64，修改后为int
+	before, _ := json.Marshal(serverList(service.LoadBalancer))
+	after := update(serverList(service.LoadBalancer))
+	if data, _ := json.Marshal(after); bytes.Equal(before, data) {
+		return false
+	}
+
+	loadBalancer := types.JSONMap{}
+	for key, value := range service.LoadBalancer {
+		loadBalancer[key] = value
+	}
+	loadBalancer["servers"] = after
+	service.LoadBalancer = loadBalancer
+	return true
+}
+
+// loadBalancerService 获取负载均衡服务
+func loadBalancerService(dao *traefikDAO.TraefikDAO, protocol, name string) (traefikModel.TraefikService, error) {
+	service, err := dao.GetService(name, protocolOf(protocol))
+	if err != nil {
+		return service, err
+	}
+	if service.Type != "loadbalancer" {
+		return service, &validationError{message: fmt.Sprintf("服务%s的类型为%s，只有负载均衡服务可以管理后端", name, service.Type)}
+	}
+	return service, nil
+}
+
+// backendServers 整理服务的后端列表，附带进行中的摘除任务
+func backendServers(dao *traefikDAO.TraefikDAO, service traefikModel.TraefikService) (BackendServers, error) {
+	drains, err := dao.GetDrains(protocolOf(service.Protocol), service.Name, "draining", 100)
+	if err != nil {
+		return BackendServers{}, err
+	}
+
+	result := BackendServers{Service: service.Name, Protocol: protocolOf(service.Protocol), Servers: make([]BackendServer, 0)}
+	key := serverKey(service.Protocol)
+	for _, item := range serverList(service.LoadBalancer) {
+		address, _ := item.(map[string]interface{})[key].(string)
+		server := BackendServer{Server: address, Weight: serverWeight(item)}
+		for i := range drains {
+			if drains[i].Server == address {
+				server.Drain = &drains[i]
+			}
+		}
+		result.Servers = append(result.Servers, server)
+	}
+	return result, nil
+}
+
+// serverList 复制服务的后端列表，修改副本不影响原配置
+func serverList(loadBalancer types.JSONMap) []interface{} {
+	items, _ := loadBalancer["servers"].([]interface{})
+	servers := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		server, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		copied := make(map[string]interface{}, len(server))
+		for key, value := range server {
+			copied[key] = value
+		}
+		servers = append(servers, copied)
+	}
+	return servers
+}
+
+// removeServer 从后端列表中删除指定后端
+func removeServer(key, server string) serverUpdate {
+	return func(servers []interface{}) []interface{} {
+		kept := make([]interface{}, 0, len(servers))
+		for _, item := range servers {
+			if address, _ := item.(map[string]interface{})[key].(string); address != server {
+				kept = append(kept, item)
+			}
+		}
+		return kept
+	}
+}
+
+// findServer 查找后端在列表中的位置，不存在时返回-1
+func findServer(servers []interface{}, key, server string) int {
+	for i, item := range servers {
+		if address, _ := item.(map[string]interface{})[key].(string); address == server {
+			return i
+		}
+	}
+	return -1
+}
+
+// serverWeight 读取后端权重，JSON中的数字解析后为float64
+func serverWeight(item interface{}) *int {
+	server, _ := item.(map[string]interface{})
+	switch weight := server["weight"].(type) {
+	case int:
+		return intPtr(weight)
+	case float64:
+		return intPtr(int(weight))
+	}
+	return nil
+}
+
+// serverKey 后端地址字段，http服务为url，tcp和udp服务为address
+func serverKey(protocol string) string {
+	if protocolOf(protocol) == "http" {
+		return "url"
+	}
+	return "address"
+}
+
+// normalizeServer 校验后端地址，http服务为带协议的URL，tcp和udp服务为host:port
+func normalizeServer(protocol, server string) (string, error) {
+	server = strings.TrimSpace(server)
+	if protocolOf(protocol) == "http" {
+		u, err := url.Parse(server)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "h2c") {
+			return "", &validationError{message: "后端地址应为http://、https://或h2c://开头的URL: " + server}
+		}
+		return server, nil
+	}
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		return "", &validationError{message: "后端地址应为host:port格式: " + server}
+	}
+	return server, nil
+}
+
+// checkWeight 只有http服务的后端支持权重
+func checkWeight(protocol string, weight *int) error {
+	if weight == nil {
+		return nil
+	}
+	if protocolOf(protocol) != "http" {
+		return &validationError{message: "只有http服务的后端支持权重"}
+	}
+	if *weight < 0 {
+		return &validationError{message: "权重不能小于0"}
+	}
+	return nil
+}
+
+// intPtr 返回整数的指针
+func intPtr(value int) *int {
+	return &value
+}
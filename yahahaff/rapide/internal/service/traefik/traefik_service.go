@@ -2,8 +2,17 @@ package traefik
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yahahaff/rapide/pkg/config"
 )
 
 // TraefikService 处理Traefik相关业务逻辑
@@ -13,252 +22,238 @@ type TraefikService struct{}
 type TraefikGroup struct {
 	TraefikService
 	TraefikHTTPProviderService
+	TraefikInstanceService
+	TraefikFileService
+	TraefikConfigService
+	TraefikKVService
+	TraefikDriftService
+	TraefikHealthService
+	TraefikPublishService
+	TraefikTemplateService
+	TraefikManifestService
+	TraefikSimulatorService
+	TraefikLintService
+	TraefikServerService
+	TraefikRolloutService
+	TraefikMaintenanceService
+	TraefikCertService
+	TraefikAuthService
+	TraefikTrafficService
+	TraefikMetricService
+	TraefikAdoptService
+	TraefikGitOpsService
+	TraefikAppService
+	TraefikOwnerService
+}
+
+// traefikAPIClient 访问Traefik API使用的HTTP客户端
+var traefikAPIClient = &http.Client{Timeout: 10 * time.Second}
+
+// runtimeObjectKinds 概览中统计的运行时对象，udp没有中间件
+var runtimeObjectKinds = []struct {
+	Protocol string
+	Kind     string
+}{
+	{"http", "routers"}, {"http", "services"}, {"http", "middlewares"},
+	{"tcp", "routers"}, {"tcp", "services"}, {"tcp", "middlewares"},
+	{"udp", "routers"}, {"udp", "services"},
 }
 
 // GetRoutes 获取Traefik路由信息
 func (ts *TraefikService) GetRoutes() ([]map[string]interface{}, error) {
-	// Traefik API地址
-	url := "http://172.16.0.60:8080/api/http/routers"
-
-	// 发送GET请求
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	// 检查响应状态码
-	if resp.StatusCode != http.StatusOK {
-		return nil, &httpError{statusCode: resp.StatusCode, message: resp.Status}
-	}
-
-	// 读取响应内容
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	// 解析JSON响应
-	var routes []map[string]interface{}
-	if err := json.Unmarshal(body, &routes); err != nil {
-		return nil, err
-	}
-
-	return routes, nil
+	return getTraefikList("/api/http/routers")
 }
 
 // GetMiddlewares 获取Traefik中间件信息
 func (ts *TraefikService) GetMiddlewares() ([]map[string]interface{}, error) {
-	// Traefik API地址
-	url := "http://172.16.0.60:8080/api/http/middlewares"
-
-	// 发送GET请求
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	// 检查响应状态码
-	if resp.StatusCode != http.StatusOK {
-		return nil, &httpError{statusCode: resp.StatusCode, message: resp.Status}
-	}
-
-	// 读取响应内容
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	// 解析JSON响应
-	var middlewares []map[string]interface{}
-	if err := json.Unmarshal(body, &middlewares); err != nil {
-		return nil, err
-	}
-
-	return middlewares, nil
+	return getTraefikList("/api/http/middlewares")
 }
 
 // GetServices 获取Traefik服务信息
 func (ts *TraefikService) GetServices() ([]map[string]interface{}, error) {
-	// Traefik API地址
-	url := "http://172.16.0.60:8080/api/http/services"
-
-	// 发送GET请求
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	// 检查响应状态码
-	if resp.StatusCode != http.StatusOK {
-		return nil, &httpError{statusCode: resp.StatusCode, message: resp.Status}
-	}
-
-	// 读取响应内容
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	// 解析JSON响应
-	var services []map[string]interface{}
-	if err := json.Unmarshal(body, &services); err != nil {
-		return nil, err
-	}
-
-	return services, nil
+	return getTraefikList("/api/http/services")
 }
 
 // GetOverview 获取Traefik概览信息
+// 以Traefik的/api/overview为基础，补充入口点、版本以及存在错误或警告的运行时对象，各接口并发请求
 func (ts *TraefikService) GetOverview() (map[string]interface{}, error) {
-	// 获取HTTP相关数据
-	httpRouters, err := ts.GetRoutes()
-	if err != nil {
-		return nil, err
+	var (
+		wg          sync.WaitGroup
+		mu          sync.Mutex
+		overview    map[string]interface{}
+		overviewErr error
+		entryPoints []map[string]interface{}
+		version     map[string]interface{}
+		objects     = make(map[string][]map[string]interface{})
+		partial     = make([]string, 0)
+	)
+
+	// fetch 并发请求一个接口，除/api/overview外的失败只记录不中断
+	fetch := func(path string, out interface{}) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := getTraefikJSON(path, out); err != nil {
+				mu.Lock()
+				partial = append(partial, path+": "+err.Error())
+				mu.Unlock()
+			}
+		}()
 	}
 
-	httpServices, err := ts.GetServices()
-	if err != nil {
-		return nil, err
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		overviewErr = getTraefikJSON("/api/overview", &overview)
+	}()
+	fetch("/api/entrypoints", &entryPoints)
+	fetch("/api/version", &version)
+	for _, item := range runtimeObjectKinds {
+		item := item
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			path := "/api/" + item.Protocol + "/" + item.Kind
+			list, err := getTraefikList(path)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				partial = append(partial, path+": "+err.Error())
+				return
+			}
+			objects[item.Protocol+"/"+item.Kind] = list
+		}()
 	}
+	wg.Wait()
 
-	httpMiddlewares, err := ts.GetMiddlewares()
-	if err != nil {
-		return nil, err
+	if overviewErr != nil {
+		return nil, overviewErr
 	}
 
-	// 构建概览数据
-	overview := map[string]interface{}{
-		"http": map[string]interface{}{
-			"routers": map[string]interface{}{
-				"total":    len(httpRouters),
-				"warnings": 0,
-				"errors":   0,
-			},
-			"services": map[string]interface{}{
-				"total":    len(httpServices),
-				"warnings": 0,
-				"errors":   0,
-			},
-			"middlewares": map[string]interface{}{
-				"total":    len(httpMiddlewares),
-				"warnings": 0,
-				"errors":   0,
-			},
-		},
-		"tcp": map[string]interface{}{
-			"routers": map[string]interface{}{
-				"total":    0,
-				"warnings": 0,
-				"errors":   0,
-			},
-			"services": map[string]interface{}{
-				"total":    0,
-				"warnings": 0,
-				"errors":   0,
-			},
-			"middlewares": map[string]interface{}{
-				"total":    0,
-				"warnings": 0,
-				"errors":   0,
-			},
-		},
-		"udp": map[string]interface{}{
-			"routers": map[string]interface{}{
-				"total":    0,
-				"warnings": 0,
-				"errors":   0,
-			},
-			"services": map[string]interface{}{
-				"total":    0,
-				"warnings": 0,
-				"errors":   0,
-			},
-		},
-		"features": map[string]interface{}{
-			"tracing":    "",
-			"metrics":    "",
-			"accessLog": false,
-		},
-		"providers": []string{"Docker"},
+	// 汇总存在错误或警告的对象
+	problems := make([]map[string]interface{}, 0)
+	for _, item := range runtimeObjectKinds {
+		for _, object := range objects[item.Protocol+"/"+item.Kind] {
+			if problem := runtimeProblem(item.Protocol, item.Kind, object); problem != nil {
+				problems = append(problems, problem)
+			}
+		}
 	}
 
+	overview["entryPoints"] = entryPoints
+	overview["version"] = version
+	overview["problems"] = problems
+	overview["partialErrors"] = partial
+
 	return overview, nil
 }
 
 // GetRouteDetail 获取Traefik HTTP路由详情
 func (ts *TraefikService) GetRouteDetail(routeName string) (map[string]interface{}, error) {
-	// Traefik API地址
-	url := "http://172.16.0.60:8080/api/http/routers/" + routeName
-
-	// 发送GET请求
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	// 检查响应状态码
-	if resp.StatusCode != http.StatusOK {
-		return nil, &httpError{statusCode: resp.StatusCode, message: resp.Status}
-	}
-
-	// 读取响应内容
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	// 解析JSON响应
 	var routeDetail map[string]interface{}
-	if err := json.Unmarshal(body, &routeDetail); err != nil {
+	if err := getTraefikJSON("/api/http/routers/"+url.PathEscape(routeName), &routeDetail); err != nil {
 		return nil, err
 	}
-
 	return routeDetail, nil
 }
 
 // GetServiceDetail 获取Traefik HTTP服务详情
 func (ts *TraefikService) GetServiceDetail(serviceName string) (map[string]interface{}, error) {
-	// Traefik API地址
-	url := "http://172.16.0.60:8080/api/http/services/" + serviceName
+	var serviceDetail map[string]interface{}
+	if err := getTraefikJSON("/api/http/services/"+url.PathEscape(serviceName), &serviceDetail); err != nil {
+		return nil, err
+	}
+	return serviceDetail, nil
+}
 
-	// 发送GET请求
-	resp, err := http.Get(url)
-	if err != nil {
+// GetMiddlewareDetail 获取Traefik HTTP中间件详情
+func (ts *TraefikService) GetMiddlewareDetail(middlewareName string) (map[string]interface{}, error) {
+	var middlewareDetail map[string]interface{}
+	if err := getTraefikJSON("/api/http/middlewares/"+url.PathEscape(middlewareName), &middlewareDetail); err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	return middlewareDetail, nil
+}
 
-	// 检查响应状态码
-	if resp.StatusCode != http.StatusOK {
-		return nil, &httpError{statusCode: resp.StatusCode, message: resp.Status}
+// runtimeProblem 根据运行时状态判断对象是否存在错误或警告，正常时返回nil
+// Traefik把出错的对象标记为disabled，部分可用的对象标记为warning，服务器宕机记录在serverStatus中
+func runtimeProblem(protocol, kind string, object map[string]interface{}) map[string]interface{} {
+	status, _ := object["status"].(string)
+	errs := make([]string, 0)
+	if items, ok := object["error"].([]interface{}); ok {
+		for _, item := range items {
+			if msg, ok := item.(string); ok {
+				errs = append(errs, msg)
+			}
+		}
 	}
 
-	// 读取响应内容
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	downServers := make([]string, 0)
+	if servers, ok := object["serverStatus"].(map[string]interface{}); ok {
+		for server, state := range servers {
+			if state != "UP" {
+				downServers = append(downServers, server)
+			}
+		}
+		sort.Strings(downServers)
 	}
 
-	// 解析JSON响应
-	var serviceDetail map[string]interface{}
-	if err := json.Unmarshal(body, &serviceDetail); err != nil {
-		return nil, err
+	level := ""
+	switch {
+	case status == "disabled":
+		level = "error"
+	case status == "warning" || len(errs) > 0 || len(downServers) > 0:
+		level = "warning"
+	default:
+		return nil
 	}
 
-	return serviceDetail, nil
+	return map[string]interface{}{
+		"protocol":    protocol,
+		"kind":        kind,
+		"name":        object["name"],
+		"provider":    object["provider"],
+		"status":      status,
+		"level":       level,
+		"errors":      errs,
+		"downServers": downServers,
+	}
 }
 
-// GetMiddlewareDetail 获取Traefik HTTP中间件详情
-func (ts *TraefikService) GetMiddlewareDetail(middlewareName string) (map[string]interface{}, error) {
-	// Traefik API地址
-	url := "http://172.16.0.60:8080/api/http/middlewares/" + middlewareName
+// traefikAPIURL 获取Traefik API地址
+func traefikAPIURL() string {
+	return strings.TrimRight(config.GetString("TRAEFIK_API_URL", "http://172.16.0.60:8080"), "/")
+}
+
+// getTraefikJSON 请求Traefik API并解析JSON响应
+func getTraefikJSON(path string, out interface{}) error {
+	_, err := requestTraefik(path, out)
+	return err
+}
+
+// getTraefikList 按X-Next-Page响应头逐页获取Traefik API的列表数据
+func getTraefikList(path string) ([]map[string]interface{}, error) {
+	var all []map[string]interface{}
+	for page := 1; ; {
+		var list []map[string]interface{}
+		header, err := requestTraefik(fmt.Sprintf("%s?page=%d&per_page=100", path, page), &list)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, list...)
+
+		next, err := strconv.Atoi(header.Get("X-Next-Page"))
+		if err != nil || next <= page {
+			return all, nil
+		}
+		page = next
+	}
+}
 
+// requestTraefik 发送GET请求到Traefik API并解析JSON响应，返回响应头
+func requestTraefik(path string, out interface{}) (http.Header, error) {
 	// 发送GET请求
-	resp, err := http.Get(url)
+	resp, err := traefikAPIClient.Get(traefikAPIURL() + path)
 	if err != nil {
 		return nil, err
 	}
@@ -276,12 +271,7 @@ func (ts *TraefikService) GetMiddlewareDetail(middlewareName string) (map[string
 	}
 
 	// 解析JSON响应
-	var middlewareDetail map[string]interface{}
-	if err := json.Unmarshal(body, &middlewareDetail); err != nil {
-		return nil, err
-	}
-
-	return middlewareDetail, nil
+	return resp.Header, json.Unmarshal(body, out)
 }
 
 // httpError 自定义HTTP错误类型
@@ -293,4 +283,4 @@ type httpError struct {
 // Error 实现error接口
 func (e *httpError) Error() string {
 	return e.message
-}
\ No newline at end of file
+}
@@ -0,0 +1,329 @@
+package traefik
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	traefikDAO "github.com/yahahaff/rapide/internal/dao/traefik"
+	traefikModel "github.com/yahahaff/rapide/internal/models/traefik"
+	"github.com/yahahaff/rapide/pkg/traefikrule"
+)
+
+// TraefikSimulatorService 按Traefik的规则语义模拟请求会命中哪个路由
+type TraefikSimulatorService struct {
+	traefikDAO *traefikDAO.TraefikDAO
+}
+
+// SimulateRequest 示例请求
+type SimulateRequest struct {
+	Protocol   string            // http或tcp，默认http
+	Method     string            // HTTP方法，默认GET
+	Host       string            // 请求域名，可带端口
+	Path       string            // 请求路径，可带?查询参数
+	Headers    map[string]string // 请求头
+	ClientIP   string            // 客户端IP
+	SNI        string            // TLS握手中的SNI，为空时HTTPS请求使用Host
+	ALPN       []string          // TLS握手中的ALPN协议
+	TLS        bool              // 是否为TLS连接
+	EntryPoint string            // 入口点，为空时不按入口点筛选
+	Source     string            // published或draft，默认published
+}
+
+// SimulateCandidate 参与匹配的路由，按Traefik的匹配顺序排列
+type SimulateCandidate struct {
+	Router      string   `json:"router"`
+	Rule        string   `json:"rule"`
+	RuleSyntax  string   `json:"ruleSyntax"`
+	Priority    int64    `json:"priority"`
+	EntryPoints []string `json:"entryPoints"`
+	TLS         bool     `json:"tls"`
+	Matched     bool     `json:"matched"`
+	Reason      string   `json:"reason,omitempty"` // 未匹配的原因
+}
+
+// SimulateMiddleware 命中路由依次经过的中间件，chain中间件展开为其引用的中间件
+type SimulateMiddleware struct {
+	Name    string `json:"name"`
+	Type    string `json:"type,omitempty"`
+	Chain   string `json:"chain,omitempty"`   // 所属的chain中间件
+	Missing bool   `json:"missing,omitempty"` // 配置中不存在，或属于其他Provider
+}
+
+// SimulateTarget 命中路由的目标服务，加权和镜像服务展开为子服务
+type SimulateTarget struct {
+	Name     string           `json:"name"`
+	Type     string           `json:"type,omitempty"`
+	Weight   interface{}      `json:"weight,omitempty"`
+	Servers  []string         `json:"servers,omitempty"`
+	Children []SimulateTarget `json:"children,omitempty"`
+	Missing  bool             `json:"missing,omitempty"`
+}
+
+// SimulateResult 模拟结果，没有路由匹配时Winner为空，Traefik会返回404
+type SimulateResult struct {
+	Source      string               `json:"source"`
+	Winner      *SimulateCandidate   `json:"winner"`
+	Candidates  []SimulateCandidate  `json:"candidates"`
+	Middlewares []SimulateMiddleware `json:"middlewares"`
+	Service     *SimulateTarget      `json:"service"`
+}
+
+// Simulate 对启用的路由逐个求值，按优先级从高到低排列，第一个匹配的路由即为命中的路由
+// 未设置priority的路由使用规则长度作为优先级，优先级相同时按名称排序
+func (ss *TraefikSimulatorService) Simulate(input SimulateRequest) (SimulateResult, error) {
+	protocol := protocolOf(input.Protocol)
+	if protocol != traefikrule.ProtocolHTTP && protocol != traefikrule.ProtocolTCP {
+		return SimulateResult{}, &validationError{message: "只支持模拟http和tcp路由"}
+	}
+	source := input.Source
+	if source == "" {
+		source = "published"
+	}
+
+	var (
+		set ConfigSet
+		err error
+	)
+	switch source {
+	case "published":
+		set, err = loadPublishedConfigSet(ss.traefikDAO)
+	case "draft":
+		set, err = loadEnabledConfigSet(ss.traefikDAO)
+	default:
+		return SimulateResult{}, &validationError{message: "source只能是published或draft"}
+	}
+	if err != nil {
+		return SimulateResult{}, err
+	}
+
+	req, err := simulateRequest(input, protocol)
+	if err != nil {
+		return SimulateResult{}, err
+	}
+
+	var routers []traefikModel.TraefikRouter
+	for _, router := range set.Routers {
+		if protocolOf(router.Protocol) == protocol {
+			routers = append(routers, router)
+		}
+	}
+	sortRoutersByPriority(routers)
+
+	result := SimulateResult{Source: source, Candidates: make([]SimulateCandidate, 0, len(routers)), Middlewares: make([]SimulateMiddleware, 0)}
+	var winner *traefikModel.TraefikRouter
+	for i, router := range routers {
+		candidate := SimulateCandidate{
+			Router:      router.Name,
+			Rule:        router.Rule,
+			RuleSyntax:  router.RuleSyntax,
+			Priority:    routerPriority(router),
+			EntryPoints: router.EntryPoints,
+			TLS:         router.TLS != nil,
+		}
+		candidate.Matched, candidate.Reason = matchRouter(router, req, input, protocol)
+		if candidate.Matched && winner != nil {
+			candidate.Reason = "被优先级更高的路由" + winner.Name + "覆盖"
+		}
+		if candidate.Matched && winner == nil {
+			winner = &routers[i]
+			matched := candidate
+			result.Winner = &matched
+		}
+		result.Candidates = append(result.Candidates, candidate)
+	}
+	if winner == nil {
+		return result, nil
+	}
+
+	result.Middlewares = expandMiddlewares(set, protocol, winner.Middlewares, "", map[string]bool{})
+	target := resolveTarget(set, protocol, winner.Service, map[string]bool{})
+	result.Service = &target
+	return result, nil
+}
+
+// simulateRequest 把示例请求转换为规则求值使用的请求
+func simulateRequest(input SimulateRequest, protocol string) (*traefikrule.Request, error) {
+	req := &traefikrule.Request{
+		Method:   strings.ToUpper(input.Method),
+		Host:     input.Host,
+		Path:     "/",
+		Query:    url.Values{},
+		Headers:  http.Header{},
+		ClientIP: input.ClientIP,
+		SNI:      input.SNI,
+		ALPN:     input.ALPN,
+	}
+	if req.Method == "" {
+		req.Method = http.MethodGet
+	}
+	for key, value := range input.Headers {
+		req.Headers.Add(key, value)
+	}
+	if req.Host == "" {
+		req.Host = req.Headers.Get("Host")
+	}
+	if req.SNI == "" && input.TLS && protocol == traefikrule.ProtocolHTTP {
+		req.SNI = req.Host
+	}
+
+	if input.Path != "" {
+		u, err := url.ParseRequestURI(input.Path)
+		if err != nil {
+			return nil, &validationError{message: "请求路径无效: " + err.Error()}
+		}
+		req.Path = u.Path
+		req.Query = u.Query()
+	}
+	return req, nil
+}
+
+// routerPriority 路由的实际优先级
+func routerPriority(router traefikModel.TraefikRouter) int64 {
+	if router.Priority != 0 {
+		return router.Priority
+	}
+	return traefikrule.DefaultPriority(router.Rule)
+}
+
+// sortRoutersByPriority 按Traefik的匹配顺序排列路由，优先级高的在前，相同时按名称排序
+func sortRoutersByPriority(routers []traefikModel.TraefikRouter) {
+	sort.SliceStable(routers, func(i, j int) bool {
+		pi, pj := routerPriority(routers[i]), routerPriority(routers[j])
+		if pi != pj {
+			return pi > pj
+		}
+		return routers[i].Name < routers[j].Name
+	})
+}
+
+// matchRouter 判断路由是否匹配示例请求，不匹配时返回原因
+// 设置了tls的路由只处理TLS连接，未设置的只处理非TLS连接
+func matchRouter(router traefikModel.TraefikRouter, req *traefikrule.Request, input SimulateRequest, protocol string) (bool, string) {
+	if input.EntryPoint != "" && len(router.EntryPoints) > 0 && !intersects(router.EntryPoints, []string{input.EntryPoint}) {
+		return false, "不监听入口点" + input.EntryPoint
+	}
+	if (router.TLS != nil) != input.TLS {
+		if input.TLS {
+			return false, "路由未启用TLS，不处理TLS连接"
+		}
+		return false, "路由启用了TLS，只处理TLS连接"
+	}
+
+	matcher, err := traefikrule.Parse(router.Rule, protocol, router.RuleSyntax)
+	if err != nil {
+		return false, "规则无效: " + err.Error()
+	}
+	if !matcher.Match(req) {
+		return false, "规则不匹配"
+	}
+	return true, ""
+}
+
+// expandMiddlewares 展开中间件引用，chain中间件按顺序展开为其引用的中间件
+func expandMiddlewares(set ConfigSet, protocol string, refs []string, chain string, visiting map[string]bool) []SimulateMiddleware {
+	result := make([]SimulateMiddleware, 0, len(refs))
+	for _, ref := range refs {
+		name, ok := localRefName(ref)
+		middleware, found := findMiddleware(set, protocol, name)
+		if !ok || !found {
+			result = append(result, SimulateMiddleware{Name: ref, Chain: chain, Missing: true})
+			continue
+		}
+
+		result = append(result, SimulateMiddleware{Name: name, Type: middleware.Type, Chain: chain})
+		if middleware.Type != "chain" || visiting[name] {
+			continue
+		}
+		visiting[name] = true
+		result = append(result, expandMiddlewares(set, protocol, chainMiddlewareRefs(middleware), name, visiting)...)
+		delete(visiting, name)
+	}
+	return result
+}
+
+// chainMiddlewareRefs 获取chain中间件中的原始引用，保留@provider后缀以便提示
+func chainMiddlewareRefs(middleware traefikModel.TraefikMiddleware) []string {
+	var refs []string
+	if items, ok := middleware.Config["middlewares"].([]interface{}); ok {
+		for _, item := range items {
+			if ref, ok := item.(string); ok {
+				refs = append(refs, ref)
+			}
+		}
+	}
+	return refs
+}
+
+// resolveTarget 解析服务引用，加权服务和镜像服务递归展开子服务
+func resolveTarget(set ConfigSet, protocol, ref string, visiting map[string]bool) SimulateTarget {
+	name, ok := localRefName(ref)
+	service, found := findService(set, protocol, name)
+	if !ok || !found {
+		return SimulateTarget{Name: ref, Missing: true}
+	}
+
+	target := SimulateTarget{Name: name, Type: service.Type}
+	if visiting[name] {
+		return target
+	}
+	visiting[name] = true
+	defer delete(visiting, name)
+
+	if servers, ok := service.LoadBalancer["servers"].([]interface{}); ok {
+		for _, item := range servers {
+			server, _ := item.(map[string]interface{})
+			for _, key := range []string{"url", "address"} {
+				if address, ok := server[key].(string); ok {
+					target.Servers = append(target.Servers, address)
+				}
+			}
+		}
+	}
+
+	addChild := func(item interface{}, label string) {
+		child, ok := item.(map[string]interface{})
+		if !ok {
+			return
+		}
+		childName, _ := child["name"].(string)
+		resolved := resolveTarget(set, protocol, childName, visiting)
+		resolved.Weight = child[label]
+		target.Children = append(target.Children, resolved)
+	}
+	if items, ok := service.Weighted["services"].([]interface{}); ok {
+		for _, item := range items {
+			addChild(item, "weight")
+		}
+	}
+	if main, ok := service.Mirror["service"].(string); ok {
+		target.Children = append(target.Children, resolveTarget(set, protocol, main, visiting))
+	}
+	if items, ok := service.Mirror["mirrors"].([]interface{}); ok {
+		for _, item := range items {
+			addChild(item, "percent")
+		}
+	}
+	return target
+}
+
+// findMiddleware 按协议和名称查找中间件
+func findMiddleware(set ConfigSet, protocol, name string) (traefikModel.TraefikMiddleware, bool) {
+	for _, middleware := range set.Middlewares {
+		if refKey(middleware.Protocol, middleware.Name) == refKey(protocol, name) {
+			return middleware, true
+		}
+	}
+	return traefikModel.TraefikMiddleware{}, false
+}
+
+// findService 按协议和名称查找服务
+func findService(set ConfigSet, protocol, name string) (traefikModel.TraefikService, bool) {
+	for _, service := range set.Services {
+		if refKey(service.Protocol, service.Name) == refKey(protocol, name) {
+			return service, true
+		}
+	}
+	return traefikModel.TraefikService{}, false
+}
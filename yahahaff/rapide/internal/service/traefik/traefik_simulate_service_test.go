@@ -0,0 +1,29 @@
+package traefik
+
+import (
+	"reflect"
+	"testing"
+
+	traefikModel "github.com/yahahaff/rapide/internal/models/traefik"
+)
+
+func TestSortRoutersByPriority(t *testing.T) {
+	routers := []traefikModel.TraefikRouter{
+		{Name: "low", Rule: "Host(`a.com`)", Priority: 1},
+		{Name: "b", Rule: "Host(`a.com`)"},
+		{Name: "long", Rule: "Host(`a.com`) && PathPrefix(`/api`)"},
+		{Name: "a", Rule: "Host(`b.com`)"},
+		{Name: "explicit", Rule: "Host(`a.com`)", Priority: 1000},
+	}
+	sortRoutersByPriority(routers)
+
+	// 显式优先级高于默认优先级；默认优先级为规则长度，长度相同时按名称排序
+	want := []string{"explicit", "long", "a", "b", "low"}
+	got := make([]string, 0, len(routers))
+	for _, router := range routers {
+		got = append(got, router.Name)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sortRoutersByPriority = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,130 @@
+package traefik
+
+import (
+	traefikModel "github.com/yahahaff/rapide/internal/models/traefik"
+)
+
+// builtinTemplates 内置应用模板，名称不能被自定义模板使用
+var builtinTemplates = []traefikModel.TraefikTemplate{
+	{
+		Name:        "https-web",
+		Title:       "HTTPS Web应用",
+		Description: "按域名转发到后端的HTTPS站点，可选HTTP自动跳转HTTPS",
+		Parameters: traefikModel.TemplateParams{
+			{Name: "host", Label: "域名", Type: "string", Required: true},
+			{Name: "backends", Label: "后端地址", Type: "list", Required: true, Description: "如http://10.0.0.1:8080"},
+			{Name: "entryPoint", Label: "HTTPS入口点", Type: "string", Default: "websecure"},
+			{Name: "certResolver", Label: "证书解析器", Type: "string", Description: "为空时使用默认证书"},
+			{Name: "redirectHTTP", Label: "HTTP跳转HTTPS", Type: "bool", Default: true},
+			{Name: "httpEntryPoint", Label: "HTTP入口点", Type: "string", Default: "web"},
+		},
+		Body: `http:
+  routers:
+    {{.name}}:
+      rule: {{quote (matcher "Host" .host)}}
+      entryPoints: [{{quote .entryPoint}}]
+      service: {{.name}}
+      tls: {{if .certResolver}}{certResolver: {{quote .certResolver}}}{{else}}{}{{end}}
+{{- if .redirectHTTP}}
+    {{.name}}-http:
+      rule: {{quote (matcher "Host" .host)}}
+      entryPoints: [{{quote .httpEntryPoint}}]
+      service: {{.name}}
+      middlewares: [{{.name}}-redirect]
+  middlewares:
+    {{.name}}-redirect:
+      redirectScheme:
+        scheme: https
+        permanent: true
+{{- end}}
+  services:
+    {{.name}}:
+      loadBalancer:
+        servers:
+{{- range .backends}}
+          - url: {{quote .}}
+{{- end}}
+`,
+	},
+	{
+		Name:        "api-ratelimit-cors",
+		Title:       "API服务（限流+CORS）",
+		Description: "按域名和路径前缀转发的HTTPS API，带限流和跨域配置，可选去掉路径前缀",
+		Parameters: traefikModel.TemplateParams{
+			{Name: "host", Label: "域名", Type: "string", Required: true},
+			{Name: "pathPrefix", Label: "路径前缀", Type: "string", Description: "如/api，为空时匹配整个域名"},
+			{Name: "backends", Label: "后端地址", Type: "list", Required: true, Description: "如http://10.0.0.1:8080"},
+			{Name: "entryPoint", Label: "HTTPS入口点", Type: "string", Default: "websecure"},
+			{Name: "certResolver", Label: "证书解析器", Type: "string", Description: "为空时使用默认证书"},
+			{Name: "rateAverage", Label: "平均每秒请求数", Type: "int", Default: 100},
+			{Name: "rateBurst", Label: "突发请求数", Type: "int", Default: 200},
+			{Name: "allowOrigins", Label: "允许的来源", Type: "list", Default: []string{"*"}},
+			{Name: "stripPrefix", Label: "转发前去掉路径前缀", Type: "bool", Default: false},
+		},
+		Body: `http:
+  routers:
+    {{.name}}:
+      rule: {{if .pathPrefix}}{{quote (printf "%s && %s" (matcher "Host" .host) (matcher "PathPrefix" .pathPrefix))}}{{else}}{{quote (matcher "Host" .host)}}{{end}}
+      entryPoints: [{{quote .entryPoint}}]
+      service: {{.name}}
+      middlewares: [{{.name}}-cors, {{.name}}-ratelimit{{if and .stripPrefix .pathPrefix}}, {{.name}}-strip{{end}}]
+      tls: {{if .certResolver}}{certResolver: {{quote .certResolver}}}{{else}}{}{{end}}
+  middlewares:
+    {{.name}}-cors:
+      headers:
+        accessControlAllowMethods: [GET, POST, PUT, PATCH, DELETE, OPTIONS]
+        accessControlAllowHeaders: ["*"]
+        accessControlAllowOriginList: {{json .allowOrigins}}
+        accessControlMaxAge: 600
+        addVaryHeader: true
+    {{.name}}-ratelimit:
+      rateLimit:
+        average: {{.rateAverage}}
+        burst: {{.rateBurst}}
+{{- if and .stripPrefix .pathPrefix}}
+    {{.name}}-strip:
+      stripPrefix:
+        prefixes: [{{quote .pathPrefix}}]
+{{- end}}
+  services:
+    {{.name}}:
+      loadBalancer:
+        servers:
+{{- range .backends}}
+          - url: {{quote .}}
+{{- end}}
+`,
+	},
+	{
+		Name:        "tcp-passthrough",
+		Title:       "TCP TLS透传",
+		Description: "按SNI把TLS连接原样转发到后端，由后端终止TLS",
+		Parameters: traefikModel.TemplateParams{
+			{Name: "host", Label: "SNI域名", Type: "string", Required: true},
+			{Name: "backends", Label: "后端地址", Type: "list", Required: true, Description: "如10.0.0.1:443"},
+			{Name: "entryPoint", Label: "入口点", Type: "string", Default: "websecure"},
+		},
+		Body: `tcp:
+  routers:
+    {{.name}}:
+      rule: {{quote (matcher "HostSNI" .host)}}
+      entryPoints: [{{quote .entryPoint}}]
+      service: {{.name}}
+      tls:
+        passthrough: true
+  services:
+    {{.name}}:
+      loadBalancer:
+        servers:
+{{- range .backends}}
+          - address: {{quote .}}
+{{- end}}
+`,
+	},
+}
+
+func init() {
+	for i := range builtinTemplates {
+		builtinTemplates[i].Builtin = true
+	}
+}
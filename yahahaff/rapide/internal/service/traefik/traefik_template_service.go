@@ -0,0 +1,427 @@
+package traefik
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	traefikDAO "github.com/yahahaff/rapide/internal/dao/traefik"
+	sysModel "github.com/yahahaff/rapide/internal/models/sys"
+	traefikModel "github.com/yahahaff/rapide/internal/models/traefik"
+	"github.com/yahahaff/rapide/internal/utils"
+	"github.com/yahahaff/rapide/pkg/database"
+	"github.com/yahahaff/rapide/pkg/types"
+	"gorm.io/gorm"
+)
+
+// TraefikTemplateService 应用模板管理，按模板一次创建一组相互引用的路由、服务和中间件
+type TraefikTemplateService struct {
+	traefikDAO *traefikDAO.TraefikDAO
+}
+
+// TemplateResult 应用模板的结果
+type TemplateResult struct {
+	DryRun   bool         `json:"dryRun"`
+	Template string       `json:"template"`
+	Name     string       `json:"name"`
+	Rendered string       `json:"rendered"` // 渲染后的动态配置YAML
+	Items    []ImportItem `json:"items"`
+}
+
+// appNamePattern 应用名称会作为对象名称的前缀
+var appNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]{0,63}$`)
+
+// templateParamTypes 支持的模板参数类型
+var templateParamTypes = map[string]bool{"string": true, "list": true, "bool": true, "int": true}
+
+// templateFuncs 模板中可用的函数
+var templateFuncs = template.FuncMap{
+	// quote 输出带引号的字符串，可安全用于YAML
+	"quote": func(value interface{}) string {
+		return marshalTemplateJSON(fmt.Sprint(value))
+	},
+	// json 以JSON输出值，列表和对象可直接作为YAML的流式写法
+	"json": marshalTemplateJSON,
+	// matcher 生成规则匹配器，如matcher "Host" "a.com" 输出Host(`a.com`)
+	"matcher": func(name string, args ...string) string {
+		quoted := make([]string, len(args))
+		for i, arg := range args {
+			quoted[i] = "`" + arg + "`"
+		}
+		return name + "(" + strings.Join(quoted, ", ") + ")"
+	},
+}
+
+// ListTemplates 获取所有模板，内置模板在前
+func (ts *TraefikTemplateService) ListTemplates() ([]traefikModel.TraefikTemplate, error) {
+	custom, err := ts.traefikDAO.GetTemplates()
+	if err != nil {
+		return nil, err
+	}
+	templates := make([]traefikModel.TraefikTemplate, 0, len(builtinTemplates)+len(custom))
+	templates = append(templates, builtinTemplates...)
+	return append(templates, custom...), nil
+}
+
+// GetTemplate 根据名称获取模板，不存在时返回gorm.ErrRecordNotFound
+func (ts *TraefikTemplateService) GetTemplate(name string) (traefikModel.TraefikTemplate, error) {
+	for _, builtin := range builtinTemplates {
+		if builtin.Name == name {
+			return builtin, nil
+		}
+	}
+	return ts.traefikDAO.GetTemplateByName(name)
+}
+
+// CreateTemplate 创建自定义模板
+func (ts *TraefikTemplateService) CreateTemplate(tmpl traefikModel.TraefikTemplate, operator string) (traefikModel.TraefikTemplate, error) {
+	if isBuiltinTemplate(tmpl.Name) {
+		return tmpl, &validationError{message: "不能使用内置模板的名称: " + tmpl.Name}
+	}
+	if _, err := ts.traefikDAO.GetTemplateByName(tmpl.Name); err == nil {
+		return tmpl, &validationError{message: "模板已存在: " + tmpl.Name}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return tmpl, err
+	}
+	if err := validateTemplate(tmpl); err != nil {
+		return tmpl, err
+	}
+
+	tmpl.Operator = operator
+	return tmpl, ts.traefikDAO.CreateTemplate(&tmpl)
+}
+
+// UpdateTemplate 更新自定义模板，内置模板不能修改
+func (ts *TraefikTemplateService) UpdateTemplate(name string, tmpl traefikModel.TraefikTemplate, operator string) (traefikModel.TraefikTemplate, error) {
+	if isBuiltinTemplate(name) {
+		return tmpl, &validationError{message: "内置模板不能修改"}
+	}
+	existing, err := ts.traefikDAO.GetTemplateByName(name)
+	if err != nil {
+		return tmpl, err
+	}
+	tmpl.Name = name
+	if err := validateTemplate(tmpl); err != nil {
+		return tmpl, err
+	}
+
+	existing.Title = tmpl.Title
+	existing.Description = tmpl.Description
+	existing.Parameters = tmpl.Parameters
+	existing.Body = tmpl.Body
+	existing.Operator = operator
+	return existing, ts.traefikDAO.UpdateTemplate(&existing)
+}
+
+// DeleteTemplate 删除自定义模板，已生成的对象不受影响
+func (ts *TraefikTemplateService) DeleteTemplate(name string) error {
+	if isBuiltinTemplate(name) {
+		return &validationError{message: "内置模板不能删除"}
+	}
+	if _, err := ts.traefikDAO.GetTemplateByName(name); err != nil {
+		return err
+	}
+	return ts.traefikDAO.DeleteTemplate(name)
+}
+
+// ApplyTemplate 按模板和参数在一个事务中创建全部对象，写入的是草稿，需要发布后才会生效
+// 任何一个对象已存在时不创建任何对象；dryRun只渲染并返回将要创建的对象
+// 生成的路由、服务和中间件归属于deptID，其他部门不能管理；TLS等共用对象只有超级管理员可以通过模板创建
+func (ts *TraefikTemplateService) ApplyTemplate(templateName, appName string, params map[string]interface{}, tags []string, deptID uint64, scope sysModel.DeptScope, dryRun bool, operator string) (TemplateResult, error) {
+	result := TemplateResult{DryRun: dryRun, Template: templateName, Name: appName, Items: make([]ImportItem, 0)}
+	owner, err := newObjectOwner(deptID, scope)
+	if err != nil {
+		return result, err
+	}
+
+	tmpl, err := ts.GetTemplate(templateName)
+	if err != nil {
+		return result, err
+	}
+	rendered, set, err := renderTemplate(tmpl, appName, params)
+	if err != nil {
+		return result, err
+	}
+	result.Rendered = rendered
+
+	remark := "应用模板" + templateName
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		dao := ts.traefikDAO.WithTx(tx)
+
+		objects := make([]indexedObject, 0)
+		for _, router := range set.Routers {
+			router.Status, router.Tags = "enabled", types.JSONSlice(tags)
+			if err := checkReferences(dao, router, scope); err != nil {
+				return err
+			}
+			if err := checkRouterHosts(dao, router, scope); err != nil {
+				return err
+			}
+			objects = append(objects, indexedObject{kindRouter, router.Name, router.Protocol, objectSnapshot(router)})
+		}
+		for _, service := range set.Services {
+			service.Status, service.Tags = "enabled", types.JSONSlice(tags)
+			if err := checkReferences(dao, service, scope); err != nil {
+				return err
+			}
+			objects = append(objects, indexedObject{kindService, service.Name, service.Protocol, objectSnapshot(service)})
+		}
+		for _, middleware := range set.Middlewares {
+			middleware.Status, middleware.Tags = "enabled", types.JSONSlice(tags)
+			if err := checkReferences(dao, middleware, scope); err != nil {
+				return err
+			}
+			objects = append(objects, indexedObject{kindMiddleware, middleware.Name, middleware.Protocol, objectSnapshot(middleware)})
+		}
+		for _, option := range set.TLSOptions {
+			option.Status, option.Tags = "enabled", types.JSONSlice(tags)
+			objects = append(objects, indexedObject{kindTLSOption, option.Name, protocolTLS, objectSnapshot(option)})
+		}
+		for _, store := range set.TLSStores {
+			store.Status, store.Tags = "enabled", types.JSONSlice(tags)
+			objects = append(objects, indexedObject{kindTLSStore, store.Name, protocolTLS, objectSnapshot(store)})
+		}
+		for _, transport := range set.ServersTransports {
+			transport.Status, transport.Tags = "enabled", types.JSONSlice(tags)
+			objects = append(objects, indexedObject{kindServersTransport, transport.Name, transport.Protocol, objectSnapshot(transport)})
+		}
+
+		// 先检查全部对象，避免只创建了一部分
+		var conflicts []string
+		for _, object := range objects {
+			if !scope.All && !ownedKind(object.kind) {
+				return &deptError{message: fmt.Sprintf("只有超级管理员可以创建%s %s", object.kind, object.name)}
+			}
+			existing, err := loadObject(dao, object.kind, object.name, object.protocol)
+			if err != nil {
+				return err
+			}
+			if existing != nil {
+				conflicts = append(conflicts, fmt.Sprintf("%s %s@%s", object.kind, object.name, protocolOf(object.protocol)))
+			}
+			result.Items = append(result.Items, ImportItem{
+				Kind:     object.kind,
+				Name:     object.name,
+				Protocol: protocolOf(object.protocol),
+				Action:   "create",
+				Changes:  utils.DiffJSON(nil, object.snapshot),
+			})
+		}
+		if len(conflicts) > 0 {
+			return &validationError{message: "以下对象已存在: " + strings.Join(conflicts, ", ")}
+		}
+		if dryRun {
+			return nil
+		}
+
+		for _, object := range objects {
+			if _, err := applyObject(dao, object.kind, object.name, object.protocol, object.snapshot, "create", operator, remark); err != nil {
+				return err
+			}
+			if err := saveObjectOwner(dao, object.kind, object.protocol, object.name, owner, operator); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return result, err
+}
+
+// renderTemplate 用参数渲染模板并解析出配置对象
+func renderTemplate(tmpl traefikModel.TraefikTemplate, appName string, params map[string]interface{}) (string, ConfigSet, error) {
+	if !appNamePattern.MatchString(appName) {
+		return "", ConfigSet{}, &validationError{message: "应用名称只能包含字母、数字、-和_，且不能以-或_开头"}
+	}
+
+	data, err := templateData(tmpl.Parameters, params)
+	if err != nil {
+		return "", ConfigSet{}, err
+	}
+	data["name"] = appName
+
+	parsed, err := template.New(tmpl.Name).Funcs(templateFuncs).Option("missingkey=error").Parse(tmpl.Body)
+	if err != nil {
+		return "", ConfigSet{}, &validationError{message: "模板语法错误: " + err.Error()}
+	}
+	var buf bytes.Buffer
+	if err := parsed.Execute(&buf, data); err != nil {
+		return "", ConfigSet{}, &validationError{message: "模板渲染失败: " + err.Error()}
+	}
+
+	set, _, err := parseDynamicConfig(buf.Bytes(), "yaml")
+	if err != nil {
+		return buf.String(), ConfigSet{}, &validationError{message: "模板生成的配置无效: " + err.Error()}
+	}
+	if len(set.Routers)+len(set.Services)+len(set.Middlewares) == 0 {
+		return buf.String(), ConfigSet{}, &validationError{message: "模板没有生成任何对象"}
+	}
+	return buf.String(), set, nil
+}
+
+// templateData 按参数定义校验并转换参数值，未提供的参数使用默认值
+func templateData(definitions traefikModel.TemplateParams, params map[string]interface{}) (map[string]interface{}, error) {
+	defined := make(map[string]bool, len(definitions))
+	data := make(map[string]interface{}, len(definitions)+1)
+	for _, definition := range definitions {
+		defined[definition.Name] = true
+
+		value, ok := params[definition.Name]
+		if !ok || value == nil || value == "" {
+			value = definition.Default
+		}
+		converted, err := convertParam(definition, value)
+		if err != nil {
+			return nil, err
+		}
+		if definition.Required && isEmptyParam(converted) {
+			return nil, &validationError{message: "缺少参数: " + definition.Name}
+		}
+		data[definition.Name] = converted
+	}
+
+	for name := range params {
+		if !defined[name] {
+			return nil, &validationError{message: "未知参数: " + name}
+		}
+	}
+	return data, nil
+}
+
+// convertParam 把参数值转换为定义的类型，list可以是数组或逗号分隔的字符串
+func convertParam(definition traefikModel.TemplateParam, value interface{}) (interface{}, error) {
+	invalid := &validationError{message: fmt.Sprintf("参数%s的值无效，应为%s", definition.Name, definition.Type)}
+	switch definition.Type {
+	case "list":
+		items := make([]string, 0)
+		switch v := value.(type) {
+		case nil:
+		case string:
+			for _, item := range strings.Split(v, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+		case []string:
+			items = append(items, v...)
+		case []interface{}:
+			for _, item := range v {
+				items = append(items, fmt.Sprint(item))
+			}
+		default:
+			return nil, invalid
+		}
+		return items, nil
+	case "bool":
+		switch v := value.(type) {
+		case nil:
+			return false, nil
+		case bool:
+			return v, nil
+		case string:
+			parsed, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, invalid
+			}
+			return parsed, nil
+		default:
+			return nil, invalid
+		}
+	case "int":
+		switch v := value.(type) {
+		case nil:
+			return int64(0), nil
+		case int:
+			return int64(v), nil
+		case int64:
+			return v, nil
+		case float64:
+			if v != float64(int64(v)) {
+				return nil, invalid
+			}
+			return int64(v), nil
+		case string:
+			parsed, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return nil, invalid
+			}
+			return parsed, nil
+		default:
+			return nil, invalid
+		}
+	default:
+		if value == nil {
+			return "", nil
+		}
+		return fmt.Sprint(value), nil
+	}
+}
+
+// isEmptyParam 判断转换后的参数是否为空
+func isEmptyParam(value interface{}) bool {
+	switch v := value.(type) {
+	case string:
+		return v == ""
+	case []string:
+		return len(v) == 0
+	default:
+		return false
+	}
+}
+
+// validateTemplate 校验模板的参数定义，并用示例参数试渲染一次
+func validateTemplate(tmpl traefikModel.TraefikTemplate) error {
+	if !appNamePattern.MatchString(tmpl.Name) {
+		return &validationError{message: "模板名称只能包含字母、数字、-和_"}
+	}
+
+	seen := make(map[string]bool)
+	sample := make(map[string]interface{})
+	for _, param := range tmpl.Parameters {
+		if param.Name == "" || param.Name == "name" || seen[param.Name] {
+			return &validationError{message: "参数名称为空、重复或使用了保留的name: " + param.Name}
+		}
+		if !templateParamTypes[param.Type] {
+			return &validationError{message: fmt.Sprintf("参数%s的类型不支持: %s", param.Name, param.Type)}
+		}
+		if _, err := convertParam(param, param.Default); err != nil {
+			return err
+		}
+		seen[param.Name] = true
+
+		switch param.Type {
+		case "string":
+			sample[param.Name] = "example.com"
+		case "list":
+			sample[param.Name] = []string{"http://127.0.0.1:80"}
+		}
+	}
+
+	_, _, err := renderTemplate(tmpl, "example", sample)
+	return err
+}
+
+// marshalTemplateJSON 以JSON输出值，不转义&、<、>，使渲染结果便于阅读
+func marshalTemplateJSON(value interface{}) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return "null"
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// isBuiltinTemplate 判断是否为内置模板名称
+func isBuiltinTemplate(name string) bool {
+	for _, builtin := range builtinTemplates {
+		if builtin.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,294 @@
+package traefik
+
+import (
+	"crypto/tls"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// tlsVersions TLS选项中可用的协议版本，值越大版本越高
+var tlsVersions = map[string]int{"VersionTLS10": 10, "VersionTLS11": 11, "VersionTLS12": 12, "VersionTLS13": 13}
+
+// tlsCurves TLS选项中可用的椭圆曲线
+var tlsCurves = map[string]bool{"CurveP256": true, "CurveP384": true, "CurveP521": true, "X25519": true, "X25519MLKEM768": true}
+
+// clientAuthTypes 客户端证书认证方式，需要校验客户端证书的方式必须指定caFiles
+var clientAuthTypes = map[string]bool{
+	"NoClientCert":               false,
+	"RequestClientCert":          false,
+	"RequireAnyClientCert":       false,
+	"VerifyClientCertIfGiven":    true,
+	"RequireAndVerifyClientCert": true,
+}
+
+// 各类配置对象允许的配置项
+var (
+	tlsOptionKeys = []string{"minVersion", "maxVersion", "cipherSuites", "curvePreferences", "clientAuth", "sniStrict", "alpnProtocols", "preferServerCipherSuites", "disableSessionTickets"}
+	tlsStoreKeys  = []string{"defaultCertificate", "defaultGeneratedCert"}
+
+	httpTransportKeys = []string{"serverName", "insecureSkipVerify", "rootCAs", "certificates", "maxIdleConnsPerHost", "forwardingTimeouts", "disableHTTP2", "peerCertURI", "spiffe"}
+	tcpTransportKeys  = []string{"dialTimeout", "dialKeepAlive", "terminationDelay", "proxyProtocol", "tls", "spiffe"}
+	tcpTransportTLS   = []string{"serverName", "insecureSkipVerify", "rootCAs", "certificates", "peerCertURI", "spiffe"}
+	forwardingKeys    = []string{"dialTimeout", "responseHeaderTimeout", "idleConnTimeout", "readIdleTimeout", "pingTimeout"}
+)
+
+// validateTLSOption 校验TLS选项：协议版本、加密套件、曲线名称以及客户端证书认证(mTLS)配置
+func validateTLSOption(config map[string]interface{}) error {
+	if err := checkKeys("TLS选项", config, tlsOptionKeys); err != nil {
+		return err
+	}
+
+	versions := make(map[string]int)
+	for _, key := range []string{"minVersion", "maxVersion"} {
+		value, ok := config[key]
+		if !ok {
+			continue
+		}
+		version, _ := value.(string)
+		if tlsVersions[version] == 0 {
+			return &validationError{message: fmt.Sprintf("%s无效: %v，可选值为VersionTLS10到VersionTLS13", key, value)}
+		}
+		versions[key] = tlsVersions[version]
+	}
+	if versions["minVersion"] > 0 && versions["maxVersion"] > 0 && versions["minVersion"] > versions["maxVersion"] {
+		return &validationError{message: "minVersion不能高于maxVersion"}
+	}
+
+	suites, err := stringList(config, "cipherSuites")
+	if err != nil {
+		return err
+	}
+	known := make(map[string]bool)
+	for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		known[suite.Name] = true
+	}
+	for _, suite := range suites {
+		if !known[suite] {
+			return &validationError{message: "未知的加密套件: " + suite}
+		}
+	}
+
+	curves, err := stringList(config, "curvePreferences")
+	if err != nil {
+		return err
+	}
+	for _, curve := range curves {
+		if !tlsCurves[curve] {
+			return &validationError{message: "未知的椭圆曲线: " + curve}
+		}
+	}
+	if _, err := stringList(config, "alpnProtocols"); err != nil {
+		return err
+	}
+	for _, key := range []string{"sniStrict", "preferServerCipherSuites", "disableSessionTickets"} {
+		if err := checkBool(config, key); err != nil {
+			return err
+		}
+	}
+
+	if value, ok := config["clientAuth"]; ok {
+		clientAuth, ok := value.(map[string]interface{})
+		if !ok {
+			return &validationError{message: "clientAuth必须是对象"}
+		}
+		if err := checkKeys("clientAuth", clientAuth, []string{"caFiles", "clientAuthType"}); err != nil {
+			return err
+		}
+		caFiles, err := stringList(clientAuth, "caFiles")
+		if err != nil {
+			return err
+		}
+		authType, _ := clientAuth["clientAuthType"].(string)
+		if authType == "" {
+			authType = "NoClientCert"
+		}
+		verify, ok := clientAuthTypes[authType]
+		if !ok {
+			return &validationError{message: "clientAuthType无效: " + authType}
+		}
+		if verify && len(caFiles) == 0 {
+			return &validationError{message: authType + "需要在caFiles中指定签发客户端证书的CA"}
+		}
+	}
+	return nil
+}
+
+// validateTLSStore 校验TLS证书存储，Traefik目前只使用名为default的存储
+func validateTLSStore(config map[string]interface{}) error {
+	if err := checkKeys("TLS证书存储", config, tlsStoreKeys); err != nil {
+		return err
+	}
+	if value, ok := config["defaultCertificate"]; ok {
+		if err := checkCertificate("defaultCertificate", value); err != nil {
+			return err
+		}
+	}
+	if value, ok := config["defaultGeneratedCert"]; ok {
+		generated, ok := value.(map[string]interface{})
+		if !ok {
+			return &validationError{message: "defaultGeneratedCert必须是对象"}
+		}
+		if resolver, _ := generated["resolver"].(string); resolver == "" {
+			return &validationError{message: "defaultGeneratedCert需要指定resolver"}
+		}
+	}
+	return nil
+}
+
+// validateServersTransport 校验serversTransport，http和tcp支持的配置项不同
+func validateServersTransport(protocol string, config map[string]interface{}) error {
+	switch protocolOf(protocol) {
+	case "http":
+		if err := checkKeys("serversTransport", config, httpTransportKeys); err != nil {
+			return err
+		}
+		if err := checkTransportTLS(config); err != nil {
+			return err
+		}
+		if value, ok := config["forwardingTimeouts"]; ok {
+			timeouts, ok := value.(map[string]interface{})
+			if !ok {
+				return &validationError{message: "forwardingTimeouts必须是对象"}
+			}
+			if err := checkKeys("forwardingTimeouts", timeouts, forwardingKeys); err != nil {
+				return err
+			}
+			for _, key := range forwardingKeys {
+				if err := checkDuration(timeouts, key); err != nil {
+					return err
+				}
+			}
+		}
+		return checkBool(config, "disableHTTP2")
+	case "tcp":
+		if err := checkKeys("serversTransport", config, tcpTransportKeys); err != nil {
+			return err
+		}
+		for _, key := range []string{"dialTimeout", "dialKeepAlive", "terminationDelay"} {
+			if err := checkDuration(config, key); err != nil {
+				return err
+			}
+		}
+		if value, ok := config["tls"]; ok {
+			tlsConfig, ok := value.(map[string]interface{})
+			if !ok {
+				return &validationError{message: "tls必须是对象"}
+			}
+			if err := checkKeys("tls", tlsConfig, tcpTransportTLS); err != nil {
+				return err
+			}
+			return checkTransportTLS(tlsConfig)
+		}
+		return nil
+	default:
+		return &validationError{message: "serversTransport只支持http和tcp"}
+	}
+}
+
+// checkTransportTLS 校验连接后端时的TLS设置：根证书和客户端证书
+func checkTransportTLS(config map[string]interface{}) error {
+	if err := checkBool(config, "insecureSkipVerify"); err != nil {
+		return err
+	}
+	if _, err := stringList(config, "rootCAs"); err != nil {
+		return err
+	}
+	if value, ok := config["certificates"]; ok {
+		certificates, ok := value.([]interface{})
+		if !ok {
+			return &validationError{message: "certificates必须是数组"}
+		}
+		for i, certificate := range certificates {
+			if err := checkCertificate(fmt.Sprintf("certificates[%d]", i), certificate); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkCertificate 校验证书配置必须同时包含certFile和keyFile
+func checkCertificate(name string, value interface{}) error {
+	certificate, ok := value.(map[string]interface{})
+	if !ok {
+		return &validationError{message: name + "必须是对象"}
+	}
+	certFile, _ := certificate["certFile"].(string)
+	keyFile, _ := certificate["keyFile"].(string)
+	if certFile == "" || keyFile == "" {
+		return &validationError{message: name + "需要同时指定certFile和keyFile"}
+	}
+	return nil
+}
+
+// checkKeys 检查配置中是否有不支持的配置项，避免拼写错误被Traefik静默忽略
+func checkKeys(name string, config map[string]interface{}, allowed []string) error {
+	var unknown []string
+	for key := range config {
+		found := false
+		for _, candidate := range allowed {
+			if key == candidate {
+				found = true
+				break
+			}
+		}
+		if !found {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Strings(unknown)
+	return &validationError{message: fmt.Sprintf("%s不支持的配置项: %s，支持的配置项为%s", name, strings.Join(unknown, ", "), strings.Join(allowed, ", "))}
+}
+
+// stringList 读取字符串数组配置项，不存在时返回nil
+func stringList(config map[string]interface{}, key string) ([]string, error) {
+	value, ok := config[key]
+	if !ok {
+		return nil, nil
+	}
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, &validationError{message: key + "必须是字符串数组"}
+	}
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		text, ok := item.(string)
+		if !ok || text == "" {
+			return nil, &validationError{message: key + "必须是字符串数组"}
+		}
+		result = append(result, text)
+	}
+	return result, nil
+}
+
+// checkBool 检查配置项是布尔值
+func checkBool(config map[string]interface{}, key string) error {
+	if value, ok := config[key]; ok {
+		if _, ok := value.(bool); !ok {
+			return &validationError{message: key + "必须是布尔值"}
+		}
+	}
+	return nil
+}
+
+// checkDuration 检查配置项是时长，可以是10s这样的字符串或表示秒数的数字
+func checkDuration(config map[string]interface{}, key string) error {
+	value, ok := config[key]
+	if !ok {
+		return nil
+	}
+	switch v := value.(type) {
+	case float64, int64:
+		return nil
+	case string:
+		if _, err := time.ParseDuration(v); err == nil {
+			return nil
+		}
+	}
+	return &validationError{message: fmt.Sprintf("%s不是有效的时长: %v", key, value)}
+}
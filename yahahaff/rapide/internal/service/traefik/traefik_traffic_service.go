@@ -0,0 +1,444 @@
+package traefik
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	traefikDAO "github.com/yahahaff/rapide/internal/dao/traefik"
+	sysModel "github.com/yahahaff/rapide/internal/models/sys"
+	traefikModel "github.com/yahahaff/rapide/internal/models/traefik"
+	"github.com/yahahaff/rapide/pkg/config"
+	"github.com/yahahaff/rapide/pkg/database"
+	"github.com/yahahaff/rapide/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// TraefikTrafficService 读取Traefik的JSON访问日志，按路由和服务分时间段统计请求数、状态码、耗时、客户端IP和路径
+type TraefikTrafficService struct {
+	traefikDAO *traefikDAO.TraefikDAO
+}
+
+// accessLogMaxRead 每次最多读取的日志字节数，剩余部分下次继续读取
+const accessLogMaxRead = 32 << 20
+
+// accessLogEntry Traefik JSON访问日志中用到的字段
+type accessLogEntry struct {
+	RouterName       string    `json:"RouterName"`
+	ServiceName      string    `json:"ServiceName"`
+	DownstreamStatus int       `json:"DownstreamStatus"`
+	Duration         int64     `json:"Duration"` // 纳秒
+	ClientHost       string    `json:"ClientHost"`
+	RequestPath      string    `json:"RequestPath"`
+	StartUTC         time.Time `json:"StartUTC"`
+}
+
+// TrafficStats 一段时间内的访问统计，耗时单位为毫秒
+type TrafficStats struct {
+	Name         string           `json:"name,omitempty"`
+	Start        *time.Time       `json:"start,omitempty"`
+	Requests     int64            `json:"requests"`
+	Status       map[string]int64 `json:"status"`
+	ErrorRate    float64          `json:"errorRate"` // 5xx请求的比例
+	P50          float64          `json:"p50"`
+	P95          float64          `json:"p95"`
+	P99          float64          `json:"p99"`
+	TopClientIPs []TrafficTop     `json:"topClientIps,omitempty"`
+	TopPaths     []TrafficTop     `json:"topPaths,omitempty"`
+}
+
+// TrafficTop 请求数排名中的一项
+type TrafficTop struct {
+	Value    string `json:"value"`
+	Requests int64  `json:"requests"`
+}
+
+// TrafficDetail 单个路由或服务的访问统计，包括整个时间范围的汇总和每个时间段的数据
+type TrafficDetail struct {
+	Summary TrafficStats   `json:"summary"`
+	Series  []TrafficStats `json:"series"`
+}
+
+// IngestAccessLogs 读取所有配置了访问日志路径的实例新增的日志并累加到统计中，然后清理超过保留期的统计
+func (ts *TraefikTrafficService) IngestAccessLogs() error {
+	instances, err := ts.traefikDAO.GetAllInstances()
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for _, instance := range instances {
+		if instance.AccessLogPath == "" || instance.Status == "disabled" {
+			continue
+		}
+		if err := ts.ingestInstance(instance); err != nil {
+			logger.ErrorString("traefik", "accesslog", instance.Name+": "+err.Error())
+			lastErr = err
+		}
+	}
+
+	if _, err := ts.traefikDAO.DeleteTrafficBucketsBefore(trafficRetentionStart(time.Now()).UTC()); err != nil {
+		return err
+	}
+	return lastErr
+}
+
+// GetAccessLogCursors 获取各实例访问日志的读取进度
+func (ts *TraefikTrafficService) GetAccessLogCursors() ([]traefikModel.TraefikAccessLogCursor, error) {
+	return ts.traefikDAO.GetAccessLogCursors()
+}
+
+// GetTrafficOverview 获取时间范围内范围内部门的每个路由或服务的访问汇总，按请求数倒序
+func (ts *TraefikTrafficService) GetTrafficOverview(kind, instance string, from, to time.Time, scope sysModel.DeptScope) ([]TrafficStats, error) {
+	buckets, err := ts.traefikDAO.GetTrafficBuckets(kind, "", instance, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, err
+	}
+	owned, err := ownedRuntimeNames(ts.traefikDAO, kind, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	grouped := make(map[string][]traefikModel.TraefikTrafficBucket)
+	for _, bucket := range buckets {
+		if owned != nil && !owned[bucket.Name] {
+			continue
+		}
+		grouped[bucket.Name] = append(grouped[bucket.Name], bucket)
+	}
+	result := make([]TrafficStats, 0, len(grouped))
+	for name, items := range grouped {
+		stats := summarizeTraffic(items, 0)
+		stats.Name = name
+		result = append(result, stats)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Requests != result[j].Requests {
+			return result[i].Requests > result[j].Requests
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+// GetTraffic 获取单个路由或服务在时间范围内的访问统计，多个实例的数据合并计算，对象需要属于范围内的部门
+func (ts *TraefikTrafficService) GetTraffic(kind, name, instance string, from, to time.Time, scope sysModel.DeptScope) (TrafficDetail, error) {
+	name, err := runtimeName(ts.traefikDAO, kind, name, scope)
+	if err != nil {
+		return TrafficDetail{}, err
+	}
+	buckets, err := ts.traefikDAO.GetTrafficBuckets(kind, name, instance, from.UTC(), to.UTC())
+	if err != nil {
+		return TrafficDetail{}, err
+	}
+
+	detail := TrafficDetail{Summary: summarizeTraffic(buckets, config.GetInt("TRAEFIK_ACCESSLOG_TOP", 20)), Series: make([]TrafficStats, 0)}
+	detail.Summary.Name = name
+	for i := 0; i < len(buckets); {
+		j := i
+		for j < len(buckets) && buckets[j].Start.Equal(buckets[i].Start) {
+			j++
+		}
+		stats := summarizeTraffic(buckets[i:j], 0)
+		start := buckets[i].Start
+		stats.Start = &start
+		detail.Series = append(detail.Series, stats)
+		i = j
+	}
+	return detail, nil
+}
+
+// ingestInstance 从上次的位置继续读取实例的访问日志，统计和读取进度在同一事务中保存
+// 只处理以换行结尾的完整行，正在写入的最后一行留到下次读取
+func (ts *TraefikTrafficService) ingestInstance(instance traefikModel.TraefikInstance) error {
+	cursor, err := ts.traefikDAO.GetAccessLogCursor(instance.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		cursor = traefikModel.TraefikAccessLogCursor{InstanceID: instance.ID}
+	} else if err != nil {
+		return err
+	}
+	if cursor.Path != instance.AccessLogPath {
+		cursor.Path = instance.AccessLogPath
+		cursor.Offset = 0
+	}
+	now := time.Now()
+	cursor.ReadAt = &now
+
+	file, err := os.Open(cursor.Path)
+	if err != nil {
+		cursor.Error = err.Error()
+		_ = ts.traefikDAO.SaveAccessLogCursor(&cursor)
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err == nil && info.Size() < cursor.Offset {
+		cursor.Offset = 0
+	}
+	if err == nil {
+		_, err = file.Seek(cursor.Offset, io.SeekStart)
+	}
+	if err != nil {
+		cursor.Error = err.Error()
+		_ = ts.traefikDAO.SaveAccessLogCursor(&cursor)
+		return err
+	}
+
+	aggregator := newTrafficAggregator(instance.Name, now)
+	reader := bufio.NewReaderSize(io.LimitReader(file, accessLogMaxRead), 64<<10)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			break
+		}
+		cursor.Offset += int64(len(line))
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		cursor.Lines++
+		var entry accessLogEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			cursor.Invalid++
+			continue
+		}
+		aggregator.add(entry)
+	}
+	cursor.Error = ""
+
+	top := config.GetInt("TRAEFIK_ACCESSLOG_TOP", 20)
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		dao := ts.traefikDAO.WithTx(tx)
+		for _, bucket := range aggregator.buckets {
+			existing, err := dao.GetTrafficBucket(bucket.Kind, bucket.Name, bucket.Instance, bucket.Start)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				existing = traefikModel.TraefikTrafficBucket{Kind: bucket.Kind, Name: bucket.Name, Instance: bucket.Instance, Start: bucket.Start}
+			} else if err != nil {
+				return err
+			}
+			mergeTrafficBucket(&existing, bucket)
+			existing.ClientIPs = topCounts(existing.ClientIPs, top)
+			existing.Paths = topCounts(existing.Paths, top)
+			if err := dao.SaveTrafficBucket(&existing); err != nil {
+				return err
+			}
+		}
+		return dao.SaveAccessLogCursor(&cursor)
+	})
+}
+
+// trafficAggregator 在内存中累加一次读取到的日志
+type trafficAggregator struct {
+	instance string
+	size     time.Duration
+	since    time.Time
+	buckets  map[string]*traefikModel.TraefikTrafficBucket
+}
+
+// newTrafficAggregator 创建日志累加器，超过保留期的日志直接忽略
+func newTrafficAggregator(instance string, now time.Time) *trafficAggregator {
+	return &trafficAggregator{
+		instance: instance,
+		size:     trafficBucketSize(),
+		since:    trafficRetentionStart(now),
+		buckets:  make(map[string]*traefikModel.TraefikTrafficBucket),
+	}
+}
+
+// add 把一条日志计入所属路由和服务的时间段
+func (a *trafficAggregator) add(entry accessLogEntry) {
+	at := entry.StartUTC
+	if at.IsZero() {
+		at = time.Now()
+	}
+	if at.Before(a.since) {
+		return
+	}
+	start := at.Truncate(a.size).UTC()
+	path, _, _ := strings.Cut(entry.RequestPath, "?")
+
+	for _, target := range []struct{ kind, name string }{{"router", entry.RouterName}, {"service", entry.ServiceName}} {
+		if target.name == "" {
+			continue
+		}
+		key := fmt.Sprintf("%s/%s/%d", target.kind, target.name, start.Unix())
+		bucket, ok := a.buckets[key]
+		if !ok {
+			bucket = &traefikModel.TraefikTrafficBucket{
+				Kind: target.kind, Name: target.name, Instance: a.instance, Start: start,
+				Status: traefikModel.TrafficCounts{}, ClientIPs: traefikModel.TrafficCounts{}, Paths: traefikModel.TrafficCounts{},
+				Latency: make(traefikModel.LatencyHistogram, len(traefikModel.TrafficLatencyBounds)+1),
+			}
+			a.buckets[key] = bucket
+		}
+		bucket.Requests++
+		bucket.Status[statusClass(entry.DownstreamStatus)]++
+		bucket.Latency[latencyIndex(float64(entry.Duration)/float64(time.Millisecond))]++
+		if entry.ClientHost != "" {
+			bucket.ClientIPs[entry.ClientHost]++
+		}
+		if path != "" {
+			bucket.Paths[path]++
+		}
+	}
+}
+
+// mergeTrafficBucket 把增量统计累加到已有的统计中
+func mergeTrafficBucket(target *traefikModel.TraefikTrafficBucket, delta *traefikModel.TraefikTrafficBucket) {
+	target.Requests += delta.Requests
+	target.Status = addCounts(target.Status, delta.Status)
+	target.ClientIPs = addCounts(target.ClientIPs, delta.ClientIPs)
+	target.Paths = addCounts(target.Paths, delta.Paths)
+	target.Latency = addHistogram(target.Latency, delta.Latency)
+}
+
+// summarizeTraffic 汇总多个时间段的统计，top大于0时包含请求数最多的客户端IP和路径
+func summarizeTraffic(buckets []traefikModel.TraefikTrafficBucket, top int) TrafficStats {
+	stats := TrafficStats{Status: make(map[string]int64)}
+	var latency traefikModel.LatencyHistogram
+	var clientIPs, paths traefikModel.TrafficCounts
+	for _, bucket := range buckets {
+		stats.Requests += bucket.Requests
+		stats.Status = addCounts(stats.Status, bucket.Status)
+		latency = addHistogram(latency, bucket.Latency)
+		if top > 0 {
+			clientIPs = addCounts(clientIPs, bucket.ClientIPs)
+			paths = addCounts(paths, bucket.Paths)
+		}
+	}
+	if stats.Requests > 0 {
+		stats.ErrorRate = float64(stats.Status["5xx"]) / float64(stats.Requests)
+	}
+	stats.P50 = latencyPercentile(latency, 0.50)
+	stats.P95 = latencyPercentile(latency, 0.95)
+	stats.P99 = latencyPercentile(latency, 0.99)
+	if top > 0 {
+		stats.TopClientIPs = rankCounts(clientIPs, top)
+		stats.TopPaths = rankCounts(paths, top)
+	}
+	return stats
+}
+
+// statusClass 状态码类别，如2xx，无效的状态码归为other
+func statusClass(status int) string {
+	if status < 100 || status > 599 {
+		return "other"
+	}
+	return fmt.Sprintf("%dxx", status/100)
+}
+
+// latencyIndex 耗时(毫秒)所在的区间
+func latencyIndex(ms float64) int {
+	for i, bound := range traefikModel.TrafficLatencyBounds {
+		if ms <= bound {
+			return i
+		}
+	}
+	return len(traefikModel.TrafficLatencyBounds)
+}
+
+// latencyPercentile 按耗时分布估算百分位耗时(毫秒)，在所在区间内线性插值，落在无上限区间时返回最后一个区间的上限
+func latencyPercentile(histogram traefikModel.LatencyHistogram, q float64) float64 {
+	bounds := traefikModel.TrafficLatencyBounds
+	var total int64
+	for _, count := range histogram {
+		total += count
+	}
+	if total == 0 {
+		return 0
+	}
+
+	rank := q * float64(total)
+	var cumulative float64
+	for i, count := range histogram {
+		if count == 0 {
+			continue
+		}
+		if cumulative+float64(count) >= rank {
+			if i >= len(bounds) {
+				return bounds[len(bounds)-1]
+			}
+			lower := 0.0
+			if i > 0 {
+				lower = bounds[i-1]
+			}
+			return lower + (bounds[i]-lower)*(rank-cumulative)/float64(count)
+		}
+		cumulative += float64(count)
+	}
+	return bounds[len(bounds)-1]
+}
+
+// addCounts 累加计数，返回累加后的结果
+func addCounts(target, delta map[string]int64) map[string]int64 {
+	if target == nil {
+		target = make(map[string]int64, len(delta))
+	}
+	for key, count := range delta {
+		target[key] += count
+	}
+	return target
+}
+
+// addHistogram 累加耗时分布，长度不同时以较长的为准
+func addHistogram(target, delta traefikModel.LatencyHistogram) traefikModel.LatencyHistogram {
+	for len(target) < len(delta) {
+		target = append(target, 0)
+	}
+	for i, count := range delta {
+		target[i] += count
+	}
+	return target
+}
+
+// rankCounts 按请求数倒序返回前n项
+func rankCounts(counts map[string]int64, n int) []TrafficTop {
+	ranked := make([]TrafficTop, 0, len(counts))
+	for value, requests := range counts {
+		ranked = append(ranked, TrafficTop{Value: value, Requests: requests})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Requests != ranked[j].Requests {
+			return ranked[i].Requests > ranked[j].Requests
+		}
+		return ranked[i].Value < ranked[j].Value
+	})
+	if len(ranked) > n {
+		ranked = ranked[:n]
+	}
+	return ranked
+}
+
+// topCounts 只保留请求数最多的n项，避免客户端IP和路径过多时统计无限增长
+func topCounts(counts traefikModel.TrafficCounts, n int) traefikModel.TrafficCounts {
+	if len(counts) <= n {
+		return counts
+	}
+	trimmed := make(traefikModel.TrafficCounts, n)
+	for _, item := range rankCounts(counts, n) {
+		trimmed[item.Value] = item.Requests
+	}
+	return trimmed
+}
+
+// trafficBucketSize 统计的时间段长度，格式错误时使用5分钟
+func trafficBucketSize() time.Duration {
+	size, err := time.ParseDuration(config.GetString("TRAEFIK_ACCESSLOG_BUCKET", "5m"))
+	if err != nil || size < time.Minute {
+		return 5 * time.Minute
+	}
+	return size
+}
+
+// trafficRetentionStart 保留期的开始时间，更早的统计会被删除
+func trafficRetentionStart(now time.Time) time.Time {
+	return now.AddDate(0, 0, -config.GetInt("TRAEFIK_ACCESSLOG_RETENTION_DAYS", 7))
+}
@@ -0,0 +1,296 @@
+package traefik
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	traefikDAO "github.com/yahahaff/rapide/internal/dao/traefik"
+	traefikModel "github.com/yahahaff/rapide/internal/models/traefik"
+	"github.com/yahahaff/rapide/pkg/config"
+	"github.com/yahahaff/rapide/pkg/traefikrule"
+	"github.com/yahahaff/rapide/pkg/types"
+)
+
+// Traefik主版本，决定下发配置时使用的中间件名称、选项和路由规则语法
+const (
+	traefikV2 = "v2"
+	traefikV3 = "v3"
+)
+
+// middlewareRenames 两个版本中名称不同的中间件，键为目标版本
+var middlewareRenames = map[string]map[string]string{
+	traefikV3: {"ipWhiteList": "ipAllowList"},
+	traefikV2: {"ipAllowList": "ipWhiteList"},
+}
+
+// middlewareOptionRenames 目标版本中改名的中间件选项，按改名后的中间件类型查找
+var middlewareOptionRenames = map[string]map[string]map[string]string{
+	traefikV3: {"headers": {"featurePolicy": "permissionsPolicy"}},
+}
+
+// removedMiddlewareOptions 目标版本不支持的中间件选项及替代方式，选项值为false或空时直接去掉
+var removedMiddlewareOptions = map[string]map[string]map[string]string{
+	traefikV3: {
+		"headers": {
+			"sslRedirect":          "请使用redirectScheme中间件或入口点重定向",
+			"sslTemporaryRedirect": "请使用redirectScheme中间件或入口点重定向",
+			"sslHost":              "请使用redirectRegex中间件",
+			"sslForceHost":         "请使用redirectRegex中间件",
+			"sslProxyHeaders":      "",
+		},
+		"stripPrefix": {"forceSlash": ""},
+		"contentType": {"autoDetect": ""},
+	},
+	traefikV2: {
+		"ipWhiteList": {"rejectStatusCode": ""},
+	},
+}
+
+// unsupportedMiddlewares 目标版本没有的中间件类型
+var unsupportedMiddlewares = map[string]map[string]bool{
+	traefikV2: {"grpcWeb": true},
+}
+
+// removedTLSOptions v3不再支持的TLS选项
+var removedTLSOptions = []string{"preferServerCipherSuites"}
+
+// defaultTraefikVersion 不针对具体实例下发配置(KV、导出、不带令牌的HTTP Provider)时的目标版本
+func defaultTraefikVersion() string {
+	if config.GetString("TRAEFIK_VERSION", traefikV3) == traefikV2 {
+		return traefikV2
+	}
+	return traefikV3
+}
+
+// instanceVersion 实例的Traefik主版本，未设置时使用TRAEFIK_VERSION
+func instanceVersion(instance traefikModel.TraefikInstance) string {
+	if instance.TraefikVersion == traefikV2 || instance.TraefikVersion == traefikV3 {
+		return instance.TraefikVersion
+	}
+	return defaultTraefikVersion()
+}
+
+// renderConfigSetFor 按目标版本转换后渲染配置，存在目标版本不支持的配置时拒绝渲染
+func renderConfigSetFor(set ConfigSet, version string) (map[string]interface{}, error) {
+	converted, issues := convertConfigSet(set, version)
+	if len(issues) > 0 {
+		messages := make([]string, 0, len(issues))
+		for _, issue := range issues {
+			messages = append(messages, fmt.Sprintf("%s %s: %s", issue.Kind, issue.Name, issue.Message))
+		}
+		return nil, &validationError{message: fmt.Sprintf("配置不兼容Traefik %s: %s", version, strings.Join(messages, "; "))}
+	}
+	return renderConfigSet(converted), nil
+}
+
+// convertConfigSet 把配置转换为目标版本的写法，返回转换后的副本和无法转换的问题
+// 路由规则按目标版本处理ruleSyntax，中间件转换改名的类型和选项，去掉目标版本不支持但未启用的选项
+func convertConfigSet(set ConfigSet, version string) (ConfigSet, []LintIssue) {
+	issues := make([]LintIssue, 0)
+	converted := set
+
+	converted.Routers = make([]traefikModel.TraefikRouter, 0, len(set.Routers))
+	for _, router := range set.Routers {
+		router.RuleSyntax = convertRuleSyntax(router, version, &issues)
+		converted.Routers = append(converted.Routers, router)
+	}
+
+	converted.Middlewares = make([]traefikModel.TraefikMiddleware, 0, len(set.Middlewares))
+	for _, middleware := range set.Middlewares {
+		converted.Middlewares = append(converted.Middlewares, convertMiddleware(middleware, version, &issues))
+	}
+
+	if version == traefikV3 {
+		converted.TLSOptions = make([]traefikModel.TraefikTLSOption, 0, len(set.TLSOptions))
+		for _, option := range set.TLSOptions {
+			option.Config = removeOptions(option.Config, removedTLSOptions, func(key string) {
+				issues = append(issues, LintIssue{
+					Level: "error", Check: lintVersionUnsupported, Kind: kindTLSOption, Name: option.Name, Protocol: protocolTLS,
+					Message: fmt.Sprintf("Traefik v3已移除TLS选项的%s", key),
+				})
+			})
+			converted.TLSOptions = append(converted.TLSOptions, option)
+		}
+	}
+
+	if version == traefikV2 {
+		for _, transport := range set.ServersTransports {
+			message := ""
+			if protocolOf(transport.Protocol) == "tcp" {
+				message = "Traefik v2不支持tcp的serversTransports"
+			} else if !isEmptyOption(transport.Config["spiffe"]) {
+				message = "Traefik v2不支持serversTransport的spiffe选项"
+			}
+			if message != "" {
+				issues = append(issues, LintIssue{
+					Level: "error", Check: lintVersionUnsupported, Kind: kindServersTransport,
+					Name: transport.Name, Protocol: protocolOf(transport.Protocol), Message: message,
+				})
+			}
+		}
+	}
+	return converted, issues
+}
+
+// convertRuleSyntax 目标版本中路由使用的ruleSyntax
+// v3中default表示使用Traefik静态配置的默认语法，不输出该字段；v2没有ruleSyntax，规则需要在两种语法下含义相同
+func convertRuleSyntax(router traefikModel.TraefikRouter, version string, issues *[]LintIssue) string {
+	syntax := router.RuleSyntax
+	if syntax == "default" {
+		syntax = ""
+	}
+	if version == traefikV3 || protocolOf(router.Protocol) == "udp" {
+		return syntax
+	}
+	if syntax != traefikrule.SyntaxV2 {
+		if err := traefikrule.Portable(router.Rule, protocolOf(router.Protocol)); err != nil {
+			*issues = append(*issues, routerIssue(router, "error", lintVersionUnsupported, "",
+				"规则无法用于Traefik v2: "+err.Error()+"，请改写为v2语法并把ruleSyntax设为v2"))
+		}
+	}
+	return ""
+}
+
+// convertMiddleware 转换中间件的类型和选项，配置复制后再修改，不影响原对象
+func convertMiddleware(middleware traefikModel.TraefikMiddleware, version string, issues *[]LintIssue) traefikModel.TraefikMiddleware {
+	issue := func(message string) {
+		*issues = append(*issues, LintIssue{
+			Level: "error", Check: lintVersionUnsupported, Kind: kindMiddleware,
+			Name: middleware.Name, Protocol: protocolOf(middleware.Protocol), Message: message,
+		})
+	}
+
+	if renamed, ok := middlewareRenames[version][middleware.Type]; ok {
+		middleware.Type = renamed
+	}
+	if unsupportedMiddlewares[version][middleware.Type] {
+		issue(fmt.Sprintf("Traefik %s没有%s中间件", version, middleware.Type))
+		return middleware
+	}
+
+	cfg := make(types.JSONMap, len(middleware.Config))
+	for key, value := range middleware.Config {
+		cfg[key] = value
+	}
+	renames := middlewareOptionRenames[version][middleware.Type]
+	for _, from := range sortedOptionKeys(renames) {
+		value, ok := cfg[from]
+		if !ok {
+			continue
+		}
+		to := renames[from]
+		if _, exists := cfg[to]; exists {
+			issue(fmt.Sprintf("同时设置了%s和%s，Traefik %s只支持%s", from, to, version, to))
+			continue
+		}
+		delete(cfg, from)
+		cfg[to] = value
+	}
+	removed := removedMiddlewareOptions[version][middleware.Type]
+	middleware.Config = removeOptions(cfg, sortedOptionKeys(removed), func(key string) {
+		message := fmt.Sprintf("Traefik %s不支持%s中间件的%s选项", version, middleware.Type, key)
+		if hint := removed[key]; hint != "" {
+			message += "，" + hint
+		}
+		issue(message)
+	})
+	return middleware
+}
+
+// removeOptions 去掉配置中未启用的选项，启用的选项保留并通过unsupported报告
+func removeOptions(cfg types.JSONMap, keys []string, unsupported func(key string)) types.JSONMap {
+	var result types.JSONMap
+	for _, key := range keys {
+		value, ok := cfg[key]
+		if !ok {
+			continue
+		}
+		if !isEmptyOption(value) {
+			unsupported(key)
+			continue
+		}
+		if result == nil {
+			result = make(types.JSONMap, len(cfg))
+			for k, v := range cfg {
+				result[k] = v
+			}
+		}
+		delete(result, key)
+	}
+	if result == nil {
+		return cfg
+	}
+	return result
+}
+
+// isEmptyOption 判断选项值是否等同于未设置：false、空字符串、0、空列表或空对象
+func isEmptyOption(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case bool:
+		return !v
+	case string:
+		return v == ""
+	case float64:
+		return v == 0
+	case int:
+		return v == 0
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// sortedOptionKeys 按顺序返回选项表的键，保证问题的顺序稳定
+func sortedOptionKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// lintTargetVersions 按各启用实例的Traefik版本检查其可见范围内的配置，同一问题只报告一次并列出涉及的实例
+// 同时按TRAEFIK_VERSION检查全部配置，KV和导出使用该版本
+func lintTargetVersions(dao *traefikDAO.TraefikDAO, set ConfigSet) ([]LintIssue, error) {
+	instances, err := dao.GetAllInstances()
+	if err != nil {
+		return nil, err
+	}
+
+	type target struct {
+		label string
+		set   ConfigSet
+		ver   string
+	}
+	targets := []target{{label: "TRAEFIK_VERSION", set: set, ver: defaultTraefikVersion()}}
+	for _, instance := range instances {
+		if instance.Status == "disabled" {
+			continue
+		}
+		targets = append(targets, target{label: instance.Name, set: scopeConfigSet(set, instance), ver: instanceVersion(instance)})
+	}
+
+	issues := make([]LintIssue, 0)
+	labels := make(map[string][]string)
+	for _, t := range targets {
+		_, found := convertConfigSet(t.set, t.ver)
+		for _, issue := range found {
+			key := strings.Join([]string{issue.Kind, issue.Protocol, issue.Name, issue.Message}, "\x00")
+			if _, ok := labels[key]; !ok {
+				issues = append(issues, issue)
+			}
+			labels[key] = append(labels[key], t.label)
+		}
+	}
+	for i, issue := range issues {
+		key := strings.Join([]string{issue.Kind, issue.Protocol, issue.Name, issue.Message}, "\x00")
+		issues[i].Message = fmt.Sprintf("%s (下发对象: %s)", issue.Message, strings.Join(labels[key], ", "))
+	}
+	return issues, nil
+}
@@ -0,0 +1,106 @@
+// Package utils
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// DiffChange 结构化差异中的一项变更
+type DiffChange struct {
+	Path string      `json:"path"`
+	Type string      `json:"type"` // added, removed, changed
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// DiffJSON 比较两个可序列化为JSON的值，返回按路径展开的差异列表
+// 比较前会先做一次JSON序列化，自定义的map/slice类型与普通类型可以直接比较
+func DiffJSON(before, after interface{}) []DiffChange {
+	changes := make([]DiffChange, 0)
+	diffValue("", normalizeJSON(before), normalizeJSON(after), &changes)
+	return changes
+}
+
+// diffValue 递归比较两个JSON值
+func diffValue(path string, before, after interface{}, changes *[]DiffChange) {
+	if before == nil && after == nil {
+		return
+	}
+	if before == nil {
+		*changes = append(*changes, DiffChange{Path: path, Type: "added", New: after})
+		return
+	}
+	if after == nil {
+		*changes = append(*changes, DiffChange{Path: path, Type: "removed", Old: before})
+		return
+	}
+
+	beforeMap, beforeIsMap := before.(map[string]interface{})
+	afterMap, afterIsMap := after.(map[string]interface{})
+	if beforeIsMap && afterIsMap {
+		keys := make(map[string]bool)
+		for key := range beforeMap {
+			keys[key] = true
+		}
+		for key := range afterMap {
+			keys[key] = true
+		}
+		sortedKeys := make([]string, 0, len(keys))
+		for key := range keys {
+			sortedKeys = append(sortedKeys, key)
+		}
+		sort.Strings(sortedKeys)
+
+		for _, key := range sortedKeys {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			diffValue(childPath, beforeMap[key], afterMap[key], changes)
+		}
+		return
+	}
+
+	beforeSlice, beforeIsSlice := before.([]interface{})
+	afterSlice, afterIsSlice := after.([]interface{})
+	if beforeIsSlice && afterIsSlice {
+		length := len(beforeSlice)
+		if len(afterSlice) > length {
+			length = len(afterSlice)
+		}
+		for i := 0; i < length; i++ {
+			var beforeItem, afterItem interface{}
+			if i < len(beforeSlice) {
+				beforeItem = beforeSlice[i]
+			}
+			if i < len(afterSlice) {
+				afterItem = afterSlice[i]
+			}
+			diffValue(fmt.Sprintf("%s[%d]", path, i), beforeItem, afterItem, changes)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(before, after) {
+		*changes = append(*changes, DiffChange{Path: path, Type: "changed", Old: before, New: after})
+	}
+}
+
+// normalizeJSON 通过JSON序列化把任意值转换为map[string]interface{}、[]interface{}和基础类型
+func normalizeJSON(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return value
+	}
+	return normalized
+}
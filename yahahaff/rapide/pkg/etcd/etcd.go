@@ -2,15 +2,35 @@ package etcd
 
 import (
 	"context"
-	clientv3 "go.etcd.io/etcd/client/v3"
+	"errors"
+	"fmt"
+	"sort"
 	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
 )
 
+// Etcd 全局 etcd 客户端，未启用 etcd 时为 nil
+var Etcd *EtcdClient
+
+// ErrConcurrentModification 事务提交时发现前缀下的键已被其他客户端修改
+var ErrConcurrentModification = errors.New("etcd: keys under prefix were modified concurrently")
+
 // EtcdClient represents the etcd client wrapper.
 type EtcdClient struct {
 	client *clientv3.Client
 }
 
+// ConnectEtcd 连接 etcd，设置全局的 Etcd 对象
+func ConnectEtcd(endpoints []string, dialTimeout time.Duration) error {
+	cli, err := NewEtcdClient(endpoints, dialTimeout)
+	if err != nil {
+		return err
+	}
+	Etcd = cli
+	return nil
+}
+
 // NewEtcdClient creates a new etcd client.
 func NewEtcdClient(endpoints []string, dialTimeout time.Duration) (*EtcdClient, error) {
 	cli, err := clientv3.New(clientv3.Config{
@@ -45,3 +65,95 @@ func (ec *EtcdClient) Get(ctx context.Context, key string) (*clientv3.GetRespons
 func (ec *EtcdClient) Delete(ctx context.Context, key string) (*clientv3.DeleteResponse, error) {
 	return ec.client.Delete(ctx, key)
 }
+
+// PartialReplaceError 分批提交时前面的批次已经写入、后面的批次失败，前缀下是新旧配置混合的状态
+// 新增和变化的键先于删除写入，失败时Traefik看到的是新配置加上尚未删除的旧键
+type PartialReplaceError struct {
+	Committed int   // 已提交的操作数
+	Total     int   // 需要提交的操作数
+	Err       error // 失败批次的错误
+}
+
+func (e *PartialReplaceError) Error() string {
+	return fmt.Sprintf("etcd: prefix partially replaced, committed %d of %d ops, remaining keys were not written or deleted: %v", e.Committed, e.Total, e.Err)
+}
+
+func (e *PartialReplaceError) Unwrap() error {
+	return e.Err
+}
+
+// ReplacePrefix 将前缀下的键值替换为kvs：写入新增和变化的键，删除kvs中不存在的键
+// 变更在事务中提交，超过maxOps（etcd默认--max-txn-ops为128）时分批提交：
+// 先按键排序写入所有新增和变化的键，再删除多余的键，每个批次都以上一次读取或提交的修订号为条件，
+// 期间有其他写入时放弃提交；第一批之后失败时返回PartialReplaceError，puts和deletes为已提交的数量
+func (ec *EtcdClient) ReplacePrefix(ctx context.Context, prefix string, kvs map[string]string, maxOps int) (puts int, deletes int, err error) {
+	resp, err := ec.client.Get(ctx, prefix, clientv3.WithPrefix())
+	if err != nil {
+		return 0, 0, err
+	}
+
+	existing := make(map[string]string, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		existing[string(kv.Key)] = string(kv.Value)
+	}
+
+	putKeys := make([]string, 0)
+	for key, value := range kvs {
+		if current, ok := existing[key]; ok && current == value {
+			continue
+		}
+		putKeys = append(putKeys, key)
+	}
+	deleteKeys := make([]string, 0)
+	for key := range existing {
+		if _, ok := kvs[key]; !ok {
+			deleteKeys = append(deleteKeys, key)
+		}
+	}
+	sort.Strings(putKeys)
+	sort.Strings(deleteKeys)
+
+	ops := make([]clientv3.Op, 0, len(putKeys)+len(deleteKeys))
+	for _, key := range putKeys {
+		ops = append(ops, clientv3.OpPut(key, kvs[key]))
+	}
+	for _, key := range deleteKeys {
+		ops = append(ops, clientv3.OpDelete(key))
+	}
+
+	if maxOps <= 0 {
+		maxOps = len(ops)
+	}
+	revision := resp.Header.Revision
+	for start := 0; start < len(ops); start += maxOps {
+		end := start + maxOps
+		if end > len(ops) {
+			end = len(ops)
+		}
+		// 以读取时或上一批提交时的修订号为条件，期间有其他写入时放弃提交
+		txnResp, err := ec.client.Txn(ctx).
+			If(clientv3.Compare(clientv3.ModRevision(prefix).WithPrefix(), "<", revision+1)).
+			Then(ops[start:end]...).
+			Commit()
+		if err == nil && !txnResp.Succeeded {
+			err = ErrConcurrentModification
+		}
+		if err != nil {
+			if start == 0 {
+				return 0, 0, err
+			}
+			puts, deletes = committedCounts(start, len(putKeys))
+			return puts, deletes, &PartialReplaceError{Committed: start, Total: len(ops), Err: err}
+		}
+		revision = txnResp.Header.Revision
+	}
+	return len(putKeys), len(deleteKeys), nil
+}
+
+// committedCounts 按先写入后删除的顺序，把已提交的操作数拆分为写入和删除的数量
+func committedCounts(committed, puts int) (int, int) {
+	if committed <= puts {
+		return committed, 0
+	}
+	return puts, committed - puts
+}
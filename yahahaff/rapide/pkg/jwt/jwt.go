@@ -22,6 +22,9 @@ var (
 	ErrHeaderMalformed        = errors.New("请求头中 Authorization 格式有误")
 )
 
+// AudienceForwardAuth forwardAuth专用令牌的接收者，只能用于Traefik的forwardAuth校验，不能访问rapide的接口
+const AudienceForwardAuth = "forwardauth"
+
 // JWT 定义一个jwt对象
 type JWT struct {
 
@@ -64,6 +67,24 @@ func (jwt *JWT) ParserToken(c *gin.Context) (*JWTCustomClaims, error) {
 	if parseErr != nil {
 		return nil, parseErr
 	}
+	return jwt.ParserTokenString(tokenString)
+}
+
+// ParserTokenString 解析访问rapide接口的 Token，带有接收者的专用令牌无效
+func (jwt *JWT) ParserTokenString(tokenString string) (*JWTCustomClaims, error) {
+	return jwt.parserAudienceToken(tokenString, "")
+}
+
+// ParserAudienceToken 解析指定接收者的专用令牌，如forwardAuth从Cookie中读取的令牌
+func (jwt *JWT) ParserAudienceToken(tokenString, audience string) (*JWTCustomClaims, error) {
+	if audience == "" {
+		return nil, ErrTokenInvalid
+	}
+	return jwt.parserAudienceToken(tokenString, audience)
+}
+
+// parserAudienceToken 解析 Token 并检查接收者，audience为空表示只接受普通令牌
+func (jwt *JWT) parserAudienceToken(tokenString, audience string) (*JWTCustomClaims, error) {
 
 	// 1. 调用 jwt 库解析用户传参的 Token
 	token, err := jwt.parseTokenString(tokenString)
@@ -82,7 +103,7 @@ func (jwt *JWT) ParserToken(c *gin.Context) (*JWTCustomClaims, error) {
 	}
 
 	// 3. 将 token 中的 claims 信息解析出来和 JWTCustomClaims 数据结构进行校验
-	if claims, ok := token.Claims.(*JWTCustomClaims); ok && token.Valid {
+	if claims, ok := token.Claims.(*JWTCustomClaims); ok && token.Valid && claims.Audience == audience {
 		return claims, nil
 	}
 
@@ -110,8 +131,11 @@ func (jwt *JWT) RefreshToken(c *gin.Context) (string, error) {
 		}
 	}
 
-	// 4. 解析 JWTCustomClaims 的数据
+	// 4. 解析 JWTCustomClaims 的数据，专用令牌不能刷新
 	claims := token.Claims.(*JWTCustomClaims)
+	if claims.Audience != "" {
+		return "", ErrTokenInvalid
+	}
 
 	// 5. 检查是否过了『最大允许刷新的时间』
 	x := app.TimenowInTimezone().Add(-jwt.MaxRefresh).Unix()
@@ -151,6 +175,25 @@ func (jwt *JWT) IssueToken(userID string, userName string) string {
 	return token
 }
 
+// IssueAudienceToken 生成指定接收者的专用令牌，有效期为ttl，普通接口不接受该令牌
+func (jwt *JWT) IssueAudienceToken(userID, userName, audience string, ttl time.Duration) (string, error) {
+	now := app.TimenowInTimezone()
+	expireAtTime := now.Add(ttl).Unix()
+	claims := JWTCustomClaims{
+		userID,
+		userName,
+		expireAtTime,
+		jwtpkg.StandardClaims{
+			Audience:  audience,
+			NotBefore: now.Unix(),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: expireAtTime,
+			Issuer:    config.GetString("APP_NAME", "Rapide"),
+		},
+	}
+	return jwt.createToken(claims)
+}
+
 // createToken 创建 Token，内部使用，外部请调用 IssueToken
 func (jwt *JWT) createToken(claims JWTCustomClaims) (string, error) {
 	// 使用HS256算法进行token生成
@@ -0,0 +1,152 @@
+// Package promtext 解析Prometheus文本格式(text/plain; version=0.0.4)的指标
+package promtext
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Sample 一个指标样本，直方图和摘要的每个_bucket、_sum、_count也是独立的样本
+type Sample struct {
+	Name   string
+	Labels map[string]string
+	Value  float64
+}
+
+// maxLineSize 单行的最大长度
+const maxLineSize = 1 << 20
+
+// Parse 解析文本格式的指标，跳过注释、HELP和TYPE行，忽略样本的时间戳
+// 任一样本格式错误时返回错误和出错的行号
+func Parse(r io.Reader) ([]Sample, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), maxLineSize)
+	samples := make([]Sample, 0)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		sample, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("第%d行: %w", lineNo, err)
+		}
+		samples = append(samples, sample)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return samples, nil
+}
+
+// parseLine 解析一行样本: 名称{标签="值",...} 值 [时间戳]
+func parseLine(line string) (Sample, error) {
+	sample := Sample{Labels: make(map[string]string)}
+	end := strings.IndexAny(line, "{ \t")
+	if end < 0 {
+		return sample, fmt.Errorf("缺少样本值: %s", line)
+	}
+	sample.Name = line[:end]
+	if !validName(sample.Name) {
+		return sample, fmt.Errorf("指标名称无效: %s", sample.Name)
+	}
+
+	// 名称和标签之间允许有空白
+	rest := strings.TrimLeft(line[end:], " \t")
+	if strings.HasPrefix(rest, "{") {
+		var err error
+		if rest, err = parseLabels(rest[1:], sample.Labels); err != nil {
+			return sample, err
+		}
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 || len(fields) > 2 {
+		return sample, fmt.Errorf("样本值格式错误: %s", line)
+	}
+	value, err := parseValue(fields[0])
+	if err != nil {
+		return sample, fmt.Errorf("样本值无效: %s", fields[0])
+	}
+	sample.Value = value
+	return sample, nil
+}
+
+// parseLabels 解析标签直到右花括号，返回剩余部分
+func parseLabels(s string, labels map[string]string) (string, error) {
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if strings.HasPrefix(s, "}") {
+			return s[1:], nil
+		}
+		eq := strings.IndexByte(s, '=')
+		if eq < 0 {
+			return "", fmt.Errorf("标签格式错误: %s", s)
+		}
+		name := strings.TrimSpace(s[:eq])
+		if !validName(name) {
+			return "", fmt.Errorf("标签名称无效: %s", name)
+		}
+		s = strings.TrimLeft(s[eq+1:], " \t")
+		if !strings.HasPrefix(s, `"`) {
+			return "", fmt.Errorf("标签%s的值缺少引号", name)
+		}
+
+		var value strings.Builder
+		i := 1
+		for ; i < len(s) && s[i] != '"'; i++ {
+			if s[i] != '\\' || i+1 >= len(s) {
+				value.WriteByte(s[i])
+				continue
+			}
+			i++
+			switch s[i] {
+			case 'n':
+				value.WriteByte('\n')
+			default:
+				value.WriteByte(s[i])
+			}
+		}
+		if i >= len(s) {
+			return "", fmt.Errorf("标签%s的值缺少结束引号", name)
+		}
+		labels[name] = value.String()
+
+		s = strings.TrimLeft(s[i+1:], " \t")
+		if strings.HasPrefix(s, ",") {
+			s = s[1:]
+		} else if !strings.HasPrefix(s, "}") {
+			return "", fmt.Errorf("标签之间缺少逗号: %s", s)
+		}
+	}
+}
+
+// parseValue 解析样本值，支持NaN和±Inf
+func parseValue(s string) (float64, error) {
+	switch s {
+	case "NaN":
+		return math.NaN(), nil
+	case "+Inf", "Inf":
+		return math.Inf(1), nil
+	case "-Inf":
+		return math.Inf(-1), nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// validName 判断是否是合法的指标或标签名称
+func validName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		if !(r == '_' || r == ':' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
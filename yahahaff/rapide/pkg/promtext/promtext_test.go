@@ -0,0 +1,95 @@
+package promtext
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	input := `# HELP traefik_router_requests_total How many HTTP requests are processed on a router.
+# TYPE traefik_router_requests_total counter
+traefik_router_requests_total{code="200",method="GET",protocol="http",router="shop@http",service="shop@http"} 1027
+traefik_router_requests_total{code="503",method="GET",protocol="http",router="shop@http",service="shop@http"} 3 1700000000000
+
+process_start_time_seconds 1.7e+09
+escaped{path="C:\\dir\"x\"\nend",empty=""} 1
+spaced { a = "1" , b="2", } -2.5
+nan_value NaN
+inf_values{le="+Inf"} +Inf
+neg_inf -Inf
+`
+	samples, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	want := []Sample{
+		{Name: "traefik_router_requests_total", Labels: map[string]string{"code": "200", "method": "GET", "protocol": "http", "router": "shop@http", "service": "shop@http"}, Value: 1027},
+		{Name: "traefik_router_requests_total", Labels: map[string]string{"code": "503", "method": "GET", "protocol": "http", "router": "shop@http", "service": "shop@http"}, Value: 3},
+		{Name: "process_start_time_seconds", Labels: map[string]string{}, Value: 1.7e9},
+		{Name: "escaped", Labels: map[string]string{"path": "C:\\dir\"x\"\nend", "empty": ""}, Value: 1},
+		{Name: "spaced", Labels: map[string]string{"a": "1", "b": "2"}, Value: -2.5},
+	}
+	if len(samples) != len(want)+3 {
+		t.Fatalf("Parse returned %d samples, want %d", len(samples), len(want)+3)
+	}
+	if !reflect.DeepEqual(samples[:len(want)], want) {
+		t.Errorf("Parse = %+v, want %+v", samples[:len(want)], want)
+	}
+	if !math.IsNaN(samples[5].Value) {
+		t.Errorf("NaN parsed as %v", samples[5].Value)
+	}
+	if !math.IsInf(samples[6].Value, 1) || samples[6].Labels["le"] != "+Inf" {
+		t.Errorf("+Inf parsed as %+v", samples[6])
+	}
+	if !math.IsInf(samples[7].Value, -1) {
+		t.Errorf("-Inf parsed as %v", samples[7].Value)
+	}
+}
+
+func TestParseEmpty(t *testing.T) {
+	samples, err := Parse(strings.NewReader("# only comments\n\n   \n"))
+	if err != nil || len(samples) != 0 {
+		t.Errorf("Parse = %v, %v, want no samples", samples, err)
+	}
+}
+
+func TestParseMalformed(t *testing.T) {
+	cases := []struct {
+		name     string
+		line     string
+		contains string
+	}{
+		{"缺少样本值", "metric", "缺少样本值"},
+		{"只有标签没有值", `metric{a="1"}`, "样本值格式错误"},
+		{"多余的字段", "metric 1 2 3", "样本值格式错误"},
+		{"样本值不是数字", "metric abc", "样本值无效"},
+		{"指标名称以数字开头", "1metric 1", "指标名称无效"},
+		{"指标名称含非法字符", "my-metric 1", "指标名称无效"},
+		{"标签缺少等号", `metric{a} 1`, "标签格式错误"},
+		{"标签名称无效", `metric{1a="x"} 1`, "标签名称无效"},
+		{"标签值缺少引号", `metric{a=1} 1`, "缺少引号"},
+		{"标签值缺少结束引号", `metric{a="1} 1`, "缺少结束引号"},
+		{"标签之间缺少逗号", `metric{a="1" b="2"} 1`, "缺少逗号"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// 出错的行号从1开始，注释行也计入
+			_, err := Parse(strings.NewReader("# TYPE metric counter\n" + c.line + "\n"))
+			if err == nil {
+				t.Fatalf("Parse(%q) succeeded, want error containing %q", c.line, c.contains)
+			}
+			if !strings.Contains(err.Error(), c.contains) || !strings.HasPrefix(err.Error(), "第2行") {
+				t.Errorf("Parse(%q) error = %q, want 第2行 and %q", c.line, err.Error(), c.contains)
+			}
+		})
+	}
+}
+
+func TestParseLineTooLong(t *testing.T) {
+	line := "metric{a=\"" + strings.Repeat("x", maxLineSize) + "\"} 1\n"
+	if _, err := Parse(strings.NewReader(line)); err == nil {
+		t.Error("超过最大长度的行应返回错误")
+	}
+}
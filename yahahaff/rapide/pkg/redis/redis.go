@@ -167,3 +167,52 @@ func (rds RedisClient) Decrement(parameters ...interface{}) bool {
 	}
 	return true
 }
+
+// ReplacePrefix 将前缀下的键值替换为kvs：写入新增和变化的键，删除kvs中不存在的键
+// 所有变更在同一个 MULTI/EXEC 事务中提交
+func (rds RedisClient) ReplacePrefix(prefix string, kvs map[string]string) (puts int, deletes int, err error) {
+	// 1. 扫描前缀下已有的键
+	var keys []string
+	iter := rds.Client.Scan(rds.Context, 0, prefix+"*", 500).Iterator()
+	for iter.Next(rds.Context) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return 0, 0, err
+	}
+
+	existing := make(map[string]string, len(keys))
+	if len(keys) > 0 {
+		values, err := rds.Client.MGet(rds.Context, keys...).Result()
+		if err != nil {
+			return 0, 0, err
+		}
+		for i, key := range keys {
+			if value, ok := values[i].(string); ok {
+				existing[key] = value
+			}
+		}
+	}
+
+	// 2. 在事务中写入变化的键并删除多余的键
+	_, err = rds.Client.TxPipelined(rds.Context, func(pipe redis.Pipeliner) error {
+		for key, value := range kvs {
+			if current, ok := existing[key]; ok && current == value {
+				continue
+			}
+			pipe.Set(rds.Context, key, value, 0)
+			puts++
+		}
+		for _, key := range keys {
+			if _, ok := kvs[key]; !ok {
+				pipe.Del(rds.Context, key)
+				deletes++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return puts, deletes, nil
+}
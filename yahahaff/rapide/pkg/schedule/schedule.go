@@ -0,0 +1,35 @@
+// Package schedule 简单的周期任务调度
+package schedule
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yahahaff/rapide/pkg/logger"
+)
+
+// Every 在后台每隔interval执行一次fn，interval不大于0时不启动
+// 上一次执行未结束时不会重复执行，fn中的panic会被恢复并记录日志
+func Every(name string, interval time.Duration, fn func()) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			run(name, fn)
+		}
+	}()
+}
+
+// run 执行一次任务并恢复panic
+func run(name string, fn func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.ErrorString("schedule", name, fmt.Sprintf("panic: %v", r))
+		}
+	}()
+	fn()
+}
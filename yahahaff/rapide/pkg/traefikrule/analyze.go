@@ -0,0 +1,240 @@
+package traefikrule
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Condition 规则中的单个匹配条件，名称和参数已规范化
+type Condition struct {
+	Name    string
+	Args    []string
+	Negated bool
+}
+
+// Term 需要同时满足的一组条件，为空表示匹配所有请求
+type Term []Condition
+
+// maxTerms 析取范式的项数上限，超过时放弃分析
+const maxTerms = 64
+
+// ErrTooComplex 规则展开后过于复杂，无法分析
+var ErrTooComplex = errors.New("规则过于复杂，无法分析")
+
+// Normalize 把规则转换为析取范式，规则匹配当且仅当任一项的条件全部满足
+// v2语法中的多参数匹配器拆分为多个条件，别名统一为v3的名称
+func Normalize(rule, protocol, syntax string) ([]Term, error) {
+	if _, err := Parse(rule, protocol, syntax); err != nil {
+		return nil, err
+	}
+	root, err := parse(rule)
+	if err != nil {
+		return nil, err
+	}
+	if syntax == "" || syntax == "default" {
+		syntax = SyntaxV3
+	}
+	return root.terms(protocol, syntax, false)
+}
+
+// Covers 判断所有匹配b的请求是否都匹配a，只做结构上的判断，无法确定时返回false
+// 例如Host(`a.com`)覆盖Host(`a.com`) && PathPrefix(`/api`)，PathPrefix(`/api`)覆盖Path(`/api/v1`)
+func Covers(a, b []Term) bool {
+	for _, tb := range b {
+		covered := false
+		for _, ta := range a {
+			if termCovers(ta, tb) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return false
+		}
+	}
+	return true
+}
+
+// Portable 判断规则在v3和v2语法下含义相同，可以不设置ruleSyntax同时用于两个版本的Traefik
+// 两种语法的规范化结果需要一致，且不能使用两种语法下含义不同的正则匹配器和路径变量
+func Portable(rule, protocol string) error {
+	v3, err := Normalize(rule, protocol, SyntaxV3)
+	if err != nil {
+		return err
+	}
+	v2, err := Normalize(rule, protocol, SyntaxV2)
+	if err != nil {
+		return fmt.Errorf("v2语法无法解析: %w", err)
+	}
+	if !reflect.DeepEqual(v3, v2) {
+		return errors.New("规则在v2和v3语法下含义不同")
+	}
+	for _, term := range v3 {
+		for _, condition := range term {
+			switch condition.Name {
+			case "HostRegexp", "HostSNIRegexp":
+				return fmt.Errorf("%s在v2语法中使用路径模板，在v3语法中使用正则表达式", condition.Name)
+			case "Path", "PathPrefix":
+				if strings.Contains(condition.Args[0], "{") {
+					return fmt.Errorf("%s在v2语法中支持变量，在v3语法中不支持", condition.Name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// terms 展开语法树，negated表示外层有奇数个取反
+func (n *node) terms(protocol, syntax string, negated bool) ([]Term, error) {
+	switch n.op {
+	case tokenIdent:
+		return callTerms(n, protocol, syntax, negated), nil
+	case tokenNot:
+		return n.left.terms(protocol, syntax, !negated)
+	}
+
+	left, err := n.left.terms(protocol, syntax, negated)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.terms(protocol, syntax, negated)
+	if err != nil {
+		return nil, err
+	}
+
+	// 取反后&&变为||，||变为&&
+	if (n.op == tokenOr) != negated {
+		if len(left)+len(right) > maxTerms {
+			return nil, ErrTooComplex
+		}
+		return append(left, right...), nil
+	}
+	if len(left)*len(right) > maxTerms {
+		return nil, ErrTooComplex
+	}
+	result := make([]Term, 0, len(left)*len(right))
+	for _, l := range left {
+		for _, r := range right {
+			term := make(Term, 0, len(l)+len(r))
+			result = append(result, append(append(term, l...), r...))
+		}
+	}
+	return result, nil
+}
+
+// callTerms 展开匹配器调用，多个参数之间是或的关系
+func callTerms(n *node, protocol, syntax string, negated bool) []Term {
+	conditions, always := normalizeCall(n, protocol, syntax)
+	if always {
+		// HostSNI(`*`)匹配所有连接，取反后不匹配任何连接
+		if negated {
+			return []Term{}
+		}
+		return []Term{{}}
+	}
+	if negated {
+		term := make(Term, 0, len(conditions))
+		for _, condition := range conditions {
+			condition.Negated = true
+			term = append(term, condition)
+		}
+		return []Term{term}
+	}
+	terms := make([]Term, 0, len(conditions))
+	for _, condition := range conditions {
+		terms = append(terms, Term{condition})
+	}
+	return terms
+}
+
+// normalizeCall 规范化匹配器名称和参数，always表示匹配所有请求
+func normalizeCall(n *node, protocol, syntax string) (conditions []Condition, always bool) {
+	name := n.name
+	switch name {
+	case "HostHeader":
+		name = "Host"
+	case "Headers":
+		name = "Header"
+	case "HeadersRegexp":
+		name = "HeaderRegexp"
+	}
+
+	switch name {
+	case "Header", "HeaderRegexp", "QueryRegexp":
+		return []Condition{{Name: name, Args: n.args}}, false
+	case "Query":
+		if syntax != SyntaxV2 {
+			return []Condition{{Name: name, Args: n.args}}, false
+		}
+	}
+
+	for _, arg := range n.args {
+		switch name {
+		case "Host", "HostSNI":
+			arg = strings.ToLower(arg)
+			if protocol == ProtocolTCP && arg == "*" {
+				return nil, true
+			}
+		case "Method":
+			arg = strings.ToUpper(arg)
+		}
+		args := []string{arg}
+		if name == "Query" {
+			key, value, found := strings.Cut(arg, "=")
+			args = []string{key}
+			if found {
+				args = append(args, value)
+			}
+		}
+		conditions = append(conditions, Condition{Name: name, Args: args})
+	}
+	return conditions, false
+}
+
+// termCovers 判断满足b的请求是否一定满足a，即a的每个条件都能由b中的某个条件推出
+func termCovers(a, b Term) bool {
+	for _, x := range a {
+		implied := false
+		for _, y := range b {
+			if implies(y, x) {
+				implied = true
+				break
+			}
+		}
+		if !implied {
+			return false
+		}
+	}
+	return true
+}
+
+// implies 判断条件y成立时x是否一定成立
+func implies(y, x Condition) bool {
+	if x.Name == y.Name && x.Negated == y.Negated && equalArgs(x.Args, y.Args) {
+		return true
+	}
+	if x.Negated || y.Negated || x.Name != "PathPrefix" || (y.Name != "Path" && y.Name != "PathPrefix") {
+		return false
+	}
+	// v2语法的路径可能带有变量，不做前缀推断
+	prefix, path := x.Args[0], y.Args[0]
+	if strings.Contains(prefix, "{") || strings.Contains(path, "{") {
+		return false
+	}
+	return strings.HasPrefix(path, prefix)
+}
+
+// equalArgs 比较两组参数
+func equalArgs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,163 @@
+package traefikrule
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// cond 构造条件，便于书写期望的析取范式
+func cond(name string, args ...string) Condition {
+	return Condition{Name: name, Args: args}
+}
+
+// not 构造取反的条件
+func not(name string, args ...string) Condition {
+	return Condition{Name: name, Args: args, Negated: true}
+}
+
+func TestNormalize(t *testing.T) {
+	cases := []struct {
+		name     string
+		rule     string
+		protocol string
+		syntax   string
+		want     []Term
+	}{
+		{"单个条件的域名转为小写", "Host(`A.com`)", ProtocolHTTP, SyntaxV3,
+			[]Term{{cond("Host", "a.com")}}},
+		{"与", "Host(`a.com`) && PathPrefix(`/api`)", ProtocolHTTP, SyntaxV3,
+			[]Term{{cond("Host", "a.com"), cond("PathPrefix", "/api")}}},
+		{"或", "Host(`a.com`) || Host(`b.com`)", ProtocolHTTP, SyntaxV3,
+			[]Term{{cond("Host", "a.com")}, {cond("Host", "b.com")}}},
+		{"与对或分配", "(Host(`a.com`) || Host(`b.com`)) && Path(`/x`)", ProtocolHTTP, SyntaxV3,
+			[]Term{{cond("Host", "a.com"), cond("Path", "/x")}, {cond("Host", "b.com"), cond("Path", "/x")}}},
+		{"取反或", "!(Host(`a.com`) || Path(`/x`))", ProtocolHTTP, SyntaxV3,
+			[]Term{{not("Host", "a.com"), not("Path", "/x")}}},
+		{"取反与", "!(Host(`a.com`) && Path(`/x`))", ProtocolHTTP, SyntaxV3,
+			[]Term{{not("Host", "a.com")}, {not("Path", "/x")}}},
+		{"双重取反", "!!Host(`a.com`)", ProtocolHTTP, SyntaxV3,
+			[]Term{{cond("Host", "a.com")}}},
+		{"方法转为大写", "Method(`get`)", ProtocolHTTP, SyntaxV3,
+			[]Term{{cond("Method", "GET")}}},
+		{"v3 query", "Query(`a`, `1`)", ProtocolHTTP, SyntaxV3,
+			[]Term{{cond("Query", "a", "1")}}},
+		{"v2 多参数拆分", "Host(`a.com`, `b.com`)", ProtocolHTTP, SyntaxV2,
+			[]Term{{cond("Host", "a.com")}, {cond("Host", "b.com")}}},
+		{"v2 取反多参数", "!Host(`a.com`, `b.com`)", ProtocolHTTP, SyntaxV2,
+			[]Term{{not("Host", "a.com"), not("Host", "b.com")}}},
+		{"v2 别名", "HostHeader(`a.com`) && Headers(`X-Env`, `prod`)", ProtocolHTTP, SyntaxV2,
+			[]Term{{cond("Host", "a.com"), cond("Header", "X-Env", "prod")}}},
+		{"v2 query拆分键值", "Query(`a=1`, `b`)", ProtocolHTTP, SyntaxV2,
+			[]Term{{cond("Query", "a", "1")}, {cond("Query", "b")}}},
+		{"tcp 通配匹配所有连接", "HostSNI(`*`)", ProtocolTCP, SyntaxV3,
+			[]Term{{}}},
+		{"tcp 取反通配不匹配任何连接", "!HostSNI(`*`)", ProtocolTCP, SyntaxV3,
+			[]Term{}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := Normalize(c.rule, c.protocol, c.syntax)
+			if err != nil {
+				t.Fatalf("Normalize(%q) error: %v", c.rule, err)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("Normalize(%q) = %v, want %v", c.rule, got, c.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeErrors(t *testing.T) {
+	if _, err := Normalize("Host(`a.com`, `b.com`)", ProtocolHTTP, SyntaxV3); err == nil {
+		t.Error("v3语法的多参数Host应返回错误")
+	}
+
+	// 7组或条件相与展开后有128项，超过上限
+	groups := make([]string, 7)
+	for i := range groups {
+		groups[i] = "(Path(`/a`) || Path(`/b`))"
+	}
+	_, err := Normalize(strings.Join(groups, " && "), ProtocolHTTP, SyntaxV3)
+	if !errors.Is(err, ErrTooComplex) {
+		t.Errorf("Normalize应返回ErrTooComplex，实际为%v", err)
+	}
+}
+
+func TestCovers(t *testing.T) {
+	cases := []struct {
+		name   string
+		a, b   string
+		syntax string
+		want   bool
+	}{
+		{"相同规则", "Host(`a.com`)", "Host(`a.com`)", SyntaxV3, true},
+		{"大小写不同的域名", "Host(`A.com`)", "Host(`a.com`)", SyntaxV3, true},
+		{"条件更少的覆盖更多的", "Host(`a.com`)", "Host(`a.com`) && PathPrefix(`/api`)", SyntaxV3, true},
+		{"条件更多的不覆盖更少的", "Host(`a.com`) && PathPrefix(`/api`)", "Host(`a.com`)", SyntaxV3, false},
+		{"前缀覆盖路径", "PathPrefix(`/api`)", "Path(`/api/v1`)", SyntaxV3, true},
+		{"前缀覆盖更长的前缀", "PathPrefix(`/api`)", "PathPrefix(`/api/v1`)", SyntaxV3, true},
+		{"路径不覆盖前缀", "Path(`/api`)", "PathPrefix(`/api`)", SyntaxV3, false},
+		{"不同前缀", "PathPrefix(`/api`)", "PathPrefix(`/web`)", SyntaxV3, false},
+		{"或覆盖其中一项", "Host(`a.com`) || Host(`b.com`)", "Host(`b.com`)", SyntaxV3, true},
+		{"单项不覆盖或", "Host(`a.com`)", "Host(`a.com`) || Host(`b.com`)", SyntaxV3, false},
+		{"取反条件", "!Host(`a.com`)", "!Host(`a.com`) && Path(`/x`)", SyntaxV3, true},
+		{"取反与未取反不同", "!Host(`a.com`)", "Host(`a.com`)", SyntaxV3, false},
+		{"取反的前缀不做推断", "!PathPrefix(`/api`)", "!Path(`/api/v1`)", SyntaxV3, false},
+		{"v2 变量不做前缀推断", "PathPrefix(`/{id}`)", "Path(`/{id}/x`)", SyntaxV2, false},
+		{"v2 多参数", "Host(`a.com`, `b.com`)", "Host(`b.com`) && Path(`/x`)", SyntaxV2, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			a, err := Normalize(c.a, ProtocolHTTP, c.syntax)
+			if err != nil {
+				t.Fatalf("Normalize(%q) error: %v", c.a, err)
+			}
+			b, err := Normalize(c.b, ProtocolHTTP, c.syntax)
+			if err != nil {
+				t.Fatalf("Normalize(%q) error: %v", c.b, err)
+			}
+			if got := Covers(a, b); got != c.want {
+				t.Errorf("Covers(%q, %q) = %v, want %v", c.a, c.b, got, c.want)
+			}
+		})
+	}
+
+	// 匹配所有连接的规则覆盖任何规则，任何规则都覆盖不匹配任何连接的规则
+	all, _ := Normalize("HostSNI(`*`)", ProtocolTCP, SyntaxV3)
+	sni, _ := Normalize("HostSNI(`a.com`)", ProtocolTCP, SyntaxV3)
+	none, _ := Normalize("!HostSNI(`*`)", ProtocolTCP, SyntaxV3)
+	if !Covers(all, sni) || Covers(sni, all) || !Covers(sni, none) {
+		t.Error("HostSNI(`*`)的覆盖关系不正确")
+	}
+}
+
+func TestPortable(t *testing.T) {
+	cases := []struct {
+		name     string
+		rule     string
+		contains string // 为空表示可移植
+	}{
+		{"通用规则", "Host(`a.com`) && PathPrefix(`/api`) && Method(`GET`)", ""},
+		{"v3不支持多参数", "Host(`a.com`, `b.com`)", "需要1个参数"},
+		{"v2不支持pathRegexp", "PathRegexp(`^/a`)", "v2语法无法解析"},
+		{"hostRegexp语义不同", "HostRegexp(`a.com`)", "路径模板"},
+		{"路径变量", "Path(`/user/{id}`)", "支持变量"},
+		{"query参数含义不同", "Query(`a=1`)", "含义不同"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := Portable(c.rule, ProtocolHTTP)
+			if c.contains == "" {
+				if err != nil {
+					t.Errorf("Portable(%q) error: %v", c.rule, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), c.contains) {
+				t.Errorf("Portable(%q) = %v, want error containing %q", c.rule, err, c.contains)
+			}
+		})
+	}
+}
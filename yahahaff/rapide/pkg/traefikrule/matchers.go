@@ -0,0 +1,438 @@
+package traefikrule
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// newMatcher 按协议和语法创建匹配器，并检查参数个数
+func newMatcher(name string, args []string, protocol, syntax string) (Matcher, error) {
+	if protocol == ProtocolTCP {
+		return newTCPMatcher(name, args, syntax)
+	}
+	if syntax == SyntaxV2 {
+		return newV2Matcher(name, args)
+	}
+	return newV3Matcher(name, args)
+}
+
+// newV3Matcher v3语法的HTTP匹配器，除Header类和Query外都只接受一个参数
+func newV3Matcher(name string, args []string) (Matcher, error) {
+	switch name {
+	case "Host":
+		if err := arity(name, args, 1, 1); err != nil {
+			return nil, err
+		}
+		return hostEquals(args), nil
+	case "HostRegexp":
+		if err := arity(name, args, 1, 1); err != nil {
+			return nil, err
+		}
+		re, err := compile(name, args[0])
+		if err != nil {
+			return nil, err
+		}
+		return matchFunc(func(req *Request) bool { return re.MatchString(requestHost(req)) }), nil
+	case "Path":
+		if err := arity(name, args, 1, 1); err != nil {
+			return nil, err
+		}
+		return matchFunc(func(req *Request) bool { return req.Path == args[0] }), nil
+	case "PathPrefix":
+		if err := arity(name, args, 1, 1); err != nil {
+			return nil, err
+		}
+		return matchFunc(func(req *Request) bool { return strings.HasPrefix(req.Path, args[0]) }), nil
+	case "PathRegexp":
+		if err := arity(name, args, 1, 1); err != nil {
+			return nil, err
+		}
+		re, err := compile(name, args[0])
+		if err != nil {
+			return nil, err
+		}
+		return matchFunc(func(req *Request) bool { return re.MatchString(req.Path) }), nil
+	case "Method":
+		if err := arity(name, args, 1, 1); err != nil {
+			return nil, err
+		}
+		return methodEquals(args), nil
+	case "Header":
+		if err := arity(name, args, 2, 2); err != nil {
+			return nil, err
+		}
+		return headerMatches(args[0], func(value string) bool { return value == args[1] }), nil
+	case "HeaderRegexp":
+		if err := arity(name, args, 2, 2); err != nil {
+			return nil, err
+		}
+		re, err := compile(name, args[1])
+		if err != nil {
+			return nil, err
+		}
+		return headerMatches(args[0], re.MatchString), nil
+	case "Query":
+		if err := arity(name, args, 1, 2); err != nil {
+			return nil, err
+		}
+		if len(args) == 1 {
+			return queryMatches(args[0], nil), nil
+		}
+		return queryMatches(args[0], func(value string) bool { return value == args[1] }), nil
+	case "QueryRegexp":
+		if err := arity(name, args, 2, 2); err != nil {
+			return nil, err
+		}
+		re, err := compile(name, args[1])
+		if err != nil {
+			return nil, err
+		}
+		return queryMatches(args[0], re.MatchString), nil
+	case "ClientIP":
+		if err := arity(name, args, 1, 1); err != nil {
+			return nil, err
+		}
+		return clientIPMatches(name, args)
+	default:
+		return nil, fmt.Errorf("v3语法不支持HTTP匹配器: %s", name)
+	}
+}
+
+// newV2Matcher v2语法的HTTP匹配器，大多数匹配器接受多个参数，满足任一即可
+// Host、Path和PathPrefix支持{name:regexp}形式的变量
+func newV2Matcher(name string, args []string) (Matcher, error) {
+	switch name {
+	case "Host", "HostHeader":
+		if err := arity(name, args, 1, -1); err != nil {
+			return nil, err
+		}
+		return hostEquals(args), nil
+	case "HostRegexp":
+		if err := arity(name, args, 1, -1); err != nil {
+			return nil, err
+		}
+		patterns, err := templates(name, args, "[^.]+", true)
+		if err != nil {
+			return nil, err
+		}
+		return anyPattern(patterns, requestHost), nil
+	case "Path":
+		if err := arity(name, args, 1, -1); err != nil {
+			return nil, err
+		}
+		patterns, err := templates(name, args, "[^/]+", true)
+		if err != nil {
+			return nil, err
+		}
+		return anyPattern(patterns, func(req *Request) string { return req.Path }), nil
+	case "PathPrefix":
+		if err := arity(name, args, 1, -1); err != nil {
+			return nil, err
+		}
+		patterns, err := templates(name, args, "[^/]+", false)
+		if err != nil {
+			return nil, err
+		}
+		return anyPattern(patterns, func(req *Request) string { return req.Path }), nil
+	case "Method":
+		if err := arity(name, args, 1, -1); err != nil {
+			return nil, err
+		}
+		return methodEquals(args), nil
+	case "Headers":
+		if err := arity(name, args, 2, 2); err != nil {
+			return nil, err
+		}
+		return headerMatches(args[0], func(value string) bool { return value == args[1] }), nil
+	case "HeadersRegexp":
+		if err := arity(name, args, 2, 2); err != nil {
+			return nil, err
+		}
+		re, err := compile(name, args[1])
+		if err != nil {
+			return nil, err
+		}
+		return headerMatches(args[0], re.MatchString), nil
+	case "Query":
+		if err := arity(name, args, 1, -1); err != nil {
+			return nil, err
+		}
+		var matchers []Matcher
+		for _, arg := range args {
+			key, value, found := strings.Cut(arg, "=")
+			if !found {
+				matchers = append(matchers, queryMatches(key, nil))
+				continue
+			}
+			matchers = append(matchers, queryMatches(key, func(v string) bool { return v == value }))
+		}
+		return anyOf(matchers), nil
+	case "ClientIP":
+		if err := arity(name, args, 1, -1); err != nil {
+			return nil, err
+		}
+		return clientIPMatches(name, args)
+	default:
+		return nil, fmt.Errorf("v2语法不支持HTTP匹配器: %s", name)
+	}
+}
+
+// newTCPMatcher TCP匹配器，v2语法的HostSNI、ClientIP可以有多个参数
+func newTCPMatcher(name string, args []string, syntax string) (Matcher, error) {
+	maxArgs := 1
+	if syntax == SyntaxV2 {
+		maxArgs = -1
+	}
+	switch name {
+	case "HostSNI":
+		if err := arity(name, args, 1, maxArgs); err != nil {
+			return nil, err
+		}
+		return matchFunc(func(req *Request) bool {
+			for _, arg := range args {
+				if arg == "*" || strings.EqualFold(req.SNI, arg) {
+					return true
+				}
+			}
+			return false
+		}), nil
+	case "HostSNIRegexp":
+		if err := arity(name, args, 1, maxArgs); err != nil {
+			return nil, err
+		}
+		if syntax == SyntaxV2 {
+			patterns, err := templates(name, args, "[^.]+", true)
+			if err != nil {
+				return nil, err
+			}
+			return anyPattern(patterns, func(req *Request) string { return strings.ToLower(req.SNI) }), nil
+		}
+		re, err := compile(name, args[0])
+		if err != nil {
+			return nil, err
+		}
+		return matchFunc(func(req *Request) bool { return re.MatchString(strings.ToLower(req.SNI)) }), nil
+	case "ClientIP":
+		if err := arity(name, args, 1, maxArgs); err != nil {
+			return nil, err
+		}
+		return clientIPMatches(name, args)
+	case "ALPN":
+		if err := arity(name, args, 1, maxArgs); err != nil {
+			return nil, err
+		}
+		return matchFunc(func(req *Request) bool {
+			for _, protocol := range req.ALPN {
+				for _, arg := range args {
+					if protocol == arg {
+						return true
+					}
+				}
+			}
+			return false
+		}), nil
+	default:
+		return nil, fmt.Errorf("不支持TCP匹配器: %s", name)
+	}
+}
+
+// arity 检查参数个数，max为-1表示不限
+func arity(name string, args []string, min, max int) error {
+	if len(args) < min || (max >= 0 && len(args) > max) {
+		if min == max {
+			return fmt.Errorf("匹配器%s需要%d个参数，实际为%d个", name, min, len(args))
+		}
+		return fmt.Errorf("匹配器%s的参数个数无效: %d", name, len(args))
+	}
+	return nil
+}
+
+// compile 编译匹配器中的正则表达式
+func compile(name, pattern string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("匹配器%s的正则表达式无效: %v", name, err)
+	}
+	return re, nil
+}
+
+// templates 把v2语法中带{name:regexp}变量的参数转换为正则表达式
+func templates(name string, args []string, defaultPattern string, full bool) ([]*regexp.Regexp, error) {
+	patterns := make([]*regexp.Regexp, 0, len(args))
+	for _, arg := range args {
+		var pattern strings.Builder
+		pattern.WriteString("^")
+		for rest := arg; rest != ""; {
+			start := strings.Index(rest, "{")
+			if start < 0 {
+				pattern.WriteString(regexp.QuoteMeta(rest))
+				break
+			}
+			end := matchingBrace(rest, start)
+			if end < 0 {
+				return nil, fmt.Errorf("匹配器%s的变量没有结束: %s", name, arg)
+			}
+			pattern.WriteString(regexp.QuoteMeta(rest[:start]))
+			if _, variable, found := strings.Cut(rest[start+1:end], ":"); found {
+				pattern.WriteString("(?:" + variable + ")")
+			} else {
+				pattern.WriteString(defaultPattern)
+			}
+			rest = rest[end+1:]
+		}
+		if full {
+			pattern.WriteString("$")
+		}
+		re, err := compile(name, pattern.String())
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, re)
+	}
+	return patterns, nil
+}
+
+// matchingBrace 找到与start处{配对的}，变量中的正则可能含有{n}
+func matchingBrace(value string, start int) int {
+	depth := 0
+	for i := start; i < len(value); i++ {
+		switch value[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// anyPattern 任一正则匹配
+func anyPattern(patterns []*regexp.Regexp, value func(req *Request) string) Matcher {
+	return matchFunc(func(req *Request) bool {
+		for _, pattern := range patterns {
+			if pattern.MatchString(value(req)) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// anyOf 满足任一匹配器
+func anyOf(matchers []Matcher) Matcher {
+	return matchFunc(func(req *Request) bool {
+		for _, matcher := range matchers {
+			if matcher.Match(req) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// hostEquals 请求域名与任一参数相同，不区分大小写
+func hostEquals(hosts []string) Matcher {
+	return matchFunc(func(req *Request) bool {
+		for _, host := range hosts {
+			if strings.EqualFold(requestHost(req), host) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// methodEquals 请求方法与任一参数相同
+func methodEquals(methods []string) Matcher {
+	return matchFunc(func(req *Request) bool {
+		for _, method := range methods {
+			if strings.EqualFold(req.Method, method) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// headerMatches 请求头的任一值满足条件
+func headerMatches(key string, match func(string) bool) Matcher {
+	key = http.CanonicalHeaderKey(key)
+	return matchFunc(func(req *Request) bool {
+		for _, value := range req.Headers[key] {
+			if match(value) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// queryMatches 查询参数的任一值满足条件，match为空时只要求参数存在
+func queryMatches(key string, match func(string) bool) Matcher {
+	return matchFunc(func(req *Request) bool {
+		values, ok := req.Query[key]
+		if !ok {
+			return false
+		}
+		if match == nil {
+			return true
+		}
+		for _, value := range values {
+			if match(value) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// clientIPMatches 客户端IP等于任一IP或属于任一网段
+func clientIPMatches(name string, args []string) (Matcher, error) {
+	networks := make([]*net.IPNet, 0, len(args))
+	for _, arg := range args {
+		if !strings.Contains(arg, "/") {
+			ip := net.ParseIP(arg)
+			if ip == nil {
+				return nil, fmt.Errorf("匹配器%s的IP无效: %s", name, arg)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(arg)
+		if err != nil {
+			return nil, fmt.Errorf("匹配器%s的网段无效: %s", name, arg)
+		}
+		networks = append(networks, network)
+	}
+	return matchFunc(func(req *Request) bool {
+		ip := net.ParseIP(req.ClientIP)
+		if ip == nil {
+			return false
+		}
+		for _, network := range networks {
+			if network.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+// requestHost 获取不带端口的小写请求域名
+func requestHost(req *Request) string {
+	host := req.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(host)
+}
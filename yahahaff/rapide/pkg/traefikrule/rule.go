@@ -0,0 +1,322 @@
+// Package traefikrule 解析并求值Traefik路由规则，支持v3和v2两种规则语法
+package traefikrule
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode"
+)
+
+// Request 用于匹配规则的示例请求，HTTP规则使用Method到ClientIP，TCP规则使用SNI、ALPN和ClientIP
+type Request struct {
+	Method   string
+	Host     string
+	Path     string
+	Query    url.Values
+	Headers  http.Header
+	ClientIP string
+	SNI      string
+	ALPN     []string
+}
+
+// Matcher 解析后的规则
+type Matcher interface {
+	Match(req *Request) bool
+}
+
+// Syntax 规则语法版本
+const (
+	SyntaxV3 = "v3"
+	SyntaxV2 = "v2"
+)
+
+// Protocol 规则所属的路由协议，决定可用的匹配器
+const (
+	ProtocolHTTP = "http"
+	ProtocolTCP  = "tcp"
+)
+
+// DefaultPriority Traefik未设置priority时使用规则长度作为优先级
+func DefaultPriority(rule string) int64 {
+	return int64(len(rule))
+}
+
+// Parse 解析规则，syntax为空或default时按v3处理
+func Parse(rule, protocol, syntax string) (Matcher, error) {
+	if syntax == "" || syntax == "default" {
+		syntax = SyntaxV3
+	}
+	if syntax != SyntaxV3 && syntax != SyntaxV2 {
+		return nil, fmt.Errorf("不支持的规则语法: %s", syntax)
+	}
+
+	root, err := parse(rule)
+	if err != nil {
+		return nil, err
+	}
+	return root.compile(protocol, syntax)
+}
+
+// parse 把规则解析为语法树
+func parse(rule string) (*node, error) {
+	tokens, err := tokenize(rule)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("规则在位置%d处有多余的内容: %s", p.tokens[p.pos].offset, p.tokens[p.pos].value)
+	}
+	return root, nil
+}
+
+// node 规则语法树节点，op为tokenAnd、tokenOr、tokenNot或表示匹配器调用的tokenIdent
+type node struct {
+	op          tokenKind
+	left, right *node
+	name        string
+	args        []string
+}
+
+// compile 把语法树转换为匹配器，同时检查匹配器名称和参数
+func (n *node) compile(protocol, syntax string) (Matcher, error) {
+	if n.op == tokenIdent {
+		return newMatcher(n.name, n.args, protocol, syntax)
+	}
+	left, err := n.left.compile(protocol, syntax)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == tokenNot {
+		return notMatcher{left}, nil
+	}
+	right, err := n.right.compile(protocol, syntax)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == tokenAnd {
+		return andMatcher{left, right}, nil
+	}
+	return orMatcher{left, right}, nil
+}
+
+// tokenKind 词法单元类型
+type tokenKind int
+
+const (
+	tokenIdent tokenKind = iota
+	tokenString
+	tokenLParen
+	tokenRParen
+	tokenComma
+	tokenAnd
+	tokenOr
+	tokenNot
+)
+
+// token 词法单元
+type token struct {
+	kind   tokenKind
+	value  string
+	offset int
+}
+
+// tokenize 把规则拆分为词法单元，字符串可以用反引号或双引号
+func tokenize(rule string) ([]token, error) {
+	var tokens []token
+	runes := []rune(rule)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{tokenLParen, "(", i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokenRParen, ")", i})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{tokenComma, ",", i})
+			i++
+		case r == '!':
+			tokens = append(tokens, token{tokenNot, "!", i})
+			i++
+		case r == '&' || r == '|':
+			if i+1 >= len(runes) || runes[i+1] != r {
+				return nil, fmt.Errorf("位置%d处的运算符无效，应为%c%c", i, r, r)
+			}
+			kind := tokenAnd
+			if r == '|' {
+				kind = tokenOr
+			}
+			tokens = append(tokens, token{kind, string([]rune{r, r}), i})
+			i += 2
+		case r == '`' || r == '"':
+			end := i + 1
+			var value strings.Builder
+			for ; end < len(runes) && runes[end] != r; end++ {
+				// 双引号字符串支持反斜杠转义
+				if r == '"' && runes[end] == '\\' && end+1 < len(runes) {
+					end++
+				}
+				value.WriteRune(runes[end])
+			}
+			if end >= len(runes) {
+				return nil, fmt.Errorf("位置%d处的字符串没有结束", i)
+			}
+			tokens = append(tokens, token{tokenString, value.String(), i})
+			i = end + 1
+		case unicode.IsLetter(r):
+			end := i
+			for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end])) {
+				end++
+			}
+			tokens = append(tokens, token{tokenIdent, string(runes[i:end]), i})
+			i = end
+		default:
+			return nil, fmt.Errorf("位置%d处有无效字符: %c", i, r)
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("规则为空")
+	}
+	return tokens, nil
+}
+
+// parser 递归下降解析器，优先级从低到高为||、&&、!
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// parseOr 解析||表达式
+func (p *parser) parseOr() (*node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept(tokenOr) {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &node{op: tokenOr, left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseAnd 解析&&表达式
+func (p *parser) parseAnd() (*node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept(tokenAnd) {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &node{op: tokenAnd, left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseUnary 解析取反、括号和匹配器调用
+func (p *parser) parseUnary() (*node, error) {
+	if p.accept(tokenNot) {
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &node{op: tokenNot, left: inner}, nil
+	}
+	if p.accept(tokenLParen) {
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(tokenRParen) {
+			return nil, p.unexpected("缺少)")
+		}
+		return inner, nil
+	}
+	return p.parseCall()
+}
+
+// parseCall 解析形如Host(`a.com`)的匹配器调用
+func (p *parser) parseCall() (*node, error) {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != tokenIdent {
+		return nil, p.unexpected("应为匹配器名称")
+	}
+	name := p.tokens[p.pos].value
+	p.pos++
+	if !p.accept(tokenLParen) {
+		return nil, p.unexpected("匹配器" + name + "后缺少(")
+	}
+
+	var args []string
+	if !p.accept(tokenRParen) {
+		for {
+			if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != tokenString {
+				return nil, p.unexpected("匹配器" + name + "的参数必须是字符串")
+			}
+			args = append(args, p.tokens[p.pos].value)
+			p.pos++
+			if p.accept(tokenRParen) {
+				break
+			}
+			if !p.accept(tokenComma) {
+				return nil, p.unexpected("匹配器" + name + "的参数之间缺少,")
+			}
+		}
+	}
+	return &node{op: tokenIdent, name: name, args: args}, nil
+}
+
+// accept 当前词法单元为指定类型时前进一步
+func (p *parser) accept(kind tokenKind) bool {
+	if p.pos < len(p.tokens) && p.tokens[p.pos].kind == kind {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// unexpected 生成解析错误
+func (p *parser) unexpected(message string) error {
+	if p.pos >= len(p.tokens) {
+		return fmt.Errorf("规则意外结束: %s", message)
+	}
+	return fmt.Errorf("位置%d处: %s", p.tokens[p.pos].offset, message)
+}
+
+// andMatcher 两个条件同时满足
+type andMatcher struct{ left, right Matcher }
+
+// Match 实现Matcher接口
+func (m andMatcher) Match(req *Request) bool { return m.left.Match(req) && m.right.Match(req) }
+
+// orMatcher 满足任一条件
+type orMatcher struct{ left, right Matcher }
+
+// Match 实现Matcher接口
+func (m orMatcher) Match(req *Request) bool { return m.left.Match(req) || m.right.Match(req) }
+
+// notMatcher 条件取反
+type notMatcher struct{ inner Matcher }
+
+// Match 实现Matcher接口
+func (m notMatcher) Match(req *Request) bool { return !m.inner.Match(req) }
+
+// matchFunc 以函数实现的匹配器
+type matchFunc func(req *Request) bool
+
+// Match 实现Matcher接口
+func (f matchFunc) Match(req *Request) bool { return f(req) }
@@ -0,0 +1,132 @@
+package traefikrule
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestParseMatch(t *testing.T) {
+	cases := []struct {
+		name     string
+		rule     string
+		protocol string
+		syntax   string
+		req      Request
+		want     bool
+	}{
+		// v3语法
+		{"v3 host忽略大小写和端口", "Host(`a.com`)", ProtocolHTTP, SyntaxV3, Request{Host: "A.com:8443"}, true},
+		{"v3 host不同", "Host(`a.com`)", ProtocolHTTP, SyntaxV3, Request{Host: "b.com"}, false},
+		{"v3 默认语法", "Host(`a.com`)", ProtocolHTTP, "default", Request{Host: "a.com"}, true},
+		{"v3 hostRegexp使用正则", "HostRegexp(`^[a-z]+\\.a\\.com$`)", ProtocolHTTP, SyntaxV3, Request{Host: "x.a.com"}, true},
+		{"v3 path完全匹配", "Path(`/a`)", ProtocolHTTP, SyntaxV3, Request{Path: "/a/b"}, false},
+		{"v3 pathPrefix", "PathPrefix(`/a`)", ProtocolHTTP, SyntaxV3, Request{Path: "/a/b"}, true},
+		{"v3 pathRegexp", "PathRegexp(`^/v[0-9]+/`)", ProtocolHTTP, SyntaxV3, Request{Path: "/v2/users"}, true},
+		{"v3 method", "Method(`POST`)", ProtocolHTTP, SyntaxV3, Request{Method: "GET"}, false},
+		{"v3 header", "Header(`x-env`, `prod`)", ProtocolHTTP, SyntaxV3, Request{Headers: http.Header{"X-Env": {"dev", "prod"}}}, true},
+		{"v3 headerRegexp", "HeaderRegexp(`X-Env`, `^pr`)", ProtocolHTTP, SyntaxV3, Request{Headers: http.Header{"X-Env": {"dev"}}}, false},
+		{"v3 query存在", "Query(`debug`)", ProtocolHTTP, SyntaxV3, Request{Query: url.Values{"debug": {""}}}, true},
+		{"v3 query值", "Query(`a`, `1`)", ProtocolHTTP, SyntaxV3, Request{Query: url.Values{"a": {"2"}}}, false},
+		{"v3 clientIP网段", "ClientIP(`10.0.0.0/8`)", ProtocolHTTP, SyntaxV3, Request{ClientIP: "10.1.2.3"}, true},
+		{"v3 clientIP单个地址", "ClientIP(`10.0.0.1`)", ProtocolHTTP, SyntaxV3, Request{ClientIP: "10.0.0.2"}, false},
+		{"双引号字符串", `Host("a.com")`, ProtocolHTTP, SyntaxV3, Request{Host: "a.com"}, true},
+
+		// 取反和运算符优先级
+		{"取反命中", "Host(`a.com`) && !PathPrefix(`/admin`)", ProtocolHTTP, SyntaxV3, Request{Host: "a.com", Path: "/admin/users"}, false},
+		{"取反未命中", "Host(`a.com`) && !PathPrefix(`/admin`)", ProtocolHTTP, SyntaxV3, Request{Host: "a.com", Path: "/users"}, true},
+		{"双重取反", "!!Host(`a.com`)", ProtocolHTTP, SyntaxV3, Request{Host: "a.com"}, true},
+		{"&&优先于||", "Host(`a.com`) || Host(`b.com`) && Path(`/x`)", ProtocolHTTP, SyntaxV3, Request{Host: "a.com", Path: "/y"}, true},
+		{"&&优先于||未命中", "Host(`a.com`) || Host(`b.com`) && Path(`/x`)", ProtocolHTTP, SyntaxV3, Request{Host: "b.com", Path: "/y"}, false},
+		{"括号改变优先级", "(Host(`a.com`) || Host(`b.com`)) && Path(`/x`)", ProtocolHTTP, SyntaxV3, Request{Host: "a.com", Path: "/y"}, false},
+		{"取反括号", "!(Host(`a.com`) || Host(`b.com`))", ProtocolHTTP, SyntaxV3, Request{Host: "c.com"}, true},
+
+		// v2语法
+		{"v2 host多个参数", "Host(`a.com`, `b.com`)", ProtocolHTTP, SyntaxV2, Request{Host: "b.com"}, true},
+		{"v2 hostHeader别名", "HostHeader(`a.com`)", ProtocolHTTP, SyntaxV2, Request{Host: "a.com"}, true},
+		{"v2 hostRegexp变量", "HostRegexp(`{sub:[a-z]+}.a.com`)", ProtocolHTTP, SyntaxV2, Request{Host: "x.a.com"}, true},
+		{"v2 hostRegexp变量不匹配", "HostRegexp(`{sub:[a-z]+}.a.com`)", ProtocolHTTP, SyntaxV2, Request{Host: "1.a.com"}, false},
+		{"v2 hostRegexp点号是字面量", "HostRegexp(`{sub}.a.com`)", ProtocolHTTP, SyntaxV2, Request{Host: "x.aXcom"}, false},
+		{"v2 path变量", "Path(`/user/{id:[0-9]+}`)", ProtocolHTTP, SyntaxV2, Request{Path: "/user/12"}, true},
+		{"v2 path变量不匹配", "Path(`/user/{id:[0-9]+}`)", ProtocolHTTP, SyntaxV2, Request{Path: "/user/ab"}, false},
+		{"v2 path变量含量词", "Path(`/code/{id:[0-9]{3}}`)", ProtocolHTTP, SyntaxV2, Request{Path: "/code/123"}, true},
+		{"v2 pathPrefix变量", "PathPrefix(`/api/{ver}`)", ProtocolHTTP, SyntaxV2, Request{Path: "/api/v1/users"}, true},
+		{"v2 method多个参数", "Method(`GET`, `POST`)", ProtocolHTTP, SyntaxV2, Request{Method: "post"}, true},
+		{"v2 headers", "Headers(`X-Env`, `prod`)", ProtocolHTTP, SyntaxV2, Request{Headers: http.Header{"X-Env": {"prod"}}}, true},
+		{"v2 query键值", "Query(`a=1`, `b`)", ProtocolHTTP, SyntaxV2, Request{Query: url.Values{"b": {"x"}}}, true},
+		{"v2 query值不同", "Query(`a=1`)", ProtocolHTTP, SyntaxV2, Request{Query: url.Values{"a": {"2"}}}, false},
+
+		// TCP
+		{"tcp hostSNI通配", "HostSNI(`*`)", ProtocolTCP, SyntaxV3, Request{SNI: "x.com"}, true},
+		{"tcp hostSNI忽略大小写", "HostSNI(`a.com`)", ProtocolTCP, SyntaxV3, Request{SNI: "A.COM"}, true},
+		{"tcp hostSNIRegexp", "HostSNIRegexp(`^.+\\.a\\.com$`)", ProtocolTCP, SyntaxV3, Request{SNI: "x.a.com"}, true},
+		{"tcp v2 hostSNIRegexp变量", "HostSNIRegexp(`{sub}.a.com`)", ProtocolTCP, SyntaxV2, Request{SNI: "x.a.com"}, true},
+		{"tcp alpn", "ALPN(`h2`)", ProtocolTCP, SyntaxV3, Request{ALPN: []string{"http/1.1", "h2"}}, true},
+		{"tcp v2 多个参数", "HostSNI(`a.com`, `b.com`)", ProtocolTCP, SyntaxV2, Request{SNI: "b.com"}, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			matcher, err := Parse(c.rule, c.protocol, c.syntax)
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", c.rule, err)
+			}
+			req := c.req
+			if got := matcher.Match(&req); got != c.want {
+				t.Errorf("Parse(%q).Match(%+v) = %v, want %v", c.rule, c.req, got, c.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := []struct {
+		name     string
+		rule     string
+		protocol string
+		syntax   string
+		contains string
+	}{
+		{"空规则", "  ", ProtocolHTTP, SyntaxV3, "规则为空"},
+		{"未知语法", "Host(`a.com`)", ProtocolHTTP, "v4", "不支持的规则语法"},
+		{"缺少右括号", "Host(`a.com`", ProtocolHTTP, SyntaxV3, "规则意外结束"},
+		{"括号不配对", "(Host(`a.com`)", ProtocolHTTP, SyntaxV3, "缺少)"},
+		{"单个&", "Host(`a.com`) & Path(`/`)", ProtocolHTTP, SyntaxV3, "运算符无效"},
+		{"缺少运算符", "Host(`a.com`) Path(`/`)", ProtocolHTTP, SyntaxV3, "多余的内容"},
+		{"字符串未结束", "Host(`a.com)", ProtocolHTTP, SyntaxV3, "没有结束"},
+		{"参数不是字符串", "Host(a)", ProtocolHTTP, SyntaxV3, "必须是字符串"},
+		{"参数缺少逗号", "Header(`a` `b`)", ProtocolHTTP, SyntaxV3, "缺少,"},
+		{"无效字符", "Host(`a.com`) ; Path(`/`)", ProtocolHTTP, SyntaxV3, "无效字符"},
+		{"v3 host只接受一个参数", "Host(`a.com`, `b.com`)", ProtocolHTTP, SyntaxV3, "需要1个参数"},
+		{"v3 不支持headers", "Headers(`a`, `b`)", ProtocolHTTP, SyntaxV3, "v3语法不支持"},
+		{"v2 不支持pathRegexp", "PathRegexp(`^/a`)", ProtocolHTTP, SyntaxV2, "v2语法不支持"},
+		{"header缺少参数", "Header(`a`)", ProtocolHTTP, SyntaxV3, "需要2个参数"},
+		{"正则无效", "HostRegexp(`[`)", ProtocolHTTP, SyntaxV3, "正则表达式无效"},
+		{"v2 变量未结束", "Path(`/user/{id`)", ProtocolHTTP, SyntaxV2, "变量没有结束"},
+		{"IP无效", "ClientIP(`300.0.0.1`)", ProtocolHTTP, SyntaxV3, "IP无效"},
+		{"网段无效", "ClientIP(`10.0.0.0/40`)", ProtocolHTTP, SyntaxV3, "网段无效"},
+		{"tcp不支持http匹配器", "Host(`a.com`)", ProtocolTCP, SyntaxV3, "不支持TCP匹配器"},
+		{"tcp v3 只接受一个参数", "HostSNI(`a.com`, `b.com`)", ProtocolTCP, SyntaxV3, "需要1个参数"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := Parse(c.rule, c.protocol, c.syntax)
+			if err == nil {
+				t.Fatalf("Parse(%q) succeeded, want error containing %q", c.rule, c.contains)
+			}
+			if !strings.Contains(err.Error(), c.contains) {
+				t.Errorf("Parse(%q) error = %q, want it to contain %q", c.rule, err.Error(), c.contains)
+			}
+		})
+	}
+}
+
+func TestDefaultPriority(t *testing.T) {
+	short, long := "Host(`a.com`)", "Host(`a.com`) && PathPrefix(`/api`)"
+	if DefaultPriority(short) != int64(len(short)) {
+		t.Errorf("DefaultPriority(%q) = %d, want %d", short, DefaultPriority(short), len(short))
+	}
+	if DefaultPriority(long) <= DefaultPriority(short) {
+		t.Errorf("更长的规则应有更高的默认优先级")
+	}
+}